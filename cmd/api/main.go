@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -21,12 +22,16 @@ import (
 	"github.com/NanoBoom/asethub/internal/cache"
 	"github.com/NanoBoom/asethub/internal/config"
 	"github.com/NanoBoom/asethub/internal/database"
+	"github.com/NanoBoom/asethub/internal/errors"
 	"github.com/NanoBoom/asethub/internal/handlers"
 	"github.com/NanoBoom/asethub/internal/logger"
 	"github.com/NanoBoom/asethub/internal/middleware"
+	"github.com/NanoBoom/asethub/internal/queue"
 	"github.com/NanoBoom/asethub/internal/repositories"
 	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/clamav"
 	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/NanoBoom/asethub/pkg/utils"
 )
 
 // @title           AssetHub API
@@ -58,6 +63,19 @@ func main() {
 		log.Fatalf("Failed to init logger: %v", err)
 	}
 
+	// 扩展 MIME 类型注册表（内置数据库 + 配置）
+	if cfg.MIME.File != "" {
+		if err := utils.DefaultMIMERegistry.LoadFile(cfg.MIME.File); err != nil {
+			zapLogger.Fatal("Failed to load MIME types", zap.Error(err))
+		}
+	}
+	for _, t := range cfg.MIME.Types {
+		utils.DefaultMIMERegistry.Register(t.Type, t.Extensions...)
+		for _, alias := range t.Aliases {
+			utils.DefaultMIMERegistry.RegisterAlias(alias, t.Type)
+		}
+	}
+
 	db, err := database.New(&cfg.Database)
 	if err != nil {
 		zapLogger.Fatal("Failed to connect to database", zap.Error(err))
@@ -76,7 +94,11 @@ func main() {
 	}
 	defer redisClient.Close()
 
-	router := setupRouter(cfg, zapLogger, db, redisClient)
+	// 后台任务（Webhook 投递、异步任务队列等）在服务关闭后停止，进行中的任务最多等待 jobs.shutdown_timeout
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+
+	router := setupRouter(workerCtx, &workers, cfg, zapLogger, db, redisClient)
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.App.Port),
@@ -103,61 +125,306 @@ func main() {
 		zapLogger.Fatal("Server forced shutdown", zap.Error(err))
 	}
 
+	stopWorkers()
+	workers.Wait()
+
 	zapLogger.Info("Server exited")
 }
 
-func setupRouter(cfg *config.Config, zapLogger *zap.Logger, db *gorm.DB, redisClient *cache.RedisClient) *gin.Engine {
+func setupRouter(workerCtx context.Context, workers *sync.WaitGroup, cfg *config.Config, zapLogger *zap.Logger, db *gorm.DB, redisClient *cache.RedisClient) *gin.Engine {
 	if cfg.App.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
+	// 客户端 IP 用于分享链接密码尝试限流，不可信来源的 X-Forwarded-For 会被忽略
+	if err := router.SetTrustedProxies(cfg.App.TrustedProxies); err != nil {
+		zapLogger.Fatal("Invalid trusted proxies", zap.Error(err))
+	}
 
 	router.Use(middleware.Recovery(zapLogger))
 	router.Use(middleware.Logger(zapLogger))
 	router.Use(middleware.ErrorHandler())
 	router.Use(middleware.CORS())
-
-	// Health check
-	healthHandler := handlers.NewHealthHandler(db, redisClient)
-	router.GET("/health", healthHandler.Check)
+	router.Use(middleware.EncryptionKey())
 
 	// File API - 初始化 Storage 和 FileService
 	fileRepo := repositories.NewFileRepository(db)
 
 	// 根据配置创建 Storage 实现（工厂函数在 storage 包中）
-	storageBackend, err := storage.NewStorage(context.Background(), &cfg.Storage)
+	storageBackend, err := storage.NewStorage(context.Background(), &cfg.Storage, repositories.NewBackendRepository(db))
 	if err != nil {
 		zapLogger.Fatal("Failed to initialize storage", zap.Error(err))
 	}
+	// 服务端加密策略（NewStorage 已校验配置），文件服务据此判断分片 ETag 是否为 MD5
+	encryptionPolicy, err := storage.NewEncryptionPolicy(cfg.Storage.Encryption)
+	if err != nil {
+		zapLogger.Fatal("Invalid storage encryption config", zap.Error(err))
+	}
+	// 跨后端复制直接读写各个后端（复制存储对象原样，不经过加密和压缩装饰器）
+	routingStorage, _ := storageBackend.(*storage.RoutingStorage)
+	// 存储分层同样操作原始存储对象（S3、OSS 或多后端路由存储）
+	tieredStorage, _ := storageBackend.(storage.TieredStorage)
+
+	// 读取故障转移：主后端读取失败、超时或熔断时从副本后端读取
+	var storageHealth handlers.StorageHealth
+	if cfg.Storage.Failover.Enabled {
+		if routingStorage == nil {
+			zapLogger.Fatal("Storage failover requires multiple storage backends (storage.backends)")
+		}
+		failover, err := storage.NewFailoverStorage(routingStorage, repositories.NewReplicationRepository(db), cfg.Storage.Failover)
+		if err != nil {
+			zapLogger.Fatal("Invalid storage failover config", zap.Error(err))
+		}
+		storageBackend = failover
+		storageHealth = failover
+	}
 
-	fileService := services.NewFileService(fileRepo, storageBackend, db)
-	fileHandler := handlers.NewFileHandler(fileService)
+	// Health check
+	healthHandler := handlers.NewHealthHandler(db, redisClient, storageHealth)
+	router.GET("/health", healthHandler.Check)
+
+	// 客户端信封加密：对象在上传前加密，数据密钥经主密钥加密后保存在文件记录上
+	if cfg.Storage.ClientEncryption.Enabled {
+		keyWrapper, err := storage.NewKeyWrapper(cfg.Storage.ClientEncryption)
+		if err != nil {
+			zapLogger.Fatal("Invalid client encryption master key", zap.Error(err))
+		}
+		storageBackend = storage.NewEncryptedStorage(storageBackend, keyWrapper, repositories.NewDataKeyRepository(db), cfg.Storage.ClientEncryption.Prefixes)
+	}
+
+	// 透明压缩：在加密之前压缩；未启用时仍解压此前压缩保存的对象
+	compression, err := storage.NewCompressionPolicy(cfg.Storage.Compression)
+	if err != nil {
+		zapLogger.Fatal("Invalid storage compression config", zap.Error(err))
+	}
+	storageBackend = storage.NewCompressedStorage(storageBackend, repositories.NewCodecRepository(db), compression)
+
+	// 异步任务队列：服务在此注册任务处理器，路由初始化完成后开始执行
+	jobManager := queue.NewManager(queue.NewRedisBroker(redisClient.Client(), cfg.Jobs.Prefix), cfg.Jobs)
+	jobHandler := handlers.NewJobHandler(jobManager)
+
+	transactor := repositories.NewTransactor(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+
+	// Webhook：文件事件写入投递队列，由后台投递器发送
+	webhookRepo := repositories.NewWebhookRepository(db)
+	webhookService := services.NewWebhookService(webhookRepo, cfg.Webhook)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	webhookDispatcher := services.NewWebhookDispatcher(webhookRepo, cfg.Webhook)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		webhookDispatcher.Run(workerCtx)
+	}()
+
+	publishers := []services.EventPublisher{
+		services.NewRedisStreamPublisher(redisClient, cfg.Outbox),
+		webhookService,
+	}
+
+	// 恶意文件扫描：文件上传完成后由扫描任务调用 clamd 扫描
+	var scanHandler *handlers.ScanHandler
+	if cfg.Scan.Enabled {
+		if _, ok := cfg.Jobs.Queues[cfg.Scan.Queue]; !ok {
+			zapLogger.Fatal("Scan queue is not configured in jobs.queues", zap.String("queue", cfg.Scan.Queue))
+		}
+		scanService := services.NewScanService(fileRepo, storageBackend, clamav.New(cfg.Scan.Address, cfg.Scan.Timeout), jobManager, cfg.Scan)
+		scanHandler = handlers.NewScanHandler(scanService)
+		publishers = append(publishers, scanService)
+	}
+
+	// 跨后端复制：文件上传完成后由复制任务把存储对象复制到副本后端，文件永久删除后清理副本
+	var replicationHandler *handlers.ReplicationHandler
+	if cfg.Replication.Enabled {
+		if routingStorage == nil {
+			zapLogger.Fatal("Replication requires multiple storage backends (storage.backends)")
+		}
+		if _, ok := cfg.Jobs.Queues[cfg.Replication.Queue]; !ok {
+			zapLogger.Fatal("Replication queue is not configured in jobs.queues", zap.String("queue", cfg.Replication.Queue))
+		}
+		replicator, err := services.NewReplicator(fileRepo, repositories.NewReplicationRepository(db), transactor, routingStorage, cfg.Replication)
+		if err != nil {
+			zapLogger.Fatal("Invalid replication config", zap.Error(err))
+		}
+		replicationService := services.NewReplicationService(replicator, fileRepo, jobManager, cfg.Replication)
+		replicationHandler = handlers.NewReplicationHandler(replicationService)
+		publishers = append(publishers, replicationService)
+	}
+	downloadPolicy := services.DownloadPolicy{RequireScan: cfg.Scan.Required}
+
+	// 发件箱中继：将文件事件发布到 Redis Stream、Webhook 投递队列、扫描队列和复制队列
+	outboxRelay := services.NewOutboxRelay(outboxRepo, transactor, cfg.Outbox, publishers...)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		outboxRelay.Run(workerCtx)
+	}()
+
+	// 内容类型校验：预签名上传和分片上传完成时读取文件头
+	switch cfg.ContentSniff.Mode {
+	case config.ContentSniffOff, config.ContentSniffFlag, config.ContentSniffReject:
+	default:
+		zapLogger.Fatal("Invalid content_sniff.mode", zap.String("mode", cfg.ContentSniff.Mode))
+	}
+	if cfg.Presign.MinExpiry > cfg.Presign.MaxExpiry {
+		zapLogger.Fatal("Invalid presign expiry range",
+			zap.Duration("min_expiry", cfg.Presign.MinExpiry), zap.Duration("max_expiry", cfg.Presign.MaxExpiry))
+	}
+
+	// 存储键模板：新文件按模板生成存储键（已有文件可用 cmd/migrate-keys 迁移）
+	keyTemplate, err := storage.ParseKeyTemplate(cfg.Storage.KeyTemplate, cfg.Storage.KeyTenant)
+	if err != nil {
+		zapLogger.Fatal("Invalid storage key template", zap.Error(err))
+	}
+
+	// 下载统计：访问计数累加到 Redis，后台定期汇总到数据库（同时更新文件的最近访问时间）
+	statsService := services.NewDownloadStatsService(redisClient, repositories.NewDownloadStatsRepository(db), fileRepo, transactor, cfg.Stats)
+	statsHandler := handlers.NewStatsHandler(statsService)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		statsService.Run(workerCtx)
+	}()
+
+	fileService := services.NewFileService(fileRepo, outboxRepo, storageBackend, transactor, downloadPolicy, services.FileServiceOptions{
+		Sniff:      cfg.ContentSniff,
+		Presign:    cfg.Presign,
+		Keys:       keyTemplate,
+		Stats:      statsService,
+		Encryption: encryptionPolicy,
+	})
+	// 存储分层：生命周期任务按规则转换存储类型、过期删除，并提供归档文件取回
+	var lifecycleHandler *handlers.LifecycleHandler
+	if cfg.Lifecycle.Enabled {
+		if tieredStorage == nil {
+			zapLogger.Fatal("Lifecycle requires S3 or OSS storage")
+		}
+		lifecycleService, err := services.NewLifecycleService(fileRepo, fileService, tieredStorage, cfg.Lifecycle)
+		if err != nil {
+			zapLogger.Fatal("Invalid lifecycle config", zap.Error(err))
+		}
+		lifecycleHandler = handlers.NewLifecycleHandler(lifecycleService)
+
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			lifecycleService.Run(workerCtx, func(action services.LifecycleAction) {
+				if action.Err != nil {
+					zapLogger.Warn("Lifecycle action failed", zap.String("file_id", action.FileID.String()),
+						zap.String("rule", action.Rule), zap.String("action", action.Action), zap.Error(action.Err))
+					return
+				}
+				zapLogger.Info("Lifecycle action", zap.String("file_id", action.FileID.String()),
+					zap.String("rule", action.Rule), zap.String("action", action.Action),
+					zap.String("storage_class", string(action.StorageClass)))
+			})
+		}()
+	}
+
+	// 分享链接：公开访问 /s/{token}，每次访问重新生成短期预签名 URL 或代理传输
+	shareService := services.NewShareService(repositories.NewShareRepository(db), fileRepo, fileService, redisClient, cfg.Shares)
+	shareHandler := handlers.NewShareHandler(shareService)
+	router.GET("/s/:token", shareHandler.OpenShare)
+
+	// 解压和异步打包由任务队列执行（并发数、重试和服务关闭时的处理由队列负责）
+	for _, q := range []string{cfg.Extraction.Queue, cfg.Archive.Queue} {
+		if _, ok := cfg.Jobs.Queues[q]; !ok {
+			zapLogger.Fatal("Extraction or archive queue is not configured in jobs.queues", zap.String("queue", q))
+		}
+	}
+	extractionService := services.NewExtractionService(fileRepo, outboxRepo, storageBackend, transactor, redisClient, jobManager, cfg.Extraction, keyTemplate)
+	fileHandler := handlers.NewFileHandler(fileService, extractionService)
+	extractionHandler := handlers.NewExtractionHandler(extractionService)
+
+	// 存储桶事件通知：自动确认预签名上传
+	storageEventService := services.NewStorageEventService(fileService, storage.Buckets(&cfg.Storage))
+	storageEventHandler := handlers.NewStorageEventHandler(storageEventService, cfg.StorageEvents.Secret)
+
+	archiveService := services.NewArchiveService(fileRepo, storageBackend, redisClient, jobManager, downloadPolicy, cfg.Archive)
+	archiveHandler := handlers.NewArchiveHandler(archiveService)
 
 	api := router.Group("/api/v1")
 	{
 		files := api.Group("/files")
 		{
 			// 小文件上传
-			files.POST("", fileHandler.UploadDirect)                      // POST /files
-			files.POST("/presigned", fileHandler.InitPresignedUpload)     // POST /files/presigned
-			files.POST("/:id/completion", fileHandler.ConfirmUpload)      // POST /files/{id}/completion
+			files.POST("", fileHandler.UploadDirect)                  // POST /files
+			files.POST("/presigned", fileHandler.InitPresignedUpload) // POST /files/presigned
+			files.POST("/:id/completion", fileHandler.ConfirmUpload)  // POST /files/{id}/completion
 
 			// 大文件分片上传
-			files.POST("/multipart", fileHandler.InitMultipartUpload)                // POST /files/multipart
-			files.POST("/:id/multipart/parts", fileHandler.GeneratePartURL)          // POST /files/{id}/multipart/parts
+			files.POST("/multipart", fileHandler.InitMultipartUpload)                    // POST /files/multipart
+			files.POST("/:id/multipart/parts", fileHandler.GeneratePartURL)              // POST /files/{id}/multipart/parts
 			files.POST("/:id/multipart/completion", fileHandler.CompleteMultipartUpload) // POST /files/{id}/multipart/completion
+			files.DELETE("/:id/multipart", fileHandler.AbortMultipartUpload)             // DELETE /files/{id}/multipart
 
 			// 通用操作
-			files.GET("/:id/link", fileHandler.GetDownloadURL)     // GET /files/{id}/link
-			files.GET("/:id/download", fileHandler.DownloadFile)   // GET /files/{id}/download
-			files.GET("/:id", fileHandler.GetFile)                 // GET /files/{id}
-			files.DELETE("/:id", fileHandler.DeleteFile)           // DELETE /files/{id}
+			files.GET("/:id/link", fileHandler.GetDownloadURL)              // GET /files/{id}/link
+			files.GET("/:id/download", fileHandler.DownloadFile)            // GET /files/{id}/download
+			files.GET("/:id", fileHandler.GetFile)                          // GET /files/{id}
+			files.POST("/:id/copy", fileHandler.CopyFile)                   // POST /files/{id}/copy
+			files.GET("/:id/stats", statsHandler.GetFileStats)              // GET /files/{id}/stats
+			files.POST("/:id/shares", shareHandler.CreateShare)             // POST /files/{id}/shares
+			files.GET("/:id/shares", shareHandler.ListShares)               // GET /files/{id}/shares
+			files.DELETE("/:id/shares/:share_id", shareHandler.RevokeShare) // DELETE /files/{id}/shares/{share_id}
+			files.DELETE("/:id", fileHandler.DeleteFile)                    // DELETE /files/{id}
+
+			if scanHandler != nil {
+				files.POST("/:id/scan", scanHandler.ScanFile) // POST /files/{id}/scan
+			}
+			if replicationHandler != nil {
+				files.GET("/:id/replicas", replicationHandler.ListReplicas)   // GET /files/{id}/replicas
+				files.POST("/:id/replicas", replicationHandler.ReplicateFile) // POST /files/{id}/replicas
+			}
+			if lifecycleHandler != nil {
+				files.POST("/:id/restore", lifecycleHandler.RestoreFile)     // POST /files/{id}/restore
+				files.GET("/:id/restore", lifecycleHandler.GetRestoreStatus) // GET /files/{id}/restore
+			}
+		}
+
+		archives := api.Group("/archives")
+		{
+			archives.POST("", archiveHandler.CreateArchive) // POST /archives
+			archives.GET("/:id", archiveHandler.GetArchive) // GET /archives/{id}
+		}
+
+		api.GET("/extractions/:id", extractionHandler.GetExtraction) // GET /extractions/{id}
+		api.GET("/jobs/:id", jobHandler.GetJob)                      // GET /jobs/{id}
+
+		webhooks := api.Group("/webhooks")
+		{
+			webhooks.POST("", webhookHandler.CreateWebhook)                                   // POST /webhooks
+			webhooks.GET("", webhookHandler.ListWebhooks)                                     // GET /webhooks
+			webhooks.GET("/:id", webhookHandler.GetWebhook)                                   // GET /webhooks/{id}
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)                             // DELETE /webhooks/{id}
+			webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)                    // GET /webhooks/{id}/deliveries
+			webhooks.POST("/:id/deliveries/:delivery_id/retry", webhookHandler.RetryDelivery) // POST /webhooks/{id}/deliveries/{delivery_id}/retry
 		}
+
+		api.POST("/storage-events", storageEventHandler.IngestStorageEvents) // POST /storage-events
+
+		// 自定义方法（POST /files:batch）
+		// gin 会把 ":batch" 解析为路径参数，这里按参数值分发
+		api.POST("/files:action", func(c *gin.Context) {
+			switch c.Param("action") {
+			case ":batch":
+				fileHandler.BatchOperate(c)
+			default:
+				c.Error(errors.NewNotFoundError("route not found"))
+			}
+		})
 	}
 
 	// Swagger 文档路由
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		jobManager.Run(workerCtx)
+	}()
+
 	return router
 }
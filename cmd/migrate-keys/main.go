@@ -0,0 +1,113 @@
+// migrate-keys 按当前存储键模板（storage.key_template）重写已有文件的存储键
+//
+// 用法：
+//
+//	go run ./cmd/migrate-keys -dry-run     # 只输出将要迁移的文件
+//	go run ./cmd/migrate-keys -limit 1000  # 迁移前 1000 个文件
+//
+// 每个文件依次执行：复制对象到新键 -> 更新数据库 -> 删除旧对象；中断后可重新执行
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/database"
+	"github.com/NanoBoom/asethub/internal/logger"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func main() {
+	configPath := flag.String("config", "./configs", "配置文件目录")
+	dryRun := flag.Bool("dry-run", false, "只输出将要迁移的文件，不复制对象也不更新数据库")
+	batchSize := flag.Int("batch-size", 100, "每批查询的文件数")
+	limit := flag.Int("limit", 0, "最多迁移的文件数（0 不限制）")
+	flag.Parse()
+
+	_ = godotenv.Load()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	zapLogger, err := logger.New(cfg.App.Env)
+	if err != nil {
+		log.Fatalf("Failed to init logger: %v", err)
+	}
+	defer zapLogger.Sync()
+
+	keyTemplate, err := storage.ParseKeyTemplate(cfg.Storage.KeyTemplate, cfg.Storage.KeyTenant)
+	if err != nil {
+		zapLogger.Fatal("Invalid storage key template", zap.Error(err))
+	}
+
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		zapLogger.Fatal("Failed to connect to database", zap.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	storageBackend, err := storage.NewStorage(ctx, &cfg.Storage, repositories.NewBackendRepository(db))
+	if err != nil {
+		zapLogger.Fatal("Failed to init storage", zap.Error(err))
+	}
+	// 客户端加密的密文原样复制，数据密钥随文件记录保留（加密与未加密前缀之间的迁移会被拒绝）
+	if cfg.Storage.ClientEncryption.Enabled {
+		keyWrapper, err := storage.NewKeyWrapper(cfg.Storage.ClientEncryption)
+		if err != nil {
+			zapLogger.Fatal("Invalid client encryption master key", zap.Error(err))
+		}
+		storageBackend = storage.NewEncryptedStorage(storageBackend, keyWrapper, repositories.NewDataKeyRepository(db), cfg.Storage.ClientEncryption.Prefixes)
+	}
+
+	zapLogger.Info("Migrating storage keys",
+		zap.String("template", keyTemplate.String()),
+		zap.Bool("dry_run", *dryRun),
+	)
+
+	migrator := services.NewKeyMigrator(repositories.NewFileRepository(db), storageBackend, keyTemplate)
+	result, err := migrator.Migrate(ctx, services.KeyMigrationOptions{
+		DryRun:    *dryRun,
+		BatchSize: *batchSize,
+		Limit:     *limit,
+	}, func(r services.KeyMigrationReport) {
+		if r.Err != nil {
+			zapLogger.Error("Failed to migrate file", zap.String("file_id", r.FileID.String()), zap.String("old_key", r.OldKey), zap.String("new_key", r.NewKey), zap.Error(r.Err))
+			return
+		}
+		message := "Migrated file"
+		if *dryRun {
+			message = "Would migrate file"
+		}
+		zapLogger.Info(message, zap.String("file_id", r.FileID.String()), zap.String("old_key", r.OldKey), zap.String("new_key", r.NewKey))
+	})
+
+	fields := []zap.Field{}
+	if result != nil {
+		fields = append(fields,
+			zap.Int("scanned", result.Scanned),
+			zap.Int("migrated", result.Migrated),
+			zap.Int("skipped", result.Skipped),
+			zap.Int("failed", result.Failed),
+		)
+	}
+	if err != nil {
+		zapLogger.Fatal("Storage key migration aborted", append(fields, zap.Error(err))...)
+	}
+	zapLogger.Info("Storage key migration completed", fields...)
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
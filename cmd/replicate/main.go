@@ -0,0 +1,136 @@
+// replicate 将主对象在源后端的文件批量复制到目标后端（storage.backends 中的名称）
+//
+// 用法：
+//
+//	go run ./cmd/replicate -target backup                      # 把默认后端的文件复制到 backup
+//	go run ./cmd/replicate -source oss -target s3 -promote     # 从 oss 迁移到 s3，oss 上的对象保留为副本
+//	go run ./cmd/replicate -target backup -bytes-per-second 10485760
+//
+// 每批处理完成后保存检查点；中断后以相同的 -source、-target 和 -promote 重新执行会从检查点继续
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/database"
+	"github.com/NanoBoom/asethub/internal/logger"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func main() {
+	configPath := flag.String("config", "./configs", "配置文件目录")
+	source := flag.String("source", "", "源后端（只处理主对象在该后端的文件，默认为 storage.routing.default）")
+	target := flag.String("target", "", "目标后端（必填）")
+	promote := flag.Bool("promote", false, "复制后将目标后端设为文件的主后端（迁移），源对象保留为副本")
+	restart := flag.Bool("restart", false, "忽略未完成任务的检查点，从头开始")
+	batchSize := flag.Int("batch-size", 100, "每批查询的文件数")
+	limit := flag.Int("limit", 0, "本次最多复制的文件数（0 不限制）")
+	filesPerSecond := flag.Float64("files-per-second", -1, "每秒最多复制的文件数（0 不限制，默认使用 replication.files_per_second）")
+	bytesPerSecond := flag.Int64("bytes-per-second", -1, "每秒最多复制的字节数（0 不限制，默认使用 replication.bytes_per_second）")
+	flag.Parse()
+
+	_ = godotenv.Load()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	zapLogger, err := logger.New(cfg.App.Env)
+	if err != nil {
+		log.Fatalf("Failed to init logger: %v", err)
+	}
+	defer zapLogger.Sync()
+
+	if *target == "" {
+		zapLogger.Fatal("-target is required")
+	}
+
+	db, err := database.New(&cfg.Database)
+	if err != nil {
+		zapLogger.Fatal("Failed to connect to database", zap.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	storageBackend, err := storage.NewStorage(ctx, &cfg.Storage, repositories.NewBackendRepository(db))
+	if err != nil {
+		zapLogger.Fatal("Failed to init storage", zap.Error(err))
+	}
+	routingStorage, ok := storageBackend.(*storage.RoutingStorage)
+	if !ok {
+		zapLogger.Fatal("Replication requires multiple storage backends (storage.backends)")
+	}
+
+	// 批量复制只复制到 -target，持续复制的 targets 不参与
+	replicationCfg := cfg.Replication
+	replicationCfg.Targets = nil
+	if *filesPerSecond >= 0 {
+		replicationCfg.FilesPerSecond = *filesPerSecond
+	}
+	if *bytesPerSecond >= 0 {
+		replicationCfg.BytesPerSecond = *bytesPerSecond
+	}
+
+	replicator, err := services.NewReplicator(repositories.NewFileRepository(db), repositories.NewReplicationRepository(db), repositories.NewTransactor(db), routingStorage, replicationCfg)
+	if err != nil {
+		zapLogger.Fatal("Invalid replication config", zap.Error(err))
+	}
+
+	zapLogger.Info("Replicating files",
+		zap.String("source", *source),
+		zap.String("target", *target),
+		zap.Bool("promote", *promote),
+		zap.Bool("verify", replicationCfg.Verify),
+	)
+
+	run, err := replicator.Migrate(ctx, services.ReplicationOptions{
+		Source:    *source,
+		Target:    *target,
+		Promote:   *promote,
+		Restart:   *restart,
+		BatchSize: *batchSize,
+		Limit:     *limit,
+	}, func(r services.ReplicationReport) {
+		if r.Err != nil {
+			zapLogger.Error("Failed to replicate file", zap.String("file_id", r.FileID.String()), zap.String("key", r.Key), zap.Error(r.Err))
+			return
+		}
+		if r.Skipped {
+			zapLogger.Debug("Replica is up to date", zap.String("file_id", r.FileID.String()), zap.String("key", r.Key))
+			return
+		}
+		zapLogger.Info("Replicated file", zap.String("file_id", r.FileID.String()), zap.String("key", r.Key), zap.Int64("size", r.Size))
+	})
+
+	fields := []zap.Field{}
+	if run != nil {
+		fields = append(fields,
+			zap.String("run_id", run.ID.String()),
+			zap.String("status", string(run.Status)),
+			zap.Int("scanned", run.Scanned),
+			zap.Int("copied", run.Copied),
+			zap.Int("skipped", run.Skipped),
+			zap.Int("failed", run.Failed),
+			zap.Int64("bytes", run.Bytes),
+		)
+	}
+	if err != nil {
+		zapLogger.Fatal("Replication aborted", append(fields, zap.Error(err))...)
+	}
+	zapLogger.Info("Replication finished", fields...)
+	if run.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -2,7 +2,10 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -48,6 +51,150 @@ func (r *RedisClient) Exists(ctx context.Context, keys ...string) (int64, error)
 	return r.client.Exists(ctx, keys...).Result()
 }
 
+// ErrNotFound 键不存在
+var ErrNotFound = errors.New("cache: key not found")
+
+// SetJSON 将值序列化为 JSON 后写入
+func (r *RedisClient) SetJSON(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+	return r.client.Set(ctx, key, data, expiration).Err()
+}
+
+// GetJSON 读取 JSON 值并反序列化，键不存在时返回 ErrNotFound
+func (r *RedisClient) GetJSON(ctx context.Context, key string, dest interface{}) error {
+	data, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// xaddOnceScript 幂等追加 Stream 消息：幂等键已存在时返回已有消息 ID，否则 XADD 并记录消息 ID
+// KEYS[1]: stream，KEYS[2]: 幂等键；ARGV[1]: 幂等键过期时间（毫秒），ARGV[2]: MAXLEN（0 不裁剪），ARGV[3...]: 字段/值
+var xaddOnceScript = redis.NewScript(`
+local existing = redis.call('GET', KEYS[2])
+if existing then
+	return existing
+end
+local args = {'XADD', KEYS[1]}
+if tonumber(ARGV[2]) > 0 then
+	table.insert(args, 'MAXLEN')
+	table.insert(args, '~')
+	table.insert(args, ARGV[2])
+end
+table.insert(args, '*')
+for i = 3, #ARGV do
+	table.insert(args, ARGV[i])
+end
+local id = redis.call(unpack(args))
+redis.call('SET', KEYS[2], id, 'PX', ARGV[1])
+return id
+`)
+
+// XAddOnce 以幂等键向 Stream 追加消息，同一幂等键在过期前只追加一次，返回消息 ID
+func (r *RedisClient) XAddOnce(ctx context.Context, stream, dedupeKey string, dedupeTTL time.Duration, maxLen int64, values map[string]interface{}) (string, error) {
+	args := []interface{}{dedupeTTL.Milliseconds(), maxLen}
+	for field, value := range values {
+		args = append(args, field, value)
+	}
+	return xaddOnceScript.Run(ctx, r.client, []string{stream, dedupeKey}, args...).Text()
+}
+
+// EnsureStreamGroup 创建消费者组（Stream 不存在时一并创建，组已存在时忽略）
+// 新建的组从 Stream 起始位置消费，保证组创建前追加的消息也不会遗漏
+func (r *RedisClient) EnsureStreamGroup(ctx context.Context, stream, group string) error {
+	err := r.client.XGroupCreateMkStream(ctx, stream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// hincrMaxScript 原子更新 Hash 计数器：前 n 对字段累加，其余字段取最大值
+// KEYS[1]: hash；ARGV[1]: 累加字段对数 n，ARGV[2...]: 字段/值
+var hincrMaxScript = redis.NewScript(`
+local n = tonumber(ARGV[1])
+for i = 2, 2 * n, 2 do
+	redis.call('HINCRBY', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+for i = 2 * n + 2, #ARGV, 2 do
+	local current = tonumber(redis.call('HGET', KEYS[1], ARGV[i]))
+	if not current or current < tonumber(ARGV[i + 1]) then
+		redis.call('HSET', KEYS[1], ARGV[i], ARGV[i + 1])
+	end
+end
+return 1
+`)
+
+// HIncrByMax 原子地累加 incr 中的 Hash 字段，并将 max 中的字段更新为较大值
+func (r *RedisClient) HIncrByMax(ctx context.Context, key string, incr, max map[string]int64) error {
+	args := []interface{}{len(incr)}
+	for field, value := range incr {
+		args = append(args, field, value)
+	}
+	for field, value := range max {
+		args = append(args, field, value)
+	}
+	return hincrMaxScript.Run(ctx, r.client, []string{key}, args...).Err()
+}
+
+// hclaimScript 认领 Hash：claimKey 不存在时将 key 改名为 claimKey 并写入认领 ID，返回 claimKey 的全部字段
+// KEYS[1]: key，KEYS[2]: claimKey；ARGV[1]: 认领 ID 字段名，ARGV[2]: 认领 ID
+var hclaimScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 0 then
+	if redis.call('EXISTS', KEYS[1]) == 0 then
+		return {}
+	end
+	redis.call('RENAME', KEYS[1], KEYS[2])
+	redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+end
+return redis.call('HGETALL', KEYS[2])
+`)
+
+// HClaim 原子地将 Hash 移到 claimKey 后读取，用于先取出再处理、处理成功后删除 claimKey
+// claimKey 已存在（上次处理未完成）时不移动 key，直接返回 claimKey 的内容；idField 字段记录首次认领时的 claimID
+func (r *RedisClient) HClaim(ctx context.Context, key, claimKey, idField, claimID string) (map[string]string, error) {
+	values, err := hclaimScript.Run(ctx, r.client, []string{key, claimKey}, idField, claimID).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		fields[values[i]] = values[i+1]
+	}
+	return fields, nil
+}
+
+// incrWindowScript 计数器加一，新建时设置过期时间（固定窗口计数）
+// KEYS[1]: 计数器；ARGV[1]: 过期时间（毫秒）
+var incrWindowScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// IncrWindow 原子地将计数器加一并返回新值，计数器在首次计数 window 后过期
+func (r *RedisClient) IncrWindow(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return incrWindowScript.Run(ctx, r.client, []string{key}, window.Milliseconds()).Int64()
+}
+
+// Count 读取计数器的值，键不存在时返回 0
+func (r *RedisClient) Count(ctx context.Context, key string) (int64, error) {
+	count, err := r.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return count, err
+}
+
 func (r *RedisClient) Close() error {
 	return r.client.Close()
 }
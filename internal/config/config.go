@@ -1,21 +1,39 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Log      LogConfig      `mapstructure:"log"`
-	Storage  StorageConfig  `mapstructure:"storage"`
+	App           AppConfig           `mapstructure:"app"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Redis         RedisConfig         `mapstructure:"redis"`
+	Log           LogConfig           `mapstructure:"log"`
+	Storage       StorageConfig       `mapstructure:"storage"`
+	Extraction    ExtractionConfig    `mapstructure:"extraction"`
+	Archive       ArchiveConfig       `mapstructure:"archive"`
+	Webhook       WebhookConfig       `mapstructure:"webhook"`
+	Outbox        OutboxConfig        `mapstructure:"outbox"`
+	StorageEvents StorageEventsConfig `mapstructure:"storage_events"`
+	Jobs          JobsConfig          `mapstructure:"jobs"`
+	Scan          ScanConfig          `mapstructure:"scan"`
+	ContentSniff  ContentSniffConfig  `mapstructure:"content_sniff"`
+	MIME          MIMEConfig          `mapstructure:"mime"`
+	Presign       PresignConfig       `mapstructure:"presign"`
+	Replication   ReplicationConfig   `mapstructure:"replication"`
+	Lifecycle     LifecycleConfig     `mapstructure:"lifecycle"`
+	Stats         StatsConfig         `mapstructure:"stats"`
+	Shares        SharesConfig        `mapstructure:"shares"`
 }
 
 type AppConfig struct {
 	Name string `mapstructure:"name"`
 	Port int    `mapstructure:"port"`
 	Env  string `mapstructure:"env"`
+
+	TrustedProxies []string `mapstructure:"trusted_proxies"` // 可信反向代理的 IP 或 CIDR，只有来自这些地址的 X-Forwarded-For 才用于确定客户端 IP（为空时使用连接地址）
 }
 
 type DatabaseConfig struct {
@@ -43,12 +61,86 @@ type LogConfig struct {
 }
 
 type StorageConfig struct {
-	Type  string      `mapstructure:"type"`
+	Type        string      `mapstructure:"type"`
+	S3          S3Config    `mapstructure:"s3"`
+	OSS         OSSConfig   `mapstructure:"oss"`
+	Local       LocalConfig `mapstructure:"local"`
+	KeyTemplate string      `mapstructure:"key_template"` // 存储键模板（如 "{tenant}/{yyyy}/{mm}/{uuid}{ext}"）
+	KeyTenant   string      `mapstructure:"key_tenant"`   // 模板中 {tenant} 的取值
+
+	Encryption       EncryptionConfig       `mapstructure:"encryption"`        // 服务端加密
+	ClientEncryption ClientEncryptionConfig `mapstructure:"client_encryption"` // 客户端信封加密
+	Compression      CompressionConfig      `mapstructure:"compression"`       // 透明压缩
+
+	Backends map[string]BackendConfig `mapstructure:"backends"` // 命名存储后端（为空时只使用 type 指定的单一后端）
+	Routing  RoutingConfig            `mapstructure:"routing"`  // 多后端放置规则
+	Failover FailoverConfig           `mapstructure:"failover"` // 读取故障转移
+}
+
+// BackendConfig 命名存储后端配置
+type BackendConfig struct {
+	Type  string      `mapstructure:"type"` // s3 / oss / local
 	S3    S3Config    `mapstructure:"s3"`
 	OSS   OSSConfig   `mapstructure:"oss"`
 	Local LocalConfig `mapstructure:"local"`
 }
 
+// RoutingConfig 多后端放置规则（新对象按顺序匹配第一条规则，均不匹配时使用 default）
+type RoutingConfig struct {
+	Default string              `mapstructure:"default"` // 默认后端（也用于没有记录后端的已有文件）
+	Rules   []RoutingRuleConfig `mapstructure:"rules"`
+}
+
+// RoutingRuleConfig 放置规则（所有非空条件均满足时匹配）
+type RoutingRuleConfig struct {
+	Backend      string   `mapstructure:"backend"`       // 目标后端名称
+	ContentTypes []string `mapstructure:"content_types"` // 内容类型（"video/*" 匹配整个主类型）
+	MinSize      int64    `mapstructure:"min_size"`      // 最小文件大小（字节）
+	MaxSize      int64    `mapstructure:"max_size"`      // 最大文件大小（字节，0 表示不限）
+	Tenants      []string `mapstructure:"tenants"`       // 租户（存储键的第一段）
+	Tags         []string `mapstructure:"tags"`          // 标签（文件带有其中任一标签时匹配）
+}
+
+// FailoverConfig 读取故障转移配置：主后端读取失败或超时时从副本后端读取
+type FailoverConfig struct {
+	Enabled          bool              `mapstructure:"enabled"`
+	Replicas         map[string]string `mapstructure:"replicas"`          // 主后端名称 -> 副本后端名称
+	Timeout          time.Duration     `mapstructure:"timeout"`           // 主后端返回响应（首字节）的超时
+	FailureThreshold int               `mapstructure:"failure_threshold"` // 连续失败多少次后熔断（直接读取副本）
+	OpenDuration     time.Duration     `mapstructure:"open_duration"`     // 熔断持续时间，之后放行一个探测请求
+}
+
+// CompressionConfig 透明压缩配置（后端直接上传的可压缩内容压缩后保存，读取时自动解压）
+type CompressionConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	Codec        string   `mapstructure:"codec"`         // 压缩编码（gzip / zstd）
+	Level        int      `mapstructure:"level"`         // 压缩级别（gzip 1-9，zstd 1-22，0 使用默认级别）
+	MinSize      int64    `mapstructure:"min_size"`      // 小于该大小（字节）的文件不压缩
+	ContentTypes []string `mapstructure:"content_types"` // 可压缩的内容类型（"text/*" 匹配整个主类型）
+}
+
+// ClientEncryptionConfig 客户端信封加密配置（对象在上传前由服务加密，存储服务只保存密文）
+type ClientEncryptionConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`
+	MasterKey   string   `mapstructure:"master_key"`    // Base64 编码的 256 位主密钥
+	MasterKeyID string   `mapstructure:"master_key_id"` // 主密钥 ID（记录在文件的数据密钥上，默认 master）
+	KeyringFile string   `mapstructure:"keyring_file"`  // 主密钥环文件（JSON，支持主密钥轮换，优先于 master_key）
+	Prefixes    []string `mapstructure:"prefixes"`      // 需要加密的存储键前缀（为空时加密所有对象）
+}
+
+// EncryptionConfig 服务端加密配置（部署级默认设置 + 按租户覆盖）
+type EncryptionConfig struct {
+	EncryptionRule `mapstructure:",squash"`
+	Tenants        map[string]EncryptionRule `mapstructure:"tenants"` // 租户 -> 加密设置（匹配以 "<tenant>/" 开头的存储键）
+}
+
+// EncryptionRule 服务端加密设置
+type EncryptionRule struct {
+	Mode      string `mapstructure:"mode"`       // none / sse-s3 / sse-kms / sse-c（sse-c 仅 S3，客户端每个请求携带密钥）
+	KMSKeyID  string `mapstructure:"kms_key_id"` // KMS 密钥 ID 或 ARN（sse-kms，为空时使用默认 KMS 密钥）
+	BucketKey bool   `mapstructure:"bucket_key"` // 启用 S3 Bucket Key（sse-kms）
+}
+
 type S3Config struct {
 	Region          string `mapstructure:"region"`
 	Bucket          string `mapstructure:"bucket"`
@@ -69,12 +161,231 @@ type LocalConfig struct {
 	BasePath string `mapstructure:"base_path"`
 }
 
+// ExtractionConfig 压缩包服务端解压配置（防 zip bomb 限制）
+type ExtractionConfig struct {
+	MaxEntries   int     `mapstructure:"max_entries"`    // 最大条目数
+	MaxTotalSize int64   `mapstructure:"max_total_size"` // 解压后总大小上限（字节）
+	MaxEntrySize int64   `mapstructure:"max_entry_size"` // 单个条目解压后大小上限（字节）
+	MaxRatio     float64 `mapstructure:"max_ratio"`      // 最大压缩比（解压后大小 / 压缩包大小）
+	Queue        string  `mapstructure:"queue"`          // 解压任务所在队列
+}
+
+// ArchiveConfig 多文件归档下载配置
+type ArchiveConfig struct {
+	Queue string `mapstructure:"queue"` // 异步归档任务所在队列
+}
+
+// WebhookConfig Webhook 投递配置
+type WebhookConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`    // 最大投递次数（超过后进入死信）
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"` // 首次重试间隔（之后指数增长）
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`     // 最大重试间隔
+	Timeout        time.Duration `mapstructure:"timeout"`         // 单次请求超时
+	PollInterval   time.Duration `mapstructure:"poll_interval"`   // 投递队列轮询间隔
+	BatchSize      int           `mapstructure:"batch_size"`      // 每次轮询领取的投递数
+
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"` // 允许投递到内网、环回和链路本地地址（仅用于开发环境）
+}
+
+// OutboxConfig 事务性发件箱中继配置
+type OutboxConfig struct {
+	PollInterval   time.Duration `mapstructure:"poll_interval"`   // 轮询未发布事件的间隔
+	BatchSize      int           `mapstructure:"batch_size"`      // 每批发布的事件数
+	Retention      time.Duration `mapstructure:"retention"`       // 已发布事件的保留时间
+	Stream         string        `mapstructure:"stream"`          // Redis Stream 名称
+	StreamMaxLen   int64         `mapstructure:"stream_max_len"`  // Stream 近似最大长度（0 不裁剪）
+	DedupeTTL      time.Duration `mapstructure:"dedupe_ttl"`      // 发布幂等键的保留时间
+	ConsumerGroups []string      `mapstructure:"consumer_groups"` // 预先创建的消费者组
+}
+
+// StorageEventsConfig 存储桶事件通知配置
+type StorageEventsConfig struct {
+	Secret string `mapstructure:"secret"` // 通知回调的共享密钥（为空时不接收通知）
+}
+
+// JobsConfig 异步任务队列配置
+type JobsConfig struct {
+	Prefix            string         `mapstructure:"prefix"`             // Redis 键前缀
+	Queues            map[string]int `mapstructure:"queues"`             // 队列名 -> 并发数
+	PollInterval      time.Duration  `mapstructure:"poll_interval"`      // 队列空闲时的轮询间隔
+	VisibilityTimeout time.Duration  `mapstructure:"visibility_timeout"` // 领取后未续期的任务在此时间后重新入队
+	MaxAttempts       int            `mapstructure:"max_attempts"`       // 默认最大执行次数
+	InitialBackoff    time.Duration  `mapstructure:"initial_backoff"`    // 首次重试间隔（之后指数增长）
+	MaxBackoff        time.Duration  `mapstructure:"max_backoff"`        // 最大重试间隔
+	Retention         time.Duration  `mapstructure:"retention"`          // 已结束任务记录的保留时间
+	ShutdownTimeout   time.Duration  `mapstructure:"shutdown_timeout"`   // 关闭时等待进行中任务的时间
+}
+
+// ScanConfig 恶意文件扫描配置（ClamAV clamd）
+type ScanConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`  // 是否在文件上传完成后扫描
+	Address  string        `mapstructure:"address"`  // clamd 地址（host:port 或 Unix socket 路径）
+	Timeout  time.Duration `mapstructure:"timeout"`  // 单次读写超时
+	Required bool          `mapstructure:"required"` // 仅允许下载扫描结果为 clean 的文件
+	Queue    string        `mapstructure:"queue"`    // 扫描任务所在队列
+}
+
+// 内容类型校验模式
+const (
+	ContentSniffOff    = "off"    // 不校验
+	ContentSniffFlag   = "flag"   // 记录不一致，下载时强制 attachment
+	ContentSniffReject = "reject" // 拒绝不一致的上传并删除对象
+)
+
+// ContentSniffConfig 上传完成后的内容类型校验配置（读取文件头识别真实类型）
+type ContentSniffConfig struct {
+	Mode      string `mapstructure:"mode"`       // off / flag / reject
+	ReadBytes int64  `mapstructure:"read_bytes"` // 读取的文件头字节数
+}
+
+// MIMEConfig MIME 类型注册表扩展配置（在内置数据库基础上追加或覆盖）
+type MIMEConfig struct {
+	File  string           `mapstructure:"file"`  // 额外的 mime.types 格式文件
+	Types []MIMETypeConfig `mapstructure:"types"` // 追加的类型
+}
+
+// MIMETypeConfig 自定义 MIME 类型
+type MIMETypeConfig struct {
+	Type       string   `mapstructure:"type"`       // MIME 类型
+	Extensions []string `mapstructure:"extensions"` // 扩展名（第一个为规范扩展名）
+	Aliases    []string `mapstructure:"aliases"`    // 别名（如 audio/x-wav）
+}
+
+// PresignConfig 预签名 URL 有效期配置（客户端指定的有效期须在 [MinExpiry, MaxExpiry] 范围内）
+type PresignConfig struct {
+	UploadExpiry   time.Duration `mapstructure:"upload_expiry"`   // 上传和分片 URL 的默认有效期
+	DownloadExpiry time.Duration `mapstructure:"download_expiry"` // 下载 URL 的默认有效期
+	MinExpiry      time.Duration `mapstructure:"min_expiry"`      // 客户端可指定的最短有效期
+	MaxExpiry      time.Duration `mapstructure:"max_expiry"`      // 客户端可指定的最长有效期（S3 SigV4 上限 7 天）
+}
+
+// ReplicationConfig 跨后端复制配置（副本后端须在 storage.backends 中配置）
+type ReplicationConfig struct {
+	Enabled        bool     `mapstructure:"enabled"`          // 是否持续复制（文件上传完成后复制到 Targets）
+	Targets        []string `mapstructure:"targets"`          // 副本所在的后端名称
+	Queue          string   `mapstructure:"queue"`            // 复制任务所在队列
+	Verify         bool     `mapstructure:"verify"`           // 复制后读回副本比对 SHA-256
+	FilesPerSecond float64  `mapstructure:"files_per_second"` // 每秒最多复制的文件数（0 不限制）
+	BytesPerSecond int64    `mapstructure:"bytes_per_second"` // 每秒最多复制的字节数（0 不限制）
+}
+
+// LifecycleConfig 存储分层和生命周期配置（存储类型转换和归档取回需要 S3 或 OSS 后端）
+type LifecycleConfig struct {
+	Enabled     bool                  `mapstructure:"enabled"`      // 是否启用生命周期任务和归档取回接口
+	Interval    time.Duration         `mapstructure:"interval"`     // 评估规则的间隔
+	BatchSize   int                   `mapstructure:"batch_size"`   // 每批评估的文件数
+	RestoreDays int                   `mapstructure:"restore_days"` // 取回副本的默认保留天数
+	RestoreTier string                `mapstructure:"restore_tier"` // 默认取回优先级（expedited / standard / bulk）
+	Rules       []LifecycleRuleConfig `mapstructure:"rules"`        // 生命周期规则（按顺序匹配第一条）
+}
+
+// LifecycleRuleConfig 生命周期规则（过滤条件均满足的文件适用该规则，未设置的条件不限制）
+type LifecycleRuleConfig struct {
+	Name                  string                      `mapstructure:"name"`                     // 规则名称
+	Folder                string                      `mapstructure:"folder"`                   // 虚拟目录（含子目录）
+	Tags                  []string                    `mapstructure:"tags"`                     // 文件含任一标签
+	Transitions           []LifecycleTransitionConfig `mapstructure:"transitions"`              // 存储类型转换（满足条件的最冷类型生效）
+	ExpireDays            int                         `mapstructure:"expire_days"`              // 上传后 N 天永久删除（0 不删除）
+	ExpireDaysSinceAccess int                         `mapstructure:"expire_days_since_access"` // 最后一次下载后 N 天永久删除（0 不删除，从未下载时按上传时间）
+}
+
+// LifecycleTransitionConfig 存储类型转换（设置的条件均满足时转换）
+type LifecycleTransitionConfig struct {
+	StorageClass    string `mapstructure:"storage_class"`     // 目标存储类型（infrequent_access / archive / cold_archive）
+	Days            int    `mapstructure:"days"`              // 上传后天数
+	DaysSinceAccess int    `mapstructure:"days_since_access"` // 最后一次下载后天数（从未下载时按上传时间）
+}
+
+// StatsConfig 文件下载统计配置（计数器先写入 Redis，定期汇总到数据库）
+type StatsConfig struct {
+	Key           string        `mapstructure:"key"`            // Redis 计数器的键前缀
+	FlushInterval time.Duration `mapstructure:"flush_interval"` // 汇总到数据库的间隔
+	MaxDays       int           `mapstructure:"max_days"`       // 查询每日统计的最大天数
+}
+
+// SharesConfig 文件分享链接配置
+type SharesConfig struct {
+	BaseURL        string        `mapstructure:"base_url"`        // 分享链接的地址前缀（如 https://assets.example.com，为空时返回相对路径 /s/{token}）
+	RedirectExpiry time.Duration `mapstructure:"redirect_expiry"` // 重定向时生成的预签名 URL 有效期
+	MaxExpiry      time.Duration `mapstructure:"max_expiry"`      // 分享链接的最长有效期（0 不限制，可创建永不过期的链接）
+
+	MaxPasswordAttempts int           `mapstructure:"max_password_attempts"` // 密码连续错误达到该次数后锁定链接（0 不锁定）
+	LockoutDuration     time.Duration `mapstructure:"lockout_duration"`      // 链接锁定时长（从第一次密码错误开始计算）
+	IPAttemptLimit      int           `mapstructure:"ip_attempt_limit"`      // 同一客户端 IP 在窗口内最多校验密码的次数（0 不限制）
+	IPAttemptWindow     time.Duration `mapstructure:"ip_attempt_window"`     // 客户端 IP 限流的时间窗口
+}
+
 func Load(path string) (*Config, error) {
 	viper.SetDefault("app.port", 8080)
 	viper.SetDefault("app.env", "development")
 	viper.SetDefault("database.max_open_conns", 10)
 	viper.SetDefault("database.max_idle_conns", 5)
 	viper.SetDefault("redis.pool_size", 10)
+	viper.SetDefault("storage.key_template", "files/{unix}/{uuid}{ext}")
+	viper.SetDefault("storage.encryption.mode", "none")
+	viper.SetDefault("storage.client_encryption.enabled", false)
+	viper.SetDefault("storage.client_encryption.master_key_id", "master")
+	viper.SetDefault("storage.compression.enabled", false)
+	viper.SetDefault("storage.compression.codec", "gzip")
+	viper.SetDefault("storage.compression.min_size", 1024)
+	viper.SetDefault("storage.compression.content_types", []string{
+		"text/*", "application/json", "application/x-ndjson", "application/xml",
+		"application/javascript", "application/yaml", "image/svg+xml",
+	})
+	viper.SetDefault("storage.failover.timeout", "5s")
+	viper.SetDefault("storage.failover.failure_threshold", 5)
+	viper.SetDefault("storage.failover.open_duration", "30s")
+	viper.SetDefault("extraction.max_entries", 10000)
+	viper.SetDefault("extraction.max_total_size", 10*1024*1024*1024)
+	viper.SetDefault("extraction.max_entry_size", 5*1024*1024*1024)
+	viper.SetDefault("extraction.max_ratio", 100)
+	viper.SetDefault("extraction.queue", "default")
+	viper.SetDefault("archive.queue", "default")
+	viper.SetDefault("webhook.max_attempts", 8)
+	viper.SetDefault("webhook.initial_backoff", "30s")
+	viper.SetDefault("webhook.max_backoff", "1h")
+	viper.SetDefault("webhook.timeout", "10s")
+	viper.SetDefault("webhook.poll_interval", "5s")
+	viper.SetDefault("webhook.batch_size", 50)
+	viper.SetDefault("webhook.allow_private_networks", false)
+	viper.SetDefault("outbox.poll_interval", "1s")
+	viper.SetDefault("outbox.batch_size", 100)
+	viper.SetDefault("outbox.retention", "168h")
+	viper.SetDefault("outbox.stream", "assethub:events")
+	viper.SetDefault("outbox.stream_max_len", 100000)
+	viper.SetDefault("outbox.dedupe_ttl", "168h")
+	viper.SetDefault("jobs.prefix", "assethub:jobs")
+	viper.SetDefault("jobs.queues", map[string]int{"default": 4})
+	viper.SetDefault("jobs.poll_interval", "1s")
+	viper.SetDefault("jobs.visibility_timeout", "5m")
+	viper.SetDefault("jobs.max_attempts", 5)
+	viper.SetDefault("jobs.initial_backoff", "10s")
+	viper.SetDefault("jobs.max_backoff", "10m")
+	viper.SetDefault("jobs.retention", "168h")
+	viper.SetDefault("jobs.shutdown_timeout", "30s")
+	viper.SetDefault("scan.address", "localhost:3310")
+	viper.SetDefault("scan.timeout", "30s")
+	viper.SetDefault("scan.queue", "default")
+	viper.SetDefault("content_sniff.mode", ContentSniffFlag)
+	viper.SetDefault("content_sniff.read_bytes", 3072)
+	viper.SetDefault("presign.upload_expiry", "1h")
+	viper.SetDefault("presign.download_expiry", "15m")
+	viper.SetDefault("presign.min_expiry", "1m")
+	viper.SetDefault("presign.max_expiry", "168h")
+	viper.SetDefault("replication.queue", "default")
+	viper.SetDefault("replication.verify", true)
+	viper.SetDefault("lifecycle.interval", "1h")
+	viper.SetDefault("lifecycle.batch_size", 100)
+	viper.SetDefault("lifecycle.restore_days", 7)
+	viper.SetDefault("lifecycle.restore_tier", "standard")
+	viper.SetDefault("stats.key", "stats:downloads")
+	viper.SetDefault("stats.flush_interval", "1m")
+	viper.SetDefault("stats.max_days", 365)
+	viper.SetDefault("shares.redirect_expiry", "5m")
+	viper.SetDefault("shares.max_password_attempts", 5)
+	viper.SetDefault("shares.lockout_duration", "15m")
+	viper.SetDefault("shares.ip_attempt_limit", 20)
+	viper.SetDefault("shares.ip_attempt_window", "1m")
 
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -98,9 +409,25 @@ func Load(path string) (*Config, error) {
 	viper.BindEnv("redis.password", "REDIS_PASSWORD")
 	viper.BindEnv("redis.db", "REDIS_DB")
 	viper.BindEnv("log.level", "LOG_LEVEL")
+	viper.BindEnv("webhook.allow_private_networks", "WEBHOOK_ALLOW_PRIVATE_NETWORKS")
+	viper.BindEnv("storage_events.secret", "STORAGE_EVENTS_SECRET")
+	viper.BindEnv("scan.enabled", "SCAN_ENABLED")
+	viper.BindEnv("scan.address", "CLAMAV_ADDRESS")
+	viper.BindEnv("replication.enabled", "REPLICATION_ENABLED")
+	viper.BindEnv("lifecycle.enabled", "LIFECYCLE_ENABLED")
+	viper.BindEnv("shares.base_url", "SHARES_BASE_URL")
 
 	// Storage 配置绑定环境变量
 	viper.BindEnv("storage.type", "STORAGE_TYPE")
+	viper.BindEnv("storage.key_template", "STORAGE_KEY_TEMPLATE")
+	viper.BindEnv("storage.key_tenant", "STORAGE_KEY_TENANT")
+	viper.BindEnv("storage.encryption.mode", "STORAGE_ENCRYPTION_MODE")
+	viper.BindEnv("storage.encryption.kms_key_id", "STORAGE_KMS_KEY_ID")
+	viper.BindEnv("storage.client_encryption.enabled", "CLIENT_ENCRYPTION_ENABLED")
+	viper.BindEnv("storage.client_encryption.master_key", "CLIENT_ENCRYPTION_MASTER_KEY")
+	viper.BindEnv("storage.client_encryption.keyring_file", "CLIENT_ENCRYPTION_KEYRING_FILE")
+	viper.BindEnv("storage.compression.enabled", "STORAGE_COMPRESSION_ENABLED")
+	viper.BindEnv("storage.failover.enabled", "STORAGE_FAILOVER_ENABLED")
 	viper.BindEnv("storage.s3.region", "S3_REGION")
 	viper.BindEnv("storage.s3.bucket", "S3_BUCKET")
 	viper.BindEnv("storage.s3.access_key_id", "S3_ACCESS_KEY_ID")
@@ -26,3 +26,15 @@ func NewBadRequestError(message string, err error) *AppError {
 func NewInternalError(err error) *AppError {
 	return &AppError{Code: 500, Message: "Internal server error", Err: err}
 }
+
+func NewUnauthorizedError(message string) *AppError {
+	return &AppError{Code: 401, Message: message}
+}
+
+func NewForbiddenError(message string) *AppError {
+	return &AppError{Code: 403, Message: message}
+}
+
+func NewTooManyRequestsError(message string) *AppError {
+	return &AppError{Code: 429, Message: message}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ArchiveHandler 多文件归档下载处理器
+type ArchiveHandler struct {
+	archiveService services.ArchiveService
+}
+
+// NewArchiveHandler 创建归档处理器实例
+func NewArchiveHandler(archiveService services.ArchiveService) *ArchiveHandler {
+	return &ArchiveHandler{
+		archiveService: archiveService,
+	}
+}
+
+// CreateArchiveRequest 创建归档请求（file_ids 与 folder 二选一）
+type CreateArchiveRequest struct {
+	FileIDs []uuid.UUID `json:"file_ids" binding:"max=10000"`
+	Folder  string      `json:"folder" example:"/campaigns/2026"`
+	Async   bool        `json:"async" example:"false"` // 异步打包，完成后通过 GET /archives/{id} 获取下载链接
+}
+
+// ArchiveTaskResponse 异步归档任务响应
+type ArchiveTaskResponse struct {
+	ArchiveID   uuid.UUID `json:"archive_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Status      string    `json:"status" example:"completed"`
+	FileCount   int       `json:"file_count" example:"12"`
+	Size        int64     `json:"size" example:"10485760"`
+	DownloadURL string    `json:"download_url,omitempty" example:"https://s3.amazonaws.com/..."`
+	ExpiresIn   int64     `json:"expires_in,omitempty" example:"3600"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   string    `json:"created_at" example:"2026-02-06T00:00:00Z"`
+	ExpiresAt   string    `json:"expires_at" example:"2026-02-07T00:00:00Z"`
+}
+
+// CreateArchive godoc
+// @Summary      打包下载多个文件
+// @Description  将多个文件（或整个目录）打包为 ZIP。同步模式直接流式返回 ZIP；异步模式返回任务，完成后获取预签名下载链接
+// @Tags         Archive
+// @Accept       json
+// @Produce      application/zip
+// @Produce      json
+// @Param        body body CreateArchiveRequest true "归档内容"
+// @Success      200 {file} binary "ZIP 内容（同步模式）"
+// @Success      202 {object} response.Response{data=ArchiveTaskResponse} "异步模式"
+// @Failure      400 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/archives [post]
+func (h *ArchiveHandler) CreateArchive(c *gin.Context) {
+	var req CreateArchiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("invalid request", err))
+		return
+	}
+
+	archiveReq := services.ArchiveRequest{
+		FileIDs: req.FileIDs,
+		Folder:  req.Folder,
+	}
+
+	// 异步模式：创建任务后立即返回
+	if req.Async {
+		task, err := h.archiveService.CreateArchiveTask(c.Request.Context(), archiveReq)
+		if err != nil {
+			c.Error(archiveError(err))
+			return
+		}
+
+		c.Status(http.StatusAccepted)
+		response.Success(c, newArchiveTaskResponse(task))
+		return
+	}
+
+	// 同步模式：先解析文件（此时仍可返回错误响应），再流式写入 ZIP
+	files, err := h.archiveService.ResolveFiles(c.Request.Context(), archiveReq)
+	if err != nil {
+		c.Error(archiveError(err))
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"archive-%d.zip\"", time.Now().Unix()))
+	c.Status(http.StatusOK)
+
+	if err := h.archiveService.WriteArchive(c.Request.Context(), archiveReq, files, c.Writer); err != nil {
+		// 注意：此时已经开始写入响应，无法返回错误响应
+		// 只能记录日志
+		c.Error(errors.NewInternalError(err))
+		return
+	}
+}
+
+// GetArchive godoc
+// @Summary      查询异步归档任务
+// @Description  查询归档任务状态，完成后返回预签名下载链接
+// @Tags         Archive
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "归档任务 UUID" format(uuid)
+// @Success      200 {object} response.Response{data=ArchiveTaskResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/archives/{id} [get]
+func (h *ArchiveHandler) GetArchive(c *gin.Context) {
+	// 解析 UUID
+	archiveIDStr := c.Param("id")
+	archiveID, err := uuid.Parse(archiveIDStr)
+	if err != nil || archiveID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	task, err := h.archiveService.GetArchiveTask(c.Request.Context(), archiveID)
+	if err != nil {
+		c.Error(archiveError(err))
+		return
+	}
+
+	response.Success(c, newArchiveTaskResponse(task))
+}
+
+// newArchiveTaskResponse 转换异步归档任务响应
+func newArchiveTaskResponse(task *services.ArchiveTask) ArchiveTaskResponse {
+	resp := ArchiveTaskResponse{
+		ArchiveID:   task.ID,
+		Status:      string(task.Status),
+		FileCount:   task.FileCount,
+		Size:        task.Size,
+		DownloadURL: task.DownloadURL,
+		Error:       task.Error,
+		CreatedAt:   task.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:   task.ExpiresAt.Format(time.RFC3339),
+	}
+	if task.DownloadURL != "" {
+		resp.ExpiresIn = 3600 // 1 小时
+	}
+	return resp
+}
+
+// archiveError 转换归档服务错误
+func archiveError(err error) *errors.AppError {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return errors.NewNotFoundError(msg)
+	case strings.Contains(msg, "required"),
+		strings.Contains(msg, "mutually exclusive"),
+		strings.Contains(msg, "too many"):
+		return errors.NewBadRequestError(msg, err)
+	default:
+		return errors.NewInternalError(err)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ExtractionHandler 压缩包解压任务处理器
+type ExtractionHandler struct {
+	extractionService services.ExtractionService
+}
+
+// NewExtractionHandler 创建解压任务处理器实例
+func NewExtractionHandler(extractionService services.ExtractionService) *ExtractionHandler {
+	return &ExtractionHandler{
+		extractionService: extractionService,
+	}
+}
+
+// ExtractionJobResponse 解压任务响应
+type ExtractionJobResponse struct {
+	JobID            uuid.UUID `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ArchiveFileID    uuid.UUID `json:"archive_file_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Folder           string    `json:"folder" example:"/asset-pack"`
+	Status           string    `json:"status" example:"running"`
+	TotalEntries     int       `json:"total_entries" example:"120"`
+	ProcessedEntries int       `json:"processed_entries" example:"48"`
+	CreatedFiles     int       `json:"created_files" example:"45"`
+	SkippedEntries   int       `json:"skipped_entries" example:"3"`
+	BytesExtracted   int64     `json:"bytes_extracted" example:"10485760"`
+	Error            string    `json:"error,omitempty"`
+	CreatedAt        string    `json:"created_at" example:"2026-02-06T00:00:00Z"`
+	UpdatedAt        string    `json:"updated_at" example:"2026-02-06T00:00:10Z"`
+}
+
+// GetExtraction godoc
+// @Summary      查询解压任务进度
+// @Description  查询压缩包服务端解压任务的状态和进度
+// @Tags         Extraction
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "解压任务 UUID" format(uuid)
+// @Success      200 {object} response.Response{data=ExtractionJobResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/extractions/{id} [get]
+func (h *ExtractionHandler) GetExtraction(c *gin.Context) {
+	// 解析 UUID
+	jobIDStr := c.Param("id")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil || jobID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	job, err := h.extractionService.GetExtractionJob(c.Request.Context(), jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("extraction job not found"))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	response.Success(c, ExtractionJobResponse{
+		JobID:            job.ID,
+		ArchiveFileID:    job.ArchiveFileID,
+		Folder:           job.Folder,
+		Status:           string(job.Status),
+		TotalEntries:     job.TotalEntries,
+		ProcessedEntries: job.ProcessedEntries,
+		CreatedFiles:     job.CreatedFiles,
+		SkippedEntries:   job.SkippedEntries,
+		BytesExtracted:   job.BytesExtracted,
+		Error:            job.Error,
+		CreatedAt:        job.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        job.UpdatedAt.Format(time.RFC3339),
+	})
+}
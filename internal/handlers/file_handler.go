@@ -18,13 +18,15 @@ import (
 
 // FileHandler 文件处理器
 type FileHandler struct {
-	fileService services.FileService
+	fileService       services.FileService
+	extractionService services.ExtractionService
 }
 
 // NewFileHandler 创建文件处理器实例
-func NewFileHandler(fileService services.FileService) *FileHandler {
+func NewFileHandler(fileService services.FileService, extractionService services.ExtractionService) *FileHandler {
 	return &FileHandler{
-		fileService: fileService,
+		fileService:       fileService,
+		extractionService: extractionService,
 	}
 }
 
@@ -32,8 +34,13 @@ func NewFileHandler(fileService services.FileService) *FileHandler {
 
 // UploadDirectRequest 直接上传请求
 type UploadDirectRequest struct {
-	Name        string `form:"name" binding:"required" example:"example.txt"`
-	ContentType string `form:"content_type" example:"text/plain"`
+	Name              string   `form:"name" binding:"required" example:"example.txt"`
+	ContentType       string   `form:"content_type" example:"text/plain"`
+	Extract           bool     `form:"extract" example:"false"`                                         // 上传后在服务端解压（仅 .zip/.tar/.tar.gz）
+	ExtractFolder     string   `form:"extract_folder" example:"/asset-pack"`                            // 解压目标目录（可选）
+	ChecksumAlgorithm string   `form:"checksum_algorithm" example:"sha256"`                             // 校验算法（md5 / crc32c / sha256，可选）
+	Checksum          string   `form:"checksum" example:"n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg="` // Base64 编码的摘要
+	Tags              []string `form:"tags"`                                                            // 文件标签（可重复，同时用于匹配存储放置规则）
 }
 
 // UploadDirectResponse 直接上传响应
@@ -43,22 +50,32 @@ type UploadDirectResponse struct {
 	Size        int64     `json:"size" example:"1024"`
 	StorageKey  string    `json:"storage_key" example:"files/1234567890/example.txt"`
 	Status      string    `json:"status" example:"completed"`
-	DownloadURL string    `json:"download_url" example:"https://s3.amazonaws.com/..."`
+	DownloadURL string    `json:"download_url,omitempty" example:"https://s3.amazonaws.com/..."` // 文件暂不可下载时为空（如 scan.required 时尚未扫描），之后通过 GET /files/{id}/link 获取
+	// 解压任务 ID（仅 extract=true 时返回）
+	ExtractionJobID *uuid.UUID `json:"extraction_job_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
 }
 
 // InitPresignedUploadRequest 初始化预签名上传请求
 type InitPresignedUploadRequest struct {
-	Name        string `json:"name" binding:"required" example:"example.txt"`
-	ContentType string `json:"content_type" example:"text/plain"`
-	Size        int64  `json:"size" binding:"required" example:"1024"`
+	Name              string   `json:"name" binding:"required" example:"example.txt"`
+	ContentType       string   `json:"content_type" example:"text/plain"`
+	Size              int64    `json:"size" binding:"required" example:"1024"`
+	ExpiresIn         int64    `json:"expires_in" binding:"omitempty,min=1" example:"3600"`             // URL 有效期（秒，可选，须在 presign.min_expiry ~ max_expiry 范围内）
+	Method            string   `json:"method" binding:"omitempty,oneof=put post" example:"put"`         // put（预签名 URL，默认）或 post（表单策略，强制文件大小）
+	ChecksumAlgorithm string   `json:"checksum_algorithm" example:"sha256"`                             // 校验算法（md5 / crc32c / sha256，可选；OSS 仅支持 md5）
+	Checksum          string   `json:"checksum" example:"n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg="` // Base64 编码的摘要（签名到上传请求）
+	Tags              []string `json:"tags" example:"campaign"`                                         // 文件标签（同时用于匹配存储放置规则）
 }
 
 // InitPresignedUploadResponse 初始化预签名上传响应
 type InitPresignedUploadResponse struct {
-	FileID     uuid.UUID `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	UploadURL  string    `json:"upload_url" example:"https://s3.amazonaws.com/..."`
-	StorageKey string    `json:"storage_key" example:"files/1234567890/example.txt"`
-	ExpiresIn  int64     `json:"expires_in" example:"3600"`
+	FileID     uuid.UUID         `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UploadURL  string            `json:"upload_url" example:"https://s3.amazonaws.com/..."`
+	Method     string            `json:"method" example:"PUT"`
+	Headers    map[string]string `json:"headers,omitempty"` // PUT 上传须携带的请求头（已签名，如 Content-Type、校验值和服务端加密头）
+	Fields     map[string]string `json:"fields,omitempty"`  // POST 表单字段（仅 method=post 时返回，须在 file 字段之前提交）
+	StorageKey string            `json:"storage_key" example:"files/1234567890/example.txt"`
+	ExpiresIn  int64             `json:"expires_in" example:"3600"`
 }
 
 // ConfirmUploadRequest 确认上传请求
@@ -74,9 +91,11 @@ type ConfirmUploadResponse struct {
 
 // InitMultipartUploadRequest 初始化分片上传请求
 type InitMultipartUploadRequest struct {
-	Name        string `json:"name" binding:"required" example:"large-video.mp4"`
-	ContentType string `json:"content_type" example:"video/mp4"`
-	Size        int64  `json:"size" binding:"required" example:"104857600"`
+	Name              string   `json:"name" binding:"required" example:"large-video.mp4"`
+	ContentType       string   `json:"content_type" example:"video/mp4"`
+	Size              int64    `json:"size" binding:"required" example:"104857600"`
+	ChecksumAlgorithm string   `json:"checksum_algorithm" example:"crc32c"` // 分片校验算法（可选，声明后每个分片须提供校验值）
+	Tags              []string `json:"tags" example:"raw-footage"`          // 文件标签（同时用于匹配存储放置规则）
 }
 
 // InitMultipartUploadResponse 初始化分片上传响应
@@ -88,51 +107,79 @@ type InitMultipartUploadResponse struct {
 
 // GeneratePartURLRequest 生成分片 URL 请求
 type GeneratePartURLRequest struct {
-	FileID     uuid.UUID `json:"file_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
-	PartNumber int       `json:"part_number" binding:"required,min=1" example:"1"`
+	FileID            uuid.UUID `json:"file_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	PartNumber        int       `json:"part_number" binding:"required,min=1" example:"1"`
+	ExpiresIn         int64     `json:"expires_in" binding:"omitempty,min=1" example:"3600"` // URL 有效期（秒，可选）
+	ChecksumAlgorithm string    `json:"checksum_algorithm" example:"crc32c"`                 // 分片校验算法（须与初始化时一致）
+	Checksum          string    `json:"checksum" example:"yZRlqg=="`                         // 分片的 Base64 摘要
 }
 
 // GeneratePartURLResponse 生成分片 URL 响应
 type GeneratePartURLResponse struct {
-	PartNumber int    `json:"part_number" example:"1"`
-	UploadURL  string `json:"upload_url" example:"https://s3.amazonaws.com/..."`
-	ExpiresIn  int64  `json:"expires_in" example:"3600"`
+	PartNumber int               `json:"part_number" example:"1"`
+	UploadURL  string            `json:"upload_url" example:"https://s3.amazonaws.com/..."`
+	Headers    map[string]string `json:"headers,omitempty"` // 须携带的已签名请求头（SSE-C 时还须携带 X-Amz-Server-Side-Encryption-Customer-Key）
+	ExpiresIn  int64             `json:"expires_in" example:"3600"`
 }
 
 // CompleteMultipartUploadRequest 完成分片上传请求
 type CompleteMultipartUploadRequest struct {
-	FileID uuid.UUID              `json:"file_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
-	Parts  []CompletedPartRequest `json:"parts" binding:"required"`
+	FileID            uuid.UUID              `json:"file_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Parts             []CompletedPartRequest `json:"parts" binding:"required"`
+	Extract           bool                   `json:"extract" example:"false"`              // 完成后在服务端解压（仅 .zip/.tar/.tar.gz）
+	ExtractFolder     string                 `json:"extract_folder" example:"/asset-pack"` // 解压目标目录（可选）
+	ChecksumAlgorithm string                 `json:"checksum_algorithm" example:"crc32c"`  // 分片校验算法（初始化时声明了校验算法时必填）
 }
 
 // CompletedPartRequest 已完成的分片
 type CompletedPartRequest struct {
 	PartNumber int    `json:"part_number" binding:"required" example:"1"`
 	ETag       string `json:"etag" binding:"required" example:"\"abc123\""`
+	Checksum   string `json:"checksum" example:"yZRlqg=="` // 分片的 Base64 摘要
 }
 
 // CompleteMultipartUploadResponse 完成分片上传响应
 type CompleteMultipartUploadResponse struct {
 	FileID uuid.UUID `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	Status string    `json:"status" example:"completed"`
+	// 解压任务 ID（仅 extract=true 时返回）
+	ExtractionJobID *uuid.UUID `json:"extraction_job_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// GetDownloadURLRequest 获取下载 URL 请求（查询参数，均为可选）
+type GetDownloadURLRequest struct {
+	ExpiresIn    int64  `form:"expires_in" binding:"omitempty,min=1" example:"900"`                           // URL 有效期（秒）
+	Disposition  string `form:"disposition" binding:"omitempty,oneof=inline attachment" example:"attachment"` // 强制 inline 或 attachment
+	Filename     string `form:"filename" example:"report.pdf"`                                                // 下载文件名
+	CacheControl string `form:"cache_control" example:"private, max-age=300"`                                 // 覆盖响应 Cache-Control
 }
 
 // GetDownloadURLResponse 获取下载 URL 响应
 type GetDownloadURLResponse struct {
-	FileID      uuid.UUID `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	DownloadURL string    `json:"download_url" example:"https://s3.amazonaws.com/..."`
-	ExpiresIn   int64     `json:"expires_in" example:"900"`
+	FileID      uuid.UUID         `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	DownloadURL string            `json:"download_url" example:"https://s3.amazonaws.com/..."`
+	Headers     map[string]string `json:"headers,omitempty"` // 须携带的已签名请求头（仅 SSE-C 对象）
+	ExpiresIn   int64             `json:"expires_in" example:"900"`
 }
 
 // GetFileResponse 获取文件信息响应
 type GetFileResponse struct {
-	FileID      uuid.UUID `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	Name        string    `json:"name" example:"example.txt"`
-	Size        int64     `json:"size" example:"1024"`
-	ContentType string    `json:"content_type" example:"text/plain"`
-	StorageKey  string    `json:"storage_key" example:"files/1234567890/example.txt"`
-	Status      string    `json:"status" example:"completed"`
-	CreatedAt   string    `json:"created_at" example:"2026-02-06T00:00:00Z"`
+	FileID              uuid.UUID `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name                string    `json:"name" example:"example.txt"`
+	Size                int64     `json:"size" example:"1024"`
+	ContentType         string    `json:"content_type" example:"text/plain"`
+	StorageKey          string    `json:"storage_key" example:"files/1234567890/example.txt"`
+	Status              string    `json:"status" example:"completed"`
+	ETag                string    `json:"etag,omitempty" example:"d41d8cd98f00b204e9800998ecf8427e"` // 由存储事件通知确认时写入
+	ScanResult          string    `json:"scan_result,omitempty" example:"clean"`                     // 恶意文件扫描结果（clean/infected，为空表示未扫描）
+	ScanSignature       string    `json:"scan_signature,omitempty"`                                  // 命中的病毒签名
+	ScanEngine          string    `json:"scan_engine,omitempty" example:"ClamAV 1.2.1/27120/Tue Dec 12 09:30:00 2023"`
+	ScannedAt           string    `json:"scanned_at,omitempty" example:"2026-02-06T00:00:05Z"`
+	DetectedContentType string    `json:"detected_content_type,omitempty" example:"text/plain"`                      // 根据文件头识别的内容类型
+	ContentTypeMismatch bool      `json:"content_type_mismatch"`                                                     // 识别结果与声明类型不一致（下载时强制 attachment）
+	ChecksumAlgorithm   string    `json:"checksum_algorithm,omitempty" example:"sha256"`                             // 完整性校验算法
+	Checksum            string    `json:"checksum,omitempty" example:"n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg="` // Base64 摘要（分片上传为 "<组合摘要>-<分片数>"）
+	CreatedAt           string    `json:"created_at" example:"2026-02-06T00:00:00Z"`
 }
 
 // DeleteFileResponse 删除文件响应
@@ -141,6 +188,46 @@ type DeleteFileResponse struct {
 	Message string    `json:"message" example:"File deleted successfully"`
 }
 
+// CopyFileRequest 复制文件请求
+type CopyFileRequest struct {
+	Name   string `json:"name" example:"copy-of-video.mp4"` // 新文件名（可选，默认与源文件相同）
+	Folder string `json:"folder" example:"/campaigns/2026"` // 目标目录（可选，默认与源文件相同）
+	Tenant string `json:"tenant" example:"acme"`            // 目标租户（可选，替换存储键模板中的 {tenant}）
+}
+
+// CopyFileResponse 复制文件响应
+type CopyFileResponse struct {
+	FileID       uuid.UUID `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	SourceFileID uuid.UUID `json:"source_file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name         string    `json:"name" example:"copy-of-video.mp4"`
+	Folder       string    `json:"folder" example:"/campaigns/2026"`
+	StorageKey   string    `json:"storage_key" example:"files/1234567890/550e8400-e29b-41d4-a716-446655440001.mp4"`
+	Status       string    `json:"status" example:"completed"`
+}
+
+// BatchOperationRequest 批量操作请求
+type BatchOperationRequest struct {
+	Operations []BatchOperationItem `json:"operations" binding:"required,min=1,max=1000,dive"`
+}
+
+// BatchOperationItem 单个批量操作
+type BatchOperationItem struct {
+	Op        string            `json:"op" binding:"required,oneof=delete restore set_metadata move copy" example:"delete"`
+	FileID    uuid.UUID         `json:"file_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Permanent bool              `json:"permanent" example:"false"`             // delete：同时删除存储对象（不可恢复）
+	Metadata  map[string]string `json:"metadata,omitempty"`                    // set_metadata：合并的元数据（空字符串值表示删除）
+	Tags      []string          `json:"tags,omitempty" example:"banner,2026"`  // set_metadata：替换的标签
+	Folder    string            `json:"folder,omitempty" example:"/campaigns"` // move/copy：目标目录
+}
+
+// BatchOperationResponse 批量操作响应
+type BatchOperationResponse struct {
+	Total     int                    `json:"total" example:"2"`
+	Succeeded int                    `json:"succeeded" example:"1"`
+	Failed    int                    `json:"failed" example:"1"`
+	Results   []services.BatchResult `json:"results"`
+}
+
 // ===== API 端点实现 =====
 
 // UploadDirect godoc
@@ -151,6 +238,10 @@ type DeleteFileResponse struct {
 // @Produce      json
 // @Param        name formData string true "文件名"
 // @Param        content_type formData string false "MIME 类型"
+// @Param        extract formData bool false "上传后在服务端解压压缩包"
+// @Param        extract_folder formData string false "解压目标目录"
+// @Param        checksum_algorithm formData string false "校验算法" Enums(md5, crc32c, sha256)
+// @Param        checksum formData string false "Base64 编码的摘要（不一致时返回 400）"
 // @Param        file formData file true "文件内容"
 // @Success      201 {object} response.Response{data=UploadDirectResponse}
 // @Failure      400 {object} response.Response
@@ -164,6 +255,18 @@ func (h *FileHandler) UploadDirect(c *gin.Context) {
 		return
 	}
 
+	checksum, err := storage.ParseChecksum(req.ChecksumAlgorithm, req.Checksum)
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error(), err))
+		return
+	}
+
+	// 解压选项仅支持压缩包（上传前校验，避免上传后才报错）
+	if req.Extract && !h.extractionService.IsExtractable(req.Name) {
+		c.Error(errors.NewBadRequestError("file is not a supported archive (zip, tar, tar.gz)", nil))
+		return
+	}
+
 	// 获取上传的文件
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
@@ -186,34 +289,51 @@ func (h *FileHandler) UploadDirect(c *gin.Context) {
 		req.ContentType,
 		fileHeader.Size,
 		file,
+		checksum,
+		req.Tags,
 	)
 	if err != nil {
-		c.Error(errors.NewInternalError(err))
+		if strings.Contains(err.Error(), "checksum mismatch") || strings.Contains(err.Error(), "encryption key") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
 		return
 	}
 
-	// 生成下载 URL
-	downloadURL, err := h.fileService.GetDownloadURL(c.Request.Context(), uploadedFile.ID, 15*time.Minute)
-	if err != nil {
-		c.Error(errors.NewInternalError(err))
-		return
+	resp := UploadDirectResponse{
+		FileID:     uploadedFile.ID,
+		Name:       uploadedFile.Name,
+		Size:       uploadedFile.Size,
+		StorageKey: uploadedFile.StorageKey,
+		Status:     string(uploadedFile.Status),
+	}
+
+	// 生成下载 URL（默认有效期）
+	// 文件已经保存，暂不可下载（如 scan.required 时尚未完成扫描）时不返回链接，避免客户端收到错误后重复上传
+	if download, err := h.fileService.GetDownloadURL(c.Request.Context(), uploadedFile.ID, services.DownloadURLOptions{}); err == nil {
+		resp.DownloadURL = download.URL
+	}
+
+	// 创建解压任务
+	if req.Extract {
+		job, err := h.extractionService.StartExtraction(c.Request.Context(), uploadedFile, req.ExtractFolder)
+		if err != nil {
+			c.Error(errors.NewInternalError(err))
+			return
+		}
+		resp.ExtractionJobID = &job.ID
 	}
 
 	// 返回响应
 	c.Status(http.StatusCreated)
-	response.Success(c, UploadDirectResponse{
-		FileID:      uploadedFile.ID,
-		Name:        uploadedFile.Name,
-		Size:        uploadedFile.Size,
-		StorageKey:  uploadedFile.StorageKey,
-		Status:      string(uploadedFile.Status),
-		DownloadURL: downloadURL,
-	})
+	response.Success(c, resp)
 }
 
 // InitPresignedUpload godoc
 // @Summary      获取小文件上传预签名 URL
-// @Description  生成预签名 URL 供前端直接上传到 S3
+// @Description  生成预签名 URL 供前端直接上传到 S3。expires_in 可指定有效期（秒，默认 presign.upload_expiry）
+// @Description  method=post 时返回 POST 表单策略（upload_url + fields），存储服务强制校验对象键、Content-Type 和文件大小（必须等于 size）
 // @Tags         Presigned Upload
 // @Accept       json
 // @Produce      json
@@ -229,15 +349,32 @@ func (h *FileHandler) InitPresignedUpload(c *gin.Context) {
 		return
 	}
 
-	// 调用 Service 层生成预签名 URL
-	result, err := h.fileService.InitPresignedUpload(
+	checksum, err := storage.ParseChecksum(req.ChecksumAlgorithm, req.Checksum)
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error(), err))
+		return
+	}
+
+	// 调用 Service 层生成预签名 URL 或 POST 表单策略
+	initUpload := h.fileService.InitPresignedUpload
+	if req.Method == "post" {
+		initUpload = h.fileService.InitPresignedPost
+	}
+	result, err := initUpload(
 		c.Request.Context(),
 		req.Name,
 		req.ContentType,
 		req.Size,
+		time.Duration(req.ExpiresIn)*time.Second,
+		checksum,
+		req.Tags,
 	)
 	if err != nil {
-		c.Error(errors.NewInternalError(err))
+		if strings.Contains(err.Error(), "invalid expiry") || strings.Contains(err.Error(), "not supported") || strings.Contains(err.Error(), "encryption key") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
 		return
 	}
 
@@ -246,6 +383,9 @@ func (h *FileHandler) InitPresignedUpload(c *gin.Context) {
 	response.Success(c, InitPresignedUploadResponse{
 		FileID:     result.FileID,
 		UploadURL:  result.UploadURL,
+		Method:     result.Method,
+		Headers:    result.Headers,
+		Fields:     result.Fields,
 		StorageKey: result.StorageKey,
 		ExpiresIn:  result.ExpiresIn,
 	})
@@ -253,7 +393,7 @@ func (h *FileHandler) InitPresignedUpload(c *gin.Context) {
 
 // ConfirmUpload godoc
 // @Summary      确认前端直传完成
-// @Description  前端上传完成后调用此接口确认
+// @Description  前端上传完成后调用此接口确认。服务端读取文件头校验真实内容类型，content_sniff.mode 为 reject 时内容与声明类型不一致的文件会被删除并返回 400。只能确认等待确认（pending）的文件，其他状态返回 400
 // @Tags         Presigned Upload
 // @Accept       json
 // @Produce      json
@@ -277,6 +417,9 @@ func (h *FileHandler) ConfirmUpload(c *gin.Context) {
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "content type mismatch") || strings.Contains(err.Error(), "encryption key") ||
+			strings.Contains(err.Error(), "not awaiting confirmation") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
 		} else {
 			c.Error(errors.NewInternalError(err))
 		}
@@ -308,15 +451,27 @@ func (h *FileHandler) InitMultipartUpload(c *gin.Context) {
 		return
 	}
 
+	algorithm, err := storage.ParseChecksumAlgorithm(req.ChecksumAlgorithm)
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error(), err))
+		return
+	}
+
 	// 调用 Service 层初始化分片上传
 	result, err := h.fileService.InitMultipartUpload(
 		c.Request.Context(),
 		req.Name,
 		req.ContentType,
 		req.Size,
+		algorithm,
+		req.Tags,
 	)
 	if err != nil {
-		c.Error(errors.NewInternalError(err))
+		if strings.Contains(err.Error(), "not supported") || strings.Contains(err.Error(), "encryption key") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
 		return
 	}
 
@@ -331,7 +486,7 @@ func (h *FileHandler) InitMultipartUpload(c *gin.Context) {
 
 // GeneratePartURL godoc
 // @Summary      生成分片上传预签名 URL
-// @Description  为指定分片生成预签名 URL
+// @Description  为指定分片生成预签名 URL。expires_in 可指定有效期（秒，默认 presign.upload_expiry）
 // @Tags         Multipart Upload
 // @Accept       json
 // @Produce      json
@@ -351,24 +506,37 @@ func (h *FileHandler) GeneratePartURL(c *gin.Context) {
 		return
 	}
 
-	// 解析请求体（只需要 part_number）
+	// 解析请求体（不需要 file_id）
 	var req struct {
-		PartNumber int `json:"part_number" binding:"required,min=1"`
+		PartNumber        int    `json:"part_number" binding:"required,min=1"`
+		ExpiresIn         int64  `json:"expires_in" binding:"omitempty,min=1"`
+		ChecksumAlgorithm string `json:"checksum_algorithm"`
+		Checksum          string `json:"checksum"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.Error(errors.NewBadRequestError("invalid request", err))
 		return
 	}
 
+	checksum, err := storage.ParseChecksum(req.ChecksumAlgorithm, req.Checksum)
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error(), err))
+		return
+	}
+
 	// 调用 Service 层生成分片 URL
 	partURL, err := h.fileService.GeneratePartUploadURL(
 		c.Request.Context(),
 		fileID,
 		req.PartNumber,
+		time.Duration(req.ExpiresIn)*time.Second,
+		checksum,
 	)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "invalid expiry") || strings.Contains(err.Error(), "checksum") || strings.Contains(err.Error(), "encryption key") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
 		} else {
 			c.Error(errors.NewInternalError(err))
 		}
@@ -378,14 +546,15 @@ func (h *FileHandler) GeneratePartURL(c *gin.Context) {
 	// 返回响应
 	response.Success(c, GeneratePartURLResponse{
 		PartNumber: req.PartNumber,
-		UploadURL:  partURL,
-		ExpiresIn:  3600, // 1 小时
+		UploadURL:  partURL.URL,
+		Headers:    partURL.Headers,
+		ExpiresIn:  partURL.ExpiresIn,
 	})
 }
 
 // CompleteMultipartUpload godoc
 // @Summary      完成大文件分片上传
-// @Description  提交所有分片的 ETag，完成上传
+// @Description  提交所有分片的 ETag，完成上传。完成后按 content_sniff 配置校验文件头（reject 模式下不一致返回 400）
 // @Tags         Multipart Upload
 // @Accept       json
 // @Produce      json
@@ -405,9 +574,12 @@ func (h *FileHandler) CompleteMultipartUpload(c *gin.Context) {
 		return
 	}
 
-	// 解析请求体（只需要 parts）
+	// 解析请求体（只需要 parts、校验算法和解压选项）
 	var req struct {
-		Parts []CompletedPartRequest `json:"parts" binding:"required"`
+		Parts             []CompletedPartRequest `json:"parts" binding:"required"`
+		Extract           bool                   `json:"extract"`
+		ExtractFolder     string                 `json:"extract_folder"`
+		ChecksumAlgorithm string                 `json:"checksum_algorithm"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.Error(errors.NewBadRequestError("invalid request", err))
@@ -417,9 +589,15 @@ func (h *FileHandler) CompleteMultipartUpload(c *gin.Context) {
 	// 转换为 Service 层的类型
 	parts := make([]storage.CompletedPart, len(req.Parts))
 	for i, part := range req.Parts {
+		checksum, err := storage.ParseChecksum(req.ChecksumAlgorithm, part.Checksum)
+		if err != nil {
+			c.Error(errors.NewBadRequestError(fmt.Sprintf("part %d: %s", part.PartNumber, err.Error()), err))
+			return
+		}
 		parts[i] = storage.CompletedPart{
 			PartNumber: part.PartNumber,
 			ETag:       part.ETag,
+			Checksum:   checksum,
 		}
 	}
 
@@ -432,12 +610,73 @@ func (h *FileHandler) CompleteMultipartUpload(c *gin.Context) {
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "content type mismatch") || strings.Contains(err.Error(), "checksum") || strings.Contains(err.Error(), "encryption key") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
 		} else {
 			c.Error(errors.NewInternalError(err))
 		}
 		return
 	}
 
+	resp := CompleteMultipartUploadResponse{
+		FileID: file.ID,
+		Status: string(file.Status),
+	}
+
+	// 创建解压任务
+	if req.Extract {
+		job, err := h.extractionService.StartExtraction(c.Request.Context(), file, req.ExtractFolder)
+		if err != nil {
+			if strings.Contains(err.Error(), "not a supported archive") {
+				c.Error(errors.NewBadRequestError(err.Error(), err))
+			} else {
+				c.Error(errors.NewInternalError(err))
+			}
+			return
+		}
+		resp.ExtractionJobID = &job.ID
+	}
+
+	// 返回响应
+	response.Success(c, resp)
+}
+
+// AbortMultipartUpload godoc
+// @Summary      取消大文件分片上传
+// @Description  取消分片上传并清理已上传的分片，文件状态变为 failed
+// @Tags         Multipart Upload
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Success      200 {object} response.Response{data=CompleteMultipartUploadResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/multipart [delete]
+func (h *FileHandler) AbortMultipartUpload(c *gin.Context) {
+	// 解析 UUID
+	fileIDStr := c.Param("id")
+	fileID, err := uuid.Parse(fileIDStr)
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	// 调用 Service 层取消上传
+	file, err := h.fileService.AbortMultipartUpload(c.Request.Context(), fileID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.Error(errors.NewNotFoundError("file not found"))
+		case strings.Contains(err.Error(), "not in multipart upload mode"),
+			strings.Contains(err.Error(), "is already"):
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		default:
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
 	// 返回响应
 	response.Success(c, CompleteMultipartUploadResponse{
 		FileID: file.ID,
@@ -447,13 +686,18 @@ func (h *FileHandler) CompleteMultipartUpload(c *gin.Context) {
 
 // GetDownloadURL godoc
 // @Summary      获取文件下载 URL
-// @Description  生成文件下载预签名 URL
+// @Description  生成文件下载预签名 URL。可指定有效期、强制 inline/attachment、下载文件名和响应 Cache-Control（内容类型校验不一致的文件始终为 attachment）
 // @Tags         File Management
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "文件 UUID" format(uuid)
+// @Param        expires_in query int false "有效期（秒，默认 presign.download_expiry）"
+// @Param        disposition query string false "Content-Disposition 类型" Enums(inline, attachment)
+// @Param        filename query string false "下载文件名"
+// @Param        cache_control query string false "响应 Cache-Control"
 // @Success      200 {object} response.Response{data=GetDownloadURLResponse}
 // @Failure      400 {object} response.Response
+// @Failure      403 {object} response.Response "文件已隔离或未通过恶意文件扫描"
 // @Failure      404 {object} response.Response
 // @Failure      500 {object} response.Response
 // @Router       /api/v1/files/{id}/link [get]
@@ -466,15 +710,35 @@ func (h *FileHandler) GetDownloadURL(c *gin.Context) {
 		return
 	}
 
-	// 调用 Service 层生成下载 URL（15 分钟有效期）
-	downloadURL, err := h.fileService.GetDownloadURL(
+	var req GetDownloadURLRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(errors.NewBadRequestError("invalid request", err))
+		return
+	}
+
+	// 调用 Service 层生成下载 URL
+	download, err := h.fileService.GetDownloadURL(
 		c.Request.Context(),
 		fileID,
-		15*time.Minute,
+		services.DownloadURLOptions{
+			Expiry:       time.Duration(req.ExpiresIn) * time.Second,
+			Disposition:  req.Disposition,
+			Filename:     req.Filename,
+			CacheControl: req.CacheControl,
+		},
 	)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if strings.Contains(err.Error(), "invalid expiry") || strings.Contains(err.Error(), "invalid disposition") ||
+			strings.Contains(err.Error(), "invalid cache control") || strings.Contains(err.Error(), "encryption key") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else if strings.Contains(err.Error(), "not found") {
 			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "quarantined") || strings.Contains(err.Error(), "malware scan") {
+			c.Error(errors.NewForbiddenError(err.Error()))
+		} else if strings.Contains(err.Error(), "archived") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else if strings.Contains(err.Error(), "not ready") {
+			c.Error(errors.NewBadRequestError("file is not ready for download", err))
 		} else {
 			c.Error(errors.NewInternalError(err))
 		}
@@ -484,8 +748,9 @@ func (h *FileHandler) GetDownloadURL(c *gin.Context) {
 	// 返回响应
 	response.Success(c, GetDownloadURLResponse{
 		FileID:      fileID,
-		DownloadURL: downloadURL,
-		ExpiresIn:   900, // 15 分钟
+		DownloadURL: download.URL,
+		Headers:     download.Headers,
+		ExpiresIn:   download.ExpiresIn,
 	})
 }
 
@@ -518,15 +783,27 @@ func (h *FileHandler) GetFile(c *gin.Context) {
 	}
 
 	// 返回响应
-	response.Success(c, GetFileResponse{
-		FileID:      file.ID,
-		Name:        file.Name,
-		Size:        file.Size,
-		ContentType: file.ContentType,
-		StorageKey:  file.StorageKey,
-		Status:      string(file.Status),
-		CreatedAt:   file.CreatedAt.Format(time.RFC3339),
-	})
+	resp := GetFileResponse{
+		FileID:              file.ID,
+		Name:                file.Name,
+		Size:                file.Size,
+		ContentType:         file.ContentType,
+		StorageKey:          file.StorageKey,
+		Status:              string(file.Status),
+		ETag:                file.ETag,
+		ScanResult:          string(file.ScanResult),
+		ScanSignature:       file.ScanSignature,
+		ScanEngine:          file.ScanEngine,
+		DetectedContentType: file.DetectedContentType,
+		ContentTypeMismatch: file.ContentTypeMismatch,
+		ChecksumAlgorithm:   file.ChecksumAlgorithm,
+		Checksum:            file.Checksum,
+		CreatedAt:           file.CreatedAt.Format(time.RFC3339),
+	}
+	if file.ScannedAt != nil {
+		resp.ScannedAt = file.ScannedAt.Format(time.RFC3339)
+	}
+	response.Success(c, resp)
 }
 
 // DeleteFile godoc
@@ -567,12 +844,14 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 
 // DownloadFile godoc
 // @Summary      直接下载文件
-// @Description  获取文件内容（流式传输）
+// @Description  获取文件内容（流式传输）。透明压缩保存的文件在客户端接受该编码时直接返回压缩内容（Content-Encoding）
 // @Tags         File Management
 // @Produce      octet-stream
 // @Param        id path string true "文件 UUID" format(uuid)
+// @Param        Accept-Encoding header string false "客户端接受的内容编码（如 gzip）"
 // @Success      200 {file} binary "文件内容"
 // @Failure      400 {object} response.Response
+// @Failure      403 {object} response.Response "文件已隔离或未通过恶意文件扫描"
 // @Failure      404 {object} response.Response
 // @Failure      500 {object} response.Response
 // @Router       /api/v1/files/{id}/download [get]
@@ -586,35 +865,53 @@ func (h *FileHandler) DownloadFile(c *gin.Context) {
 	}
 
 	// 调用 Service 层下载文件
-	reader, file, err := h.fileService.DownloadFile(c.Request.Context(), fileID)
+	result, err := h.fileService.DownloadFile(c.Request.Context(), fileID, c.GetHeader("Accept-Encoding"))
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "quarantined") || strings.Contains(err.Error(), "malware scan") {
+			c.Error(errors.NewForbiddenError(err.Error()))
+		} else if strings.Contains(err.Error(), "archived") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
 		} else if strings.Contains(err.Error(), "not ready") {
 			c.Error(errors.NewBadRequestError("file is not ready for download", err))
+		} else if strings.Contains(err.Error(), "encryption key") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
 		} else {
 			c.Error(errors.NewInternalError(err))
 		}
 		return
 	}
-	defer reader.Close()
+	defer result.Reader.Close()
+
+	writeDownload(c, result)
+}
+
+// writeDownload 设置下载响应头并流式传输文件内容
+func writeDownload(c *gin.Context, result *services.DownloadResult) {
+	file := result.File
 
 	// 设置 Content-Type
 	c.Header("Content-Type", file.ContentType)
 
+	// 压缩保存的文件直接返回压缩内容（响应内容随 Accept-Encoding 变化）
+	if file.ContentEncoding != "" {
+		c.Header("Vary", "Accept-Encoding")
+	}
+	if result.ContentEncoding != "" {
+		c.Header("Content-Encoding", result.ContentEncoding)
+	}
+
 	// 设置 Content-Length
-	c.Header("Content-Length", fmt.Sprintf("%d", file.Size))
+	c.Header("Content-Length", fmt.Sprintf("%d", result.Size))
 
-	// 根据文件类型决定 Content-Disposition
-	disposition := "attachment"
-	if utils.IsPreviewable(file.ContentType) {
-		disposition = "inline"
-	}
-	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, file.Name))
+	// 根据文件类型决定 Content-Disposition（内容类型校验不一致的文件强制下载，文件名按 RFC 6266 编码）
+	disposition := services.DispositionType(file)
+	c.Header("Content-Disposition", utils.ContentDisposition(disposition, file.Name))
 
 	// 流式传输文件内容
 	c.Status(http.StatusOK)
-	if _, err := io.Copy(c.Writer, reader); err != nil {
+	if _, err := io.Copy(c.Writer, result.Reader); err != nil {
 		// 注意：此时已经开始写入响应，无法返回错误响应
 		// 只能记录日志
 		c.Error(errors.NewInternalError(err))
@@ -622,3 +919,120 @@ func (h *FileHandler) DownloadFile(c *gin.Context) {
 	}
 }
 
+// BatchOperate godoc
+// @Summary      批量文件操作
+// @Description  批量执行删除、恢复、设置元数据、移动、复制操作，逐项返回结果（允许部分失败）
+// @Tags         File Management
+// @Accept       json
+// @Produce      json
+// @Param        body body BatchOperationRequest true "操作列表"
+// @Success      200 {object} response.Response{data=BatchOperationResponse}
+// @Failure      400 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files:batch [post]
+func (h *FileHandler) BatchOperate(c *gin.Context) {
+	var req BatchOperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("invalid request", err))
+		return
+	}
+
+	// 转换为 Service 层的类型
+	ops := make([]services.BatchOperation, len(req.Operations))
+	for i, item := range req.Operations {
+		ops[i] = services.BatchOperation{
+			Op:        services.BatchOperationType(item.Op),
+			FileID:    item.FileID,
+			Permanent: item.Permanent,
+			Metadata:  item.Metadata,
+			Tags:      item.Tags,
+			Folder:    item.Folder,
+		}
+	}
+
+	// 调用 Service 层批量执行
+	results, err := h.fileService.BatchOperate(c.Request.Context(), ops)
+	if err != nil {
+		c.Error(errors.NewInternalError(err))
+		return
+	}
+
+	// 统计结果
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+
+	response.Success(c, BatchOperationResponse{
+		Total:     len(results),
+		Succeeded: succeeded,
+		Failed:    len(results) - succeeded,
+		Results:   results,
+	})
+}
+
+// CopyFile godoc
+// @Summary      复制文件
+// @Description  服务端复制文件（无需重新上传），生成新的文件记录
+// @Tags         File Management
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Param        body body CopyFileRequest false "复制选项"
+// @Success      201 {object} response.Response{data=CopyFileResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/copy [post]
+func (h *FileHandler) CopyFile(c *gin.Context) {
+	// 解析 UUID
+	fileIDStr := c.Param("id")
+	fileID, err := uuid.Parse(fileIDStr)
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	// 解析请求体（可选）
+	var req CopyFileRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(errors.NewBadRequestError("invalid request", err))
+			return
+		}
+	}
+
+	// 调用 Service 层复制文件
+	file, err := h.fileService.CopyFile(c.Request.Context(), fileID, services.CopyFileOptions{
+		Name:   req.Name,
+		Folder: req.Folder,
+		Tenant: req.Tenant,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "archived") || strings.Contains(err.Error(), "invalid tenant") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else if strings.Contains(err.Error(), "not ready") {
+			c.Error(errors.NewBadRequestError("file is not ready for copy", err))
+		} else if strings.Contains(err.Error(), "encryption key") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	// 返回响应
+	c.Status(http.StatusCreated)
+	response.Success(c, CopyFileResponse{
+		FileID:       file.ID,
+		SourceFileID: fileID,
+		Name:         file.Name,
+		Folder:       file.Folder,
+		StorageKey:   file.StorageKey,
+		Status:       string(file.Status),
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/NanoBoom/asethub/internal/handlers"
 	"github.com/NanoBoom/asethub/internal/middleware"
 	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/queue"
 	"github.com/NanoBoom/asethub/internal/repositories"
 	"github.com/NanoBoom/asethub/internal/services"
 	"github.com/NanoBoom/asethub/pkg/response"
@@ -49,11 +50,15 @@ func (m *MockStorage) Upload(ctx context.Context, key string, reader io.Reader,
 	return nil
 }
 
-func (m *MockStorage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
-	return fmt.Sprintf("https://mock-s3.example.com/upload/%s", key), nil
+func (m *MockStorage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string, checksum *storage.Checksum) (*storage.PresignedRequest, error) {
+	return &storage.PresignedRequest{URL: fmt.Sprintf("https://mock-s3.example.com/upload/%s", key)}, nil
 }
 
-func (m *MockStorage) InitMultipartUpload(ctx context.Context, key string, contentType string) (*storage.MultipartUpload, error) {
+func (m *MockStorage) GeneratePresignedPost(ctx context.Context, key string, expiry time.Duration, policy storage.PostPolicy) (*storage.PresignedPost, error) {
+	return &storage.PresignedPost{URL: "https://mock-s3.example.com/upload", Fields: map[string]string{"key": key}}, nil
+}
+
+func (m *MockStorage) InitMultipartUpload(ctx context.Context, key string, contentType string, algorithm storage.ChecksumAlgorithm) (*storage.MultipartUpload, error) {
 	return &storage.MultipartUpload{
 		UploadID: "mock-upload-id-" + key,
 		Key:      key,
@@ -61,8 +66,8 @@ func (m *MockStorage) InitMultipartUpload(ctx context.Context, key string, conte
 	}, nil
 }
 
-func (m *MockStorage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration) (string, error) {
-	return fmt.Sprintf("https://mock-s3.example.com/upload/%s/part/%d", key, partNumber), nil
+func (m *MockStorage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration, checksum *storage.Checksum) (*storage.PresignedRequest, error) {
+	return &storage.PresignedRequest{URL: fmt.Sprintf("https://mock-s3.example.com/upload/%s/part/%d", key, partNumber)}, nil
 }
 
 func (m *MockStorage) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []storage.CompletedPart) error {
@@ -71,8 +76,35 @@ func (m *MockStorage) CompleteMultipartUpload(ctx context.Context, key string, u
 	return nil
 }
 
-func (m *MockStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *storage.PresignOptions) (string, error) {
-	return fmt.Sprintf("https://mock-s3.example.com/download/%s", key), nil
+func (m *MockStorage) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	return nil
+}
+
+func (m *MockStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	data, ok := m.files[key]
+	if !ok {
+		return nil, "", 0, fmt.Errorf("object not found: %s", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), "application/octet-stream", int64(len(data)), nil
+}
+
+func (m *MockStorage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	data, ok := m.files[key]
+	if !ok {
+		return nil, fmt.Errorf("object not found: %s", key)
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	end := offset + length
+	if end > int64(len(data)) {
+		end = int64(len(data))
+	}
+	return io.NopCloser(bytes.NewReader(data[offset:end])), nil
+}
+
+func (m *MockStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *storage.PresignOptions) (*storage.PresignedRequest, error) {
+	return &storage.PresignedRequest{URL: fmt.Sprintf("https://mock-s3.example.com/download/%s", key)}, nil
 }
 
 func (m *MockStorage) Delete(ctx context.Context, key string) error {
@@ -80,6 +112,22 @@ func (m *MockStorage) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+func (m *MockStorage) DeleteObjects(ctx context.Context, keys []string) (map[string]error, error) {
+	for _, key := range keys {
+		delete(m.files, key)
+	}
+	return map[string]error{}, nil
+}
+
+func (m *MockStorage) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	data, ok := m.files[srcKey]
+	if !ok {
+		return fmt.Errorf("object not found: %s", srcKey)
+	}
+	m.files[dstKey] = append([]byte(nil), data...)
+	return nil
+}
+
 // setupTestServerWithMock 创建使用 Mock Storage 的测试服务器
 func setupTestServerWithMock(t *testing.T) (*gin.Engine, *gorm.DB, storage.Storage, func()) {
 	// 加载配置
@@ -100,15 +148,16 @@ func setupTestServerWithMock(t *testing.T) (*gin.Engine, *gorm.DB, storage.Stora
 	require.NoError(t, err, "Failed to connect to database")
 
 	// 自动迁移（忽略约束错误）
-	_ = db.AutoMigrate(&models.File{})
+	_ = db.AutoMigrate(&models.File{}, &models.OutboxEvent{})
 
 	// 使用 Mock Storage
 	mockStorage := NewMockStorage()
 
 	// 初始化服务
 	fileRepo := repositories.NewFileRepository(db)
-	fileService := services.NewFileService(fileRepo, mockStorage, db)
-	fileHandler := handlers.NewFileHandler(fileService)
+	fileService := services.NewFileService(fileRepo, repositories.NewOutboxRepository(db), mockStorage, repositories.NewTransactor(db), services.DownloadPolicy{}, services.FileServiceOptions{})
+	extractionService := services.NewExtractionService(fileRepo, repositories.NewOutboxRepository(db), mockStorage, repositories.NewTransactor(db), nil, queue.NewManager(nil, cfg.Jobs), cfg.Extraction, nil)
+	fileHandler := handlers.NewFileHandler(fileService, extractionService)
 
 	// 创建路由
 	gin.SetMode(gin.TestMode)
@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/handlers"
+	"github.com/NanoBoom/asethub/internal/middleware"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memFileRepository 内存文件仓储（只实现直接上传和生成下载 URL 用到的方法）
+type memFileRepository struct {
+	repositories.FileRepository
+	files map[uuid.UUID]*models.File
+}
+
+func (m *memFileRepository) Create(ctx context.Context, file *models.File) error {
+	m.files[file.ID] = file
+	return nil
+}
+
+func (m *memFileRepository) Update(ctx context.Context, file *models.File) error {
+	m.files[file.ID] = file
+	return nil
+}
+
+func (m *memFileRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.File, error) {
+	file, ok := m.files[id]
+	if !ok {
+		return nil, fmt.Errorf("record not found")
+	}
+	return file, nil
+}
+
+func (m *memFileRepository) MarkAccessed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return nil
+}
+
+// memOutboxRepository 丢弃事件的发件箱仓储
+type memOutboxRepository struct {
+	repositories.OutboxRepository
+}
+
+func (memOutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	return nil
+}
+
+// noTransactor 直接执行函数的事务管理器
+type noTransactor struct{}
+
+func (noTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// stubExtractionService 记录启动的解压任务
+type stubExtractionService struct {
+	services.ExtractionService
+	started []*models.File
+}
+
+func (s *stubExtractionService) IsExtractable(name string) bool {
+	return true
+}
+
+func (s *stubExtractionService) StartExtraction(ctx context.Context, archive *models.File, folder string) (*services.ExtractionJob, error) {
+	s.started = append(s.started, archive)
+	return &services.ExtractionJob{ID: uuid.New(), ArchiveFileID: archive.ID}, nil
+}
+
+// TestUploadDirectRequireScan 测试 scan.required 时直接上传：文件尚未扫描，不返回下载 URL，上传仍然成功并创建解压任务
+func TestUploadDirectRequireScan(t *testing.T) {
+	for _, requireScan := range []bool{true, false} {
+		t.Run(fmt.Sprintf("require_scan=%t", requireScan), func(t *testing.T) {
+			repo := &memFileRepository{files: make(map[uuid.UUID]*models.File)}
+			fileService := services.NewFileService(repo, memOutboxRepository{}, NewMockStorage(), noTransactor{},
+				services.DownloadPolicy{RequireScan: requireScan}, services.FileServiceOptions{})
+			extraction := &stubExtractionService{}
+			fileHandler := handlers.NewFileHandler(fileService, extraction)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.POST("/api/v1/files", fileHandler.UploadDirect)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			_ = writer.WriteField("name", "pack.zip")
+			_ = writer.WriteField("extract", "true")
+			part, err := writer.CreateFormFile("file", "pack.zip")
+			require.NoError(t, err)
+			_, err = part.Write([]byte("PK\x05\x06" + string(make([]byte, 18))))
+			require.NoError(t, err)
+			writer.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/files", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var resp struct {
+				Data handlers.UploadDirectResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, string(models.FileStatusCompleted), resp.Data.Status)
+			assert.Equal(t, requireScan, resp.Data.DownloadURL == "", "download_url = %q", resp.Data.DownloadURL)
+			assert.NotNil(t, resp.Data.ExtractionJobID)
+			assert.Len(t, extraction.started, 1)
+			assert.Len(t, repo.files, 1)
+		})
+	}
+}
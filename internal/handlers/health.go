@@ -9,27 +9,36 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/NanoBoom/asethub/internal/cache"
+	"github.com/NanoBoom/asethub/pkg/storage"
 )
 
+// StorageHealth 存储后端健康状态（由 storage.FailoverStorage 实现）
+type StorageHealth interface {
+	Health() []storage.BackendHealth
+}
+
 type HealthHandler struct {
-	db    *gorm.DB
-	redis *cache.RedisClient
+	db      *gorm.DB
+	redis   *cache.RedisClient
+	storage StorageHealth
 }
 
 // HealthResponse 健康检查响应
 type HealthResponse struct {
-	Status   string `json:"status" example:"ok"`                // 整体状态: ok/degraded
-	Database string `json:"database" example:"ok"`              // 数据库状态: ok/error
-	Redis    string `json:"redis" example:"ok"`                 // Redis状态: ok/error
+	Status   string                  `json:"status" example:"ok"`   // 整体状态: ok/degraded
+	Database string                  `json:"database" example:"ok"` // 数据库状态: ok/error
+	Redis    string                  `json:"redis" example:"ok"`    // Redis状态: ok/error
+	Storage  []storage.BackendHealth `json:"storage,omitempty"`     // 启用故障转移时各主后端的熔断状态和计数
 }
 
-func NewHealthHandler(db *gorm.DB, redis *cache.RedisClient) *HealthHandler {
-	return &HealthHandler{db: db, redis: redis}
+// NewHealthHandler 创建健康检查处理器（storage 为 nil 时不返回存储后端状态）
+func NewHealthHandler(db *gorm.DB, redis *cache.RedisClient, storage StorageHealth) *HealthHandler {
+	return &HealthHandler{db: db, redis: redis, storage: storage}
 }
 
 // Check godoc
 // @Summary      健康检查
-// @Description  检查服务、数据库和 Redis 的健康状态
+// @Description  检查服务、数据库和 Redis 的健康状态；启用存储故障转移时返回各主后端的熔断状态（熔断时整体状态为 degraded）
 // @Tags         system
 // @Accept       json
 // @Produce      json
@@ -54,9 +63,20 @@ func (h *HealthHandler) Check(c *gin.Context) {
 		status = "degraded"
 	}
 
+	var backends []storage.BackendHealth
+	if h.storage != nil {
+		backends = h.storage.Health()
+		for _, backend := range backends {
+			if backend.State != storage.BreakerClosed {
+				status = "degraded"
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, HealthResponse{
 		Status:   status,
 		Database: dbStatus,
 		Redis:    redisStatus,
+		Storage:  backends,
 	})
 }
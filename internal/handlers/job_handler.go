@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/queue"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// JobHandler 异步任务处理器
+type JobHandler struct {
+	jobs *queue.Manager
+}
+
+// NewJobHandler 创建异步任务处理器实例
+func NewJobHandler(jobs *queue.Manager) *JobHandler {
+	return &JobHandler{
+		jobs: jobs,
+	}
+}
+
+// JobResponse 异步任务响应
+type JobResponse struct {
+	JobID       uuid.UUID `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Queue       string    `json:"queue" example:"default"`
+	Type        string    `json:"type" example:"file.checksum"`
+	Status      string    `json:"status" example:"pending"`
+	Attempts    int       `json:"attempts" example:"1"`
+	MaxAttempts int       `json:"max_attempts" example:"5"`
+	LastError   string    `json:"last_error,omitempty"`
+	RunAt       string    `json:"run_at" example:"2026-02-06T00:00:10Z"` // 下次可执行时间
+	CreatedAt   string    `json:"created_at" example:"2026-02-06T00:00:00Z"`
+	StartedAt   string    `json:"started_at,omitempty" example:"2026-02-06T00:00:01Z"`
+	FinishedAt  string    `json:"finished_at,omitempty" example:"2026-02-06T00:00:02Z"`
+}
+
+// GetJob godoc
+// @Summary      查询异步任务状态
+// @Description  查询后台任务的状态、执行次数和最近一次错误。已结束的任务记录保留时间由 jobs.retention 配置
+// @Tags         Job
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "任务 UUID" format(uuid)
+// @Success      200 {object} response.Response{data=JobResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/jobs/{id} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	// 解析 UUID
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil || jobID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	job, err := h.jobs.Get(c.Request.Context(), jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("job not found"))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	resp := JobResponse{
+		JobID:       job.ID,
+		Queue:       job.Queue,
+		Type:        job.Type,
+		Status:      string(job.Status),
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		LastError:   job.LastError,
+		RunAt:       job.RunAt.Format(time.RFC3339),
+		CreatedAt:   job.CreatedAt.Format(time.RFC3339),
+	}
+	if job.StartedAt != nil {
+		resp.StartedAt = job.StartedAt.Format(time.RFC3339)
+	}
+	if job.FinishedAt != nil {
+		resp.FinishedAt = job.FinishedAt.Format(time.RFC3339)
+	}
+
+	response.Success(c, resp)
+}
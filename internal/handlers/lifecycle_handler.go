@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LifecycleHandler 存储分层处理器（归档文件取回）
+type LifecycleHandler struct {
+	lifecycleService services.LifecycleService
+}
+
+// NewLifecycleHandler 创建存储分层处理器实例
+func NewLifecycleHandler(lifecycleService services.LifecycleService) *LifecycleHandler {
+	return &LifecycleHandler{
+		lifecycleService: lifecycleService,
+	}
+}
+
+// RestoreFileRequest 取回归档文件请求
+type RestoreFileRequest struct {
+	Days int    `json:"days" binding:"omitempty,min=1" example:"7"`                                // 取回副本的保留天数（默认 lifecycle.restore_days）
+	Tier string `json:"tier" binding:"omitempty,oneof=expedited standard bulk" example:"standard"` // 取回优先级（默认 lifecycle.restore_tier）
+}
+
+// RestoreStatusResponse 存储类型和取回状态
+type RestoreStatusResponse struct {
+	FileID           uuid.UUID            `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	StorageClass     string               `json:"storage_class" example:"archive"`      // standard / infrequent_access / archive / cold_archive
+	RestoreStatus    models.RestoreStatus `json:"restore_status" example:"in_progress"` // 为空表示未取回；in_progress / restored
+	RestoreExpiresAt *time.Time           `json:"restore_expires_at,omitempty"`         // 已取回副本的过期时间（过期前可下载）
+	Downloadable     bool                 `json:"downloadable" example:"false"`         // 当前是否可以下载（非归档文件或已取回）
+}
+
+// newRestoreStatusResponse 构造取回状态响应
+func newRestoreStatusResponse(file *models.File) RestoreStatusResponse {
+	storageClass := file.StorageClass
+	if storageClass == "" {
+		storageClass = string(storage.StorageClassStandard)
+	}
+	return RestoreStatusResponse{
+		FileID:           file.ID,
+		StorageClass:     storageClass,
+		RestoreStatus:    file.RestoreStatus,
+		RestoreExpiresAt: file.RestoreExpiresAt,
+		Downloadable:     !services.RequiresRestore(file, time.Now()),
+	}
+}
+
+// RestoreFile godoc
+// @Summary      取回归档文件
+// @Description  为存储类型为 archive 或 cold_archive 的文件发起取回（S3 Glacier restore / OSS 解冻）。取回需要数分钟到数十小时，完成前文件不能下载；通过 GET 同一地址查询状态。已取回的文件再次取回会延长副本的保留时间
+// @Tags         File Management
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Param        request body RestoreFileRequest false "取回选项"
+// @Success      202 {object} response.Response{data=RestoreStatusResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/restore [post]
+func (h *LifecycleHandler) RestoreFile(c *gin.Context) {
+	// 解析 UUID
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	var req RestoreFileRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(errors.NewBadRequestError("invalid request body", err))
+			return
+		}
+	}
+
+	file, err := h.lifecycleService.RestoreFile(c.Request.Context(), fileID, req.Days, req.Tier)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "invalid ") || strings.Contains(err.Error(), "not archived") || strings.Contains(err.Error(), "not ready") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else if strings.Contains(err.Error(), "not supported") {
+			c.Error(errors.NewBadRequestError("storage classes are not supported by the file's storage backend", err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+	response.Success(c, newRestoreStatusResponse(file))
+}
+
+// GetRestoreStatus godoc
+// @Summary      查询归档文件取回状态
+// @Description  返回文件的存储类型和取回状态；取回进行中时向存储服务查询最新状态
+// @Tags         File Management
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Success      200 {object} response.Response{data=RestoreStatusResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/restore [get]
+func (h *LifecycleHandler) GetRestoreStatus(c *gin.Context) {
+	// 解析 UUID
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	file, err := h.lifecycleService.GetRestoreStatus(c.Request.Context(), fileID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("file not found"))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	response.Success(c, newRestoreStatusResponse(file))
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReplicationHandler 跨后端复制处理器
+type ReplicationHandler struct {
+	replicationService services.ReplicationService
+}
+
+// NewReplicationHandler 创建跨后端复制处理器实例
+func NewReplicationHandler(replicationService services.ReplicationService) *ReplicationHandler {
+	return &ReplicationHandler{
+		replicationService: replicationService,
+	}
+}
+
+// ReplicateFileResponse 复制任务响应
+type ReplicateFileResponse struct {
+	FileID uuid.UUID `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	JobID  uuid.UUID `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"` // 通过 GET /api/v1/jobs/{id} 查询进度
+}
+
+// ListReplicas godoc
+// @Summary      查询文件副本
+// @Description  返回文件在其他存储后端上的副本位置和校验状态（replicated / verified / failed）
+// @Tags         File Management
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Success      200 {object} response.Response{data=[]models.FileReplica}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/replicas [get]
+func (h *ReplicationHandler) ListReplicas(c *gin.Context) {
+	// 解析 UUID
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	replicas, err := h.replicationService.ListReplicas(c.Request.Context(), fileID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("file not found"))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+	if replicas == nil {
+		replicas = []*models.FileReplica{}
+	}
+
+	response.Success(c, replicas)
+}
+
+// ReplicateFile godoc
+// @Summary      复制文件到副本后端
+// @Description  为已上传完成的文件创建复制任务，把存储对象复制到 replication.targets 中的后端（如复制启用前上传的文件、重试失败的副本）。已是最新的副本不会重复复制
+// @Tags         File Management
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Success      202 {object} response.Response{data=ReplicateFileResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/replicas [post]
+func (h *ReplicationHandler) ReplicateFile(c *gin.Context) {
+	// 解析 UUID
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	job, err := h.replicationService.EnqueueReplication(c.Request.Context(), fileID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "not ready") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+	response.Success(c, ReplicateFileResponse{
+		FileID: fileID,
+		JobID:  job.ID,
+	})
+}
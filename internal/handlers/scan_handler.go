@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ScanHandler 恶意文件扫描处理器
+type ScanHandler struct {
+	scanService services.ScanService
+}
+
+// NewScanHandler 创建恶意文件扫描处理器实例
+func NewScanHandler(scanService services.ScanService) *ScanHandler {
+	return &ScanHandler{
+		scanService: scanService,
+	}
+}
+
+// ScanFileResponse 扫描任务响应
+type ScanFileResponse struct {
+	FileID uuid.UUID `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	JobID  uuid.UUID `json:"job_id" example:"550e8400-e29b-41d4-a716-446655440001"` // 通过 GET /api/v1/jobs/{id} 查询进度
+}
+
+// ScanFile godoc
+// @Summary      重新扫描文件
+// @Description  为已上传完成或已隔离的文件创建 ClamAV 扫描任务（如扫描启用前上传的文件、病毒库更新后复查）。扫描结果写入文件的 scan_result 字段，发现恶意内容时文件状态变为 quarantined
+// @Tags         File Management
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Success      202 {object} response.Response{data=ScanFileResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/scan [post]
+func (h *ScanHandler) ScanFile(c *gin.Context) {
+	// 解析 UUID
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	job, err := h.scanService.EnqueueScan(c.Request.Context(), fileID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "not ready") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+	response.Success(c, ScanFileResponse{
+		FileID: fileID,
+		JobID:  job.ID,
+	})
+}
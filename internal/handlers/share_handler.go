@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SharePasswordHeader 访问带密码的分享链接时提供密码的请求头（也可使用 HTTP Basic 认证的密码）
+const SharePasswordHeader = "X-Share-Password"
+
+// ShareHandler 文件分享链接处理器
+type ShareHandler struct {
+	shareService services.ShareService
+}
+
+// NewShareHandler 创建文件分享链接处理器实例
+func NewShareHandler(shareService services.ShareService) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+	}
+}
+
+// CreateShareRequest 创建分享链接请求（均为可选）
+type CreateShareRequest struct {
+	ExpiresIn    int64  `json:"expires_in" binding:"omitempty,min=1" example:"2592000"`           // 有效期（秒，不填表示不过期或 shares.max_expiry）
+	Password     string `json:"password" binding:"omitempty,max=128" example:"s3cret"`            // 访问密码
+	MaxDownloads int    `json:"max_downloads" binding:"omitempty,min=1" example:"10"`             // 最多下载次数（不填不限制）
+	Mode         string `json:"mode" binding:"omitempty,oneof=redirect proxy" example:"redirect"` // 访问方式：redirect 重定向到预签名 URL / proxy 服务端代理
+}
+
+// ShareResponse 分享链接
+type ShareResponse struct {
+	ID             uuid.UUID        `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	FileID         uuid.UUID        `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Token          string           `json:"token,omitempty" example:"q3Xk9..."`                            // 分享令牌（只在创建时返回）
+	URL            string           `json:"url,omitempty" example:"https://assets.example.com/s/q3Xk9..."` // 分享地址（只在创建时返回）
+	Mode           models.ShareMode `json:"mode" example:"redirect"`
+	HasPassword    bool             `json:"has_password" example:"true"`
+	ExpiresAt      *time.Time       `json:"expires_at,omitempty"`
+	MaxDownloads   int              `json:"max_downloads" example:"10"` // 0 表示不限制
+	DownloadCount  int              `json:"download_count" example:"3"`
+	LastAccessedAt *time.Time       `json:"last_accessed_at,omitempty"`
+	RevokedAt      *time.Time       `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// newShareResponse 构造分享链接响应
+func newShareResponse(share *models.FileShare) ShareResponse {
+	return ShareResponse{
+		ID:             share.ID,
+		FileID:         share.FileID,
+		Mode:           share.Mode,
+		HasPassword:    share.PasswordHash != "",
+		ExpiresAt:      share.ExpiresAt,
+		MaxDownloads:   share.MaxDownloads,
+		DownloadCount:  share.DownloadCount,
+		LastAccessedAt: share.LastAccessedAt,
+		RevokedAt:      share.RevokedAt,
+		CreatedAt:      share.CreatedAt,
+	}
+}
+
+// CreateShare godoc
+// @Summary      创建分享链接
+// @Description  为已上传完成的文件创建公开分享链接，可设置有效期、访问密码和下载次数上限。令牌和分享地址只在创建时返回；通过 GET /s/{token} 访问，不需要认证
+// @Tags         File Management
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Param        request body CreateShareRequest false "分享选项"
+// @Success      201 {object} response.Response{data=ShareResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/shares [post]
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	// 解析 UUID
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	var req CreateShareRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(errors.NewBadRequestError("invalid request body", err))
+			return
+		}
+	}
+
+	created, err := h.shareService.CreateShare(c.Request.Context(), fileID, services.CreateShareOptions{
+		ExpiresIn:    time.Duration(req.ExpiresIn) * time.Second,
+		Password:     req.Password,
+		MaxDownloads: req.MaxDownloads,
+		Mode:         models.ShareMode(req.Mode),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "invalid ") || strings.Contains(err.Error(), "not ready") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	resp := newShareResponse(created.Share)
+	resp.Token = created.Token
+	resp.URL = created.URL
+	c.Status(http.StatusCreated)
+	response.Success(c, resp)
+}
+
+// ListShares godoc
+// @Summary      查询文件的分享链接
+// @Description  返回文件的所有分享链接（包含已撤销和已过期的），不返回令牌
+// @Tags         File Management
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Success      200 {object} response.Response{data=[]ShareResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/shares [get]
+func (h *ShareHandler) ListShares(c *gin.Context) {
+	// 解析 UUID
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	shares, err := h.shareService.ListShares(c.Request.Context(), fileID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("file not found"))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	resp := make([]ShareResponse, len(shares))
+	for i, share := range shares {
+		resp[i] = newShareResponse(share)
+	}
+	response.Success(c, resp)
+}
+
+// RevokeShare godoc
+// @Summary      撤销分享链接
+// @Description  撤销后通过该链接访问返回 404，已重定向出去的预签名 URL 在 shares.redirect_expiry 后失效
+// @Tags         File Management
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Param        share_id path string true "分享链接 UUID" format(uuid)
+// @Success      204 "No Content"
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/shares/{share_id} [delete]
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	// 解析 UUID
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+	shareID, err := uuid.Parse(c.Param("share_id"))
+	if err != nil || shareID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil share UUID", err))
+		return
+	}
+
+	if err := h.shareService.RevokeShare(c.Request.Context(), fileID, shareID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("share not found"))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// OpenShare godoc
+// @Summary      访问分享链接
+// @Description  公开访问（不需要认证）。redirect 模式 302 重定向到新生成的短期预签名 URL，proxy 模式或存储不支持预签名时直接返回文件内容。带密码的链接通过 X-Share-Password 请求头或 HTTP Basic 认证的密码提供密码；同一 IP 校验密码过于频繁或链接因密码错误次数过多被锁定时返回 429
+// @Tags         Shares
+// @Produce      octet-stream
+// @Param        token path string true "分享令牌"
+// @Param        X-Share-Password header string false "访问密码"
+// @Success      200 {file} binary
+// @Success      302 "重定向到预签名 URL"
+// @Failure      400 {object} response.Response
+// @Failure      401 {object} response.Response "需要密码或密码错误"
+// @Failure      403 {object} response.Response "链接已过期、达到下载次数上限或文件被禁止下载"
+// @Failure      404 {object} response.Response
+// @Failure      429 {object} response.Response "密码尝试过于频繁或链接已锁定"
+// @Failure      500 {object} response.Response
+// @Router       /s/{token} [get]
+func (h *ShareHandler) OpenShare(c *gin.Context) {
+	password := c.GetHeader(SharePasswordHeader)
+	if password == "" {
+		_, password, _ = c.Request.BasicAuth()
+	}
+
+	access, err := h.shareService.OpenShare(c.Request.Context(), c.Param("token"), password, c.ClientIP(), c.GetHeader("Accept-Encoding"))
+	if err != nil {
+		if strings.Contains(err.Error(), "too many") {
+			c.Error(errors.NewTooManyRequestsError(err.Error()))
+		} else if strings.Contains(err.Error(), "password attempts") {
+			// 尝试计数器不可用（Redis 故障）不是密码错误，不应返回密码质询
+			c.Error(errors.NewInternalError(err))
+		} else if strings.Contains(err.Error(), "password") {
+			// 浏览器收到 Basic 质询后弹出密码输入框
+			c.Header("WWW-Authenticate", `Basic realm="share", charset="UTF-8"`)
+			c.Error(errors.NewUnauthorizedError(err.Error()))
+		} else if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("share not found"))
+		} else if strings.Contains(err.Error(), "expired") || strings.Contains(err.Error(), "download limit") ||
+			strings.Contains(err.Error(), "quarantined") || strings.Contains(err.Error(), "malware scan") {
+			c.Error(errors.NewForbiddenError(err.Error()))
+		} else if strings.Contains(err.Error(), "archived") || strings.Contains(err.Error(), "encryption key") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	// 分享链接的响应不应被共享缓存保存（撤销和下载次数需要每次经过服务端）
+	c.Header("Cache-Control", "no-store")
+	if access.RedirectURL != "" {
+		c.Redirect(http.StatusFound, access.RedirectURL)
+		return
+	}
+	defer access.Download.Reader.Close()
+	writeDownload(c, access.Download)
+}
@@ -0,0 +1,55 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NanoBoom/asethub/internal/handlers"
+	"github.com/NanoBoom/asethub/internal/middleware"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubShareService 访问分享链接时返回指定错误
+type stubShareService struct {
+	services.ShareService
+	err error
+}
+
+func (s stubShareService) OpenShare(ctx context.Context, token, password, clientIP, acceptEncoding string) (*services.ShareAccess, error) {
+	return nil, s.err
+}
+
+// TestOpenShareErrors 测试访问分享链接的错误映射：计数器故障返回 500 而不是密码质询
+func TestOpenShareErrors(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		status    int
+		challenge bool
+	}{
+		{fmt.Errorf("share password required"), http.StatusUnauthorized, true},
+		{fmt.Errorf("incorrect share password"), http.StatusUnauthorized, true},
+		{fmt.Errorf("too many share password attempts, try again later"), http.StatusTooManyRequests, false},
+		{fmt.Errorf("too many incorrect share passwords, share link is locked"), http.StatusTooManyRequests, false},
+		{fmt.Errorf("failed to count share password attempts: %w", fmt.Errorf("dial tcp: connection refused")), http.StatusInternalServerError, false},
+		{fmt.Errorf("failed to reset share password attempts: %w", fmt.Errorf("dial tcp: connection refused")), http.StatusInternalServerError, false},
+		{fmt.Errorf("share not found"), http.StatusNotFound, false},
+	} {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.GET("/s/:token", handlers.NewShareHandler(stubShareService{err: tc.err}).OpenShare)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/s/token", nil))
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.challenge, w.Header().Get("WWW-Authenticate") != "")
+		})
+	}
+}
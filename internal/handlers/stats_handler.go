@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// StatsHandler 文件下载统计处理器
+type StatsHandler struct {
+	statsService services.DownloadStatsService
+}
+
+// NewStatsHandler 创建文件下载统计处理器实例
+func NewStatsHandler(statsService services.DownloadStatsService) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetFileStatsRequest 查询文件下载统计请求（查询参数）
+type GetFileStatsRequest struct {
+	Days int `form:"days" binding:"omitempty,min=1" example:"30"` // 每日统计的天数（默认 30，最多 stats.max_days）
+}
+
+// GetFileStats godoc
+// @Summary      查询文件下载统计
+// @Description  返回文件的累计下载次数、生成下载 URL 次数、服务端下载传输的字节数、最近访问时间和每日统计。计数器定期从 Redis 汇总，最多落后 stats.flush_interval；通过预签名 URL 下载的字节数无法统计
+// @Tags         File Management
+// @Produce      json
+// @Param        id path string true "文件 UUID" format(uuid)
+// @Param        days query int false "每日统计的天数（默认 30）"
+// @Success      200 {object} response.Response{data=services.FileStats}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/files/{id}/stats [get]
+func (h *StatsHandler) GetFileStats(c *gin.Context) {
+	// 解析 UUID
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil || fileID == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return
+	}
+
+	var req GetFileStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(errors.NewBadRequestError("invalid request", err))
+		return
+	}
+
+	stats, err := h.statsService.GetStats(c.Request.Context(), fileID, req.Days)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("file not found"))
+		} else if strings.Contains(err.Error(), "invalid ") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	response.Success(c, stats)
+}
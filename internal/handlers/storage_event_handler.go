@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// storageEventMaxBody 通知请求体大小上限
+const storageEventMaxBody = 1 << 20
+
+// StorageEventTokenHeader 通知回调的共享密钥请求头（也可使用 Authorization: Bearer 或 token 查询参数）
+const StorageEventTokenHeader = "X-AssetHub-Token"
+
+// StorageEventHandler 存储桶事件通知处理器
+type StorageEventHandler struct {
+	storageEventService services.StorageEventService
+	secret              string
+}
+
+// NewStorageEventHandler 创建存储桶事件通知处理器实例
+func NewStorageEventHandler(storageEventService services.StorageEventService, secret string) *StorageEventHandler {
+	return &StorageEventHandler{
+		storageEventService: storageEventService,
+		secret:              secret,
+	}
+}
+
+// IngestStorageEvents godoc
+// @Summary      接收存储桶事件通知
+// @Description  接收 S3 / MinIO（Webhook、SNS、EventBridge）和 OSS（MNS）的对象创建通知，按存储键匹配等待确认的预签名上传，并以实际大小和 ETag 将其标记为已完成。需使用共享密钥认证
+// @Tags         存储事件
+// @Accept       json
+// @Produce      json
+// @Param        X-AssetHub-Token header string false "共享密钥（也可使用 Authorization: Bearer 或 token 查询参数）"
+// @Param        token query string false "共享密钥"
+// @Success      200 {object} response.Response{data=services.StorageEventResult}
+// @Failure      400 {object} response.Response
+// @Failure      401 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/storage-events [post]
+func (h *StorageEventHandler) IngestStorageEvents(c *gin.Context) {
+	if !h.authorized(c) {
+		c.Error(errors.NewUnauthorizedError("invalid storage event token"))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, storageEventMaxBody))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("invalid request", err))
+		return
+	}
+
+	result, err := h.storageEventService.Ingest(c.Request.Context(), body)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// authorized 校验共享密钥（未配置密钥时拒绝所有请求）
+func (h *StorageEventHandler) authorized(c *gin.Context) bool {
+	if h.secret == "" {
+		return false
+	}
+
+	token := c.GetHeader(StorageEventTokenHeader)
+	if auth := c.GetHeader("Authorization"); token == "" && strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if token == "" {
+		token = c.Query("token")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.secret)) == 1
+}
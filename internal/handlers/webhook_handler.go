@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/services"
+	"github.com/NanoBoom/asethub/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebhookHandler Webhook 订阅处理器
+type WebhookHandler struct {
+	webhookService services.WebhookService
+}
+
+// NewWebhookHandler 创建 Webhook 处理器实例
+func NewWebhookHandler(webhookService services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhookRequest 创建订阅请求
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required" example:"https://example.com/hooks/assethub"`
+	Secret      string   `json:"secret" example:""`                            // 签名密钥（为空时自动生成）
+	Events      []string `json:"events" example:"file.completed,file.deleted"` // 订阅的事件（为空表示全部）
+	Description string   `json:"description" binding:"max=255" example:"转码服务"`
+}
+
+// WebhookResponse 订阅响应
+type WebhookResponse struct {
+	WebhookID   uuid.UUID `json:"webhook_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	URL         string    `json:"url" example:"https://example.com/hooks/assethub"`
+	Secret      string    `json:"secret,omitempty" example:"3f9c..."` // 仅创建时返回
+	Events      []string  `json:"events" example:"file.completed,file.deleted"`
+	Description string    `json:"description" example:"转码服务"`
+	Active      bool      `json:"active" example:"true"`
+	CreatedAt   string    `json:"created_at" example:"2026-02-06T00:00:00Z"`
+}
+
+// WebhookDeliveryResponse 投递记录响应
+type WebhookDeliveryResponse struct {
+	DeliveryID    uuid.UUID `json:"delivery_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	EventID       uuid.UUID `json:"event_id" example:"550e8400-e29b-41d4-a716-446655440002"`
+	EventType     string    `json:"event_type" example:"file.completed"`
+	Status        string    `json:"status" example:"succeeded"`
+	Attempts      int       `json:"attempts" example:"1"`
+	ResponseCode  int       `json:"response_code,omitempty" example:"200"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt string    `json:"next_attempt_at,omitempty" example:"2026-02-06T00:00:30Z"` // 仅待投递时返回
+	DeliveredAt   string    `json:"delivered_at,omitempty" example:"2026-02-06T00:00:01Z"`
+	CreatedAt     string    `json:"created_at" example:"2026-02-06T00:00:00Z"`
+}
+
+// ListWebhookDeliveriesResponse 投递日志响应
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	Total      int64                     `json:"total" example:"42"`
+	Offset     int                       `json:"offset" example:"0"`
+	Limit      int                       `json:"limit" example:"20"`
+}
+
+// CreateWebhook godoc
+// @Summary      创建 Webhook 订阅
+// @Description  订阅文件生命周期事件（file.created、file.completed、file.deleted、multipart.aborted）。请求使用 HMAC-SHA256 签名，密钥仅在创建时返回
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        body body CreateWebhookRequest true "订阅信息"
+// @Success      201 {object} response.Response{data=WebhookResponse}
+// @Failure      400 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("invalid request", err))
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), services.WebhookInput{
+		URL:         req.URL,
+		Secret:      req.Secret,
+		Events:      req.Events,
+		Description: req.Description,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	resp := newWebhookResponse(webhook)
+	resp.Secret = webhook.Secret
+
+	c.Status(http.StatusCreated)
+	response.Success(c, resp)
+}
+
+// ListWebhooks godoc
+// @Summary      查询 Webhook 订阅列表
+// @Description  查询所有 Webhook 订阅（不返回签名密钥）
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} response.Response{data=[]WebhookResponse}
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.Error(errors.NewInternalError(err))
+		return
+	}
+
+	resp := make([]WebhookResponse, len(webhooks))
+	for i, webhook := range webhooks {
+		resp[i] = newWebhookResponse(webhook)
+	}
+	response.Success(c, resp)
+}
+
+// GetWebhook godoc
+// @Summary      查询 Webhook 订阅
+// @Description  查询单个 Webhook 订阅（不返回签名密钥）
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "订阅 UUID" format(uuid)
+// @Success      200 {object} response.Response{data=WebhookResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Router       /api/v1/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	webhookID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(c.Request.Context(), webhookID)
+	if err != nil {
+		c.Error(errors.NewNotFoundError("webhook not found"))
+		return
+	}
+
+	response.Success(c, newWebhookResponse(webhook))
+}
+
+// DeleteWebhook godoc
+// @Summary      删除 Webhook 订阅
+// @Description  删除订阅，尚未投递的记录将进入死信
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "订阅 UUID" format(uuid)
+// @Success      204 "No Content"
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Router       /api/v1/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	webhookID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), webhookID); err != nil {
+		c.Error(errors.NewNotFoundError("webhook not found"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary      查询 Webhook 投递日志
+// @Description  按创建时间倒序分页查询投递记录；status=dead 返回死信列表
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "订阅 UUID" format(uuid)
+// @Param        status query string false "投递状态" Enums(pending, succeeded, dead)
+// @Param        offset query int false "偏移量" default(0)
+// @Param        limit query int false "数量（最大 100）" default(20)
+// @Success      200 {object} response.Response{data=ListWebhookDeliveriesResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	webhookID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	status := models.WebhookDeliveryStatus(c.Query("status"))
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryDead:
+	default:
+		c.Error(errors.NewBadRequestError("invalid status", nil))
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.Error(errors.NewBadRequestError("invalid offset", err))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.Error(errors.NewBadRequestError("limit must be between 1 and 100", err))
+		return
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Request.Context(), webhookID, status, offset, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Error(errors.NewNotFoundError("webhook not found"))
+		} else {
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	resp := ListWebhookDeliveriesResponse{
+		Deliveries: make([]WebhookDeliveryResponse, len(deliveries)),
+		Total:      total,
+		Offset:     offset,
+		Limit:      limit,
+	}
+	for i, delivery := range deliveries {
+		resp.Deliveries[i] = newWebhookDeliveryResponse(delivery)
+	}
+	response.Success(c, resp)
+}
+
+// RetryDelivery godoc
+// @Summary      重新投递死信
+// @Description  将死信重新加入投递队列（重置尝试次数）
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "订阅 UUID" format(uuid)
+// @Param        delivery_id path string true "投递 UUID" format(uuid)
+// @Success      202 {object} response.Response{data=WebhookDeliveryResponse}
+// @Failure      400 {object} response.Response
+// @Failure      404 {object} response.Response
+// @Failure      500 {object} response.Response
+// @Router       /api/v1/webhooks/{id}/deliveries/{delivery_id}/retry [post]
+func (h *WebhookHandler) RetryDelivery(c *gin.Context) {
+	webhookID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseUUIDParam(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.RetryDelivery(c.Request.Context(), webhookID, deliveryID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.Error(errors.NewNotFoundError("delivery not found"))
+		case strings.Contains(err.Error(), "only dead"):
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+		default:
+			c.Error(errors.NewInternalError(err))
+		}
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+	response.Success(c, newWebhookDeliveryResponse(delivery))
+}
+
+// parseUUIDParam 解析路径中的 UUID 参数（失败时写入 400 错误）
+func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil || id == uuid.Nil {
+		c.Error(errors.NewBadRequestError("invalid or nil UUID", err))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// newWebhookResponse 转换订阅响应（不包含密钥）
+func newWebhookResponse(webhook *models.Webhook) WebhookResponse {
+	events := []string(webhook.Events)
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookResponse{
+		WebhookID:   webhook.ID,
+		URL:         webhook.URL,
+		Events:      events,
+		Description: webhook.Description,
+		Active:      webhook.Active,
+		CreatedAt:   webhook.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// newWebhookDeliveryResponse 转换投递记录响应
+func newWebhookDeliveryResponse(delivery *models.WebhookDelivery) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		DeliveryID:   delivery.ID,
+		EventID:      delivery.EventID,
+		EventType:    delivery.EventType,
+		Status:       string(delivery.Status),
+		Attempts:     delivery.Attempts,
+		ResponseCode: delivery.ResponseCode,
+		LastError:    delivery.LastError,
+		CreatedAt:    delivery.CreatedAt.Format(time.RFC3339),
+	}
+	if delivery.Status == models.WebhookDeliveryPending {
+		resp.NextAttemptAt = delivery.NextAttemptAt.Format(time.RFC3339)
+	}
+	if delivery.DeliveredAt != nil {
+		resp.DeliveredAt = delivery.DeliveredAt.Format(time.RFC3339)
+	}
+	return resp
+}
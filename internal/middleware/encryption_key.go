@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/NanoBoom/asethub/internal/errors"
+	"github.com/NanoBoom/asethub/pkg/storage"
+)
+
+// EncryptionKeyHeader SSE-C 客户密钥请求头（Base64 编码的 256 位密钥）
+const EncryptionKeyHeader = "X-Encryption-Key"
+
+// EncryptionKey 将请求头中的 SSE-C 客户密钥放入请求上下文（密钥只在本次请求中使用，不记录不落库）
+func EncryptionKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.GetHeader(EncryptionKeyHeader)
+		if value == "" {
+			c.Next()
+			return
+		}
+
+		key, err := storage.ParseCustomerKey(value)
+		if err != nil {
+			c.Error(errors.NewBadRequestError(err.Error(), err))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(storage.WithCustomerKey(c.Request.Context(), key))
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileDownloadStats 文件的累计下载统计（由统计任务从 Redis 计数器定期汇总）
+type FileDownloadStats struct {
+	FileID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"file_id"`
+	Downloads      int64      `gorm:"not null;default:0" json:"downloads"`     // 经服务端下载的次数
+	LinkRequests   int64      `gorm:"not null;default:0" json:"link_requests"` // 生成下载 URL 的次数
+	BytesServed    int64      `gorm:"not null;default:0" json:"bytes_served"`  // 经服务端下载传输的字节数（不含预签名 URL 直连下载）
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`              // 最近一次下载或生成下载 URL 的时间
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (FileDownloadStats) TableName() string {
+	return "file_download_stats"
+}
+
+// FileDailyDownloadStats 文件的每日下载统计（按 UTC 日期）
+type FileDailyDownloadStats struct {
+	FileID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	Day          time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Downloads    int64     `gorm:"not null;default:0" json:"downloads"`
+	LinkRequests int64     `gorm:"not null;default:0" json:"link_requests"`
+	BytesServed  int64     `gorm:"not null;default:0" json:"bytes_served"`
+}
+
+// TableName 指定表名
+func (FileDailyDownloadStats) TableName() string {
+	return "file_daily_download_stats"
+}
@@ -1,25 +1,70 @@
 package models
 
+import "time"
+
 // FileStatus 文件上传状态
 type FileStatus string
 
 const (
-	FileStatusPending    FileStatus = "pending"     // 待上传（已创建记录，等待上传）
-	FileStatusUploading  FileStatus = "uploading"   // 上传中（分片上传进行中）
-	FileStatusCompleted  FileStatus = "completed"   // 上传完成
-	FileStatusFailed     FileStatus = "failed"      // 上传失败
+	FileStatusPending     FileStatus = "pending"     // 待上传（已创建记录，等待上传）
+	FileStatusUploading   FileStatus = "uploading"   // 上传中（分片上传进行中）
+	FileStatusCompleted   FileStatus = "completed"   // 上传完成
+	FileStatusFailed      FileStatus = "failed"      // 上传失败
+	FileStatusQuarantined FileStatus = "quarantined" // 已隔离（扫描发现恶意内容，禁止下载）
+)
+
+// ScanResult 恶意文件扫描结果（为空表示尚未扫描）
+type ScanResult string
+
+const (
+	ScanResultClean    ScanResult = "clean"    // 未发现恶意内容
+	ScanResultInfected ScanResult = "infected" // 发现恶意内容
+)
+
+// RestoreStatus 归档文件的取回状态（为空表示未取回）
+type RestoreStatus string
+
+const (
+	RestoreStatusInProgress RestoreStatus = "in_progress" // 取回中
+	RestoreStatusRestored   RestoreStatus = "restored"    // 已取回（RestoreExpiresAt 之前可下载）
 )
 
 // File 文件元数据模型
 type File struct {
 	BaseModel
-	Name        string     `gorm:"type:varchar(255);not null;index" json:"name"`                    // 文件名
-	Size        int64      `gorm:"not null" json:"size"`                                            // 文件大小（字节）
-	ContentType string     `gorm:"type:varchar(100)" json:"content_type"`                           // MIME 类型
-	StorageKey  string     `gorm:"type:varchar(500);not null;uniqueIndex" json:"storage_key"`       // 存储键（S3 对象键）
-	Status      FileStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // 上传状态
-	Hash        string     `gorm:"type:varchar(64);index" json:"hash"`                              // 文件哈希值（SHA256，可选）
-	UploadID    string     `gorm:"type:varchar(255)" json:"upload_id"`                              // 分片上传 ID（仅分片上传时使用）
+	Name                string     `gorm:"type:varchar(255);not null;index" json:"name"`                    // 文件名
+	Size                int64      `gorm:"not null" json:"size"`                                            // 文件大小（字节）
+	ContentType         string     `gorm:"type:varchar(100)" json:"content_type"`                           // MIME 类型
+	StorageKey          string     `gorm:"type:varchar(500);not null;uniqueIndex" json:"storage_key"`       // 存储键（S3 对象键）
+	Status              FileStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // 上传状态
+	Hash                string     `gorm:"type:varchar(64);index" json:"hash"`                              // 文件哈希值（SHA256，可选）
+	UploadID            string     `gorm:"type:varchar(255)" json:"upload_id"`                              // 分片上传 ID（仅分片上传时使用）
+	ETag                string     `gorm:"type:varchar(100)" json:"etag"`                                   // 存储对象 ETag（来自存储事件通知）
+	Folder              string     `gorm:"type:varchar(500);not null;default:'/';index" json:"folder"`      // 虚拟目录（如 "/campaigns/2026"）
+	Tags                Tags       `gorm:"type:jsonb" json:"tags"`                                          // 标签
+	Metadata            Metadata   `gorm:"type:jsonb" json:"metadata"`                                      // 自定义元数据
+	ScanResult          ScanResult `gorm:"type:varchar(20);index" json:"scan_result"`                       // 恶意文件扫描结果
+	ScanSignature       string     `gorm:"type:varchar(255)" json:"scan_signature"`                         // 命中的病毒签名
+	ScanEngine          string     `gorm:"type:varchar(255)" json:"scan_engine"`                            // 扫描引擎及病毒库版本
+	ScannedAt           *time.Time `json:"scanned_at"`                                                      // 扫描时间
+	DetectedContentType string     `gorm:"type:varchar(100)" json:"detected_content_type"`                  // 根据文件头识别的内容类型
+	ContentTypeMismatch bool       `gorm:"not null;default:false" json:"content_type_mismatch"`             // 识别结果与声明类型不一致
+	ChecksumAlgorithm   string     `gorm:"type:varchar(16)" json:"checksum_algorithm"`                      // 完整性校验算法（md5 / crc32c / sha256）
+	Checksum            string     `gorm:"type:varchar(100)" json:"checksum"`                               // 已校验的 Base64 摘要（分片上传为 "<组合摘要>-<分片数>"）
+	Backend             string     `gorm:"type:varchar(64);<-:create" json:"backend"`                       // 对象所在的存储后端（多后端路由，为空表示默认后端）
+	Tenant              string     `gorm:"type:varchar(64);<-:create" json:"tenant"`                        // 存储键模板中 {tenant} 的取值（复制到其他租户时写入，为空表示 storage.key_tenant）
+	ContentEncoding     string     `gorm:"type:varchar(16);<-:create" json:"content_encoding"`              // 透明压缩编码（如 gzip，由 CodecStore 写入）
+	EncryptionKeyID     string     `gorm:"type:varchar(64);<-:create" json:"-"`                             // 客户端加密：加密数据密钥的主密钥 ID
+	EncryptionKey       string     `gorm:"type:text;<-:create" json:"-"`                                    // 客户端加密：经主密钥加密的数据密钥（由 DataKeyStore 写入）
+
+	// 存储分层（由生命周期任务和取回请求修改，Update 不覆盖）
+	StorageClass     string        `gorm:"type:varchar(32);not null;default:'standard';<-:create" json:"storage_class"` // 存储类型（storage.StorageClass）
+	RestoreStatus    RestoreStatus `gorm:"type:varchar(20);<-:create" json:"restore_status"`                            // 归档文件的取回状态
+	RestoreExpiresAt *time.Time    `gorm:"<-:create" json:"restore_expires_at"`                                         // 已取回副本的过期时间
+	LastAccessedAt   *time.Time    `gorm:"<-:create" json:"last_accessed_at"`                                           // 最近一次下载时间（按小时记录）
+
+	// 软删除时已删除存储对象（由 DeleteWithObject 写入，不为空时不可恢复）
+	ObjectPurgedAt *time.Time `gorm:"<-:create" json:"object_purged_at,omitempty"`
 }
 
 // TableName 指定表名
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent 事务性发件箱事件
+// 与业务数据在同一事务中写入，由中继进程异步发布，保证事件不丢失、不产生幽灵事件
+type OutboxEvent struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`                  // 事件 ID（下游用于去重）
+	Sequence      int64      `gorm:"->" json:"sequence"`                              // 写入顺序（数据库自增，只读）
+	AggregateType string     `gorm:"type:varchar(50);not null" json:"aggregate_type"` // 聚合类型（如 "file"）
+	AggregateID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"aggregate_id"`    // 聚合 ID（如文件 ID）
+	EventType     string     `gorm:"type:varchar(100);not null" json:"event_type"`    // 事件类型
+	Payload       string     `gorm:"type:jsonb;not null" json:"payload"`              // 事件内容（JSON）
+	CreatedAt     time.Time  `json:"created_at"`                                      // 创建时间
+	PublishedAt   *time.Time `gorm:"index" json:"published_at"`                       // 发布时间（未发布为空）
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`              // 发布失败次数
+	LastError     string     `gorm:"type:text" json:"last_error"`                     // 最近一次发布失败原因
+	DeadAt        *time.Time `json:"dead_at"`                                         // 放弃发布的时间（如内容无法解析，中继不再读取）
+}
+
+// TableName 指定表名
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
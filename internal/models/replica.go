@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReplicaStatus 副本状态
+type ReplicaStatus string
+
+const (
+	ReplicaStatusReplicated ReplicaStatus = "replicated" // 已复制（未校验）
+	ReplicaStatusVerified   ReplicaStatus = "verified"   // 已复制并校验一致
+	ReplicaStatusFailed     ReplicaStatus = "failed"     // 复制或校验失败
+)
+
+// FileReplica 文件在其他存储后端上的副本
+// 副本是存储对象的原样拷贝（客户端加密的密文、压缩后的内容），与主对象共用文件记录上的数据密钥和压缩编码
+type FileReplica struct {
+	ID           uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	FileID       uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_file_replicas_file_backend" json:"file_id"`        // 文件 ID
+	Backend      string        `gorm:"type:varchar(64);not null;uniqueIndex:idx_file_replicas_file_backend" json:"backend"` // 副本所在的后端
+	StorageKey   string        `gorm:"type:varchar(1024);not null" json:"storage_key"`                                      // 副本的存储键
+	Status       ReplicaStatus `gorm:"type:varchar(20);not null;index" json:"status"`                                       // 副本状态
+	Size         int64         `gorm:"not null;default:0" json:"size"`                                                      // 副本大小（存储对象的字节数）
+	SHA256       string        `gorm:"column:sha256;type:varchar(64)" json:"sha256,omitempty"`                              // 复制时计算的 SHA-256（十六进制）
+	Attempts     int           `gorm:"not null;default:0" json:"attempts"`                                                  // 连续失败次数
+	LastError    string        `gorm:"type:text" json:"last_error,omitempty"`                                               // 最近一次失败原因
+	ReplicatedAt *time.Time    `json:"replicated_at,omitempty"`                                                             // 最近一次复制成功时间
+	VerifiedAt   *time.Time    `json:"verified_at,omitempty"`                                                               // 最近一次校验通过时间
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (FileReplica) TableName() string {
+	return "file_replicas"
+}
+
+// ReplicationRunStatus 批量迁移状态
+type ReplicationRunStatus string
+
+const (
+	ReplicationRunRunning   ReplicationRunStatus = "running"   // 执行中（中断后可从检查点继续）
+	ReplicationRunCompleted ReplicationRunStatus = "completed" // 已完成
+)
+
+// ReplicationRun 批量复制/迁移任务（检查点）
+// 按文件 ID 顺序处理，Cursor 为最后处理的文件 ID，中断后从 Cursor 之后继续
+type ReplicationRun struct {
+	ID         uuid.UUID            `gorm:"type:uuid;primaryKey" json:"id"`
+	Source     string               `gorm:"type:varchar(64);not null" json:"source"`       // 源后端（只处理主对象在该后端的文件）
+	Target     string               `gorm:"type:varchar(64);not null" json:"target"`       // 目标后端
+	Promote    bool                 `gorm:"not null;default:false" json:"promote"`         // 复制后将目标后端设为文件的主后端
+	Status     ReplicationRunStatus `gorm:"type:varchar(20);not null;index" json:"status"` // 状态
+	Cursor     uuid.UUID            `gorm:"type:uuid;not null" json:"cursor"`              // 检查点：最后处理的文件 ID
+	Scanned    int                  `gorm:"not null;default:0" json:"scanned"`             // 检查的文件数
+	Copied     int                  `gorm:"not null;default:0" json:"copied"`              // 复制成功的文件数
+	Skipped    int                  `gorm:"not null;default:0" json:"skipped"`             // 跳过的文件数
+	Failed     int                  `gorm:"not null;default:0" json:"failed"`              // 失败的文件数
+	Bytes      int64                `gorm:"not null;default:0" json:"bytes"`               // 复制的字节数
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`                         // 完成时间
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ReplicationRun) TableName() string {
+	return "replication_runs"
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShareMode 分享链接的访问方式
+type ShareMode string
+
+const (
+	ShareModeRedirect ShareMode = "redirect" // 重定向到新生成的预签名 URL（存储不支持预签名时改为代理）
+	ShareModeProxy    ShareMode = "proxy"    // 由服务端代理传输文件内容（不暴露存储地址）
+)
+
+// FileShare 文件分享链接
+// 令牌只在创建时返回一次，数据库只保存其 SHA-256；撤销、过期或达到下载次数上限后链接失效
+type FileShare struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	FileID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"file_id"`                  // 分享的文件
+	TokenHash      string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`           // 令牌的 SHA-256（十六进制）
+	PasswordHash   string     `gorm:"type:varchar(255)" json:"-"`                               // 访问密码的 PBKDF2 哈希（为空表示无密码）
+	Mode           ShareMode  `gorm:"type:varchar(16);not null;default:'redirect'" json:"mode"` // 访问方式
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`                                     // 过期时间（为空表示不过期）
+	MaxDownloads   int        `gorm:"not null;default:0" json:"max_downloads"`                  // 最多下载次数（0 不限制）
+	DownloadCount  int        `gorm:"not null;default:0" json:"download_count"`                 // 已下载次数
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`                               // 最近一次下载时间
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`                                     // 撤销时间
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (FileShare) TableName() string {
+	return "file_shares"
+}
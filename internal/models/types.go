@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Metadata 自定义元数据（以 JSONB 存储）
+type Metadata map[string]string
+
+// Value 实现 driver.Valuer 接口
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现 sql.Scanner 接口
+func (m *Metadata) Scan(value interface{}) error {
+	data, err := jsonBytes(value)
+	if err != nil || data == nil {
+		*m = nil
+		return err
+	}
+	return json.Unmarshal(data, m)
+}
+
+// Tags 标签列表（以 JSONB 存储）
+type Tags []string
+
+// Value 实现 driver.Valuer 接口
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现 sql.Scanner 接口
+func (t *Tags) Scan(value interface{}) error {
+	data, err := jsonBytes(value)
+	if err != nil || data == nil {
+		*t = nil
+		return err
+	}
+	return json.Unmarshal(data, t)
+}
+
+// Has 判断是否包含指定标签
+func (t Tags) Has(tag string) bool {
+	for _, v := range t {
+		if v == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonBytes 将数据库返回值转换为 JSON 字节
+func jsonBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("unsupported JSON column type: %T", value)
+	}
+}
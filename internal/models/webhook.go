@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryStatus Webhook 投递状态
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // 等待投递（包含等待重试）
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // 投递成功
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"      // 超过最大重试次数（死信）
+)
+
+// Webhook Webhook 订阅
+type Webhook struct {
+	BaseModel
+	URL         string `gorm:"type:varchar(2048);not null" json:"url"`    // 回调地址
+	Secret      string `gorm:"type:varchar(255);not null" json:"-"`       // HMAC 签名密钥
+	Events      Tags   `gorm:"type:jsonb" json:"events"`                  // 订阅的事件类型（为空表示全部事件）
+	Description string `gorm:"type:varchar(255)" json:"description"`      // 描述
+	Active      bool   `gorm:"not null;default:true;index" json:"active"` // 是否启用
+}
+
+// TableName 指定表名
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Subscribes 判断是否订阅了指定事件
+func (w *Webhook) Subscribes(eventType string) bool {
+	return len(w.Events) == 0 || w.Events.Has(eventType)
+}
+
+// WebhookDelivery Webhook 投递记录（投递日志 + 重试队列）
+type WebhookDelivery struct {
+	BaseModel
+	WebhookID     uuid.UUID             `gorm:"type:uuid;not null;index" json:"webhook_id"`                      // 所属订阅
+	EventID       uuid.UUID             `gorm:"type:uuid;not null;index" json:"event_id"`                        // 事件 ID（消费方可用于去重）
+	EventType     string                `gorm:"type:varchar(100);not null" json:"event_type"`                    // 事件类型
+	Payload       string                `gorm:"type:jsonb;not null" json:"payload"`                              // 请求体（JSON）
+	Status        WebhookDeliveryStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // 投递状态
+	Attempts      int                   `gorm:"not null;default:0" json:"attempts"`                              // 已尝试次数
+	NextAttemptAt time.Time             `gorm:"not null;index" json:"next_attempt_at"`                           // 下次尝试时间
+	ResponseCode  int                   `json:"response_code"`                                                   // 最近一次响应状态码
+	LastError     string                `gorm:"type:text" json:"last_error"`                                     // 最近一次失败原因
+	DeliveredAt   *time.Time            `json:"delivered_at"`                                                    // 投递成功时间
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
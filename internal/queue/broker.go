@@ -0,0 +1,199 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Broker 任务存储
+// 每个队列维护两个集合：待执行集合（按可执行时间排序）和执行中集合（按可见性截止时间排序）
+type Broker interface {
+	// Push 保存任务并按 RunAt 加入待执行集合
+	Push(ctx context.Context, job *Job) error
+
+	// Pop 领取一个到期任务并移入执行中集合，截止时间为 now + visibility；没有到期任务时返回 nil
+	Pop(ctx context.Context, queue string, now time.Time, visibility time.Duration) (*Job, error)
+
+	// Extend 将执行中任务的可见性截止时间延长到 deadline
+	Extend(ctx context.Context, job *Job, deadline time.Time) error
+
+	// Save 更新任务记录
+	Save(ctx context.Context, job *Job) error
+
+	// Requeue 保存任务并将其从执行中集合放回待执行集合（按 RunAt 调度）
+	Requeue(ctx context.Context, job *Job) error
+
+	// Finish 保存结束的任务并移出执行中集合，记录保留 retention
+	Finish(ctx context.Context, job *Job, retention time.Duration) error
+
+	// RecoverExpired 将可见性已超时的任务放回待执行集合，返回数量
+	RecoverExpired(ctx context.Context, queue string, now time.Time) (int, error)
+
+	// Get 查询任务，不存在时返回 ErrJobNotFound
+	Get(ctx context.Context, id uuid.UUID) (*Job, error)
+}
+
+// popScript 原子地领取一个到期任务
+// KEYS[1]: 待执行集合，KEYS[2]: 执行中集合；ARGV[1]: 当前时间（毫秒），ARGV[2]: 可见性截止时间（毫秒），ARGV[3]: 任务记录键前缀
+var popScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+	return false
+end
+redis.call('ZREM', KEYS[1], ids[1])
+local data = redis.call('GET', ARGV[3] .. ids[1])
+if not data then
+	return false
+end
+redis.call('ZADD', KEYS[2], ARGV[2], ids[1])
+return data
+`)
+
+// recoverScript 将可见性超时的任务放回待执行集合
+// KEYS[1]: 执行中集合，KEYS[2]: 待执行集合；ARGV[1]: 当前时间（毫秒）
+var recoverScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('ZADD', KEYS[2], ARGV[1], id)
+end
+return #ids
+`)
+
+// RedisBroker 基于 Redis 的任务存储
+// 键：<prefix>:job:<id>（任务 JSON）、<prefix>:queue:<queue>:scheduled、<prefix>:queue:<queue>:active
+type RedisBroker struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisBroker 创建 Redis 任务存储
+func NewRedisBroker(client *redis.Client, prefix string) *RedisBroker {
+	return &RedisBroker{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (b *RedisBroker) jobKey(id string) string {
+	return fmt.Sprintf("%s:job:%s", b.prefix, id)
+}
+
+func (b *RedisBroker) scheduledKey(queue string) string {
+	return fmt.Sprintf("%s:queue:%s:scheduled", b.prefix, queue)
+}
+
+func (b *RedisBroker) activeKey(queue string) string {
+	return fmt.Sprintf("%s:queue:%s:active", b.prefix, queue)
+}
+
+// Push 保存任务并加入待执行集合
+func (b *RedisBroker) Push(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, b.jobKey(job.ID.String()), data, 0)
+		pipe.ZAdd(ctx, b.scheduledKey(job.Queue), redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: job.ID.String()})
+		return nil
+	})
+	return err
+}
+
+// Pop 领取一个到期任务
+func (b *RedisBroker) Pop(ctx context.Context, queue string, now time.Time, visibility time.Duration) (*Job, error) {
+	data, err := popScript.Run(ctx, b.client,
+		[]string{b.scheduledKey(queue), b.activeKey(queue)},
+		now.UnixMilli(), now.Add(visibility).UnixMilli(), b.jobKey(""),
+	).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+	return &job, nil
+}
+
+// Extend 延长可见性截止时间（任务已不在执行中集合时不做处理）
+func (b *RedisBroker) Extend(ctx context.Context, job *Job, deadline time.Time) error {
+	return b.client.ZAddXX(ctx, b.activeKey(job.Queue), redis.Z{Score: float64(deadline.UnixMilli()), Member: job.ID.String()}).Err()
+}
+
+// Save 更新任务记录
+func (b *RedisBroker) Save(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+	return b.client.Set(ctx, b.jobKey(job.ID.String()), data, 0).Err()
+}
+
+// Requeue 将任务放回待执行集合
+func (b *RedisBroker) Requeue(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, b.jobKey(job.ID.String()), data, 0)
+		pipe.ZRem(ctx, b.activeKey(job.Queue), job.ID.String())
+		pipe.ZAdd(ctx, b.scheduledKey(job.Queue), redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: job.ID.String()})
+		return nil
+	})
+	return err
+}
+
+// Finish 保存结束的任务并移出执行中集合
+func (b *RedisBroker) Finish(ctx context.Context, job *Job, retention time.Duration) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, b.jobKey(job.ID.String()), data, retention)
+		pipe.ZRem(ctx, b.activeKey(job.Queue), job.ID.String())
+		return nil
+	})
+	return err
+}
+
+// RecoverExpired 将可见性超时的任务放回待执行集合
+func (b *RedisBroker) RecoverExpired(ctx context.Context, queue string, now time.Time) (int, error) {
+	return recoverScript.Run(ctx, b.client,
+		[]string{b.activeKey(queue), b.scheduledKey(queue)},
+		now.UnixMilli(),
+	).Int()
+}
+
+// Get 查询任务
+func (b *RedisBroker) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	data, err := b.client.Get(ctx, b.jobKey(id.String())).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+	return &job, nil
+}
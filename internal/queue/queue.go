@@ -0,0 +1,370 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/google/uuid"
+)
+
+// DefaultQueue 未指定队列时使用的队列名
+const DefaultQueue = "default"
+
+// ErrJobNotFound 任务不存在（或记录已过期）
+var ErrJobNotFound = errors.New("job not found")
+
+// Status 任务状态
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // 等待执行（包括延迟任务和等待重试的任务）
+	StatusRunning   Status = "running"   // 执行中
+	StatusSucceeded Status = "succeeded" // 执行成功
+	StatusFailed    Status = "failed"    // 执行失败（重试次数用尽或不可重试的错误）
+)
+
+// Job 任务记录
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	Queue       string          `json:"queue"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      Status          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	RunAt       time.Time       `json:"run_at"` // 下次可执行时间
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Handler 任务处理器，返回错误时按退避策略重试
+type Handler interface {
+	Handle(ctx context.Context, job *Job) error
+}
+
+// HandlerFunc 函数形式的任务处理器
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// Handle 调用 f(ctx, job)
+func (f HandlerFunc) Handle(ctx context.Context, job *Job) error {
+	return f(ctx, job)
+}
+
+// Typed 将接收具体载荷类型的函数包装为 Handler（载荷无法解析时不重试）
+func Typed[T any](fn func(ctx context.Context, job *Job, payload T) error) Handler {
+	return HandlerFunc(func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+		return fn(ctx, job, payload)
+	})
+}
+
+// permanentError 不可重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 标记错误不可重试，任务直接进入失败状态
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// EnqueueOptions 入队选项
+type EnqueueOptions struct {
+	Queue       string        // 队列名（默认 DefaultQueue）
+	Delay       time.Duration // 延迟执行时间
+	MaxAttempts int           // 最大执行次数（默认使用配置值）
+}
+
+// Manager 任务队列管理器
+// 负责入队、查询，并按队列的并发数运行已注册的处理器。
+// 任务至少执行一次：领取后在可见性超时内未完成也未续期（如进程崩溃）的任务会重新入队，处理器应保证幂等
+type Manager struct {
+	broker Broker
+	cfg    config.JobsConfig
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewManager 创建任务队列管理器
+func NewManager(broker Broker, cfg config.JobsConfig) *Manager {
+	return &Manager{
+		broker:   broker,
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register 注册任务类型的处理器（应在 Run 之前调用）
+func (m *Manager) Register(jobType string, handler Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[jobType] = handler
+}
+
+// handler 查询任务类型的处理器
+func (m *Manager) handler(jobType string) (Handler, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	handler, ok := m.handlers[jobType]
+	return handler, ok
+}
+
+// Enqueue 创建任务并加入队列，payload 会被序列化为 JSON
+func (m *Manager) Enqueue(ctx context.Context, jobType string, payload interface{}, opts *EnqueueOptions) (*Job, error) {
+	if opts == nil {
+		opts = &EnqueueOptions{}
+	}
+
+	queue := opts.Queue
+	if queue == "" {
+		queue = DefaultQueue
+	}
+	if _, ok := m.cfg.Queues[queue]; !ok {
+		return nil, fmt.Errorf("unknown queue: %s", queue)
+	}
+	if _, ok := m.handler(jobType); !ok {
+		return nil, fmt.Errorf("no handler registered for job type: %s", jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = m.cfg.MaxAttempts
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:          uuid.New(),
+		Queue:       queue,
+		Type:        jobType,
+		Payload:     data,
+		Status:      StatusPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       now.Add(opts.Delay),
+		CreatedAt:   now,
+	}
+	if err := m.broker.Push(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Get 查询任务，任务不存在时返回 ErrJobNotFound
+func (m *Manager) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	return m.broker.Get(ctx, id)
+}
+
+// Run 按配置的并发数运行所有队列，直到 ctx 取消
+// 取消后停止领取新任务，等待进行中的任务完成；超过 ShutdownTimeout 时取消它们并放回队列
+func (m *Manager) Run(ctx context.Context) {
+	// 任务使用独立的 ctx，服务关闭时不会立即中断进行中的任务
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	var inflight, fetchers sync.WaitGroup
+	for queue, concurrency := range m.cfg.Queues {
+		fetchers.Add(1)
+		go func(queue string, concurrency int) {
+			defer fetchers.Done()
+			m.fetch(ctx, jobCtx, queue, concurrency, &inflight)
+		}(queue, concurrency)
+	}
+
+	fetchers.Add(1)
+	go func() {
+		defer fetchers.Done()
+		m.recoverLoop(ctx)
+	}()
+
+	fetchers.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(m.cfg.ShutdownTimeout):
+		cancelJobs()
+		<-done
+	}
+}
+
+// fetch 持续领取队列中的到期任务，同时执行的任务数不超过 concurrency
+func (m *Manager) fetch(ctx, jobCtx context.Context, queue string, concurrency int, inflight *sync.WaitGroup) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case slots <- struct{}{}:
+		}
+
+		job, err := m.broker.Pop(ctx, queue, time.Now(), m.cfg.VisibilityTimeout)
+		if err != nil || job == nil {
+			<-slots
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(m.cfg.PollInterval):
+			}
+			continue
+		}
+
+		inflight.Add(1)
+		go func() {
+			defer inflight.Done()
+			defer func() { <-slots }()
+			m.process(jobCtx, job)
+		}()
+	}
+}
+
+// recoverLoop 定期将可见性超时的任务放回队列
+func (m *Manager) recoverLoop(ctx context.Context) {
+	interval := m.cfg.VisibilityTimeout / 2
+	if interval <= 0 {
+		interval = m.cfg.PollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for queue := range m.cfg.Queues {
+			_, _ = m.broker.RecoverExpired(ctx, queue, time.Now())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process 执行单个已领取的任务并记录结果
+func (m *Manager) process(ctx context.Context, job *Job) {
+	// 状态写入不受任务取消影响
+	storeCtx := context.WithoutCancel(ctx)
+
+	now := time.Now()
+	job.Status = StatusRunning
+	job.Attempts++
+	job.StartedAt = &now
+	_ = m.broker.Save(storeCtx, job)
+
+	// 执行期间定期续期，避免长任务被重新投递
+	heartbeatCtx, stopHeartbeat := context.WithCancel(storeCtx)
+	go m.heartbeat(heartbeatCtx, job)
+	err := m.execute(ctx, job)
+	stopHeartbeat()
+
+	finished := time.Now()
+	switch {
+	case err == nil:
+		job.Status = StatusSucceeded
+		job.LastError = ""
+		job.FinishedAt = &finished
+		_ = m.broker.Finish(storeCtx, job, m.cfg.Retention)
+
+	case ctx.Err() != nil:
+		// 服务关闭时被中断：立即放回队列，不计入执行次数
+		job.Status = StatusPending
+		job.Attempts--
+		job.LastError = err.Error()
+		job.RunAt = finished
+		_ = m.broker.Requeue(storeCtx, job)
+
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		job.Status = StatusFailed
+		job.LastError = err.Error()
+		job.FinishedAt = &finished
+		_ = m.broker.Finish(storeCtx, job, m.cfg.Retention)
+
+	default:
+		job.Status = StatusPending
+		job.LastError = err.Error()
+		job.RunAt = finished.Add(backoff(job.Attempts, m.cfg.InitialBackoff, m.cfg.MaxBackoff))
+		_ = m.broker.Requeue(storeCtx, job)
+	}
+}
+
+// execute 调用任务处理器，处理器 panic 时转为错误
+func (m *Manager) execute(ctx context.Context, job *Job) (err error) {
+	handler, ok := m.handler(job.Type)
+	if !ok {
+		return Permanent(fmt.Errorf("no handler registered for job type: %s", job.Type))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler.Handle(ctx, job)
+}
+
+// heartbeat 每隔半个可见性超时续期一次，直到 ctx 取消
+func (m *Manager) heartbeat(ctx context.Context, job *Job) {
+	interval := m.cfg.VisibilityTimeout / 2
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = m.broker.Extend(ctx, job, time.Now().Add(m.cfg.VisibilityTimeout))
+		}
+	}
+}
+
+// isPermanent 判断错误是否不可重试
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// backoff 计算第 attempt 次失败后的重试间隔：initial * 2^(attempt-1)，不超过 maxDelay
+func backoff(attempt int, initial, maxDelay time.Duration) time.Duration {
+	delay := initial
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
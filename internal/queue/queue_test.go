@@ -0,0 +1,377 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/google/uuid"
+)
+
+// memoryBroker 内存实现的任务存储
+type memoryBroker struct {
+	mu        sync.Mutex
+	jobs      map[uuid.UUID]Job
+	scheduled map[uuid.UUID]time.Time
+	active    map[uuid.UUID]time.Time
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{
+		jobs:      make(map[uuid.UUID]Job),
+		scheduled: make(map[uuid.UUID]time.Time),
+		active:    make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (b *memoryBroker) Push(ctx context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.jobs[job.ID] = *job
+	b.scheduled[job.ID] = job.RunAt
+	return nil
+}
+
+func (b *memoryBroker) Pop(ctx context.Context, queue string, now time.Time, visibility time.Duration) (*Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var due []uuid.UUID
+	for id, runAt := range b.scheduled {
+		if b.jobs[id].Queue == queue && !runAt.After(now) {
+			due = append(due, id)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	sort.Slice(due, func(i, j int) bool { return b.scheduled[due[i]].Before(b.scheduled[due[j]]) })
+
+	id := due[0]
+	delete(b.scheduled, id)
+	b.active[id] = now.Add(visibility)
+	job := b.jobs[id]
+	return &job, nil
+}
+
+func (b *memoryBroker) Extend(ctx context.Context, job *Job, deadline time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.active[job.ID]; ok {
+		b.active[job.ID] = deadline
+	}
+	return nil
+}
+
+func (b *memoryBroker) Save(ctx context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.jobs[job.ID] = *job
+	return nil
+}
+
+func (b *memoryBroker) Requeue(ctx context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.jobs[job.ID] = *job
+	delete(b.active, job.ID)
+	b.scheduled[job.ID] = job.RunAt
+	return nil
+}
+
+func (b *memoryBroker) Finish(ctx context.Context, job *Job, retention time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.jobs[job.ID] = *job
+	delete(b.active, job.ID)
+	return nil
+}
+
+func (b *memoryBroker) RecoverExpired(ctx context.Context, queue string, now time.Time) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var n int
+	for id, deadline := range b.active {
+		if b.jobs[id].Queue == queue && !deadline.After(now) {
+			delete(b.active, id)
+			b.scheduled[id] = now
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (b *memoryBroker) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, ok := b.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return &job, nil
+}
+
+func newTestJobsConfig() config.JobsConfig {
+	return config.JobsConfig{
+		Queues:            map[string]int{DefaultQueue: 2},
+		PollInterval:      5 * time.Millisecond,
+		VisibilityTimeout: time.Minute,
+		MaxAttempts:       3,
+		InitialBackoff:    time.Second,
+		MaxBackoff:        time.Minute,
+		Retention:         time.Hour,
+		ShutdownTimeout:   time.Second,
+	}
+}
+
+// processDue 领取并同步执行一个到期任务，返回是否领取到任务
+func processDue(t *testing.T, m *Manager, broker *memoryBroker) bool {
+	t.Helper()
+	job, err := broker.Pop(context.Background(), DefaultQueue, time.Now(), m.cfg.VisibilityTimeout)
+	if err != nil {
+		t.Fatalf("Pop() error = %v", err)
+	}
+	if job == nil {
+		return false
+	}
+	m.process(context.Background(), job)
+	return true
+}
+
+func TestManagerRetry(t *testing.T) {
+	ctx := context.Background()
+	broker := newMemoryBroker()
+	m := NewManager(broker, newTestJobsConfig())
+
+	type payload struct {
+		FileID string `json:"file_id"`
+	}
+	var calls int
+	m.Register("file.checksum", Typed(func(ctx context.Context, job *Job, p payload) error {
+		calls++
+		if p.FileID != "f-1" {
+			t.Errorf("payload = %+v", p)
+		}
+		if calls < 3 {
+			return errors.New("storage unavailable")
+		}
+		return nil
+	}))
+
+	job, err := m.Enqueue(ctx, "file.checksum", payload{FileID: "f-1"}, nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if !processDue(t, m, broker) {
+			t.Fatalf("attempt %d: no job due", attempt)
+		}
+		got, _ := m.Get(ctx, job.ID)
+		if got.Status != StatusPending || got.Attempts != attempt || got.LastError != "storage unavailable" {
+			t.Fatalf("after attempt %d: status=%s attempts=%d error=%q", attempt, got.Status, got.Attempts, got.LastError)
+		}
+		// 按指数退避推迟，到期前不会被领取
+		if want := backoff(attempt, time.Second, time.Minute); got.RunAt.Sub(time.Now()) > want || got.RunAt.Before(time.Now()) {
+			t.Errorf("after attempt %d: run_at in %v, want ~%v", attempt, time.Until(got.RunAt), want)
+		}
+		if processDue(t, m, broker) {
+			t.Fatalf("after attempt %d: job should wait for backoff", attempt)
+		}
+		broker.scheduled[job.ID] = time.Now()
+	}
+
+	processDue(t, m, broker)
+	got, _ := m.Get(ctx, job.ID)
+	if got.Status != StatusSucceeded || got.Attempts != 3 || got.LastError != "" || got.FinishedAt == nil {
+		t.Errorf("final job = %+v", got)
+	}
+}
+
+func TestManagerFailure(t *testing.T) {
+	ctx := context.Background()
+	broker := newMemoryBroker()
+	m := NewManager(broker, newTestJobsConfig())
+
+	m.Register("always.fail", HandlerFunc(func(ctx context.Context, job *Job) error {
+		return errors.New("boom")
+	}))
+	m.Register("bad.input", HandlerFunc(func(ctx context.Context, job *Job) error {
+		return Permanent(errors.New("unsupported format"))
+	}))
+	m.Register("panics", HandlerFunc(func(ctx context.Context, job *Job) error {
+		panic("nil map")
+	}))
+
+	// 重试次数用尽
+	exhausted, _ := m.Enqueue(ctx, "always.fail", nil, &EnqueueOptions{MaxAttempts: 2})
+	processDue(t, m, broker)
+	broker.scheduled[exhausted.ID] = time.Now()
+	processDue(t, m, broker)
+	if got, _ := m.Get(ctx, exhausted.ID); got.Status != StatusFailed || got.Attempts != 2 {
+		t.Errorf("exhausted job: status=%s attempts=%d", got.Status, got.Attempts)
+	}
+
+	// 不可重试的错误
+	permanent, _ := m.Enqueue(ctx, "bad.input", nil, nil)
+	processDue(t, m, broker)
+	if got, _ := m.Get(ctx, permanent.ID); got.Status != StatusFailed || got.Attempts != 1 {
+		t.Errorf("permanent job: status=%s attempts=%d", got.Status, got.Attempts)
+	}
+
+	// panic 视为普通错误
+	panicked, _ := m.Enqueue(ctx, "panics", nil, nil)
+	processDue(t, m, broker)
+	if got, _ := m.Get(ctx, panicked.ID); got.Status != StatusPending || got.LastError != "job panicked: nil map" {
+		t.Errorf("panicked job: status=%s error=%q", got.Status, got.LastError)
+	}
+}
+
+func TestManagerEnqueue(t *testing.T) {
+	ctx := context.Background()
+	broker := newMemoryBroker()
+	m := NewManager(broker, newTestJobsConfig())
+	m.Register("noop", HandlerFunc(func(ctx context.Context, job *Job) error { return nil }))
+
+	if _, err := m.Enqueue(ctx, "unknown", nil, nil); err == nil {
+		t.Error("Enqueue() with unregistered type should fail")
+	}
+	if _, err := m.Enqueue(ctx, "noop", nil, &EnqueueOptions{Queue: "missing"}); err == nil {
+		t.Error("Enqueue() to unknown queue should fail")
+	}
+
+	delayed, err := m.Enqueue(ctx, "noop", nil, &EnqueueOptions{Delay: time.Hour})
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if delayed.MaxAttempts != 3 || delayed.Queue != DefaultQueue {
+		t.Errorf("job defaults = queue %q max_attempts %d", delayed.Queue, delayed.MaxAttempts)
+	}
+	if processDue(t, m, broker) {
+		t.Error("delayed job should not run before its delay")
+	}
+
+	if _, err := m.Get(ctx, uuid.New()); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Get() unknown job error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestManagerRun(t *testing.T) {
+	broker := newMemoryBroker()
+	m := NewManager(broker, newTestJobsConfig())
+
+	var running, peak, done int32
+	release := make(chan struct{})
+	m.Register("slow", HandlerFunc(func(ctx context.Context, job *Job) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&done, 1)
+		return nil
+	}))
+
+	var ids []uuid.UUID
+	for i := 0; i < 5; i++ {
+		job, _ := m.Enqueue(context.Background(), "slow", nil, nil)
+		ids = append(ids, job.ID)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(stopped)
+	}()
+
+	// 并发数不超过队列配置
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&running) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt32(&peak); got != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", got)
+	}
+
+	// 关闭时等待进行中的任务完成，不再领取新任务
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-stopped
+
+	if got := atomic.LoadInt32(&done); got != 2 {
+		t.Errorf("completed jobs = %d, want 2", got)
+	}
+	var pending int
+	for _, id := range ids {
+		if job, _ := m.Get(context.Background(), id); job.Status == StatusPending {
+			pending++
+		}
+	}
+	if pending != 3 {
+		t.Errorf("pending jobs after shutdown = %d, want 3", pending)
+	}
+}
+
+func TestManagerShutdownTimeout(t *testing.T) {
+	broker := newMemoryBroker()
+	cfg := newTestJobsConfig()
+	cfg.ShutdownTimeout = 10 * time.Millisecond
+	m := NewManager(broker, cfg)
+
+	started := make(chan struct{})
+	m.Register("stuck", HandlerFunc(func(ctx context.Context, job *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	job, _ := m.Enqueue(context.Background(), "stuck", nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(stopped)
+	}()
+	<-started
+	cancel()
+	<-stopped
+
+	// 被中断的任务放回队列，不计入执行次数
+	got, _ := m.Get(context.Background(), job.ID)
+	if got.Status != StatusPending || got.Attempts != 0 {
+		t.Errorf("interrupted job: status=%s attempts=%d", got.Status, got.Attempts)
+	}
+	if _, ok := broker.scheduled[job.ID]; !ok {
+		t.Error("interrupted job should be back in the queue")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{10, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempt, 10*time.Second, 10*time.Minute); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// backendRepository 存储后端仓储（对象所在的后端记录在文件记录上，按存储键读写）
+type backendRepository struct {
+	*BaseRepository
+}
+
+// NewBackendRepository 创建存储后端仓储实例
+func NewBackendRepository(db *gorm.DB) storage.BackendStore {
+	return &backendRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// GetBackend 读取对象所在的后端（包含已软删除的记录；没有文件记录时返回空字符串）
+func (r *backendRepository) GetBackend(ctx context.Context, key string) (string, error) {
+	var file models.File
+	err := r.conn(ctx).Unscoped().
+		Select("backend").
+		Where("storage_key = ?", key).
+		First(&file).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return file.Backend, nil
+}
+
+// PutBackend 记录对象所在的后端
+// 后端字段只允许创建时写入（避免 Save 覆盖），这里直接执行 UPDATE
+func (r *backendRepository) PutBackend(ctx context.Context, key string, backend string) error {
+	result := r.conn(ctx).Exec("UPDATE files SET backend = ? WHERE storage_key = ?", backend, key)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", storage.ErrObjectNotTracked, key)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// codecRepository 透明压缩编码仓储（压缩编码保存在文件记录上，按存储键读写）
+type codecRepository struct {
+	*BaseRepository
+}
+
+// NewCodecRepository 创建压缩编码仓储实例
+func NewCodecRepository(db *gorm.DB) storage.CodecStore {
+	return &codecRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// GetCodec 读取对象的压缩编码（包含已软删除的记录；没有文件记录的对象（如打包文件）视为未压缩）
+func (r *codecRepository) GetCodec(ctx context.Context, key string) (storage.Codec, error) {
+	var file models.File
+	err := r.conn(ctx).Unscoped().
+		Select("content_encoding").
+		Where("storage_key = ?", key).
+		First(&file).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return storage.CodecNone, nil
+		}
+		return storage.CodecNone, err
+	}
+	return storage.Codec(file.ContentEncoding), nil
+}
+
+// PutCodec 保存对象的压缩编码
+// 压缩编码字段只允许创建时写入（避免 Save 覆盖），这里直接执行 UPDATE
+func (r *codecRepository) PutCodec(ctx context.Context, key string, codec storage.Codec) error {
+	result := r.conn(ctx).Exec("UPDATE files SET content_encoding = ? WHERE storage_key = ?", string(codec), key)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("file record not found for %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// dataKeyRepository 客户端加密数据密钥仓储（数据密钥保存在文件记录上，按存储键读写）
+type dataKeyRepository struct {
+	*BaseRepository
+}
+
+// NewDataKeyRepository 创建数据密钥仓储实例
+func NewDataKeyRepository(db *gorm.DB) storage.DataKeyStore {
+	return &dataKeyRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// GetDataKey 读取对象的数据密钥（包含已软删除的记录，回收站中的文件仍可恢复和读取）
+func (r *dataKeyRepository) GetDataKey(ctx context.Context, key string) (*storage.WrappedKey, error) {
+	var file models.File
+	err := r.conn(ctx).Unscoped().
+		Select("encryption_key_id", "encryption_key").
+		Where("storage_key = ?", key).
+		First(&file).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if file.EncryptionKey == "" {
+		return nil, nil
+	}
+	return &storage.WrappedKey{KeyID: file.EncryptionKeyID, Ciphertext: file.EncryptionKey}, nil
+}
+
+// PutDataKey 保存对象的数据密钥
+// 数据密钥字段只允许创建时写入（避免 Save 覆盖），这里直接执行 UPDATE
+func (r *dataKeyRepository) PutDataKey(ctx context.Context, key string, wrapped *storage.WrappedKey) error {
+	result := r.conn(ctx).Exec(
+		"UPDATE files SET encryption_key_id = ?, encryption_key = ? WHERE storage_key = ?",
+		wrapped.KeyID, wrapped.Ciphertext, key,
+	)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", storage.ErrObjectNotTracked, key)
+	}
+	return nil
+}
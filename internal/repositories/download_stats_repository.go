@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DownloadStatsRepository 文件下载统计仓储接口
+type DownloadStatsRepository interface {
+	// RecordFlush 记录统计批次（批次已记录过时返回 false，调用方不应再次累加该批次）
+	RecordFlush(ctx context.Context, batchID uuid.UUID, at time.Time) (bool, error)
+
+	// AddStats 累加文件的累计统计和每日统计（文件已被永久删除时忽略）
+	AddStats(ctx context.Context, total *models.FileDownloadStats, daily []*models.FileDailyDownloadStats) error
+
+	// GetStats 查询文件的累计统计（没有统计时返回 nil）
+	GetStats(ctx context.Context, fileID uuid.UUID) (*models.FileDownloadStats, error)
+
+	// ListDaily 查询文件 since（含）之后的每日统计，按日期升序
+	ListDaily(ctx context.Context, fileID uuid.UUID, since time.Time) ([]*models.FileDailyDownloadStats, error)
+
+	// DeleteFlushesBefore 删除 before 之前记录的统计批次，返回删除的数量
+	DeleteFlushesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// downloadStatsRepository 文件下载统计仓储实现
+type downloadStatsRepository struct {
+	*BaseRepository
+}
+
+// NewDownloadStatsRepository 创建文件下载统计仓储实例
+func NewDownloadStatsRepository(db *gorm.DB) DownloadStatsRepository {
+	return &downloadStatsRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// RecordFlush 插入统计批次，主键冲突表示该批次已写入
+func (r *downloadStatsRepository) RecordFlush(ctx context.Context, batchID uuid.UUID, at time.Time) (bool, error) {
+	result := r.conn(ctx).Exec(
+		"INSERT INTO download_stats_flushes (batch_id, flushed_at) VALUES (?, ?) ON CONFLICT (batch_id) DO NOTHING",
+		batchID, at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// AddStats 以 upsert 累加计数（只写入仍存在的文件，避免统计落后于删除时违反外键）
+func (r *downloadStatsRepository) AddStats(ctx context.Context, total *models.FileDownloadStats, daily []*models.FileDailyDownloadStats) error {
+	db := r.conn(ctx)
+	for _, day := range daily {
+		err := db.Exec(`INSERT INTO file_daily_download_stats (file_id, day, downloads, link_requests, bytes_served)
+SELECT ?::uuid, ?::date, ?::bigint, ?::bigint, ?::bigint WHERE EXISTS (SELECT 1 FROM files WHERE id = ?)
+ON CONFLICT (file_id, day) DO UPDATE SET
+	downloads = file_daily_download_stats.downloads + EXCLUDED.downloads,
+	link_requests = file_daily_download_stats.link_requests + EXCLUDED.link_requests,
+	bytes_served = file_daily_download_stats.bytes_served + EXCLUDED.bytes_served`,
+			day.FileID, day.Day, day.Downloads, day.LinkRequests, day.BytesServed, day.FileID).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.Exec(`INSERT INTO file_download_stats (file_id, downloads, link_requests, bytes_served, last_accessed_at, updated_at)
+SELECT ?::uuid, ?::bigint, ?::bigint, ?::bigint, ?::timestamp, ?::timestamp WHERE EXISTS (SELECT 1 FROM files WHERE id = ?)
+ON CONFLICT (file_id) DO UPDATE SET
+	downloads = file_download_stats.downloads + EXCLUDED.downloads,
+	link_requests = file_download_stats.link_requests + EXCLUDED.link_requests,
+	bytes_served = file_download_stats.bytes_served + EXCLUDED.bytes_served,
+	last_accessed_at = GREATEST(file_download_stats.last_accessed_at, EXCLUDED.last_accessed_at),
+	updated_at = EXCLUDED.updated_at`,
+		total.FileID, total.Downloads, total.LinkRequests, total.BytesServed, total.LastAccessedAt, total.UpdatedAt, total.FileID).Error
+}
+
+// GetStats 查询文件的累计统计
+func (r *downloadStatsRepository) GetStats(ctx context.Context, fileID uuid.UUID) (*models.FileDownloadStats, error) {
+	var stats models.FileDownloadStats
+	err := r.conn(ctx).Where("file_id = ?", fileID).First(&stats).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ListDaily 查询文件的每日统计
+func (r *downloadStatsRepository) ListDaily(ctx context.Context, fileID uuid.UUID, since time.Time) ([]*models.FileDailyDownloadStats, error) {
+	var daily []*models.FileDailyDownloadStats
+	err := r.conn(ctx).Where("file_id = ? AND day >= ?", fileID, since.Format(time.DateOnly)).Order("day").Find(&daily).Error
+	if err != nil {
+		return nil, err
+	}
+	return daily, nil
+}
+
+// DeleteFlushesBefore 清理过期的统计批次记录
+func (r *downloadStatsRepository) DeleteFlushesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.conn(ctx).Exec("DELETE FROM download_stats_flushes WHERE flushed_at < ?", before)
+	return result.RowsAffected, result.Error
+}
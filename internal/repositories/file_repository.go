@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/NanoBoom/asethub/internal/models"
 	"github.com/google/uuid"
@@ -30,6 +32,42 @@ type FileRepository interface {
 
 	// List 分页查询文件列表
 	List(ctx context.Context, offset, limit int) ([]*models.File, int64, error)
+
+	// GetByIDs 批量查询文件（包含已软删除的记录）
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.File, error)
+
+	// DeleteWithObject 软删除文件记录并记录存储对象已删除（之后不能再恢复）
+	DeleteWithObject(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// Restore 恢复已软删除的文件记录（存储对象已删除的记录返回 gorm.ErrRecordNotFound）
+	Restore(ctx context.Context, id uuid.UUID) error
+
+	// DeletePermanently 永久删除文件记录（物理删除，不可恢复）
+	DeletePermanently(ctx context.Context, ids []uuid.UUID) error
+
+	// ListByFolder 查询目录（含子目录）下的所有文件
+	ListByFolder(ctx context.Context, folder string) ([]*models.File, error)
+
+	// ListAfter 按 ID 顺序分批查询文件（包含已软删除的记录），返回 ID 大于 afterID 的前 limit 条
+	ListAfter(ctx context.Context, afterID uuid.UUID, limit int) ([]*models.File, error)
+
+	// UpdateStorageKey 更新文件存储键（包含已软删除的记录）
+	// 仅当当前存储键仍为 oldKey 时更新，否则返回 gorm.ErrRecordNotFound
+	UpdateStorageKey(ctx context.Context, id uuid.UUID, oldKey, newKey string) error
+
+	// UpdateStorageClass 更新文件存储类型并清除取回状态
+	// 仅当当前存储类型仍为 from 时更新，否则返回 gorm.ErrRecordNotFound
+	UpdateStorageClass(ctx context.Context, id uuid.UUID, from, to string) error
+
+	// UpdateScanResult 保存扫描结果（scan_* 字段和状态）
+	// 仅当文件未删除且状态仍为 completed 或 quarantined 时更新，否则返回 gorm.ErrRecordNotFound
+	UpdateScanResult(ctx context.Context, file *models.File) error
+
+	// UpdateRestoreStatus 更新归档文件的取回状态
+	UpdateRestoreStatus(ctx context.Context, id uuid.UUID, status models.RestoreStatus, expiresAt *time.Time) error
+
+	// MarkAccessed 记录文件的最近访问时间（距上次记录不足一小时时不更新）
+	MarkAccessed(ctx context.Context, id uuid.UUID, at time.Time) error
 }
 
 // fileRepository 文件仓储实现
@@ -46,13 +84,13 @@ func NewFileRepository(db *gorm.DB) FileRepository {
 
 // Create 创建文件记录
 func (r *fileRepository) Create(ctx context.Context, file *models.File) error {
-	return r.db.WithContext(ctx).Create(file).Error
+	return r.conn(ctx).Create(file).Error
 }
 
 // GetByID 根据 ID 查询文件
 func (r *fileRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.File, error) {
 	var file models.File
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&file).Error
+	err := r.conn(ctx).Where("id = ?", id).First(&file).Error
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +100,7 @@ func (r *fileRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Fil
 // GetByStorageKey 根据存储键查询文件
 func (r *fileRepository) GetByStorageKey(ctx context.Context, storageKey string) (*models.File, error) {
 	var file models.File
-	err := r.db.WithContext(ctx).Where("storage_key = ?", storageKey).First(&file).Error
+	err := r.conn(ctx).Where("storage_key = ?", storageKey).First(&file).Error
 	if err != nil {
 		return nil, err
 	}
@@ -71,17 +109,17 @@ func (r *fileRepository) GetByStorageKey(ctx context.Context, storageKey string)
 
 // Update 更新文件记录
 func (r *fileRepository) Update(ctx context.Context, file *models.File) error {
-	return r.db.WithContext(ctx).Save(file).Error
+	return r.conn(ctx).Save(file).Error
 }
 
 // UpdateStatus 更新文件状态
 func (r *fileRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.FileStatus) error {
-	return r.db.WithContext(ctx).Model(&models.File{}).Where("id = ?", id).Update("status", status).Error
+	return r.conn(ctx).Model(&models.File{}).Where("id = ?", id).Update("status", status).Error
 }
 
 // Delete 删除文件记录（软删除）
 func (r *fileRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.File{}).Error
+	return r.conn(ctx).Where("id = ?", id).Delete(&models.File{}).Error
 }
 
 // List 分页查询文件列表
@@ -90,14 +128,158 @@ func (r *fileRepository) List(ctx context.Context, offset, limit int) ([]*models
 	var total int64
 
 	// 查询总数
-	if err := r.db.WithContext(ctx).Model(&models.File{}).Count(&total).Error; err != nil {
+	if err := r.conn(ctx).Model(&models.File{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// 分页查询
-	if err := r.db.WithContext(ctx).Offset(offset).Limit(limit).Find(&files).Error; err != nil {
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&files).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return files, total, nil
 }
+
+// GetByIDs 批量查询文件（包含已软删除的记录）
+func (r *fileRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.File, error) {
+	var files []*models.File
+	if len(ids) == 0 {
+		return files, nil
+	}
+	err := r.conn(ctx).Unscoped().Where("id IN ?", ids).Find(&files).Error
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// DeleteWithObject 软删除文件记录并记录存储对象的删除时间
+func (r *fileRepository) DeleteWithObject(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.conn(ctx).Exec(
+		"UPDATE files SET deleted_at = ?, object_purged_at = ? WHERE id = ? AND deleted_at IS NULL",
+		at, at, id).Error
+}
+
+// Restore 恢复已软删除的文件记录
+func (r *fileRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	result := r.conn(ctx).Unscoped().Model(&models.File{}).
+		Where("id = ? AND deleted_at IS NOT NULL AND object_purged_at IS NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeletePermanently 永久删除文件记录（物理删除，不可恢复）
+func (r *fileRepository) DeletePermanently(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.conn(ctx).Unscoped().Where("id IN ?", ids).Delete(&models.File{}).Error
+}
+
+// ListByFolder 查询目录（含子目录）下的所有文件
+func (r *fileRepository) ListByFolder(ctx context.Context, folder string) ([]*models.File, error) {
+	var files []*models.File
+
+	query := r.conn(ctx)
+	if folder != "/" {
+		// 转义 LIKE 通配符，匹配目录本身及其子目录
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(folder)
+		query = query.Where("folder = ? OR folder LIKE ?", folder, escaped+"/%")
+	}
+
+	if err := query.Order("folder, name").Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ListAfter 按 ID 顺序分批查询文件（包含已软删除的记录）
+func (r *fileRepository) ListAfter(ctx context.Context, afterID uuid.UUID, limit int) ([]*models.File, error) {
+	var files []*models.File
+	err := r.conn(ctx).Unscoped().
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&files).Error
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// UpdateStorageKey 更新文件存储键（存储键已被其他操作修改时不更新）
+func (r *fileRepository) UpdateStorageKey(ctx context.Context, id uuid.UUID, oldKey, newKey string) error {
+	result := r.conn(ctx).Unscoped().Model(&models.File{}).
+		Where("id = ? AND storage_key = ?", id, oldKey).
+		Update("storage_key", newKey)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateStorageClass 更新文件存储类型并清除取回状态（存储类型已被其他操作修改时不更新）
+// 存储分层字段只允许创建时写入（避免 Save 覆盖），这里直接执行 UPDATE
+func (r *fileRepository) UpdateStorageClass(ctx context.Context, id uuid.UUID, from, to string) error {
+	result := r.conn(ctx).Exec(
+		"UPDATE files SET storage_class = ?, restore_status = NULL, restore_expires_at = NULL, updated_at = ? WHERE id = ? AND storage_class = ?",
+		to, time.Now(), id, from)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateScanResult 保存扫描结果（扫描期间被删除或状态变化的文件不更新，避免覆盖并发修改）
+func (r *fileRepository) UpdateScanResult(ctx context.Context, file *models.File) error {
+	result := r.conn(ctx).Exec(
+		"UPDATE files SET scan_result = ?, scan_signature = ?, scan_engine = ?, scanned_at = ?, status = ?, updated_at = ? "+
+			"WHERE id = ? AND deleted_at IS NULL AND status IN (?, ?)",
+		string(file.ScanResult), file.ScanSignature, file.ScanEngine, file.ScannedAt, string(file.Status), time.Now(),
+		file.ID, string(models.FileStatusCompleted), string(models.FileStatusQuarantined))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateRestoreStatus 更新归档文件的取回状态（status 为空时清除）
+func (r *fileRepository) UpdateRestoreStatus(ctx context.Context, id uuid.UUID, status models.RestoreStatus, expiresAt *time.Time) error {
+	var value interface{}
+	if status != "" {
+		value = string(status)
+	}
+	result := r.conn(ctx).Exec("UPDATE files SET restore_status = ?, restore_expires_at = ? WHERE id = ?", value, expiresAt, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// accessGranularity 最近访问时间的记录粒度（避免每次下载都写数据库）
+const accessGranularity = time.Hour
+
+// MarkAccessed 记录文件的最近访问时间
+func (r *fileRepository) MarkAccessed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.conn(ctx).Exec(
+		"UPDATE files SET last_accessed_at = ? WHERE id = ? AND (last_accessed_at IS NULL OR last_accessed_at < ?)",
+		at, id, at.Add(-accessGranularity)).Error
+}
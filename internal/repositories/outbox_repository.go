@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxRepository 事务性发件箱仓储接口
+type OutboxRepository interface {
+	// Create 写入事件（需在业务数据所在的 Transactor 事务中调用）
+	Create(ctx context.Context, event *models.OutboxEvent) error
+
+	// ListUnpublished 按写入顺序查询未发布的事件（不包含已放弃发布的事件）
+	// 在事务中调用时锁定返回的行（FOR UPDATE SKIP LOCKED），多个中继实例不会重复处理
+	ListUnpublished(ctx context.Context, limit int) ([]*models.OutboxEvent, error)
+
+	// MarkPublished 标记事件已发布
+	MarkPublished(ctx context.Context, ids []uuid.UUID, publishedAt time.Time) error
+
+	// MarkFailed 记录发布失败
+	MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error
+
+	// MarkDead 放弃发布无法处理的事件（记录原因，ListUnpublished 不再返回）
+	MarkDead(ctx context.Context, id uuid.UUID, errMsg string, deadAt time.Time) error
+
+	// DeletePublishedBefore 清理指定时间之前已发布的事件
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// outboxRepository 发件箱仓储实现
+type outboxRepository struct {
+	*BaseRepository
+}
+
+// NewOutboxRepository 创建发件箱仓储实例
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 写入事件
+func (r *outboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	return r.conn(ctx).Create(event).Error
+}
+
+// ListUnpublished 按写入顺序查询未发布的事件
+func (r *outboxRepository) ListUnpublished(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+	err := r.conn(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("published_at IS NULL AND dead_at IS NULL").
+		Order("sequence").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// MarkPublished 标记事件已发布
+func (r *outboxRepository) MarkPublished(ctx context.Context, ids []uuid.UUID, publishedAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.conn(ctx).Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("published_at", publishedAt).Error
+}
+
+// MarkFailed 记录发布失败
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error {
+	return r.conn(ctx).Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": errMsg,
+	}).Error
+}
+
+// MarkDead 放弃发布无法处理的事件
+func (r *outboxRepository) MarkDead(ctx context.Context, id uuid.UUID, errMsg string, deadAt time.Time) error {
+	return r.conn(ctx).Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": errMsg,
+		"dead_at":    deadAt,
+	}).Error
+}
+
+// DeletePublishedBefore 清理指定时间之前已发布的事件
+func (r *outboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.conn(ctx).Where("published_at IS NOT NULL AND published_at < ?", before).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReplicationRepository 文件副本与批量复制任务仓储接口
+type ReplicationRepository interface {
+	// ListReplicas 查询文件的所有副本
+	ListReplicas(ctx context.Context, fileID uuid.UUID) ([]*models.FileReplica, error)
+
+	// SaveReplica 保存副本（同一文件在同一后端只有一条记录，已存在时覆盖）
+	SaveReplica(ctx context.Context, replica *models.FileReplica) error
+
+	// DeleteReplica 删除副本记录
+	DeleteReplica(ctx context.Context, id uuid.UUID) error
+
+	// HasReplica 判断存储键在后端上是否有已复制（或已校验）的副本（实现 storage.ReplicaStore）
+	HasReplica(ctx context.Context, key string, backend string) (bool, error)
+
+	// PromoteBackend 将文件的主后端从 from 改为 to（当前后端不是 from 时返回 gorm.ErrRecordNotFound）
+	PromoteBackend(ctx context.Context, fileID uuid.UUID, from, to string) error
+
+	// CreateRun 创建批量复制任务
+	CreateRun(ctx context.Context, run *models.ReplicationRun) error
+
+	// FindRunningRun 查询源后端、目标后端和 promote 相同的未完成任务（没有时返回 nil）
+	FindRunningRun(ctx context.Context, source, target string, promote bool) (*models.ReplicationRun, error)
+
+	// UpdateRun 更新批量复制任务（保存检查点）
+	UpdateRun(ctx context.Context, run *models.ReplicationRun) error
+}
+
+// replicationRepository 文件副本仓储实现
+type replicationRepository struct {
+	*BaseRepository
+}
+
+// NewReplicationRepository 创建文件副本仓储实例
+func NewReplicationRepository(db *gorm.DB) ReplicationRepository {
+	return &replicationRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// ListReplicas 查询文件的所有副本
+func (r *replicationRepository) ListReplicas(ctx context.Context, fileID uuid.UUID) ([]*models.FileReplica, error) {
+	var replicas []*models.FileReplica
+	if err := r.conn(ctx).Where("file_id = ?", fileID).Order("backend").Find(&replicas).Error; err != nil {
+		return nil, err
+	}
+	return replicas, nil
+}
+
+// SaveReplica 按 (file_id, backend) 插入或更新副本
+func (r *replicationRepository) SaveReplica(ctx context.Context, replica *models.FileReplica) error {
+	if replica.ID == uuid.Nil {
+		replica.ID = uuid.New()
+	}
+	return r.conn(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "file_id"}, {Name: "backend"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"storage_key", "status", "size", "sha256", "attempts", "last_error", "replicated_at", "verified_at", "updated_at",
+		}),
+	}).Create(replica).Error
+}
+
+// DeleteReplica 删除副本记录
+func (r *replicationRepository) DeleteReplica(ctx context.Context, id uuid.UUID) error {
+	return r.conn(ctx).Delete(&models.FileReplica{}, "id = ?", id).Error
+}
+
+// HasReplica 判断存储键在后端上是否有可用的副本
+func (r *replicationRepository) HasReplica(ctx context.Context, key string, backend string) (bool, error) {
+	var count int64
+	err := r.conn(ctx).Model(&models.FileReplica{}).
+		Where("storage_key = ? AND backend = ? AND status IN ?", key, backend,
+			[]models.ReplicaStatus{models.ReplicaStatusReplicated, models.ReplicaStatusVerified}).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// PromoteBackend 更新文件的主后端
+// 后端字段只允许创建时写入（避免 Save 覆盖），这里直接执行 UPDATE；空字符串表示默认后端
+func (r *replicationRepository) PromoteBackend(ctx context.Context, fileID uuid.UUID, from, to string) error {
+	result := r.conn(ctx).Exec("UPDATE files SET backend = ? WHERE id = ? AND COALESCE(backend, '') = ?", to, fileID, from)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateRun 创建批量复制任务
+func (r *replicationRepository) CreateRun(ctx context.Context, run *models.ReplicationRun) error {
+	if run.ID == uuid.Nil {
+		run.ID = uuid.New()
+	}
+	return r.conn(ctx).Create(run).Error
+}
+
+// FindRunningRun 查询最近一次未完成的任务
+func (r *replicationRepository) FindRunningRun(ctx context.Context, source, target string, promote bool) (*models.ReplicationRun, error) {
+	var run models.ReplicationRun
+	err := r.conn(ctx).
+		Where("source = ? AND target = ? AND promote = ? AND status = ?", source, target, promote, models.ReplicationRunRunning).
+		Order("created_at DESC").
+		First(&run).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// UpdateRun 更新批量复制任务
+func (r *replicationRepository) UpdateRun(ctx context.Context, run *models.ReplicationRun) error {
+	return r.conn(ctx).Save(run).Error
+}
@@ -1,6 +1,10 @@
 package repositories
 
-import "gorm.io/gorm"
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
 
 // BaseRepository provides common database operations
 type BaseRepository struct {
@@ -14,3 +18,11 @@ func NewBaseRepository(db *gorm.DB) *BaseRepository {
 func (r *BaseRepository) DB() *gorm.DB {
 	return r.db
 }
+
+// conn 返回当前上下文使用的数据库连接（处于 Transactor 事务中时返回事务连接）
+func (r *BaseRepository) conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return r.db.WithContext(ctx)
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareRepository 文件分享链接仓储接口
+type ShareRepository interface {
+	// Create 创建分享链接
+	Create(ctx context.Context, share *models.FileShare) error
+
+	// GetByTokenHash 根据令牌哈希查询分享链接（不存在时返回 nil）
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.FileShare, error)
+
+	// ListByFile 查询文件的所有分享链接（按创建时间倒序，包含已撤销的）
+	ListByFile(ctx context.Context, fileID uuid.UUID) ([]*models.FileShare, error)
+
+	// Revoke 撤销文件的分享链接（不存在或已撤销时返回 gorm.ErrRecordNotFound）
+	Revoke(ctx context.Context, fileID, shareID uuid.UUID, at time.Time) error
+
+	// ConsumeDownload 在链接未撤销、未过期且未达到下载次数上限时增加下载次数，返回是否成功
+	ConsumeDownload(ctx context.Context, shareID uuid.UUID, at time.Time) (bool, error)
+}
+
+// shareRepository 文件分享链接仓储实现
+type shareRepository struct {
+	*BaseRepository
+}
+
+// NewShareRepository 创建文件分享链接仓储实例
+func NewShareRepository(db *gorm.DB) ShareRepository {
+	return &shareRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 创建分享链接
+func (r *shareRepository) Create(ctx context.Context, share *models.FileShare) error {
+	if share.ID == uuid.Nil {
+		share.ID = uuid.New()
+	}
+	return r.conn(ctx).Create(share).Error
+}
+
+// GetByTokenHash 根据令牌哈希查询分享链接
+func (r *shareRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.FileShare, error) {
+	var share models.FileShare
+	err := r.conn(ctx).Where("token_hash = ?", tokenHash).First(&share).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &share, nil
+}
+
+// ListByFile 查询文件的所有分享链接
+func (r *shareRepository) ListByFile(ctx context.Context, fileID uuid.UUID) ([]*models.FileShare, error) {
+	var shares []*models.FileShare
+	if err := r.conn(ctx).Where("file_id = ?", fileID).Order("created_at DESC").Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// Revoke 撤销分享链接
+func (r *shareRepository) Revoke(ctx context.Context, fileID, shareID uuid.UUID, at time.Time) error {
+	result := r.conn(ctx).Exec(
+		"UPDATE file_shares SET revoked_at = ?, updated_at = ? WHERE id = ? AND file_id = ? AND revoked_at IS NULL",
+		at, at, shareID, fileID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ConsumeDownload 条件更新下载次数（并发访问时不会超过下载次数上限）
+func (r *shareRepository) ConsumeDownload(ctx context.Context, shareID uuid.UUID, at time.Time) (bool, error) {
+	result := r.conn(ctx).Exec(`UPDATE file_shares SET download_count = download_count + 1, last_accessed_at = ?, updated_at = ?
+WHERE id = ? AND revoked_at IS NULL AND (max_downloads = 0 OR download_count < max_downloads) AND (expires_at IS NULL OR expires_at > ?)`,
+		at, at, shareID, at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey 事务连接在 context 中的键
+type txKey struct{}
+
+// Transactor 事务管理接口
+// fn 内使用传入的 ctx 调用的仓储方法共享同一个数据库事务；fn 返回错误时回滚
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// gormTransactor 基于 GORM 的事务管理实现
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor 创建事务管理实例
+func NewTransactor(db *gorm.DB) Transactor {
+	return &gormTransactor{db: db}
+}
+
+// Transaction 在事务中执行 fn（已处于事务中时直接复用外层事务）
+func (t *gormTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository Webhook 订阅与投递记录仓储接口
+type WebhookRepository interface {
+	// Create 创建订阅
+	Create(ctx context.Context, webhook *models.Webhook) error
+
+	// GetByID 根据 ID 查询订阅
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Webhook, error)
+
+	// List 查询所有订阅
+	List(ctx context.Context) ([]*models.Webhook, error)
+
+	// ListActive 查询所有启用的订阅
+	ListActive(ctx context.Context) ([]*models.Webhook, error)
+
+	// Delete 删除订阅（软删除）
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// CreateDeliveries 批量创建投递记录（同一订阅的同一事件只创建一次）
+	CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+
+	// GetDelivery 查询订阅下的投递记录
+	GetDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*models.WebhookDelivery, error)
+
+	// UpdateDelivery 更新投递记录
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// ListDeliveries 分页查询订阅的投递记录（status 为空表示全部状态），按创建时间倒序
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, status models.WebhookDeliveryStatus, offset, limit int) ([]*models.WebhookDelivery, int64, error)
+
+	// ClaimDueDeliveries 领取到期的待投递记录
+	// 领取时将 next_attempt_at 推迟 lease，避免多实例重复投递；投递结束后由调用方更新记录
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+}
+
+// webhookRepository Webhook 仓储实现
+type webhookRepository struct {
+	*BaseRepository
+}
+
+// NewWebhookRepository 创建 Webhook 仓储实例
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 创建订阅
+func (r *webhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	return r.conn(ctx).Create(webhook).Error
+}
+
+// GetByID 根据 ID 查询订阅
+func (r *webhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.conn(ctx).Where("id = ?", id).First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// List 查询所有订阅
+func (r *webhookRepository) List(ctx context.Context) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	if err := r.conn(ctx).Order("created_at").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// ListActive 查询所有启用的订阅
+func (r *webhookRepository) ListActive(ctx context.Context) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	if err := r.conn(ctx).Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// Delete 删除订阅（软删除）
+func (r *webhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.conn(ctx).Where("id = ?", id).Delete(&models.Webhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateDeliveries 批量创建投递记录（webhook_id + event_id 冲突时忽略，保证重复发布幂等）
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.conn(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// GetDelivery 查询订阅下的投递记录
+func (r *webhookRepository) GetDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.conn(ctx).Where("id = ? AND webhook_id = ?", deliveryID, webhookID).First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// UpdateDelivery 更新投递记录
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.conn(ctx).Save(delivery).Error
+}
+
+// ListDeliveries 分页查询订阅的投递记录
+func (r *webhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status models.WebhookDeliveryStatus, offset, limit int) ([]*models.WebhookDelivery, int64, error) {
+	var deliveries []*models.WebhookDelivery
+	var total int64
+
+	query := r.conn(ctx).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// 查询总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// ClaimDueDeliveries 领取到期的待投递记录（SELECT ... FOR UPDATE SKIP LOCKED）
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
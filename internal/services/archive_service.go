@@ -0,0 +1,468 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/cache"
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/queue"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxArchiveFiles 单个归档允许包含的最大文件数
+	MaxArchiveFiles = 10000
+
+	// archiveTaskTTL 异步归档任务（及临时归档文件）的保留时间
+	archiveTaskTTL = 24 * time.Hour
+
+	// archiveLinkExpiry 异步归档下载链接的有效期
+	archiveLinkExpiry = 1 * time.Hour
+
+	// archiveKeyPrefix 临时归档文件的存储键前缀（到期后由清理任务删除）
+	archiveKeyPrefix = "archives/"
+)
+
+// ArchiveJobType 异步归档打包任务类型
+const ArchiveJobType = "archive.build"
+
+// ArchiveCleanupJobType 临时归档文件清理任务类型（延迟到归档任务过期时执行）
+const ArchiveCleanupJobType = "archive.cleanup"
+
+// archiveJobPayload 异步归档任务载荷（执行时重新解析文件，跳过期间被删除或不再允许下载的文件）
+type archiveJobPayload struct {
+	TaskID  uuid.UUID   `json:"task_id"`
+	FileIDs []uuid.UUID `json:"file_ids,omitempty"`
+	Folder  string      `json:"folder,omitempty"`
+}
+
+// archiveCleanupPayload 临时归档文件清理任务载荷
+type archiveCleanupPayload struct {
+	TaskID     uuid.UUID `json:"task_id"`
+	StorageKey string    `json:"storage_key"`
+}
+
+// ArchiveTaskStatus 异步归档任务状态
+type ArchiveTaskStatus string
+
+const (
+	ArchiveTaskPending   ArchiveTaskStatus = "pending"   // 等待执行（包括出错后等待重试）
+	ArchiveTaskRunning   ArchiveTaskStatus = "running"   // 打包中
+	ArchiveTaskCompleted ArchiveTaskStatus = "completed" // 已完成，可下载
+	ArchiveTaskFailed    ArchiveTaskStatus = "failed"    // 失败
+)
+
+// ArchiveRequest 归档请求（FileIDs 与 Folder 二选一）
+type ArchiveRequest struct {
+	FileIDs []uuid.UUID // 指定文件 ID 列表
+	Folder  string      // 指定目录（包含子目录，ZIP 内保留相对目录结构）
+}
+
+// ArchiveTask 异步归档任务
+type ArchiveTask struct {
+	ID          uuid.UUID         `json:"id"`
+	Status      ArchiveTaskStatus `json:"status"`
+	FileCount   int               `json:"file_count"`
+	Size        int64             `json:"size"`
+	StorageKey  string            `json:"storage_key,omitempty"`
+	DownloadURL string            `json:"download_url,omitempty"` // 仅在查询已完成任务时生成
+	Error       string            `json:"error,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+}
+
+// ArchiveService 多文件归档下载服务接口
+type ArchiveService interface {
+	// ResolveFiles 解析归档包含的文件（仅包含允许下载的文件）
+	ResolveFiles(ctx context.Context, req ArchiveRequest) ([]*models.File, error)
+
+	// WriteArchive 将文件按顺序从存储流式写入 ZIP（不落盘，超过 4GB 或 65535 个条目时自动使用 ZIP64）
+	WriteArchive(ctx context.Context, req ArchiveRequest, files []*models.File, w io.Writer) error
+
+	// CreateArchiveTask 创建异步归档任务（由任务队列打包后保存为临时文件并提供预签名下载链接）
+	CreateArchiveTask(ctx context.Context, req ArchiveRequest) (*ArchiveTask, error)
+
+	// GetArchiveTask 查询异步归档任务
+	GetArchiveTask(ctx context.Context, id uuid.UUID) (*ArchiveTask, error)
+}
+
+// archiveService 归档服务实现
+type archiveService struct {
+	fileRepo repositories.FileRepository
+	storage  storage.Storage
+	redis    jsonStore
+	jobs     jobQueue
+	policy   DownloadPolicy
+	cfg      config.ArchiveConfig
+}
+
+// NewArchiveService 创建归档服务实例，并注册异步归档和清理任务处理器
+func NewArchiveService(fileRepo repositories.FileRepository, storage storage.Storage, redis *cache.RedisClient, jobs jobQueue, policy DownloadPolicy, cfg config.ArchiveConfig) ArchiveService {
+	s := &archiveService{
+		fileRepo: fileRepo,
+		storage:  storage,
+		redis:    redis,
+		jobs:     jobs,
+		policy:   policy,
+		cfg:      cfg,
+	}
+	jobs.Register(ArchiveJobType, queue.Typed(s.handleArchiveJob))
+	jobs.Register(ArchiveCleanupJobType, queue.Typed(s.handleArchiveCleanupJob))
+	return s
+}
+
+// ResolveFiles 解析归档包含的文件
+func (s *archiveService) ResolveFiles(ctx context.Context, req ArchiveRequest) ([]*models.File, error) {
+	var files []*models.File
+	var err error
+
+	switch {
+	case len(req.FileIDs) > 0 && req.Folder != "":
+		return nil, fmt.Errorf("file_ids and folder are mutually exclusive")
+	case len(req.FileIDs) > 0:
+		files, err = s.fileRepo.GetByIDs(ctx, req.FileIDs)
+	case req.Folder != "":
+		files, err = s.fileRepo.ListByFolder(ctx, normalizeFolder(req.Folder))
+	default:
+		return nil, fmt.Errorf("file_ids or folder is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load files: %w", err)
+	}
+
+	// 过滤已删除和不允许下载（未上传完成、已隔离、未通过扫描或未取回的归档文件）的文件
+	result := make([]*models.File, 0, len(files))
+	for _, file := range files {
+		if file.DeletedAt.Valid || checkDownloadable(file, s.policy) != nil {
+			continue
+		}
+		result = append(result, file)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no files found for archive")
+	}
+	if len(result) > MaxArchiveFiles {
+		return nil, fmt.Errorf("too many files for archive: %d (max %d)", len(result), MaxArchiveFiles)
+	}
+
+	return result, nil
+}
+
+// WriteArchive 将文件流式写入 ZIP
+func (s *archiveService) WriteArchive(ctx context.Context, req ArchiveRequest, files []*models.File, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	names := archiveEntryNames(files, archiveRoot(req))
+
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		header := &zip.FileHeader{
+			Name:     names[i],
+			Method:   archiveMethod(file.ContentType),
+			Modified: file.UpdatedAt,
+		}
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("failed to create archive entry %s: %w", names[i], err)
+		}
+
+		reader, _, _, err := s.storage.GetObject(ctx, file.StorageKey)
+		if err != nil {
+			return fmt.Errorf("failed to get file %s: %w", file.ID, err)
+		}
+		_, err = io.Copy(entry, reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write archive entry %s: %w", names[i], err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// CreateArchiveTask 创建异步归档任务
+func (s *archiveService) CreateArchiveTask(ctx context.Context, req ArchiveRequest) (*ArchiveTask, error) {
+	files, err := s.ResolveFiles(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	task := &ArchiveTask{
+		ID:        uuid.New(),
+		Status:    ArchiveTaskPending,
+		FileCount: len(files),
+		CreatedAt: now,
+		ExpiresAt: now.Add(archiveTaskTTL),
+	}
+	task.StorageKey = archiveKeyPrefix + task.ID.String() + ".zip"
+
+	if err := s.saveTask(ctx, task); err != nil {
+		return nil, err
+	}
+
+	// 由任务队列打包（受队列并发数限制，服务关闭时放回队列），并在任务过期时删除临时归档文件
+	payload := archiveJobPayload{TaskID: task.ID, FileIDs: req.FileIDs, Folder: req.Folder}
+	cleanup := archiveCleanupPayload{TaskID: task.ID, StorageKey: task.StorageKey}
+	_, err = s.jobs.Enqueue(ctx, ArchiveJobType, payload, &queue.EnqueueOptions{Queue: s.cfg.Queue})
+	if err == nil {
+		_, err = s.jobs.Enqueue(ctx, ArchiveCleanupJobType, cleanup, &queue.EnqueueOptions{Queue: s.cfg.Queue, Delay: archiveTaskTTL})
+	}
+	if err != nil {
+		task.Status = ArchiveTaskFailed
+		task.Error = err.Error()
+		_ = s.saveTask(ctx, task)
+		return nil, fmt.Errorf("failed to enqueue archive task: %w", err)
+	}
+
+	return task, nil
+}
+
+// GetArchiveTask 查询异步归档任务
+func (s *archiveService) GetArchiveTask(ctx context.Context, id uuid.UUID) (*ArchiveTask, error) {
+	task, err := s.loadTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已完成的任务每次查询时生成新的下载链接
+	if task.Status == ArchiveTaskCompleted {
+		opts := &storage.PresignOptions{
+			ContentType:        "application/zip",
+			ContentDisposition: "attachment",
+			Filename:           fmt.Sprintf("archive-%s.zip", task.ID),
+		}
+		presigned, err := s.storage.GeneratePresignedDownloadURL(ctx, task.StorageKey, archiveLinkExpiry, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate download URL: %w", err)
+		}
+		task.DownloadURL = presigned.URL
+	}
+
+	return task, nil
+}
+
+// loadTask 从 Redis 读取任务状态
+func (s *archiveService) loadTask(ctx context.Context, id uuid.UUID) (*ArchiveTask, error) {
+	var task ArchiveTask
+	err := s.redis.GetJSON(ctx, archiveTaskKey(id), &task)
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, fmt.Errorf("archive task not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive task: %w", err)
+	}
+	return &task, nil
+}
+
+// handleArchiveJob 执行异步归档：先写入本地临时文件（获得确定的大小），再上传到存储
+// 重新执行时覆盖同一存储键，必须在任务过期前完成
+func (s *archiveService) handleArchiveJob(ctx context.Context, job *queue.Job, payload archiveJobPayload) error {
+	task, err := s.loadTask(ctx, payload.TaskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return queue.Permanent(err)
+		}
+		return err
+	}
+	if task.Status == ArchiveTaskCompleted || task.Status == ArchiveTaskFailed {
+		return nil
+	}
+
+	// 状态写入不受任务取消影响
+	storeCtx := context.WithoutCancel(ctx)
+	fail := func(err error) error {
+		task.Status = ArchiveTaskFailed
+		task.Error = err.Error()
+		_ = s.saveTask(storeCtx, task)
+		return queue.Permanent(err)
+	}
+
+	req := ArchiveRequest{FileIDs: payload.FileIDs, Folder: payload.Folder}
+	files, err := s.ResolveFiles(ctx, req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to ") && job.Attempts < job.MaxAttempts {
+			return err
+		}
+		return fail(err)
+	}
+
+	task.Status = ArchiveTaskRunning
+	task.FileCount = len(files)
+	task.Error = ""
+	_ = s.saveTask(ctx, task)
+
+	runCtx, cancel := context.WithDeadline(ctx, task.ExpiresAt)
+	defer cancel()
+	size, err := s.buildArchive(runCtx, task.StorageKey, req, files)
+	switch {
+	case err == nil:
+		task.Status = ArchiveTaskCompleted
+		task.Size = size
+	case ctx.Err() != nil:
+		// 服务关闭：任务放回队列
+		return err
+	case runCtx.Err() == nil && job.Attempts < job.MaxAttempts:
+		task.Status = ArchiveTaskPending
+		task.Error = err.Error()
+		_ = s.saveTask(storeCtx, task)
+		return err
+	default:
+		return fail(err)
+	}
+	if err := s.saveTask(storeCtx, task); err != nil {
+		return queue.Permanent(err)
+	}
+	return nil
+}
+
+// handleArchiveCleanupJob 删除过期的临时归档文件（对象不存在时也视为成功）
+func (s *archiveService) handleArchiveCleanupJob(ctx context.Context, job *queue.Job, payload archiveCleanupPayload) error {
+	if !strings.HasPrefix(payload.StorageKey, archiveKeyPrefix) {
+		return queue.Permanent(fmt.Errorf("invalid archive key: %s", payload.StorageKey))
+	}
+	if err := s.storage.Delete(ctx, payload.StorageKey); err != nil {
+		return fmt.Errorf("failed to delete archive %s: %w", payload.TaskID, err)
+	}
+	return nil
+}
+
+// buildArchive 打包到临时文件并上传，返回归档大小
+func (s *archiveService) buildArchive(ctx context.Context, key string, req ArchiveRequest, files []*models.File) (int64, error) {
+	tmp, err := os.CreateTemp("", "assethub-archive-*.zip")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := s.WriteArchive(ctx, req, files, tmp); err != nil {
+		return 0, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get archive size: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to rewind archive: %w", err)
+	}
+
+	if err := s.storage.Upload(ctx, key, tmp, size, "application/zip"); err != nil {
+		return 0, fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	return size, nil
+}
+
+// saveTask 保存任务状态到 Redis（到期自动清除）
+func (s *archiveService) saveTask(ctx context.Context, task *ArchiveTask) error {
+	ttl := time.Until(task.ExpiresAt)
+	if err := s.redis.SetJSON(ctx, archiveTaskKey(task.ID), task, ttl); err != nil {
+		return fmt.Errorf("failed to save archive task: %w", err)
+	}
+	return nil
+}
+
+// archiveRoot 归档根目录（按目录归档时保留子目录结构，按文件 ID 归档时不保留）
+func archiveRoot(req ArchiveRequest) string {
+	if req.Folder == "" {
+		return ""
+	}
+	return normalizeFolder(req.Folder)
+}
+
+// archiveTaskKey 归档任务的 Redis 键
+func archiveTaskKey(id uuid.UUID) string {
+	return "archive:task:" + id.String()
+}
+
+// archiveEntryNames 生成 ZIP 条目名称
+// 按目录归档时保留相对于该目录的子目录结构；同名条目（忽略大小写）追加 " (n)" 后缀去重
+func archiveEntryNames(files []*models.File, root string) []string {
+	names := make([]string, len(files))
+	used := make(map[string]bool, len(files))
+
+	for i, file := range files {
+		name := archiveSafeName(file.Name)
+
+		// 按目录归档时加上相对目录
+		if rel := relativeFolder(file.Folder, root); rel != "" {
+			name = path.Join(rel, name)
+		}
+
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		candidate := name
+		for n := 1; used[strings.ToLower(candidate)]; n++ {
+			candidate = fmt.Sprintf("%s (%d)%s", base, n, ext)
+		}
+		used[strings.ToLower(candidate)] = true
+		names[i] = candidate
+	}
+
+	return names
+}
+
+// relativeFolder 计算文件目录相对于归档根目录的路径（root 为空表示不保留目录结构）
+func relativeFolder(folder, root string) string {
+	switch {
+	case root == "" || folder == root:
+		return ""
+	case root == "/":
+		return strings.TrimPrefix(folder, "/")
+	case strings.HasPrefix(folder, root+"/"):
+		return folder[len(root)+1:]
+	default:
+		return ""
+	}
+}
+
+// archiveSafeName 清理条目文件名（去除路径分隔符和控制字符，防止解压时路径穿越）
+func archiveSafeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\':
+			return '_'
+		case r < 0x20 || r == 0x7f:
+			return -1
+		}
+		return r
+	}, name)
+
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." {
+		name = "file"
+	}
+	return name
+}
+
+// archiveMethod 根据内容类型选择压缩方式（图片、视频等已压缩格式直接存储）
+func archiveMethod(contentType string) uint16 {
+	baseType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	switch {
+	case strings.HasPrefix(baseType, "text/"),
+		baseType == "application/json",
+		baseType == "application/xml",
+		baseType == "application/javascript",
+		baseType == "image/svg+xml":
+		return zip.Deflate
+	default:
+		return zip.Store
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// TestArchiveEntryNames 测试 ZIP 条目名称去重与目录结构
+func TestArchiveEntryNames(t *testing.T) {
+	files := []*models.File{
+		{Name: "a.txt", Folder: "/assets"},
+		{Name: "A.txt", Folder: "/assets"},
+		{Name: "a.txt", Folder: "/assets"},
+		{Name: "../evil.png", Folder: "/assets/img"},
+		{Name: "logo.png", Folder: "/assets/img"},
+	}
+
+	tests := []struct {
+		name string
+		root string
+		want []string
+	}{
+		{
+			name: "flat",
+			root: "",
+			want: []string{"a.txt", "A (1).txt", "a (2).txt", ".._evil.png", "logo.png"},
+		},
+		{
+			name: "folder",
+			root: "/assets",
+			want: []string{"a.txt", "A (1).txt", "a (2).txt", "img/.._evil.png", "img/logo.png"},
+		},
+		{
+			name: "root folder",
+			root: "/",
+			want: []string{"assets/a.txt", "assets/A (1).txt", "assets/a (2).txt", "assets/img/.._evil.png", "assets/img/logo.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := archiveEntryNames(files, tt.root)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("entry %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestWriteArchive 测试从存储流式写入 ZIP
+func TestWriteArchive(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	svc := NewArchiveService(repo, store, nil, &fakeJobQueue{}, DownloadPolicy{}, config.ArchiveConfig{})
+
+	a := newBatchTestFile(t, repo, store, "a.txt")
+	b := newBatchTestFile(t, repo, store, "b.txt")
+	b.Name = "a.txt"
+
+	req := ArchiveRequest{FileIDs: []uuid.UUID{a.ID, b.ID}}
+	files, err := svc.ResolveFiles(ctx, req)
+	if err != nil {
+		t.Fatalf("ResolveFiles failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := svc.WriteArchive(ctx, req, files, &buf); err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	if len(zr.File) != 2 || zr.File[0].Name != "a.txt" || zr.File[1].Name != "a (1).txt" {
+		t.Fatalf("unexpected entries: %v", zr.File)
+	}
+
+	rc, err := zr.File[1].Open()
+	if err != nil {
+		t.Fatalf("open entry failed: %v", err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "hello" {
+		t.Errorf("entry content = %q, want %q", data, "hello")
+	}
+}
+
+// TestArchiveTaskJob 测试异步归档任务由任务队列执行
+func TestArchiveTaskJob(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	jobs := &fakeJobQueue{}
+	svc := NewArchiveService(repo, store, nil, jobs, DownloadPolicy{}, config.ArchiveConfig{Queue: "archives"}).(*archiveService)
+	svc.redis = NewMemoryJSONStore()
+
+	a := newBatchTestFile(t, repo, store, "a.txt")
+	task, err := svc.CreateArchiveTask(ctx, ArchiveRequest{FileIDs: []uuid.UUID{a.ID}})
+	if err != nil {
+		t.Fatalf("CreateArchiveTask failed: %v", err)
+	}
+	if task.Status != ArchiveTaskPending || len(jobs.jobs) != 2 || jobs.jobs[0].Queue != "archives" {
+		t.Fatalf("task = %+v, jobs = %+v", task, jobs.jobs)
+	}
+
+	// 清理任务延迟到任务过期时执行
+	cleanup := jobs.jobs[1]
+	if cleanup.Type != ArchiveCleanupJobType || cleanup.RunAt.Before(task.ExpiresAt.Add(-time.Minute)) {
+		t.Fatalf("cleanup job = %+v, want delayed until %s", cleanup, task.ExpiresAt)
+	}
+	jobs.jobs = jobs.jobs[:1]
+
+	for _, err := range jobs.run(ctx) {
+		if err != nil {
+			t.Fatalf("archive job failed: %v", err)
+		}
+	}
+	got, err := svc.GetArchiveTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetArchiveTask failed: %v", err)
+	}
+	if got.Status != ArchiveTaskCompleted || got.Size == 0 || got.DownloadURL == "" {
+		t.Errorf("unexpected task: %+v", got)
+	}
+	if int64(len(store.objects[task.StorageKey])) != got.Size {
+		t.Errorf("archive object size = %d, want %d", len(store.objects[task.StorageKey]), got.Size)
+	}
+
+	// 过期后删除临时归档文件
+	jobs.jobs = append(jobs.jobs, cleanup)
+	for _, err := range jobs.run(ctx) {
+		if err != nil {
+			t.Fatalf("cleanup job failed: %v", err)
+		}
+	}
+	if _, ok := store.objects[task.StorageKey]; ok {
+		t.Error("archive object should be deleted after expiry")
+	}
+}
+
+// mockDataKeys 保存在内存文件记录上的数据密钥（与 DataKeyRepository 一样，没有文件记录时返回 ErrObjectNotTracked）
+type mockDataKeys struct {
+	repo *MockFileRepository
+}
+
+func (m mockDataKeys) GetDataKey(ctx context.Context, key string) (*storage.WrappedKey, error) {
+	file, _ := m.repo.GetByStorageKey(ctx, key)
+	if file == nil || file.EncryptionKey == "" {
+		return nil, nil
+	}
+	return &storage.WrappedKey{KeyID: file.EncryptionKeyID, Ciphertext: file.EncryptionKey}, nil
+}
+
+func (m mockDataKeys) PutDataKey(ctx context.Context, key string, wrapped *storage.WrappedKey) error {
+	file, _ := m.repo.GetByStorageKey(ctx, key)
+	if file == nil {
+		return storage.ErrObjectNotTracked
+	}
+	file.EncryptionKeyID, file.EncryptionKey = wrapped.KeyID, wrapped.Ciphertext
+	return nil
+}
+
+// TestArchiveTaskEncrypted 测试客户端加密所有对象（prefixes 为空）时异步打包：
+// 打包文件没有文件记录，按明文保存并可以预签名下载
+func TestArchiveTaskEncrypted(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	keyring, err := storage.NewKeyring("k1", map[string][]byte{"k1": []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("NewKeyring failed: %v", err)
+	}
+	encrypted := storage.NewEncryptedStorage(store, keyring, mockDataKeys{repo}, nil)
+
+	file := &models.File{Name: "a.txt", Size: 5, ContentType: "text/plain", StorageKey: "files/a.txt", Status: models.FileStatusCompleted, Folder: "/"}
+	if err := repo.Create(ctx, file); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := encrypted.Upload(ctx, file.StorageKey, strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if file.EncryptionKey == "" || string(store.objects[file.StorageKey]) == "hello" {
+		t.Fatal("file should be stored encrypted")
+	}
+
+	jobs := &fakeJobQueue{}
+	svc := NewArchiveService(repo, encrypted, nil, jobs, DownloadPolicy{}, config.ArchiveConfig{}).(*archiveService)
+	svc.redis = NewMemoryJSONStore()
+	task, err := svc.CreateArchiveTask(ctx, ArchiveRequest{FileIDs: []uuid.UUID{file.ID}})
+	if err != nil {
+		t.Fatalf("CreateArchiveTask failed: %v", err)
+	}
+	jobs.jobs = jobs.jobs[:1]
+	for _, err := range jobs.run(ctx) {
+		if err != nil {
+			t.Fatalf("archive job failed: %v", err)
+		}
+	}
+
+	got, err := svc.GetArchiveTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetArchiveTask failed: %v", err)
+	}
+	if got.Status != ArchiveTaskCompleted || got.DownloadURL == "" {
+		t.Fatalf("unexpected task: %+v", got)
+	}
+	data := store.objects[task.StorageKey]
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil || len(zr.File) != 1 {
+		t.Fatalf("archive object is not a plaintext ZIP: %v", err)
+	}
+	entry, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatalf("Open entry failed: %v", err)
+	}
+	content, _ := io.ReadAll(entry)
+	if string(content) != "hello" {
+		t.Errorf("entry content = %q, want decrypted hello", content)
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/pkg/storage"
+)
+
+// TestUploadDirectChecksum 测试直接上传时校验接收到的内容
+func TestUploadDirectChecksum(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	svc := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	content := "hello checksum"
+	sum := sha256.Sum256([]byte(content))
+	checksum := &storage.Checksum{Algorithm: storage.ChecksumSHA256, Value: base64.StdEncoding.EncodeToString(sum[:])}
+
+	file, err := svc.UploadDirect(ctx, "a.txt", "text/plain", int64(len(content)), strings.NewReader(content), checksum, nil)
+	if err != nil {
+		t.Fatalf("UploadDirect() error = %v", err)
+	}
+	if file.ChecksumAlgorithm != "sha256" || file.Checksum != checksum.Value {
+		t.Errorf("stored checksum = %s %s, want sha256 %s", file.ChecksumAlgorithm, file.Checksum, checksum.Value)
+	}
+
+	// 内容被篡改时拒绝并删除已上传的对象
+	_, err = svc.UploadDirect(ctx, "b.txt", "text/plain", int64(len(content)), strings.NewReader("hello tampered!"), checksum, nil)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("UploadDirect(tampered) error = %v, want checksum mismatch", err)
+	}
+	if len(store.objects) != 1 {
+		t.Errorf("storage has %d objects, want only the verified upload", len(store.objects))
+	}
+}
+
+// TestMultipartChecksum 测试分片校验值的一致性检查和组合校验值
+func TestMultipartChecksum(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	svc := NewFileService(repo, NewMockOutboxRepository(), NewMockStorage(), MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	partChecksum := func(data string) (*storage.Checksum, string) {
+		sum := md5.Sum([]byte(data))
+		return &storage.Checksum{Algorithm: storage.ChecksumMD5, Value: base64.StdEncoding.EncodeToString(sum[:])}, `"` + hex.EncodeToString(sum[:]) + `"`
+	}
+	first, firstETag := partChecksum("part one")
+	second, secondETag := partChecksum("part two")
+
+	result, err := svc.InitMultipartUpload(ctx, "video.mp4", "video/mp4", 1<<30, storage.ChecksumMD5, nil)
+	if err != nil {
+		t.Fatalf("InitMultipartUpload() error = %v", err)
+	}
+
+	// 声明了校验算法后分片 URL 必须携带校验值
+	if _, err := svc.GeneratePartUploadURL(ctx, result.FileID, 1, 0, nil); err == nil || !strings.Contains(err.Error(), "checksum required") {
+		t.Errorf("GeneratePartUploadURL(nil) error = %v, want checksum required", err)
+	}
+	sha := &storage.Checksum{Algorithm: storage.ChecksumSHA256, Value: base64.StdEncoding.EncodeToString(make([]byte, 32))}
+	if _, err := svc.GeneratePartUploadURL(ctx, result.FileID, 1, 0, sha); err == nil || !strings.Contains(err.Error(), "invalid checksum") {
+		t.Errorf("GeneratePartUploadURL(sha256) error = %v, want invalid checksum", err)
+	}
+	if _, err := svc.GeneratePartUploadURL(ctx, result.FileID, 1, 0, first); err != nil {
+		t.Errorf("GeneratePartUploadURL() error = %v", err)
+	}
+
+	// ETag 与声明的 MD5 不一致
+	_, err = svc.CompleteMultipartUpload(ctx, result.FileID, []storage.CompletedPart{
+		{PartNumber: 1, ETag: firstETag, Checksum: first},
+		{PartNumber: 2, ETag: firstETag, Checksum: second},
+	})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("CompleteMultipartUpload(bad etag) error = %v, want checksum mismatch", err)
+	}
+
+	parts := []storage.CompletedPart{
+		{PartNumber: 1, ETag: firstETag, Checksum: first},
+		{PartNumber: 2, ETag: secondETag, Checksum: second},
+	}
+	file, err := svc.CompleteMultipartUpload(ctx, result.FileID, parts)
+	if err != nil {
+		t.Fatalf("CompleteMultipartUpload() error = %v", err)
+	}
+	want, _ := storage.CompositeChecksum(storage.ChecksumMD5, []*storage.Checksum{first, second})
+	if file.ChecksumAlgorithm != "md5" || file.Checksum != want || !strings.HasSuffix(file.Checksum, "-2") {
+		t.Errorf("stored checksum = %s %s, want md5 %s", file.ChecksumAlgorithm, file.Checksum, want)
+	}
+}
+
+// TestMultipartChecksumEncrypted 测试 SSE-KMS / SSE-C 对象的分片 ETag 不是 MD5，完成分片上传时不比对 ETag
+func TestMultipartChecksumEncrypted(t *testing.T) {
+	ctx := context.Background()
+	sum := md5.Sum([]byte("part one"))
+	part := &storage.Checksum{Algorithm: storage.ChecksumMD5, Value: base64.StdEncoding.EncodeToString(sum[:])}
+	opaqueETag := `"` + strings.Repeat("f", 32) + `"`
+
+	for _, tc := range []struct {
+		mode    string
+		checked bool
+	}{
+		{"sse-s3", true},
+		{"sse-kms", false},
+		{"sse-c", false},
+	} {
+		policy, err := storage.NewEncryptionPolicy(config.EncryptionConfig{EncryptionRule: config.EncryptionRule{Mode: tc.mode}})
+		if err != nil {
+			t.Fatalf("NewEncryptionPolicy(%s) error = %v", tc.mode, err)
+		}
+		svc := NewFileService(NewMockFileRepository(), NewMockOutboxRepository(), NewMockStorage(), MockTransactor{}, DownloadPolicy{}, FileServiceOptions{Encryption: policy})
+
+		result, err := svc.InitMultipartUpload(ctx, "video.mp4", "video/mp4", 1<<30, storage.ChecksumMD5, nil)
+		if err != nil {
+			t.Fatalf("InitMultipartUpload(%s) error = %v", tc.mode, err)
+		}
+		_, err = svc.CompleteMultipartUpload(ctx, result.FileID, []storage.CompletedPart{{PartNumber: 1, ETag: opaqueETag, Checksum: part}})
+		if tc.checked && (err == nil || !strings.Contains(err.Error(), "checksum mismatch")) {
+			t.Errorf("%s: CompleteMultipartUpload() error = %v, want checksum mismatch", tc.mode, err)
+		}
+		if !tc.checked && err != nil {
+			t.Errorf("%s: CompleteMultipartUpload() error = %v", tc.mode, err)
+		}
+	}
+}
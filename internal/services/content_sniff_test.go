@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestContentSniff(t *testing.T) {
+	ctx := context.Background()
+	exe := append([]byte("MZ\x90\x00"), make([]byte, 60)...)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	tests := []struct {
+		name         string
+		mode         string
+		contentType  string
+		content      []byte
+		wantErr      string
+		wantStatus   models.FileStatus
+		wantType     string
+		wantMismatch bool
+	}{
+		{"matching png", config.ContentSniffReject, "image/png", png, "", models.FileStatusCompleted, "image/png", false},
+		{"generic type adopts detected", config.ContentSniffFlag, "application/octet-stream", png, "", models.FileStatusCompleted, "image/png", false},
+		{"flagged executable", config.ContentSniffFlag, "image/png", exe, "", models.FileStatusCompleted, "image/png", true},
+		{"rejected executable", config.ContentSniffReject, "image/png", exe, "content type mismatch", models.FileStatusFailed, "image/png", true},
+		{"disabled", config.ContentSniffOff, "image/png", exe, "", models.FileStatusCompleted, "image/png", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockFileRepository()
+			store := NewMockStorage()
+			outbox := NewMockOutboxRepository()
+			svc := NewFileService(repo, outbox, store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{Sniff: config.ContentSniffConfig{Mode: tt.mode, ReadBytes: 3072}})
+
+			file := &models.File{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "a.png", ContentType: tt.contentType, StorageKey: "files/a.png", Status: models.FileStatusPending}
+			repo.Create(ctx, file)
+			store.objects[file.StorageKey] = tt.content
+
+			_, err := svc.ConfirmUpload(ctx, file.ID)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ConfirmUpload() error = %v, want %q", err, tt.wantErr)
+			}
+			if file.Status != tt.wantStatus || file.ContentType != tt.wantType || file.ContentTypeMismatch != tt.wantMismatch {
+				t.Errorf("file = status %s type %s mismatch %v", file.Status, file.ContentType, file.ContentTypeMismatch)
+			}
+
+			if tt.wantStatus == models.FileStatusFailed {
+				if _, ok := store.objects[file.StorageKey]; ok {
+					t.Error("rejected object should be deleted")
+				}
+				if types := outbox.eventTypes(); len(types) != 1 || types[0] != string(EventFileRejected) {
+					t.Errorf("outbox events = %v, want [file.rejected]", types)
+				}
+			}
+			if tt.wantMismatch && DispositionType(file) != "attachment" {
+				t.Errorf("DispositionType() = %s, want attachment", DispositionType(file))
+			}
+		})
+	}
+}
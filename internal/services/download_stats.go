@@ -0,0 +1,359 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/cache"
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/google/uuid"
+)
+
+// AccessKind 文件访问类型
+type AccessKind string
+
+const (
+	AccessDownload AccessKind = "download" // 经服务端下载
+	AccessLink     AccessKind = "link"     // 生成下载 URL
+)
+
+// DownloadStatsRecorder 记录文件访问（FileService 在下载和生成下载 URL 时调用）
+type DownloadStatsRecorder interface {
+	// RecordAccess 累加文件访问计数，bytesServed 为下载传输的字节数
+	RecordAccess(ctx context.Context, fileID uuid.UUID, kind AccessKind, bytesServed int64, at time.Time) error
+}
+
+// DailyDownloadStats 单日下载统计
+type DailyDownloadStats struct {
+	Day          string `json:"day" example:"2026-01-31"` // UTC 日期
+	Downloads    int64  `json:"downloads" example:"12"`
+	LinkRequests int64  `json:"link_requests" example:"30"`
+	BytesServed  int64  `json:"bytes_served" example:"1048576"`
+}
+
+// FileStats 文件的下载统计（汇总到数据库的部分，最多落后 stats.flush_interval）
+type FileStats struct {
+	FileID         uuid.UUID            `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Downloads      int64                `json:"downloads" example:"120"`         // 经服务端下载的次数
+	LinkRequests   int64                `json:"link_requests" example:"300"`     // 生成下载 URL 的次数
+	BytesServed    int64                `json:"bytes_served" example:"10485760"` // 经服务端下载传输的字节数（不含预签名 URL 直连下载）
+	LastAccessedAt *time.Time           `json:"last_accessed_at,omitempty"`      // 最近一次下载或生成下载 URL 的时间
+	Daily          []DailyDownloadStats `json:"daily"`                           // 每日统计（按日期升序，无访问的日期计数为 0）
+}
+
+// DownloadStatsService 文件下载统计服务接口
+// 访问计数先累加到 Redis，由 Run 定期汇总到数据库，避免每次下载都写数据库
+type DownloadStatsService interface {
+	DownloadStatsRecorder
+
+	// Run 按 stats.flush_interval 定期汇总计数器，直到 ctx 取消
+	Run(ctx context.Context)
+
+	// Flush 将 Redis 中的计数器汇总到数据库，返回更新的文件数（批次已写入过时为 0）
+	Flush(ctx context.Context) (int, error)
+
+	// GetStats 查询文件的累计统计和最近 days 天的每日统计（days 为 0 时默认 30 天）
+	GetStats(ctx context.Context, fileID uuid.UUID, days int) (*FileStats, error)
+}
+
+// counterStore Hash 计数器存储（由 cache.RedisClient 实现）
+type counterStore interface {
+	HIncrByMax(ctx context.Context, key string, incr, max map[string]int64) error
+	HClaim(ctx context.Context, key, claimKey, idField, claimID string) (map[string]string, error)
+	Delete(ctx context.Context, keys ...string) error
+}
+
+const (
+	// defaultStatsDays 默认查询的每日统计天数
+	defaultStatsDays = 30
+	// statsBatchField 认领的计数器中记录批次 ID 的字段
+	statsBatchField = "batch"
+	// statsFlushRetention 已写入批次的保留时间（在此期间重试同一批次不会重复累加）
+	statsFlushRetention = 7 * 24 * time.Hour
+)
+
+// downloadStatsService 文件下载统计服务实现
+// 计数器字段：{文件 ID}:{UTC 日期}:{d 下载次数 | l 链接次数 | b 字节数}，{文件 ID}:last 为最近访问时间（毫秒）
+type downloadStatsService struct {
+	counters   counterStore
+	statsRepo  repositories.DownloadStatsRepository
+	fileRepo   repositories.FileRepository
+	transactor repositories.Transactor
+	cfg        config.StatsConfig
+	now        func() time.Time
+}
+
+// NewDownloadStatsService 创建文件下载统计服务
+func NewDownloadStatsService(redis *cache.RedisClient, statsRepo repositories.DownloadStatsRepository, fileRepo repositories.FileRepository, transactor repositories.Transactor, cfg config.StatsConfig) DownloadStatsService {
+	return &downloadStatsService{
+		counters:   redis,
+		statsRepo:  statsRepo,
+		fileRepo:   fileRepo,
+		transactor: transactor,
+		cfg:        cfg,
+		now:        time.Now,
+	}
+}
+
+// pendingKey 累加中的计数器
+func (s *downloadStatsService) pendingKey() string {
+	return s.cfg.Key + ":pending"
+}
+
+// flushingKey 正在汇总的计数器（汇总成功后删除，失败时下次继续汇总）
+func (s *downloadStatsService) flushingKey() string {
+	return s.cfg.Key + ":flushing"
+}
+
+// RecordAccess 累加访问计数并更新最近访问时间
+func (s *downloadStatsService) RecordAccess(ctx context.Context, fileID uuid.UUID, kind AccessKind, bytesServed int64, at time.Time) error {
+	prefix := fileID.String() + ":" + at.UTC().Format(time.DateOnly) + ":"
+	incr := make(map[string]int64, 2)
+	switch kind {
+	case AccessDownload:
+		incr[prefix+"d"] = 1
+		if bytesServed > 0 {
+			incr[prefix+"b"] = bytesServed
+		}
+	case AccessLink:
+		incr[prefix+"l"] = 1
+	default:
+		return fmt.Errorf("invalid access kind: %s", kind)
+	}
+
+	latest := map[string]int64{fileID.String() + ":last": at.UnixMilli()}
+	if err := s.counters.HIncrByMax(ctx, s.pendingKey(), incr, latest); err != nil {
+		return fmt.Errorf("failed to record file access: %w", err)
+	}
+	return nil
+}
+
+// Run 定期汇总计数器
+func (s *downloadStatsService) Run(ctx context.Context) {
+	if s.cfg.FlushInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.Flush(ctx)
+			_, _ = s.statsRepo.DeleteFlushesBefore(ctx, s.now().Add(-statsFlushRetention))
+		}
+	}
+}
+
+// Flush 认领计数器后在一个事务中写入数据库
+// 批次 ID 与计数一起提交，删除认领的计数器失败后重试同一批次不会重复累加；多个实例同时汇总时只有一个生效
+func (s *downloadStatsService) Flush(ctx context.Context) (int, error) {
+	fields, err := s.counters.HClaim(ctx, s.pendingKey(), s.flushingKey(), statsBatchField, uuid.NewString())
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim download counters: %w", err)
+	}
+	if len(fields) == 0 {
+		return 0, nil
+	}
+
+	batchID, err := uuid.Parse(fields[statsBatchField])
+	if err != nil {
+		// 无法识别的批次无法保证只写入一次，丢弃
+		_ = s.counters.Delete(ctx, s.flushingKey())
+		return 0, fmt.Errorf("invalid download counter batch: %q", fields[statsBatchField])
+	}
+	totals, daily := parseCounters(fields)
+	now := s.now()
+
+	applied := 0
+	err = s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		first, err := s.statsRepo.RecordFlush(ctx, batchID, now)
+		if err != nil {
+			return fmt.Errorf("failed to record stats batch: %w", err)
+		}
+		if !first {
+			return nil
+		}
+		applied = len(totals)
+		for _, total := range totals {
+			total.UpdatedAt = now
+			if err := s.statsRepo.AddStats(ctx, total, daily[total.FileID]); err != nil {
+				return fmt.Errorf("failed to add stats for file %s: %w", total.FileID, err)
+			}
+			if total.LastAccessedAt != nil {
+				if err := s.fileRepo.MarkAccessed(ctx, total.FileID, *total.LastAccessedAt); err != nil {
+					return fmt.Errorf("failed to update last access of file %s: %w", total.FileID, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.counters.Delete(ctx, s.flushingKey()); err != nil {
+		return 0, fmt.Errorf("failed to delete flushed download counters: %w", err)
+	}
+	return applied, nil
+}
+
+// parseCounters 将计数器字段解析为每个文件的累计统计（按文件 ID 排序）和每日统计，忽略无法识别的字段
+func parseCounters(fields map[string]string) ([]*models.FileDownloadStats, map[uuid.UUID][]*models.FileDailyDownloadStats) {
+	totals := make(map[uuid.UUID]*models.FileDownloadStats)
+	days := make(map[uuid.UUID]map[string]*models.FileDailyDownloadStats)
+
+	total := func(id uuid.UUID) *models.FileDownloadStats {
+		if totals[id] == nil {
+			totals[id] = &models.FileDownloadStats{FileID: id}
+			days[id] = make(map[string]*models.FileDailyDownloadStats)
+		}
+		return totals[id]
+	}
+
+	for field, raw := range fields {
+		parts := strings.Split(field, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		id, err := uuid.Parse(parts[0])
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if len(parts) == 2 && parts[1] == "last" {
+			at := time.UnixMilli(value).UTC()
+			t := total(id)
+			if t.LastAccessedAt == nil || at.After(*t.LastAccessedAt) {
+				t.LastAccessedAt = &at
+			}
+			continue
+		}
+		if len(parts) != 3 {
+			continue
+		}
+		day, err := time.Parse(time.DateOnly, parts[1])
+		if err != nil {
+			continue
+		}
+
+		t := total(id)
+		d := days[id][parts[1]]
+		if d == nil {
+			d = &models.FileDailyDownloadStats{FileID: id, Day: day}
+			days[id][parts[1]] = d
+		}
+		switch parts[2] {
+		case "d":
+			d.Downloads += value
+			t.Downloads += value
+		case "l":
+			d.LinkRequests += value
+			t.LinkRequests += value
+		case "b":
+			d.BytesServed += value
+			t.BytesServed += value
+		}
+	}
+
+	// 按文件 ID 和日期排序，多个实例并发写入时加锁顺序一致
+	sorted := make([]*models.FileDownloadStats, 0, len(totals))
+	daily := make(map[uuid.UUID][]*models.FileDailyDownloadStats, len(totals))
+	for id, t := range totals {
+		sorted = append(sorted, t)
+		for _, d := range days[id] {
+			daily[id] = append(daily[id], d)
+		}
+		sort.Slice(daily[id], func(i, j int) bool { return daily[id][i].Day.Before(daily[id][j].Day) })
+	}
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i].FileID[:], sorted[j].FileID[:]) < 0 })
+	return sorted, daily
+}
+
+// GetStats 查询文件的下载统计
+func (s *downloadStatsService) GetStats(ctx context.Context, fileID uuid.UUID, days int) (*FileStats, error) {
+	if days == 0 {
+		days = defaultStatsDays
+	}
+	if days < 0 || (s.cfg.MaxDays > 0 && days > s.cfg.MaxDays) {
+		return nil, fmt.Errorf("invalid days: must be between 1 and %d", s.cfg.MaxDays)
+	}
+
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+
+	total, err := s.statsRepo.GetStats(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get download stats: %w", err)
+	}
+	today := s.now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+	rows, err := s.statsRepo.ListDaily(ctx, fileID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daily download stats: %w", err)
+	}
+
+	stats := &FileStats{FileID: fileID, LastAccessedAt: file.LastAccessedAt}
+	if total != nil {
+		stats.Downloads = total.Downloads
+		stats.LinkRequests = total.LinkRequests
+		stats.BytesServed = total.BytesServed
+		if total.LastAccessedAt != nil && (stats.LastAccessedAt == nil || total.LastAccessedAt.After(*stats.LastAccessedAt)) {
+			stats.LastAccessedAt = total.LastAccessedAt
+		}
+	}
+
+	// 补齐没有访问的日期
+	byDay := make(map[string]*models.FileDailyDownloadStats, len(rows))
+	for _, row := range rows {
+		byDay[row.Day.Format(time.DateOnly)] = row
+	}
+	stats.Daily = make([]DailyDownloadStats, days)
+	for i := range stats.Daily {
+		day := since.AddDate(0, 0, i).Format(time.DateOnly)
+		stats.Daily[i].Day = day
+		if row := byDay[day]; row != nil {
+			stats.Daily[i].Downloads = row.Downloads
+			stats.Daily[i].LinkRequests = row.LinkRequests
+			stats.Daily[i].BytesServed = row.BytesServed
+		}
+	}
+	return stats, nil
+}
+
+// downloadReader 统计读取的字节数，关闭时回调一次（用于记录下载统计）
+type downloadReader struct {
+	io.ReadCloser
+	read    int64
+	once    sync.Once
+	onClose func(read int64)
+}
+
+func (r *downloadReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+func (r *downloadReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(func() { r.onClose(r.read) })
+	return err
+}
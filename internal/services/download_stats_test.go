@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+)
+
+// memoryCounterStore 用于测试的内存 Hash 计数器
+type memoryCounterStore struct {
+	hashes    map[string]map[string]string
+	deleteErr error
+}
+
+func newMemoryCounterStore() *memoryCounterStore {
+	return &memoryCounterStore{hashes: make(map[string]map[string]string)}
+}
+
+func (m *memoryCounterStore) HIncrByMax(ctx context.Context, key string, incr, max map[string]int64) error {
+	hash := m.hashes[key]
+	if hash == nil {
+		hash = make(map[string]string)
+		m.hashes[key] = hash
+	}
+	for field, value := range incr {
+		current, _ := strconv.ParseInt(hash[field], 10, 64)
+		hash[field] = strconv.FormatInt(current+value, 10)
+	}
+	for field, value := range max {
+		if current, err := strconv.ParseInt(hash[field], 10, 64); err != nil || current < value {
+			hash[field] = strconv.FormatInt(value, 10)
+		}
+	}
+	return nil
+}
+
+func (m *memoryCounterStore) HClaim(ctx context.Context, key, claimKey, idField, claimID string) (map[string]string, error) {
+	if m.hashes[claimKey] == nil {
+		if m.hashes[key] == nil {
+			return map[string]string{}, nil
+		}
+		m.hashes[claimKey] = m.hashes[key]
+		m.hashes[claimKey][idField] = claimID
+		delete(m.hashes, key)
+	}
+	fields := make(map[string]string)
+	for field, value := range m.hashes[claimKey] {
+		fields[field] = value
+	}
+	return fields, nil
+}
+
+func (m *memoryCounterStore) Delete(ctx context.Context, keys ...string) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	for _, key := range keys {
+		delete(m.hashes, key)
+	}
+	return nil
+}
+
+// MockDownloadStatsRepository 用于测试的内存下载统计仓储
+type MockDownloadStatsRepository struct {
+	flushes map[uuid.UUID]time.Time
+	totals  map[uuid.UUID]*models.FileDownloadStats
+	daily   map[uuid.UUID]map[string]*models.FileDailyDownloadStats
+}
+
+func NewMockDownloadStatsRepository() *MockDownloadStatsRepository {
+	return &MockDownloadStatsRepository{
+		flushes: make(map[uuid.UUID]time.Time),
+		totals:  make(map[uuid.UUID]*models.FileDownloadStats),
+		daily:   make(map[uuid.UUID]map[string]*models.FileDailyDownloadStats),
+	}
+}
+
+func (m *MockDownloadStatsRepository) RecordFlush(ctx context.Context, batchID uuid.UUID, at time.Time) (bool, error) {
+	if _, ok := m.flushes[batchID]; ok {
+		return false, nil
+	}
+	m.flushes[batchID] = at
+	return true, nil
+}
+
+func (m *MockDownloadStatsRepository) AddStats(ctx context.Context, total *models.FileDownloadStats, daily []*models.FileDailyDownloadStats) error {
+	t := m.totals[total.FileID]
+	if t == nil {
+		t = &models.FileDownloadStats{FileID: total.FileID}
+		m.totals[total.FileID] = t
+		m.daily[total.FileID] = make(map[string]*models.FileDailyDownloadStats)
+	}
+	t.Downloads += total.Downloads
+	t.LinkRequests += total.LinkRequests
+	t.BytesServed += total.BytesServed
+	if total.LastAccessedAt != nil && (t.LastAccessedAt == nil || total.LastAccessedAt.After(*t.LastAccessedAt)) {
+		t.LastAccessedAt = total.LastAccessedAt
+	}
+	for _, day := range daily {
+		key := day.Day.Format(time.DateOnly)
+		d := m.daily[total.FileID][key]
+		if d == nil {
+			d = &models.FileDailyDownloadStats{FileID: day.FileID, Day: day.Day}
+			m.daily[total.FileID][key] = d
+		}
+		d.Downloads += day.Downloads
+		d.LinkRequests += day.LinkRequests
+		d.BytesServed += day.BytesServed
+	}
+	return nil
+}
+
+func (m *MockDownloadStatsRepository) GetStats(ctx context.Context, fileID uuid.UUID) (*models.FileDownloadStats, error) {
+	return m.totals[fileID], nil
+}
+
+func (m *MockDownloadStatsRepository) ListDaily(ctx context.Context, fileID uuid.UUID, since time.Time) ([]*models.FileDailyDownloadStats, error) {
+	var daily []*models.FileDailyDownloadStats
+	for _, d := range m.daily[fileID] {
+		if !d.Day.Before(since) {
+			daily = append(daily, d)
+		}
+	}
+	return daily, nil
+}
+
+func (m *MockDownloadStatsRepository) DeleteFlushesBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for id, at := range m.flushes {
+		if at.Before(before) {
+			delete(m.flushes, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// TestDownloadStats 测试下载计数累加、汇总到数据库和查询每日统计
+func TestDownloadStats(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	counters := newMemoryCounterStore()
+	statsRepo := NewMockDownloadStatsRepository()
+	now := time.Now().UTC()
+	stats := &downloadStatsService{
+		counters:   counters,
+		statsRepo:  statsRepo,
+		fileRepo:   repo,
+		transactor: MockTransactor{},
+		cfg:        config.StatsConfig{Key: "stats:downloads", MaxDays: 365},
+		now:        func() time.Time { return now },
+	}
+	files := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{Stats: stats})
+	file := newBatchTestFile(t, repo, store, "report.txt")
+
+	// 服务端下载按关闭时实际读取的字节数统计
+	result, err := files.DownloadFile(ctx, file.ID, "")
+	if err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if _, err := io.ReadAll(result.Reader); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	result.Reader.Close()
+	result.Reader.Close()
+	if _, err := files.GetDownloadURL(ctx, file.ID, DownloadURLOptions{}); err != nil {
+		t.Fatalf("GetDownloadURL failed: %v", err)
+	}
+	yesterday := now.Add(-24 * time.Hour)
+	if err := stats.RecordAccess(ctx, file.ID, AccessDownload, 3, yesterday); err != nil {
+		t.Fatalf("RecordAccess failed: %v", err)
+	}
+
+	// 删除认领的计数器失败时，重试同一批次不会重复累加
+	counters.deleteErr = errors.New("redis unavailable")
+	if _, err := stats.Flush(ctx); err == nil {
+		t.Fatal("Flush should report the delete failure")
+	}
+	counters.deleteErr = nil
+	if err := stats.RecordAccess(ctx, file.ID, AccessLink, 0, now); err != nil {
+		t.Fatalf("RecordAccess failed: %v", err)
+	}
+	if n, err := stats.Flush(ctx); err != nil || n != 0 {
+		t.Fatalf("retried Flush = %d, %v, want 0 files", n, err)
+	}
+	if n, err := stats.Flush(ctx); err != nil || n != 1 {
+		t.Fatalf("Flush = %d, %v, want 1 file", n, err)
+	}
+	if n, err := stats.Flush(ctx); err != nil || n != 0 {
+		t.Fatalf("empty Flush = %d, %v, want 0 files", n, err)
+	}
+
+	got, err := stats.GetStats(ctx, file.ID, 3)
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if got.Downloads != 2 || got.LinkRequests != 2 || got.BytesServed != 8 {
+		t.Errorf("unexpected totals: %+v", got)
+	}
+	if file.LastAccessedAt == nil || got.LastAccessedAt == nil || !got.LastAccessedAt.Equal(*file.LastAccessedAt) {
+		t.Errorf("last access = %v, file last access = %v", got.LastAccessedAt, file.LastAccessedAt)
+	}
+	want := []DailyDownloadStats{
+		{Day: now.AddDate(0, 0, -2).Format(time.DateOnly)},
+		{Day: yesterday.Format(time.DateOnly), Downloads: 1, BytesServed: 3},
+		{Day: now.Format(time.DateOnly), Downloads: 1, LinkRequests: 2, BytesServed: 5},
+	}
+	if len(got.Daily) != len(want) {
+		t.Fatalf("daily = %+v, want %+v", got.Daily, want)
+	}
+	for i := range want {
+		if got.Daily[i] != want[i] {
+			t.Errorf("daily[%d] = %+v, want %+v", i, got.Daily[i], want[i])
+		}
+	}
+
+	if _, err := stats.GetStats(ctx, file.ID, 400); err == nil {
+		t.Error("GetStats should reject more than max_days")
+	}
+}
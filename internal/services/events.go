@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+)
+
+// EventType 文件生命周期事件类型
+type EventType string
+
+const (
+	EventFileCreated      EventType = "file.created"      // 文件记录已创建（等待上传）
+	EventFileCompleted    EventType = "file.completed"    // 文件上传完成，可下载
+	EventFileDeleted      EventType = "file.deleted"      // 文件已删除
+	EventMultipartAborted EventType = "multipart.aborted" // 分片上传已取消
+	EventFileRejected     EventType = "file.rejected"     // 文件内容与声明类型不一致，已拒绝并删除
+)
+
+// EventTypes 所有支持订阅的事件类型
+var EventTypes = []EventType{
+	EventFileCreated,
+	EventFileCompleted,
+	EventFileDeleted,
+	EventMultipartAborted,
+	EventFileRejected,
+}
+
+// IsValidEventType 判断是否为支持的事件类型
+func IsValidEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if string(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event 文件生命周期事件
+type Event struct {
+	ID         uuid.UUID    `json:"id"`
+	Type       EventType    `json:"type"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	Data       *models.File `json:"data"`
+}
+
+// NewFileEvent 创建文件事件（Data 为事件发生时的文件快照）
+func NewFileEvent(eventType EventType, file *models.File) *Event {
+	snapshot := *file
+	return &Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       &snapshot,
+	}
+}
+
+// OutboxEvent 转换为发件箱记录
+func (e *Event) OutboxEvent() (*models.OutboxEvent, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return &models.OutboxEvent{
+		ID:            e.ID,
+		AggregateType: "file",
+		AggregateID:   e.Data.ID,
+		EventType:     string(e.Type),
+		Payload:       string(payload),
+		CreatedAt:     e.OccurredAt,
+	}, nil
+}
+
+// EventPublisher 事件发布接口（由 OutboxRelay 调用）
+// 发布至少一次：中继在标记事件已发布前崩溃时会重新发布，实现需按 Event.ID 保证幂等
+type EventPublisher interface {
+	// Publish 发布事件
+	Publish(ctx context.Context, event *Event) error
+}
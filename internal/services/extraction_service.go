@@ -0,0 +1,575 @@
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/cache"
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/queue"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/NanoBoom/asethub/pkg/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// extractionJobTTL 解压任务状态的保留时间
+	extractionJobTTL = 7 * 24 * time.Hour
+
+	// extractionTimeout 单次执行解压任务的最长时间
+	extractionTimeout = 6 * time.Hour
+)
+
+// ExtractionJobType 压缩包解压任务类型
+const ExtractionJobType = "archive.extract"
+
+// extractionJobPayload 解压任务载荷（任务进度保存在 Redis 的 ExtractionJob 中）
+type extractionJobPayload struct {
+	JobID uuid.UUID `json:"job_id"`
+}
+
+// ExtractionJobStatus 解压任务状态
+type ExtractionJobStatus string
+
+const (
+	ExtractionJobPending   ExtractionJobStatus = "pending"   // 等待执行（包括出错后等待重试）
+	ExtractionJobRunning   ExtractionJobStatus = "running"   // 解压中
+	ExtractionJobCompleted ExtractionJobStatus = "completed" // 已完成
+	ExtractionJobFailed    ExtractionJobStatus = "failed"    // 失败（已解压的文件会保留）
+)
+
+// ExtractionJob 压缩包解压任务
+type ExtractionJob struct {
+	ID               uuid.UUID           `json:"id"`
+	ArchiveFileID    uuid.UUID           `json:"archive_file_id"`
+	Folder           string              `json:"folder"` // 解压目标目录
+	Status           ExtractionJobStatus `json:"status"`
+	TotalEntries     int                 `json:"total_entries"` // ZIP 可预知；TAR 需流式读取，完成前为 0
+	ProcessedEntries int                 `json:"processed_entries"`
+	CreatedFiles     int                 `json:"created_files"`
+	SkippedEntries   int                 `json:"skipped_entries"` // 目录、符号链接等非普通文件
+	BytesExtracted   int64               `json:"bytes_extracted"`
+	Error            string              `json:"error,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
+
+// ExtractionService 压缩包服务端解压服务接口
+type ExtractionService interface {
+	// IsExtractable 判断文件名是否为支持解压的压缩包（.zip/.tar/.tar.gz/.tgz）
+	IsExtractable(name string) bool
+
+	// StartExtraction 为已上传完成的压缩包创建解压任务（由任务队列执行）
+	// folder 为空时解压到压缩包所在目录下与压缩包同名的子目录
+	StartExtraction(ctx context.Context, archive *models.File, folder string) (*ExtractionJob, error)
+
+	// GetExtractionJob 查询解压任务进度
+	GetExtractionJob(ctx context.Context, id uuid.UUID) (*ExtractionJob, error)
+}
+
+// extractionService 解压服务实现
+type extractionService struct {
+	fileRepo   repositories.FileRepository
+	outboxRepo repositories.OutboxRepository
+	storage    storage.Storage
+	transactor repositories.Transactor
+	redis      jsonStore
+	jobs       jobQueue
+	cfg        config.ExtractionConfig
+	keys       *storage.KeyTemplate
+}
+
+// NewExtractionService 创建解压服务实例，并注册解压任务处理器
+// 解压出的文件与上传的文件一样写入 file.created / file.completed 事件（供 Webhook、扫描等订阅方处理）
+func NewExtractionService(fileRepo repositories.FileRepository, outboxRepo repositories.OutboxRepository, storage storage.Storage, transactor repositories.Transactor, redis *cache.RedisClient, jobs jobQueue, cfg config.ExtractionConfig, keys *storage.KeyTemplate) ExtractionService {
+	s := &extractionService{
+		fileRepo:   fileRepo,
+		outboxRepo: outboxRepo,
+		storage:    storage,
+		transactor: transactor,
+		redis:      redis,
+		jobs:       jobs,
+		cfg:        cfg,
+		keys:       keyTemplateOrDefault(keys),
+	}
+	jobs.Register(ExtractionJobType, queue.Typed(s.handleExtractionJob))
+	return s
+}
+
+// archiveFormat 压缩包格式
+type archiveFormat int
+
+const (
+	formatUnknown archiveFormat = iota
+	formatZip
+	formatTar
+	formatTarGz
+)
+
+// detectArchiveFormat 根据文件名识别压缩包格式
+func detectArchiveFormat(name string) archiveFormat {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return formatZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return formatTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return formatTar
+	default:
+		return formatUnknown
+	}
+}
+
+// archiveBaseName 去除压缩包扩展名
+func archiveBaseName(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// IsExtractable 判断文件名是否为支持解压的压缩包
+func (s *extractionService) IsExtractable(name string) bool {
+	return detectArchiveFormat(name) != formatUnknown
+}
+
+// StartExtraction 创建解压任务
+func (s *extractionService) StartExtraction(ctx context.Context, archive *models.File, folder string) (*ExtractionJob, error) {
+	if !s.IsExtractable(archive.Name) {
+		return nil, fmt.Errorf("file is not a supported archive (zip, tar, tar.gz)")
+	}
+	if archive.Status != models.FileStatusCompleted {
+		return nil, fmt.Errorf("file is not ready for extraction")
+	}
+
+	if folder == "" {
+		folder = path.Join(archive.Folder, archiveSafeName(archiveBaseName(archive.Name)))
+	}
+
+	now := time.Now()
+	job := &ExtractionJob{
+		ID:            uuid.New(),
+		ArchiveFileID: archive.ID,
+		Folder:        normalizeFolder(folder),
+		Status:        ExtractionJobPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.saveJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// 由任务队列执行（受队列并发数限制，服务关闭时放回队列）
+	if _, err := s.jobs.Enqueue(ctx, ExtractionJobType, extractionJobPayload{JobID: job.ID}, &queue.EnqueueOptions{Queue: s.cfg.Queue}); err != nil {
+		job.Status = ExtractionJobFailed
+		job.Error = err.Error()
+		_ = s.saveJob(ctx, job)
+		return nil, fmt.Errorf("failed to enqueue extraction: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetExtractionJob 查询解压任务进度
+func (s *extractionService) GetExtractionJob(ctx context.Context, id uuid.UUID) (*ExtractionJob, error) {
+	var job ExtractionJob
+	err := s.redis.GetJSON(ctx, extractionJobKey(id), &job)
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, fmt.Errorf("extraction job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extraction job: %w", err)
+	}
+	return &job, nil
+}
+
+// handleExtractionJob 执行解压任务
+// 任务被中断（服务关闭、进程崩溃）或出错重试时，从已记录进度的条目之后继续解压
+// （中断恰好发生在创建文件之后、记录进度之前时，该条目会再创建一次）
+func (s *extractionService) handleExtractionJob(ctx context.Context, qjob *queue.Job, payload extractionJobPayload) error {
+	job, err := s.GetExtractionJob(ctx, payload.JobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return queue.Permanent(err)
+		}
+		return err
+	}
+	if job.Status == ExtractionJobCompleted || job.Status == ExtractionJobFailed {
+		return nil
+	}
+
+	// 状态写入不受任务取消影响
+	storeCtx := context.WithoutCancel(ctx)
+	archive, err := s.fileRepo.GetByID(ctx, job.ArchiveFileID)
+	if err != nil {
+		job.Status = ExtractionJobFailed
+		job.Error = "archive file not found"
+		_ = s.saveJob(storeCtx, job)
+		return queue.Permanent(fmt.Errorf("archive file not found: %w", err))
+	}
+
+	job.Status = ExtractionJobRunning
+	job.Error = ""
+	_ = s.saveJob(ctx, job)
+
+	runCtx, cancel := context.WithTimeout(ctx, extractionTimeout)
+	defer cancel()
+	err = s.extract(runCtx, job, archive)
+	switch {
+	case err == nil:
+		job.Status = ExtractionJobCompleted
+	case ctx.Err() != nil:
+		// 服务关闭：任务放回队列，保持 running 状态
+		_ = s.saveJob(storeCtx, job)
+		return err
+	case extractionRetryable(err) && qjob.Attempts < qjob.MaxAttempts:
+		job.Status = ExtractionJobPending
+		job.Error = err.Error()
+		_ = s.saveJob(storeCtx, job)
+		return err
+	default:
+		job.Status = ExtractionJobFailed
+		job.Error = err.Error()
+		_ = s.saveJob(storeCtx, job)
+		return queue.Permanent(err)
+	}
+	_ = s.saveJob(storeCtx, job)
+	return nil
+}
+
+// extractionRetryable 判断解压错误是否可以重试（读取存储、上传或写入数据库失败）
+// 压缩包格式错误、路径不安全或超出限制时重试不会成功
+func extractionRetryable(err error) bool {
+	return strings.HasPrefix(err.Error(), "failed to ")
+}
+
+// extract 读取压缩包并逐个条目创建文件
+func (s *extractionService) extract(ctx context.Context, job *ExtractionJob, archive *models.File) error {
+	reader, _, _, err := s.storage.GetObject(ctx, archive.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to get archive: %w", err)
+	}
+	defer reader.Close()
+
+	limits := &extractionLimits{cfg: s.cfg, archiveSize: archive.Size}
+
+	switch detectArchiveFormat(archive.Name) {
+	case formatZip:
+		return s.extractZip(ctx, job, reader, limits)
+	case formatTar:
+		return s.extractTar(ctx, job, reader, limits)
+	case formatTarGz:
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("invalid gzip archive: %w", err)
+		}
+		defer gz.Close()
+		return s.extractTar(ctx, job, gz, limits)
+	default:
+		return fmt.Errorf("unsupported archive format")
+	}
+}
+
+// extractZip 解压 ZIP（需要随机读取中央目录，先下载到本地临时文件）
+func (s *extractionService) extractZip(ctx context.Context, job *ExtractionJob, reader io.Reader, limits *extractionLimits) error {
+	tmp, err := os.CreateTemp("", "assethub-extract-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, reader)
+	if err != nil {
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+	limits.archiveSize = size
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %w", err)
+	}
+
+	// 解压前根据中央目录声明的大小检查限制
+	var declared int64
+	for _, f := range zr.File {
+		declared += int64(f.UncompressedSize64)
+	}
+	if err := limits.checkDeclared(len(zr.File), declared); err != nil {
+		return err
+	}
+	job.TotalEntries = len(zr.File)
+	done := job.ProcessedEntries
+
+	for i, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := limits.addEntry(); err != nil {
+			return err
+		}
+		// 重新执行时跳过此前已处理的条目（仍计入限制）
+		resumed := i < done
+
+		if !f.Mode().IsRegular() {
+			if !resumed {
+				s.skipEntry(ctx, job)
+			}
+			continue
+		}
+
+		size := int64(f.UncompressedSize64)
+		if err := limits.addBytes(size); err != nil {
+			return err
+		}
+		if resumed {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open entry %s: %w", f.Name, err)
+		}
+		err = s.storeEntry(ctx, job, f.Name, rc, size)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractTar 流式解压 TAR
+func (s *extractionService) extractTar(ctx context.Context, job *ExtractionJob, reader io.Reader, limits *extractionLimits) error {
+	tr := tar.NewReader(reader)
+	done := job.ProcessedEntries
+
+	for i := 0; ; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		header, err := tr.Next()
+		if err == io.EOF {
+			job.TotalEntries = job.ProcessedEntries
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %w", err)
+		}
+		if err := limits.addEntry(); err != nil {
+			return err
+		}
+		// 重新执行时跳过此前已处理的条目（仍计入限制）
+		resumed := i < done
+
+		if header.Typeflag != tar.TypeReg {
+			if !resumed {
+				s.skipEntry(ctx, job)
+			}
+			continue
+		}
+
+		if err := limits.addBytes(header.Size); err != nil {
+			return err
+		}
+		if resumed {
+			continue
+		}
+		if err := s.storeEntry(ctx, job, header.Name, tr, header.Size); err != nil {
+			return err
+		}
+	}
+}
+
+// storeEntry 上传单个条目并创建文件记录
+func (s *extractionService) storeEntry(ctx context.Context, job *ExtractionJob, entryName string, reader io.Reader, size int64) error {
+	dir, name, err := safeEntryPath(entryName)
+	if err != nil {
+		return err
+	}
+
+	contentType := utils.DetectContentTypeFromFilename(name)
+	file := &models.File{
+		BaseModel:   models.BaseModel{ID: uuid.New()},
+		Name:        name,
+		Size:        size,
+		ContentType: contentType,
+		Status:      models.FileStatusCompleted,
+		Folder:      normalizeFolder(path.Join(job.Folder, dir)),
+	}
+	file.StorageKey = newStorageKey(s.keys, file)
+
+	// 在事务中创建记录、上传并写入事件（记录先于上传创建，客户端加密时数据密钥保存在该记录上）
+	// 限制实际读取的字节数，防止条目头部声明的大小与实际内容不符
+	body := &sizeGuardReader{reader: reader, remaining: size}
+	uploaded := false
+	err = s.transactor.Transaction(withObjectHints(ctx, file), func(ctx context.Context) error {
+		if err := s.fileRepo.Create(ctx, file); err != nil {
+			return fmt.Errorf("failed to create file record for %s: %w", entryName, err)
+		}
+		if err := s.storage.Upload(ctx, file.StorageKey, body, size, contentType); err != nil {
+			return fmt.Errorf("failed to upload entry %s: %w", entryName, err)
+		}
+		uploaded = true
+		if body.exceeded {
+			return fmt.Errorf("entry %s exceeds its declared size", entryName)
+		}
+		return recordFileEvents(ctx, s.outboxRepo, file, EventFileCreated, EventFileCompleted)
+	})
+	if err != nil {
+		if uploaded {
+			_ = s.storage.Delete(ctx, file.StorageKey)
+		}
+		return err
+	}
+
+	job.ProcessedEntries++
+	job.CreatedFiles++
+	job.BytesExtracted += size
+	_ = s.saveJob(ctx, job)
+	return nil
+}
+
+// skipEntry 记录跳过的条目
+func (s *extractionService) skipEntry(ctx context.Context, job *ExtractionJob) {
+	job.ProcessedEntries++
+	job.SkippedEntries++
+	_ = s.saveJob(ctx, job)
+}
+
+// saveJob 保存任务状态到 Redis
+func (s *extractionService) saveJob(ctx context.Context, job *ExtractionJob) error {
+	job.UpdatedAt = time.Now()
+	if err := s.redis.SetJSON(ctx, extractionJobKey(job.ID), job, extractionJobTTL); err != nil {
+		return fmt.Errorf("failed to save extraction job: %w", err)
+	}
+	return nil
+}
+
+// extractionJobKey 解压任务的 Redis 键
+func extractionJobKey(id uuid.UUID) string {
+	return "extraction:job:" + id.String()
+}
+
+// safeEntryPath 校验条目路径（防 zip slip），返回相对目录和文件名
+// 拒绝绝对路径、盘符路径、包含 ".." 的路径和控制字符
+func safeEntryPath(entryName string) (string, string, error) {
+	name := strings.ReplaceAll(entryName, "\\", "/")
+
+	if strings.HasPrefix(name, "/") || (len(name) >= 2 && name[1] == ':') {
+		return "", "", fmt.Errorf("unsafe entry path: %s", entryName)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", "", fmt.Errorf("unsafe entry path: %s", entryName)
+		}
+	}
+	if strings.IndexFunc(name, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0 {
+		return "", "", fmt.Errorf("unsafe entry path: %s", entryName)
+	}
+
+	cleaned := path.Clean(name)
+	dir, base := path.Split(cleaned)
+	if base == "" || base == "." {
+		return "", "", fmt.Errorf("invalid entry name: %s", entryName)
+	}
+
+	return strings.TrimSuffix(dir, "/"), utils.SanitizeFilename(base), nil
+}
+
+// extractionLimits 解压限制（条目数、总大小、单条目大小、压缩比）
+type extractionLimits struct {
+	cfg         config.ExtractionConfig
+	archiveSize int64
+	entries     int
+	totalBytes  int64
+}
+
+// checkDeclared 检查压缩包声明的条目数和总大小
+func (l *extractionLimits) checkDeclared(entries int, totalBytes int64) error {
+	if l.cfg.MaxEntries > 0 && entries > l.cfg.MaxEntries {
+		return fmt.Errorf("archive has too many entries: %d (max %d)", entries, l.cfg.MaxEntries)
+	}
+	if l.cfg.MaxTotalSize > 0 && totalBytes > l.cfg.MaxTotalSize {
+		return fmt.Errorf("archive uncompressed size %d exceeds limit %d", totalBytes, l.cfg.MaxTotalSize)
+	}
+	return l.checkRatio(totalBytes)
+}
+
+// addEntry 累计条目数
+func (l *extractionLimits) addEntry() error {
+	l.entries++
+	if l.cfg.MaxEntries > 0 && l.entries > l.cfg.MaxEntries {
+		return fmt.Errorf("archive has too many entries (max %d)", l.cfg.MaxEntries)
+	}
+	return nil
+}
+
+// addBytes 累计解压大小
+func (l *extractionLimits) addBytes(size int64) error {
+	if size < 0 {
+		return fmt.Errorf("invalid entry size: %d", size)
+	}
+	if l.cfg.MaxEntrySize > 0 && size > l.cfg.MaxEntrySize {
+		return fmt.Errorf("archive entry size %d exceeds limit %d", size, l.cfg.MaxEntrySize)
+	}
+
+	l.totalBytes += size
+	if l.cfg.MaxTotalSize > 0 && l.totalBytes > l.cfg.MaxTotalSize {
+		return fmt.Errorf("archive uncompressed size exceeds limit %d", l.cfg.MaxTotalSize)
+	}
+	return l.checkRatio(l.totalBytes)
+}
+
+// checkRatio 检查压缩比
+func (l *extractionLimits) checkRatio(totalBytes int64) error {
+	if l.cfg.MaxRatio <= 0 || l.archiveSize <= 0 {
+		return nil
+	}
+	if ratio := float64(totalBytes) / float64(l.archiveSize); ratio > l.cfg.MaxRatio {
+		return fmt.Errorf("archive compression ratio %.1f exceeds limit %.1f", ratio, l.cfg.MaxRatio)
+	}
+	return nil
+}
+
+// sizeGuardReader 最多读取声明的字节数，超出时标记 exceeded 并截断
+type sizeGuardReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (r *sizeGuardReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		// 声明的大小已读完，探测是否仍有多余数据
+		var probe [1]byte
+		if n, _ := r.reader.Read(probe[:]); n > 0 {
+			r.exceeded = true
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
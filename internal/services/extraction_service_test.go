@@ -0,0 +1,177 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+)
+
+// TestSafeEntryPath 测试条目路径校验（zip slip 防护）
+func TestSafeEntryPath(t *testing.T) {
+	tests := []struct {
+		entry   string
+		dir     string
+		name    string
+		wantErr bool
+	}{
+		{entry: "a.txt", dir: "", name: "a.txt"},
+		{entry: "img/icons/logo.png", dir: "img/icons", name: "logo.png"},
+		{entry: "img\\logo.png", dir: "img", name: "logo.png"},
+		{entry: "./img//logo.png", dir: "img", name: "logo.png"},
+		{entry: "../evil.sh", wantErr: true},
+		{entry: "img/../../evil.sh", wantErr: true},
+		{entry: "/etc/passwd", wantErr: true},
+		{entry: "C:\\Windows\\evil.dll", wantErr: true},
+		{entry: "bad\nname.txt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			dir, name, err := safeEntryPath(tt.entry)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("safeEntryPath(%q) expected error", tt.entry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("safeEntryPath(%q) unexpected error: %v", tt.entry, err)
+			}
+			if dir != tt.dir || name != tt.name {
+				t.Errorf("safeEntryPath(%q) = (%q, %q), want (%q, %q)", tt.entry, dir, name, tt.dir, tt.name)
+			}
+		})
+	}
+}
+
+// TestExtractionLimits 测试 zip bomb 限制
+func TestExtractionLimits(t *testing.T) {
+	limits := &extractionLimits{
+		cfg:         config.ExtractionConfig{MaxEntries: 2, MaxTotalSize: 5000, MaxEntrySize: 600, MaxRatio: 10},
+		archiveSize: 100,
+	}
+
+	if err := limits.checkDeclared(3, 10); err == nil {
+		t.Errorf("expected entry count error")
+	}
+	if err := limits.checkDeclared(1, 6000); err == nil {
+		t.Errorf("expected total size error")
+	}
+	if err := limits.addBytes(700); err == nil {
+		t.Errorf("expected entry size error")
+	}
+	if err := limits.addBytes(500); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := limits.addBytes(600); err == nil || !strings.Contains(err.Error(), "ratio") {
+		t.Errorf("expected ratio error, got %v", err)
+	}
+}
+
+// TestExtractZip 测试解压 ZIP 并保留目录结构
+func TestExtractZip(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	outbox := NewMockOutboxRepository()
+	svc := NewExtractionService(repo, outbox, store, MockTransactor{}, nil, &fakeJobQueue{}, config.ExtractionConfig{MaxEntries: 10, MaxRatio: 100}, nil).(*extractionService)
+	svc.redis = NewMemoryJSONStore()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"readme.txt": "hi", "img/logo.png": "png"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Create("img/")
+	zw.Close()
+
+	archive := &models.File{Name: "pack.zip", Size: int64(buf.Len()), StorageKey: "files/1/pack.zip", Status: models.FileStatusCompleted, Folder: "/"}
+	store.objects[archive.StorageKey] = buf.Bytes()
+
+	job := &ExtractionJob{ID: uuid.New(), Folder: "/pack"}
+	if err := svc.extract(ctx, job, archive); err != nil {
+		t.Fatalf("extract failed: %v", err)
+	}
+
+	if job.CreatedFiles != 2 || job.SkippedEntries != 1 {
+		t.Fatalf("unexpected job progress: %+v", job)
+	}
+	found := map[string]bool{}
+	for _, file := range repo.files {
+		found[file.Folder+"/"+file.Name] = true
+	}
+	if !found["/pack/readme.txt"] || !found["/pack/img/logo.png"] {
+		t.Errorf("unexpected files: %v", found)
+	}
+	if events := outbox.eventTypes(); len(events) != 4 {
+		t.Errorf("outbox events = %v, want created/completed for each file", events)
+	}
+}
+
+// TestExtractionJobResume 测试解压任务由任务队列执行，中断后从已处理的条目之后继续
+func TestExtractionJobResume(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	jobs := &fakeJobQueue{}
+	svc := NewExtractionService(repo, NewMockOutboxRepository(), store, MockTransactor{}, nil, jobs, config.ExtractionConfig{MaxEntries: 10, MaxRatio: 100, Queue: "extract"}, nil).(*extractionService)
+	svc.redis = NewMemoryJSONStore()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(name))
+	}
+	zw.Close()
+	archive := &models.File{Name: "pack.zip", Size: int64(buf.Len()), StorageKey: "files/1/pack.zip", Status: models.FileStatusCompleted, Folder: "/"}
+	if err := repo.Create(ctx, archive); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	store.objects[archive.StorageKey] = buf.Bytes()
+
+	job, err := svc.StartExtraction(ctx, archive, "/pack")
+	if err != nil {
+		t.Fatalf("StartExtraction failed: %v", err)
+	}
+	if len(jobs.jobs) != 1 || jobs.jobs[0].Queue != "extract" {
+		t.Fatalf("jobs = %+v", jobs.jobs)
+	}
+
+	// 模拟上次执行在处理完第一个条目后中断
+	job.Status = ExtractionJobRunning
+	job.ProcessedEntries = 1
+	job.CreatedFiles = 1
+	if err := svc.saveJob(ctx, job); err != nil {
+		t.Fatalf("saveJob failed: %v", err)
+	}
+	for _, err := range jobs.run(ctx) {
+		if err != nil {
+			t.Fatalf("extraction job failed: %v", err)
+		}
+	}
+
+	got, err := svc.GetExtractionJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetExtractionJob failed: %v", err)
+	}
+	if got.Status != ExtractionJobCompleted || got.ProcessedEntries != 3 || got.CreatedFiles != 3 {
+		t.Errorf("unexpected job: %+v", got)
+	}
+	var names []string
+	for _, file := range repo.files {
+		if file.Folder == "/pack" {
+			names = append(names, file.Name)
+		}
+	}
+	if len(names) != 2 {
+		t.Errorf("extracted files = %v, want b.txt and c.txt only", names)
+	}
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+)
+
+// MaxBatchOperations 单次批量请求允许的最大操作数
+const MaxBatchOperations = 1000
+
+// BatchOperationType 批量操作类型
+type BatchOperationType string
+
+const (
+	BatchOpDelete      BatchOperationType = "delete"       // 删除（默认软删除，可恢复）
+	BatchOpRestore     BatchOperationType = "restore"      // 恢复软删除的文件
+	BatchOpSetMetadata BatchOperationType = "set_metadata" // 设置元数据/标签
+	BatchOpMove        BatchOperationType = "move"         // 移动到其他目录
+	BatchOpCopy        BatchOperationType = "copy"         // 复制文件
+)
+
+// BatchOperation 单个批量操作
+type BatchOperation struct {
+	Op        BatchOperationType
+	FileID    uuid.UUID
+	Permanent bool              // delete：同时删除存储对象，不可恢复
+	Metadata  map[string]string // set_metadata：合并到现有元数据（值为空字符串表示删除该键）
+	Tags      []string          // set_metadata：替换标签（nil 表示不修改）
+	Folder    string            // move/copy：目标目录（copy 时为空表示与源文件相同）
+}
+
+// BatchResult 单个批量操作的执行结果
+type BatchResult struct {
+	Index     int                `json:"index"`
+	Op        BatchOperationType `json:"op"`
+	FileID    uuid.UUID          `json:"file_id"`
+	Success   bool               `json:"success"`
+	Error     string             `json:"error,omitempty"`
+	NewFileID *uuid.UUID         `json:"new_file_id,omitempty"` // copy 操作生成的新文件 ID
+}
+
+// BatchOperate 批量执行文件操作
+// 操作按顺序执行，单个操作失败不影响其他操作；永久删除的对象统一在最后通过存储后端的批量删除接口删除
+func (s *fileService) BatchOperate(ctx context.Context, ops []BatchOperation) ([]BatchResult, error) {
+	if len(ops) > MaxBatchOperations {
+		return nil, fmt.Errorf("too many operations: %d (max %d)", len(ops), MaxBatchOperations)
+	}
+
+	// 一次性加载所有涉及的文件（包含已软删除的记录，供 restore 使用）
+	ids := make([]uuid.UUID, 0, len(ops))
+	for _, op := range ops {
+		ids = append(ids, op.FileID)
+	}
+	files, err := s.fileRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load files: %w", err)
+	}
+	fileMap := make(map[uuid.UUID]*models.File, len(files))
+	for _, file := range files {
+		fileMap[file.ID] = file
+	}
+
+	results := make([]BatchResult, len(ops))
+	// 待永久删除的文件：storage key -> 结果下标
+	purgeIndex := make(map[string]int)
+	purgeFiles := make(map[uuid.UUID]*models.File)
+
+	for i, op := range ops {
+		results[i] = BatchResult{Index: i, Op: op.Op, FileID: op.FileID}
+
+		file, ok := fileMap[op.FileID]
+		if !ok {
+			results[i].Error = "file not found"
+			continue
+		}
+		if _, scheduled := purgeFiles[file.ID]; scheduled {
+			results[i].Error = "file is scheduled for permanent deletion"
+			continue
+		}
+
+		var opErr error
+		switch op.Op {
+		case BatchOpDelete:
+			if op.Permanent {
+				purgeFiles[file.ID] = file
+				purgeIndex[file.StorageKey] = i
+				continue
+			}
+			opErr = s.batchSoftDelete(ctx, file)
+		case BatchOpRestore:
+			opErr = s.batchRestore(ctx, file)
+		case BatchOpSetMetadata:
+			opErr = s.batchSetMetadata(ctx, file, op.Metadata, op.Tags)
+		case BatchOpMove:
+			opErr = s.batchMove(ctx, file, op.Folder)
+		case BatchOpCopy:
+			var copied *models.File
+			copied, opErr = s.batchCopy(ctx, file, op.Folder)
+			if opErr == nil {
+				results[i].NewFileID = &copied.ID
+			}
+		default:
+			opErr = fmt.Errorf("unsupported operation: %s", op.Op)
+		}
+
+		if opErr != nil {
+			results[i].Error = opErr.Error()
+			continue
+		}
+		results[i].Success = true
+	}
+
+	s.purgeFiles(ctx, purgeFiles, purgeIndex, results)
+
+	return results, nil
+}
+
+// purgeFiles 通过批量删除接口删除存储对象，并物理删除对应的数据库记录
+func (s *fileService) purgeFiles(ctx context.Context, files map[uuid.UUID]*models.File, index map[string]int, results []BatchResult) {
+	if len(files) == 0 {
+		return
+	}
+
+	// 未完成的分片上传先取消，否则已上传的分片会一直保留在存储桶中（取消失败时保留记录，可以重试）
+	aborted := make(map[string]bool)
+	for id, file := range files {
+		if file.UploadID == "" || file.Status != models.FileStatusUploading {
+			continue
+		}
+		if err := s.storage.AbortMultipartUpload(ctx, file.StorageKey, file.UploadID); err != nil {
+			results[index[file.StorageKey]].Error = fmt.Sprintf("failed to abort multipart upload: %v", err)
+			delete(index, file.StorageKey)
+			delete(files, id)
+			continue
+		}
+		aborted[file.StorageKey] = true
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	// 已取消的分片上传没有生成对象，无需删除
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		if !aborted[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	failed := make(map[string]error)
+	if len(keys) > 0 {
+		var err error
+		failed, err = s.storage.DeleteObjects(ctx, keys)
+		if err != nil {
+			// 整批删除失败时保留这些文件的记录，已取消的分片上传仍然删除记录
+			failed = make(map[string]error, len(keys))
+			for _, key := range keys {
+				failed[key] = err
+			}
+		}
+	}
+
+	deletedIDs := make([]uuid.UUID, 0, len(files))
+	for id, file := range files {
+		i := index[file.StorageKey]
+		if keyErr, ok := failed[file.StorageKey]; ok {
+			results[i].Error = fmt.Sprintf("failed to delete from storage: %v", keyErr)
+			continue
+		}
+		deletedIDs = append(deletedIDs, id)
+	}
+
+	err := s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.fileRepo.DeletePermanently(ctx, deletedIDs); err != nil {
+			return fmt.Errorf("failed to delete file record: %w", err)
+		}
+		for _, id := range deletedIDs {
+			// 已软删除的文件在软删除时已记录过 file.deleted 事件
+			if files[id].DeletedAt.Valid {
+				continue
+			}
+			if err := s.recordEvents(ctx, files[id], EventFileDeleted); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, id := range deletedIDs {
+			results[index[files[id].StorageKey]].Error = err.Error()
+		}
+		return
+	}
+	for _, id := range deletedIDs {
+		results[index[files[id].StorageKey]].Success = true
+	}
+}
+
+// batchSoftDelete 软删除文件记录（保留存储对象，可通过 restore 恢复）
+func (s *fileService) batchSoftDelete(ctx context.Context, file *models.File) error {
+	if file.DeletedAt.Valid {
+		return fmt.Errorf("file is already deleted")
+	}
+	err := s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.fileRepo.Delete(ctx, file.ID); err != nil {
+			return fmt.Errorf("failed to delete file record: %w", err)
+		}
+		return s.recordEvents(ctx, file, EventFileDeleted)
+	})
+	if err != nil {
+		return err
+	}
+	file.DeletedAt.Valid = true
+	return nil
+}
+
+// batchRestore 恢复软删除的文件
+func (s *fileService) batchRestore(ctx context.Context, file *models.File) error {
+	if !file.DeletedAt.Valid {
+		return fmt.Errorf("file is not deleted")
+	}
+	// 通过 DELETE /files/{id} 删除的文件已删除存储对象，恢复记录会得到没有内容的文件
+	if file.ObjectPurgedAt != nil {
+		return fmt.Errorf("file content has been deleted and cannot be restored")
+	}
+	if err := s.fileRepo.Restore(ctx, file.ID); err != nil {
+		return fmt.Errorf("failed to restore file record: %w", err)
+	}
+	file.DeletedAt.Valid = false
+	return nil
+}
+
+// batchSetMetadata 合并元数据并替换标签
+func (s *fileService) batchSetMetadata(ctx context.Context, file *models.File, metadata map[string]string, tags []string) error {
+	if file.DeletedAt.Valid {
+		return fmt.Errorf("file not found")
+	}
+	if len(metadata) == 0 && tags == nil {
+		return fmt.Errorf("metadata or tags is required")
+	}
+
+	if len(metadata) > 0 && file.Metadata == nil {
+		file.Metadata = models.Metadata{}
+	}
+	for k, v := range metadata {
+		if v == "" {
+			delete(file.Metadata, k)
+		} else {
+			file.Metadata[k] = v
+		}
+	}
+	if tags != nil {
+		file.Tags = normalizeTags(tags)
+	}
+
+	if err := s.fileRepo.Update(ctx, file); err != nil {
+		return fmt.Errorf("failed to update file: %w", err)
+	}
+	return nil
+}
+
+// batchMove 移动文件到其他目录（仅修改虚拟目录，存储对象不变）
+func (s *fileService) batchMove(ctx context.Context, file *models.File, folder string) error {
+	if file.DeletedAt.Valid {
+		return fmt.Errorf("file not found")
+	}
+	if folder == "" {
+		return fmt.Errorf("folder is required")
+	}
+
+	file.Folder = normalizeFolder(folder)
+	if err := s.fileRepo.Update(ctx, file); err != nil {
+		return fmt.Errorf("failed to update file: %w", err)
+	}
+	return nil
+}
+
+// batchCopy 复制文件（新建存储对象和文件记录）
+func (s *fileService) batchCopy(ctx context.Context, file *models.File, folder string) (*models.File, error) {
+	if file.DeletedAt.Valid {
+		return nil, fmt.Errorf("file not found")
+	}
+	return s.copyFile(ctx, file, CopyFileOptions{Folder: folder})
+}
+
+// normalizeFolder 规范化虚拟目录路径（以 / 开头，不以 / 结尾）
+func normalizeFolder(folder string) string {
+	folder = strings.ReplaceAll(folder, "\\", "/")
+	return path.Clean("/" + folder)
+}
+
+// normalizeTags 去除空白和重复的标签
+func normalizeTags(tags []string) models.Tags {
+	result := make(models.Tags, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !result.Has(tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// copyMetadata 复制元数据
+func copyMetadata(metadata models.Metadata) models.Metadata {
+	if metadata == nil {
+		return nil
+	}
+	result := make(models.Metadata, len(metadata))
+	for k, v := range metadata {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// newBatchTestFile 创建已上传完成的测试文件
+func newBatchTestFile(t *testing.T, repo *MockFileRepository, store *MockStorage, name string) *models.File {
+	t.Helper()
+
+	file := &models.File{
+		Name:        name,
+		Size:        5,
+		ContentType: "text/plain",
+		StorageKey:  "files/1700000000/" + name,
+		Status:      models.FileStatusCompleted,
+		Folder:      "/",
+	}
+	if err := repo.Create(context.Background(), file); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	store.objects[file.StorageKey] = []byte("hello")
+	return file
+}
+
+// TestBatchOperate 测试批量操作逐项执行与部分失败
+func TestBatchOperate(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	outbox := NewMockOutboxRepository()
+	svc := NewFileService(repo, outbox, store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	a := newBatchTestFile(t, repo, store, "a.txt")
+	b := newBatchTestFile(t, repo, store, "b.txt")
+	c := newBatchTestFile(t, repo, store, "c.txt")
+
+	results, err := svc.BatchOperate(ctx, []BatchOperation{
+		{Op: BatchOpMove, FileID: a.ID, Folder: "campaigns//2026/"},
+		{Op: BatchOpSetMetadata, FileID: a.ID, Metadata: map[string]string{"owner": "ops"}, Tags: []string{"banner", " banner ", ""}},
+		{Op: BatchOpCopy, FileID: a.ID},
+		{Op: BatchOpDelete, FileID: b.ID},
+		{Op: BatchOpRestore, FileID: b.ID},
+		{Op: BatchOpDelete, FileID: c.ID, Permanent: true},
+		{Op: BatchOpMove, FileID: c.ID, Folder: "/x"},
+		{Op: BatchOpDelete, FileID: uuid.New()},
+	})
+	if err != nil {
+		t.Fatalf("BatchOperate failed: %v", err)
+	}
+
+	wantSuccess := []bool{true, true, true, true, true, true, false, false}
+	for i, want := range wantSuccess {
+		if results[i].Success != want {
+			t.Errorf("result %d: success = %v, want %v (error: %s)", i, results[i].Success, want, results[i].Error)
+		}
+	}
+
+	if a.Folder != "/campaigns/2026" {
+		t.Errorf("folder = %q, want %q", a.Folder, "/campaigns/2026")
+	}
+	if len(a.Tags) != 1 || a.Tags[0] != "banner" || a.Metadata["owner"] != "ops" {
+		t.Errorf("unexpected tags/metadata: %v %v", a.Tags, a.Metadata)
+	}
+
+	// 复制生成新记录和新对象
+	if results[2].NewFileID == nil {
+		t.Fatalf("copy result has no new file ID")
+	}
+	copied := repo.files[*results[2].NewFileID]
+	if copied == nil || copied.StorageKey == a.StorageKey || string(store.objects[copied.StorageKey]) != "hello" {
+		t.Errorf("copy did not create an independent object: %+v", copied)
+	}
+
+	// 软删除后恢复
+	if b.DeletedAt.Valid {
+		t.Errorf("file b should be restored")
+	}
+
+	// 永久删除同时移除记录和对象
+	if _, ok := repo.files[c.ID]; ok {
+		t.Errorf("file c record should be deleted permanently")
+	}
+	if _, ok := store.objects[c.StorageKey]; ok {
+		t.Errorf("file c object should be deleted")
+	}
+
+	// 事件写入发件箱：复制（created + completed）、软删除、永久删除
+	want := []string{"file.created", "file.completed", "file.deleted", "file.deleted"}
+	if got := outbox.eventTypes(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("outbox events = %v, want %v", got, want)
+	}
+}
+
+// TestBatchRestorePurgedFile 测试通过 DeleteFile 删除（已删除存储对象）的文件不能恢复
+func TestBatchRestorePurgedFile(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	svc := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	file := newBatchTestFile(t, repo, store, "a.txt")
+	if err := svc.DeleteFile(ctx, file.ID); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+
+	results, err := svc.BatchOperate(ctx, []BatchOperation{{Op: BatchOpRestore, FileID: file.ID}})
+	if err != nil {
+		t.Fatalf("BatchOperate failed: %v", err)
+	}
+	if results[0].Success || !strings.Contains(results[0].Error, "cannot be restored") {
+		t.Errorf("restore result = %+v, want cannot be restored", results[0])
+	}
+	if !file.DeletedAt.Valid {
+		t.Error("file should stay deleted")
+	}
+}
+
+// TestBatchPurgeMultipartUpload 测试永久删除上传中的分片上传文件时取消分片上传，取消失败时保留文件记录
+func TestBatchPurgeMultipartUpload(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	svc := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	uploading, err := svc.InitMultipartUpload(ctx, "video.mp4", "video/mp4", 1<<30, "", nil)
+	if err != nil {
+		t.Fatalf("InitMultipartUpload failed: %v", err)
+	}
+	completed := newBatchTestFile(t, repo, store, "a.txt")
+	completed.UploadID = "finished-upload-id"
+
+	store.abortErr = errors.New("service unavailable")
+	results, err := svc.BatchOperate(ctx, []BatchOperation{{Op: BatchOpDelete, FileID: uploading.FileID, Permanent: true}})
+	if err != nil {
+		t.Fatalf("BatchOperate failed: %v", err)
+	}
+	if results[0].Success || !strings.Contains(results[0].Error, "abort multipart upload") {
+		t.Errorf("result = %+v, want abort failure", results[0])
+	}
+	if file, _ := repo.GetByID(ctx, uploading.FileID); file == nil {
+		t.Error("file record should be kept when the abort fails")
+	}
+
+	store.abortErr = nil
+	results, err = svc.BatchOperate(ctx, []BatchOperation{
+		{Op: BatchOpDelete, FileID: uploading.FileID, Permanent: true},
+		{Op: BatchOpDelete, FileID: completed.ID, Permanent: true},
+	})
+	if err != nil {
+		t.Fatalf("BatchOperate failed: %v", err)
+	}
+	for i, result := range results {
+		if !result.Success {
+			t.Errorf("result %d = %+v, want success", i, result)
+		}
+	}
+	if len(store.aborted) != 1 || store.aborted[uploading.StorageKey] != "mock-upload-id" {
+		t.Errorf("aborted uploads = %v, want only the uploading file", store.aborted)
+	}
+	if file, _ := repo.GetByID(ctx, uploading.FileID); file != nil {
+		t.Error("file record should be deleted")
+	}
+}
+
+// TestCopyFileToTenant 测试复制到其他租户的存储键前缀
+func TestCopyFileToTenant(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	file := newBatchTestFile(t, repo, store, "a.txt")
+
+	plain := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+	if _, err := plain.CopyFile(ctx, file.ID, CopyFileOptions{Tenant: "globex"}); err == nil || !strings.Contains(err.Error(), "has no {tenant}") {
+		t.Errorf("copy without {tenant} in template error = %v", err)
+	}
+
+	keys, err := storage.ParseKeyTemplate("{tenant}/{uuid}{ext}", "acme")
+	if err != nil {
+		t.Fatalf("ParseKeyTemplate failed: %v", err)
+	}
+	svc := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{Keys: keys})
+	if _, err := svc.CopyFile(ctx, file.ID, CopyFileOptions{Tenant: "../acme"}); err == nil || !strings.Contains(err.Error(), "invalid tenant") {
+		t.Errorf("copy to invalid tenant error = %v", err)
+	}
+
+	// 副本沿用源文件的内容类型识别和扫描结果
+	scannedAt := time.Now()
+	file.DetectedContentType = "application/x-msdownload"
+	file.ContentTypeMismatch = true
+	file.ScanResult = models.ScanResultClean
+	file.ScanEngine = "ClamAV 1.2.1/27120"
+	file.ScannedAt = &scannedAt
+
+	copied, err := svc.CopyFile(ctx, file.ID, CopyFileOptions{Tenant: "globex"})
+	if err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+	if copied.StorageKey != "globex/"+copied.ID.String()+".txt" || string(store.objects[copied.StorageKey]) != "hello" {
+		t.Errorf("copied key = %q", copied.StorageKey)
+	}
+	if copied.DetectedContentType != file.DetectedContentType || !copied.ContentTypeMismatch ||
+		copied.ScanResult != models.ScanResultClean || copied.ScanEngine != file.ScanEngine || copied.ScannedAt != file.ScannedAt {
+		t.Errorf("copy lost sniff/scan fields: %+v", copied)
+	}
+}
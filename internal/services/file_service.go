@@ -3,62 +3,113 @@ package services
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
-	"path/filepath"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/NanoBoom/asethub/internal/config"
 	"github.com/NanoBoom/asethub/internal/models"
 	"github.com/NanoBoom/asethub/internal/repositories"
 	"github.com/NanoBoom/asethub/pkg/storage"
 	"github.com/NanoBoom/asethub/pkg/utils"
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 // FileService 文件服务接口
 type FileService interface {
 	// UploadDirect 直接上传小文件（后端代理）
-	UploadDirect(ctx context.Context, name string, contentType string, size int64, reader io.Reader) (*models.File, error)
+	// checksum 不为 nil 时校验接收到的内容，不一致时删除对象并返回错误；tags 为文件标签（同时用于匹配存储放置规则）
+	UploadDirect(ctx context.Context, name string, contentType string, size int64, reader io.Reader, checksum *storage.Checksum, tags []string) (*models.File, error)
 
 	// InitPresignedUpload 生成小文件上传预签名 URL
-	InitPresignedUpload(ctx context.Context, name string, contentType string, size int64) (*PresignedUploadResult, error)
+	// expiry 为 0 时使用默认有效期；checksum 不为 nil 时签名到 URL，存储服务拒绝内容不一致的上传
+	InitPresignedUpload(ctx context.Context, name string, contentType string, size int64, expiry time.Duration, checksum *storage.Checksum, tags []string) (*PresignedUploadResult, error)
+
+	// InitPresignedPost 生成小文件预签名 POST 表单上传策略（由存储服务强制校验文件大小和类型）
+	// expiry 为 0 时使用默认有效期
+	InitPresignedPost(ctx context.Context, name string, contentType string, size int64, expiry time.Duration, checksum *storage.Checksum, tags []string) (*PresignedUploadResult, error)
 
 	// ConfirmUpload 确认前端直传完成
 	ConfirmUpload(ctx context.Context, fileID uuid.UUID) (*models.File, error)
 
+	// ConfirmStoredObject 根据存储事件通知确认预签名上传（按存储键匹配，写入实际大小和 ETag）
+	ConfirmStoredObject(ctx context.Context, storageKey string, size int64, etag string) (*models.File, error)
+
 	// InitMultipartUpload 初始化大文件分片上传
-	InitMultipartUpload(ctx context.Context, name string, contentType string, size int64) (*MultipartUploadResult, error)
+	// algorithm 不为空时每个分片须声明校验值，完成时校验组合校验值
+	InitMultipartUpload(ctx context.Context, name string, contentType string, size int64, algorithm storage.ChecksumAlgorithm, tags []string) (*MultipartUploadResult, error)
 
 	// GeneratePartUploadURL 生成分片上传预签名 URL
-	GeneratePartUploadURL(ctx context.Context, fileID uuid.UUID, partNumber int) (string, error)
+	// expiry 为 0 时使用默认有效期；checksum 为分片校验值（初始化时声明了校验算法时必填）
+	GeneratePartUploadURL(ctx context.Context, fileID uuid.UUID, partNumber int, expiry time.Duration, checksum *storage.Checksum) (*PresignedURLResult, error)
 
 	// CompleteMultipartUpload 完成大文件分片上传
 	CompleteMultipartUpload(ctx context.Context, fileID uuid.UUID, parts []storage.CompletedPart) (*models.File, error)
 
+	// AbortMultipartUpload 取消大文件分片上传（清理已上传的分片，文件标记为失败）
+	AbortMultipartUpload(ctx context.Context, fileID uuid.UUID) (*models.File, error)
+
 	// DownloadFile 直接下载文件内容（流式传输）
-	DownloadFile(ctx context.Context, fileID uuid.UUID) (io.ReadCloser, *models.File, error)
+	// acceptEncoding 为客户端的 Accept-Encoding，压缩保存的文件在客户端接受该编码时直接返回压缩内容
+	DownloadFile(ctx context.Context, fileID uuid.UUID, acceptEncoding string) (*DownloadResult, error)
 
-	// GetDownloadURL 生成下载预签名 URL
-	GetDownloadURL(ctx context.Context, fileID uuid.UUID, expiry time.Duration) (string, error)
+	// GetDownloadURL 生成下载预签名 URL（可指定有效期、Content-Disposition、文件名和 Cache-Control）
+	GetDownloadURL(ctx context.Context, fileID uuid.UUID, opts DownloadURLOptions) (*PresignedURLResult, error)
 
 	// DeleteFile 删除文件（S3 + 数据库）
 	DeleteFile(ctx context.Context, fileID uuid.UUID) error
 
+	// CopyFile 服务端复制文件（不经过后端中转数据），生成新的文件记录
+	CopyFile(ctx context.Context, fileID uuid.UUID, opts CopyFileOptions) (*models.File, error)
+
 	// GetFile 获取文件信息
 	GetFile(ctx context.Context, fileID uuid.UUID) (*models.File, error)
 
 	// ListFiles 分页查询文件列表
 	ListFiles(ctx context.Context, offset, limit int) ([]*models.File, int64, error)
+
+	// BatchOperate 批量执行文件操作（删除、恢复、设置元数据、移动、复制），逐项返回结果
+	BatchOperate(ctx context.Context, ops []BatchOperation) ([]BatchResult, error)
 }
 
 // PresignedUploadResult 预签名上传结果
 type PresignedUploadResult struct {
-	FileID     uuid.UUID `json:"file_id"`
-	UploadURL  string    `json:"upload_url"`
-	StorageKey string    `json:"storage_key"`
-	ExpiresIn  int64     `json:"expires_in"` // 秒
+	FileID     uuid.UUID         `json:"file_id"`
+	UploadURL  string            `json:"upload_url"`
+	Method     string            `json:"method"`            // PUT（预签名 URL）或 POST（表单策略）
+	Headers    map[string]string `json:"headers,omitempty"` // PUT 请求须携带的已签名请求头（Content-Type、校验值、加密头）
+	Fields     map[string]string `json:"fields,omitempty"`  // POST 表单字段（须在 file 字段之前提交）
+	StorageKey string            `json:"storage_key"`
+	ExpiresIn  int64             `json:"expires_in"` // 秒
+}
+
+// PresignedURLResult 预签名 URL 结果
+type PresignedURLResult struct {
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"` // 须携带的已签名请求头（如 SSE-C 密钥头）
+	ExpiresIn int64             `json:"expires_in"`        // 秒
+}
+
+// DownloadResult 直接下载结果
+type DownloadResult struct {
+	Reader          io.ReadCloser // 文件内容流（使用后必须关闭）
+	File            *models.File
+	ContentEncoding string // 非空时 Reader 为按该编码压缩的内容
+	Size            int64  // Reader 的字节数
+}
+
+// DownloadURLOptions 下载 URL 选项（零值使用默认行为）
+type DownloadURLOptions struct {
+	Expiry       time.Duration // 有效期（0 使用 presign.download_expiry）
+	Disposition  string        // "inline" 或 "attachment"（为空时按文件类型决定）
+	Filename     string        // 下载文件名（为空时使用原文件名）
+	CacheControl string        // 覆盖响应 Cache-Control（为空时使用对象元数据）
 }
 
 // MultipartUploadResult 分片上传初始化结果
@@ -68,42 +119,170 @@ type MultipartUploadResult struct {
 	StorageKey string    `json:"storage_key"`
 }
 
+// CopyFileOptions 复制文件选项
+type CopyFileOptions struct {
+	Name   string // 新文件名（为空时与源文件相同）
+	Folder string // 目标目录（为空时与源文件相同）
+	Tenant string // 目标租户（替换存储键模板中的 {tenant}，为空时使用 storage.key_tenant）
+}
+
+// DownloadPolicy 下载策略
+type DownloadPolicy struct {
+	RequireScan bool // 仅允许下载恶意文件扫描结果为 clean 的文件
+}
+
+// checkDownloadable 检查文件是否允许下载（直接下载、预签名链接和打包下载共用）
+// 扫描结果为 infected 的文件无论策略和状态如何都不允许下载
+func checkDownloadable(file *models.File, policy DownloadPolicy) error {
+	if file.Status == models.FileStatusQuarantined || file.ScanResult == models.ScanResultInfected {
+		return fmt.Errorf("file is quarantined: %s", file.ScanSignature)
+	}
+	if file.Status != models.FileStatusCompleted {
+		return fmt.Errorf("file is not ready for download")
+	}
+	if policy.RequireScan && file.ScanResult != models.ScanResultClean {
+		return fmt.Errorf("file has not passed malware scan")
+	}
+	if RequiresRestore(file, time.Now()) {
+		return fmt.Errorf("file is archived (storage class %s): restore it before downloading", file.StorageClass)
+	}
+	return nil
+}
+
+// maxCacheControlLength 客户端指定的 Cache-Control 最大长度
+const maxCacheControlLength = 256
+
+// DispositionType 返回下载时使用的 Content-Disposition 类型
+// 可预览的文件使用 inline；内容类型校验不一致的文件强制 attachment，避免浏览器按声明类型渲染
+func DispositionType(file *models.File) string {
+	if !file.ContentTypeMismatch && utils.IsPreviewable(file.ContentType) {
+		return "inline"
+	}
+	return "attachment"
+}
+
 // fileService 文件服务实现
 type fileService struct {
-	fileRepo repositories.FileRepository
-	storage  storage.Storage
-	db       *gorm.DB
+	fileRepo   repositories.FileRepository
+	outboxRepo repositories.OutboxRepository
+	storage    storage.Storage
+	transactor repositories.Transactor
+	policy     DownloadPolicy
+	sniff      config.ContentSniffConfig
+	presign    config.PresignConfig
+	keys       *storage.KeyTemplate
+	stats      DownloadStatsRecorder
+	encryption *storage.EncryptionPolicy
+}
+
+// FileServiceOptions 文件服务的可选依赖（零值均可用）
+type FileServiceOptions struct {
+	Sniff      config.ContentSniffConfig // 预签名上传和分片上传完成时读取文件头校验真实内容类型（为空时不校验）
+	Presign    config.PresignConfig      // 预签名 URL 有效期（未配置的使用默认值：上传 1 小时、下载 15 分钟、范围 1 分钟 ~ 7 天）
+	Keys       *storage.KeyTemplate      // 存储键模板（为 nil 时使用默认模板）
+	Stats      DownloadStatsRecorder     // 下载统计（为 nil 时只直接更新文件的最近访问时间）
+	Encryption *storage.EncryptionPolicy // 服务端加密策略（为 nil 时视为不加密，SSE-KMS / SSE-C 对象完成分片上传时不比对分片 ETag）
 }
 
 // NewFileService 创建文件服务实例
-func NewFileService(fileRepo repositories.FileRepository, storage storage.Storage, db *gorm.DB) FileService {
+// 文件状态变更与生命周期事件（outbox_events）在同一事务中写入，由 OutboxRelay 异步发布
+func NewFileService(fileRepo repositories.FileRepository, outboxRepo repositories.OutboxRepository, storage storage.Storage, transactor repositories.Transactor, policy DownloadPolicy, opts FileServiceOptions) FileService {
 	return &fileService{
-		fileRepo: fileRepo,
-		storage:  storage,
-		db:       db,
+		fileRepo:   fileRepo,
+		outboxRepo: outboxRepo,
+		storage:    storage,
+		transactor: transactor,
+		policy:     policy,
+		sniff:      opts.Sniff,
+		presign:    presignConfigOrDefault(opts.Presign),
+		keys:       keyTemplateOrDefault(opts.Keys),
+		stats:      opts.Stats,
+		encryption: opts.Encryption,
 	}
 }
 
-// generateStorageKey 生成存储键（确保始终带扩展名）
-// 参数：
-//   - name: 原始文件名
-//   - contentType: MIME 类型（用于推断扩展名）
-func (s *fileService) generateStorageKey(name string, contentType string) string {
-	timestamp := time.Now().Unix()
+// recordEvents 将文件事件写入发件箱（需在 transactor 事务中调用，与文件状态变更一起提交或回滚）
+func (s *fileService) recordEvents(ctx context.Context, file *models.File, eventTypes ...EventType) error {
+	return recordFileEvents(ctx, s.outboxRepo, file, eventTypes...)
+}
+
+// recordFileEvents 将文件事件写入发件箱
+func recordFileEvents(ctx context.Context, outboxRepo repositories.OutboxRepository, file *models.File, eventTypes ...EventType) error {
+	for _, eventType := range eventTypes {
+		event, err := NewFileEvent(eventType, file).OutboxEvent()
+		if err != nil {
+			return err
+		}
+		if err := outboxRepo.Create(ctx, event); err != nil {
+			return fmt.Errorf("failed to record %s event: %w", eventType, err)
+		}
+	}
+	return nil
+}
 
-	// 优先使用文件名中的扩展名
-	ext := filepath.Ext(name)
+// newStorageKey 按存储键模板为文件生成存储键（需先生成文件 ID，ID 保证存储键唯一）
+func newStorageKey(keys *storage.KeyTemplate, file *models.File) string {
+	return newTenantStorageKey(keys, file, "")
+}
+
+// newTenantStorageKey 按模板生成指定租户下的存储键（tenant 为空时使用 storage.key_tenant）
+func newTenantStorageKey(keys *storage.KeyTemplate, file *models.File, tenant string) string {
+	return keys.Render(storage.KeyParams{
+		ID:          file.ID,
+		Name:        file.Name,
+		ContentType: file.ContentType,
+		Folder:      file.Folder,
+		Time:        time.Now(),
+		Tenant:      tenant,
+	})
+}
+
+// presignConfigOrDefault 为未配置的预签名有效期填充默认值
+func presignConfigOrDefault(cfg config.PresignConfig) config.PresignConfig {
+	if cfg.UploadExpiry <= 0 {
+		cfg.UploadExpiry = time.Hour
+	}
+	if cfg.DownloadExpiry <= 0 {
+		cfg.DownloadExpiry = 15 * time.Minute
+	}
+	if cfg.MinExpiry <= 0 {
+		cfg.MinExpiry = time.Minute
+	}
+	if cfg.MaxExpiry <= 0 {
+		cfg.MaxExpiry = 7 * 24 * time.Hour
+	}
+	return cfg
+}
 
-	// 如果文件名没有扩展名，从 Content-Type 推断
-	if ext == "" {
-		ext = utils.GetExtensionFromMIME(contentType)
+// presignExpiry 计算预签名 URL 有效期：未指定时使用默认值，指定时须在 [MinExpiry, MaxExpiry] 范围内
+func (s *fileService) presignExpiry(requested, fallback time.Duration) (time.Duration, error) {
+	if requested == 0 {
+		return fallback, nil
+	}
+	if requested < s.presign.MinExpiry || requested > s.presign.MaxExpiry {
+		return 0, fmt.Errorf("invalid expiry: must be between %d and %d seconds",
+			int64(s.presign.MinExpiry.Seconds()), int64(s.presign.MaxExpiry.Seconds()))
 	}
+	return requested, nil
+}
 
-	return fmt.Sprintf("files/%d/%s%s", timestamp, fmt.Sprintf("%d", timestamp), ext)
+// keyTemplateOrDefault 未配置存储键模板时使用默认模板
+func keyTemplateOrDefault(keys *storage.KeyTemplate) *storage.KeyTemplate {
+	if keys != nil {
+		return keys
+	}
+	keys, err := storage.ParseKeyTemplate(storage.DefaultKeyTemplate, "")
+	if err != nil {
+		panic(err)
+	}
+	return keys
 }
 
 // UploadDirect 直接上传小文件（后端代理）
-func (s *fileService) UploadDirect(ctx context.Context, name string, contentType string, size int64, reader io.Reader) (*models.File, error) {
+func (s *fileService) UploadDirect(ctx context.Context, name string, contentType string, size int64, reader io.Reader, checksum *storage.Checksum, tags []string) (*models.File, error) {
+	// 清理文件名（去除路径和控制字符，保留中文等非 ASCII 字符）
+	name = utils.SanitizeFilename(name)
+
 	// 检测 Content-Type（读取前 512 字节）
 	buffer := make([]byte, 512)
 	n, err := reader.Read(buffer)
@@ -120,62 +299,148 @@ func (s *fileService) UploadDirect(ctx context.Context, name string, contentType
 	// 创建新的 reader，包含已读取的 buffer 和剩余内容
 	multiReader := io.MultiReader(bytes.NewReader(buffer[:n]), reader)
 
-	// 生成存储键（传入 contentType 以确保有扩展名）
-	storageKey := s.generateStorageKey(name, contentType)
+	// 声明了校验值时边上传边计算摘要
+	var hasher hash.Hash
+	if checksum != nil {
+		hasher = storage.NewChecksumHash(checksum.Algorithm)
+		multiReader = io.TeeReader(multiReader, hasher)
+	}
 
-	// 创建文件记录
+	// 创建文件记录（按存储键模板生成存储键）
 	file := &models.File{
+		BaseModel:   models.BaseModel{ID: uuid.New()},
 		Name:        name,
 		Size:        size,
 		ContentType: contentType,
-		StorageKey:  storageKey,
 		Status:      models.FileStatusPending,
+		Tags:        normalizeTags(tags),
 	}
+	file.StorageKey = newStorageKey(s.keys, file)
+	storageKey := file.StorageKey
+
+	// 在事务中创建记录、上传并写入事件
+	uploaded := false
+	err = s.transactor.Transaction(withObjectHints(ctx, file), func(ctx context.Context) error {
+		// 创建数据库记录
+		if err := s.fileRepo.Create(ctx, file); err != nil {
+			return fmt.Errorf("failed to create file record: %w", err)
+		}
+
+		// 上传到 S3（使用 multiReader 包含完整内容，并设置 Content-Type）
+		if err := s.storage.Upload(ctx, storageKey, multiReader, size, contentType); err != nil {
+			return fmt.Errorf("failed to upload to storage: %w", err)
+		}
+		uploaded = true
+
+		// 校验接收到的内容（不一致时回滚并删除已上传的对象）
+		if hasher != nil {
+			computed := base64.StdEncoding.EncodeToString(hasher.Sum(nil))
+			if computed != checksum.Value {
+				return fmt.Errorf("checksum mismatch: declared %s %s, computed %s", checksum.Algorithm, checksum.Value, computed)
+			}
+			file.ChecksumAlgorithm = string(checksum.Algorithm)
+			file.Checksum = computed
+		}
 
-	// 开启事务
-	tx := s.db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+		// 更新状态为已完成
+		file.Status = models.FileStatusCompleted
+		if err := s.fileRepo.Update(ctx, file); err != nil {
+			return fmt.Errorf("failed to update file status: %w", err)
+		}
+
+		return s.recordEvents(ctx, file, EventFileCreated, EventFileCompleted)
+	})
+	if err != nil {
+		// 事务回滚后尝试删除已上传的 S3 文件
+		if uploaded {
+			_ = s.storage.Delete(ctx, storageKey)
 		}
-	}()
+		return nil, err
+	}
+
+	return file, nil
+}
 
-	// 创建数据库记录
-	if err := tx.Create(file).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to create file record: %w", err)
+// InitPresignedUpload 生成小文件上传预签名 URL
+func (s *fileService) InitPresignedUpload(ctx context.Context, name string, contentType string, size int64, expiry time.Duration, checksum *storage.Checksum, tags []string) (*PresignedUploadResult, error) {
+	expiry, err := s.presignExpiry(expiry, s.presign.UploadExpiry)
+	if err != nil {
+		return nil, err
 	}
 
-	// 上传到 S3（使用 multiReader 包含完整内容，并设置 Content-Type）
-	if err := s.storage.Upload(ctx, storageKey, multiReader, size, contentType); err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to upload to storage: %w", err)
+	file := newPendingUpload(s.keys, name, contentType, size, checksum, tags)
+
+	// 生成预签名 URL（传递 contentType 和校验值确保签名一致，存储服务拒绝内容不一致的上传）
+	var presigned *storage.PresignedRequest
+	err = s.createPendingFile(ctx, file, func(ctx context.Context) error {
+		presigned, err = s.storage.GeneratePresignedUploadURL(ctx, file.StorageKey, expiry, file.ContentType, checksum)
+		if err != nil {
+			return fmt.Errorf("failed to generate presigned URL: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 更新状态为已完成
-	file.Status = models.FileStatusCompleted
-	if err := tx.Save(file).Error; err != nil {
-		tx.Rollback()
-		// 尝试删除 S3 文件
-		_ = s.storage.Delete(ctx, storageKey)
-		return nil, fmt.Errorf("failed to update file status: %w", err)
+	return &PresignedUploadResult{
+		FileID:     file.ID,
+		UploadURL:  presigned.URL,
+		Method:     http.MethodPut,
+		Headers:    presigned.Headers,
+		StorageKey: file.StorageKey,
+		ExpiresIn:  int64(expiry.Seconds()),
+	}, nil
+}
+
+// InitPresignedPost 生成小文件预签名 POST 表单上传策略
+func (s *fileService) InitPresignedPost(ctx context.Context, name string, contentType string, size int64, expiry time.Duration, checksum *storage.Checksum, tags []string) (*PresignedUploadResult, error) {
+	expiry, err := s.presignExpiry(expiry, s.presign.UploadExpiry)
+	if err != nil {
+		return nil, err
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	file := newPendingUpload(s.keys, name, contentType, size, checksum, tags)
+
+	// 策略条件来自文件记录：精确的存储键、Content-Type、声明的文件大小和校验值
+	var post *storage.PresignedPost
+	err = s.createPendingFile(ctx, file, func(ctx context.Context) error {
+		post, err = s.storage.GeneratePresignedPost(ctx, file.StorageKey, expiry, storage.PostPolicy{
+			ContentType: file.ContentType,
+			MinSize:     file.Size,
+			MaxSize:     file.Size,
+			Checksum:    checksum,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate presigned post: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return file, nil
+	return &PresignedUploadResult{
+		FileID:     file.ID,
+		UploadURL:  post.URL,
+		Method:     http.MethodPost,
+		Fields:     post.Fields,
+		StorageKey: file.StorageKey,
+		ExpiresIn:  int64(expiry.Seconds()),
+	}, nil
 }
 
-// InitPresignedUpload 生成小文件上传预签名 URL
-func (s *fileService) InitPresignedUpload(ctx context.Context, name string, contentType string, size int64) (*PresignedUploadResult, error) {
+// newPendingUpload 构造等待前端直传的文件记录（预签名 PUT 和 POST 共用）
+// 存储服务按签名的校验值校验上传内容，因此声明的校验值直接记录为文件校验值
+func newPendingUpload(keys *storage.KeyTemplate, name string, contentType string, size int64, checksum *storage.Checksum, tags []string) *models.File {
+	// 清理文件名（去除路径和控制字符，保留中文等非 ASCII 字符）
+	name = utils.SanitizeFilename(name)
+
 	// 根据文件名推断 Content-Type（不信任前端输入）
 	detectedType := utils.DetectContentTypeFromFilename(name)
 
 	// 如果前端提供了 Content-Type，验证是否匹配
-	if contentType != "" && contentType != "application/octet-stream" {
+	if !utils.IsGenericContentType(contentType) {
 		matches, _ := utils.ValidateContentType(name, contentType)
 		if !matches {
 			// 前端提供的类型与文件扩展名不匹配，使用推断的类型
@@ -186,38 +451,44 @@ func (s *fileService) InitPresignedUpload(ctx context.Context, name string, cont
 		contentType = detectedType
 	}
 
-	// 生成存储键（传入 contentType 以确保有扩展名）
-	storageKey := s.generateStorageKey(name, contentType)
-
-	// 创建文件记录
+	// 创建文件记录（按存储键模板生成存储键）
 	file := &models.File{
+		BaseModel:   models.BaseModel{ID: uuid.New()},
 		Name:        name,
 		Size:        size,
 		ContentType: contentType,
-		StorageKey:  storageKey,
 		Status:      models.FileStatusPending,
+		Tags:        normalizeTags(tags),
 	}
-
-	if err := s.fileRepo.Create(ctx, file); err != nil {
-		return nil, fmt.Errorf("failed to create file record: %w", err)
+	file.StorageKey = newStorageKey(keys, file)
+	if checksum != nil {
+		file.ChecksumAlgorithm = string(checksum.Algorithm)
+		file.Checksum = checksum.Value
 	}
 
-	// 生成预签名 URL（1 小时有效期，传递 contentType 确保签名一致）
-	expiry := 1 * time.Hour
-	uploadURL, err := s.storage.GeneratePresignedUploadURL(ctx, storageKey, expiry, contentType)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate presigned URL: %w", err)
-	}
+	return file
+}
 
-	return &PresignedUploadResult{
-		FileID:     file.ID,
-		UploadURL:  uploadURL,
-		StorageKey: storageKey,
-		ExpiresIn:  int64(expiry.Seconds()),
-	}, nil
+// createPendingFile 在事务中创建文件记录、生成上传凭证并写入 file.created 事件
+// 凭证在记录创建之后生成，存储层可以把放置的后端记录到该文件上；生成失败时记录随事务回滚
+func (s *fileService) createPendingFile(ctx context.Context, file *models.File, sign func(ctx context.Context) error) error {
+	return s.transactor.Transaction(withObjectHints(ctx, file), func(ctx context.Context) error {
+		if err := s.fileRepo.Create(ctx, file); err != nil {
+			return fmt.Errorf("failed to create file record: %w", err)
+		}
+		if err := sign(ctx); err != nil {
+			return err
+		}
+		return s.recordEvents(ctx, file, EventFileCreated)
+	})
 }
 
-// ConfirmUpload 确认前端直传完成
+// withObjectHints 将文件大小和标签作为存储放置提示放入上下文
+func withObjectHints(ctx context.Context, file *models.File) context.Context {
+	return storage.WithObjectHints(ctx, storage.ObjectHints{Size: file.Size, Tags: file.Tags})
+}
+
+// ConfirmUpload 确认前端直传完成（仅处理等待确认的文件，避免已隔离或失败的文件被重新标记为已完成）
 func (s *fileService) ConfirmUpload(ctx context.Context, fileID uuid.UUID) (*models.File, error) {
 	// 查询文件记录
 	file, err := s.fileRepo.GetByID(ctx, fileID)
@@ -225,22 +496,123 @@ func (s *fileService) ConfirmUpload(ctx context.Context, fileID uuid.UUID) (*mod
 		return nil, fmt.Errorf("file not found: %w", err)
 	}
 
+	if file.Status != models.FileStatusPending {
+		return nil, fmt.Errorf("file is not awaiting confirmation (status: %s)", file.Status)
+	}
+
 	// 更新状态为已完成
-	file.Status = models.FileStatusCompleted
-	if err := s.fileRepo.Update(ctx, file); err != nil {
-		return nil, fmt.Errorf("failed to update file status: %w", err)
+	if err := s.completeFile(ctx, file); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// ConfirmStoredObject 根据存储事件通知确认预签名上传
+// 仅处理等待确认（pending）的文件；分片上传由 CompleteMultipartUpload 完成，不在此处确认
+func (s *fileService) ConfirmStoredObject(ctx context.Context, storageKey string, size int64, etag string) (*models.File, error) {
+	file, err := s.fileRepo.GetByStorageKey(ctx, storageKey)
+	if err != nil || file == nil {
+		return nil, fmt.Errorf("file not found: %s", storageKey)
+	}
+
+	if file.Status != models.FileStatusPending {
+		return nil, fmt.Errorf("file is not awaiting confirmation (status: %s)", file.Status)
+	}
+
+	// 使用存储中的实际大小和 ETag
+	file.Size = size
+	file.ETag = strings.Trim(etag, `"`)
+	if err := s.completeFile(ctx, file); err != nil {
+		return nil, err
 	}
 
 	return file, nil
 }
 
+// completeFile 校验内容类型后将文件标记为已完成并写入 file.completed 事件
+func (s *fileService) completeFile(ctx context.Context, file *models.File) error {
+	if err := s.verifyContent(ctx, file); err != nil {
+		return err
+	}
+
+	return s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		file.Status = models.FileStatusCompleted
+		if err := s.fileRepo.Update(ctx, file); err != nil {
+			return fmt.Errorf("failed to update file status: %w", err)
+		}
+		return s.recordEvents(ctx, file, EventFileCompleted)
+	})
+}
+
+// verifyContent 读取文件头识别真实内容类型，与声明类型比对
+// flag 模式下记录不一致（下载时强制 attachment）；reject 模式下将文件标记为失败、删除对象并返回错误
+func (s *fileService) verifyContent(ctx context.Context, file *models.File) error {
+	if s.sniff.Mode == "" || s.sniff.Mode == config.ContentSniffOff {
+		return nil
+	}
+
+	reader, err := s.storage.GetObjectRange(ctx, file.StorageKey, 0, s.sniff.ReadBytes)
+	if err != nil {
+		return fmt.Errorf("failed to read file header: %w", err)
+	}
+	head, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read file header: %w", err)
+	}
+
+	detected := utils.SniffContentType(head)
+	file.DetectedContentType = detected
+	file.ContentTypeMismatch = !utils.ContentTypeMatches(file.ContentType, detected)
+
+	if !file.ContentTypeMismatch {
+		// 未声明具体类型时采用识别结果
+		if detected != "" && utils.IsGenericContentType(file.ContentType) {
+			file.ContentType = detected
+		}
+		return nil
+	}
+
+	if s.sniff.Mode != config.ContentSniffReject {
+		return nil
+	}
+
+	err = s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		file.Status = models.FileStatusFailed
+		if err := s.fileRepo.Update(ctx, file); err != nil {
+			return fmt.Errorf("failed to update file status: %w", err)
+		}
+		return s.recordEvents(ctx, file, EventFileRejected)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 对象删除失败不影响结果（文件已标记为失败，不可下载）
+	_ = s.storage.Delete(ctx, file.StorageKey)
+
+	return fmt.Errorf("content type mismatch: declared %s, detected %s", file.ContentType, detectedOrUnknown(detected))
+}
+
+// detectedOrUnknown 识别结果为空时返回 unknown（用于错误信息）
+func detectedOrUnknown(detected string) string {
+	if detected == "" {
+		return "unknown"
+	}
+	return detected
+}
+
 // InitMultipartUpload 初始化大文件分片上传
-func (s *fileService) InitMultipartUpload(ctx context.Context, name string, contentType string, size int64) (*MultipartUploadResult, error) {
+func (s *fileService) InitMultipartUpload(ctx context.Context, name string, contentType string, size int64, algorithm storage.ChecksumAlgorithm, tags []string) (*MultipartUploadResult, error) {
+	// 清理文件名（去除路径和控制字符，保留中文等非 ASCII 字符）
+	name = utils.SanitizeFilename(name)
+
 	// 根据文件名推断 Content-Type（与预签名上传保持一致）
 	detectedType := utils.DetectContentTypeFromFilename(name)
 
 	// 如果前端提供了 Content-Type，验证是否匹配
-	if contentType != "" && contentType != "application/octet-stream" {
+	if !utils.IsGenericContentType(contentType) {
 		matches, _ := utils.ValidateContentType(name, contentType)
 		if !matches {
 			contentType = detectedType
@@ -249,56 +621,75 @@ func (s *fileService) InitMultipartUpload(ctx context.Context, name string, cont
 		contentType = detectedType
 	}
 
-	// 生成存储键（传入 contentType 以确保有扩展名）
-	storageKey := s.generateStorageKey(name, contentType)
-
-	// 初始化 S3 分片上传（传递 Content-Type）
-	multipartUpload, err := s.storage.InitMultipartUpload(ctx, storageKey, contentType)
-	if err != nil {
-		return nil, fmt.Errorf("failed to init multipart upload: %w", err)
-	}
-
-	// 创建文件记录
+	// 创建文件记录（按存储键模板生成存储键）
 	file := &models.File{
+		BaseModel:   models.BaseModel{ID: uuid.New()},
 		Name:        name,
 		Size:        size,
 		ContentType: contentType,
-		StorageKey:  storageKey,
 		Status:      models.FileStatusUploading,
-		UploadID:    multipartUpload.UploadID,
+		Tags:        normalizeTags(tags),
+		// 组合校验值在完成时计算
+		ChecksumAlgorithm: string(algorithm),
 	}
+	file.StorageKey = newStorageKey(s.keys, file)
+	storageKey := file.StorageKey
 
-	if err := s.fileRepo.Create(ctx, file); err != nil {
-		return nil, fmt.Errorf("failed to create file record: %w", err)
+	// 在事务中创建记录、初始化分片上传（传递 Content-Type 和校验算法）并写入事件
+	err := s.transactor.Transaction(withObjectHints(ctx, file), func(ctx context.Context) error {
+		if err := s.fileRepo.Create(ctx, file); err != nil {
+			return fmt.Errorf("failed to create file record: %w", err)
+		}
+
+		multipartUpload, err := s.storage.InitMultipartUpload(ctx, storageKey, contentType, algorithm)
+		if err != nil {
+			return fmt.Errorf("failed to init multipart upload: %w", err)
+		}
+		file.UploadID = multipartUpload.UploadID
+		if err := s.fileRepo.Update(ctx, file); err != nil {
+			return fmt.Errorf("failed to update file record: %w", err)
+		}
+
+		return s.recordEvents(ctx, file, EventFileCreated)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &MultipartUploadResult{
 		FileID:     file.ID,
-		UploadID:   multipartUpload.UploadID,
+		UploadID:   file.UploadID,
 		StorageKey: storageKey,
 	}, nil
 }
 
 // GeneratePartUploadURL 生成分片上传预签名 URL
-func (s *fileService) GeneratePartUploadURL(ctx context.Context, fileID uuid.UUID, partNumber int) (string, error) {
+func (s *fileService) GeneratePartUploadURL(ctx context.Context, fileID uuid.UUID, partNumber int, expiry time.Duration, checksum *storage.Checksum) (*PresignedURLResult, error) {
+	expiry, err := s.presignExpiry(expiry, s.presign.UploadExpiry)
+	if err != nil {
+		return nil, err
+	}
+
 	// 查询文件记录
 	file, err := s.fileRepo.GetByID(ctx, fileID)
 	if err != nil {
-		return "", fmt.Errorf("file not found: %w", err)
+		return nil, fmt.Errorf("file not found: %w", err)
 	}
 
 	if file.UploadID == "" {
-		return "", fmt.Errorf("file is not in multipart upload mode")
+		return nil, fmt.Errorf("file is not in multipart upload mode")
+	}
+	if err := checkPartChecksum(file, checksum); err != nil {
+		return nil, err
 	}
 
-	// 生成分片预签名 URL（1 小时有效期）
-	expiry := 1 * time.Hour
-	partURL, err := s.storage.GeneratePresignedPartURL(ctx, file.StorageKey, file.UploadID, partNumber, expiry)
+	// 生成分片预签名 URL（签名分片校验值）
+	presigned, err := s.storage.GeneratePresignedPartURL(ctx, file.StorageKey, file.UploadID, partNumber, expiry, checksum)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate part URL: %w", err)
+		return nil, fmt.Errorf("failed to generate part URL: %w", err)
 	}
 
-	return partURL, nil
+	return &PresignedURLResult{URL: presigned.URL, Headers: presigned.Headers, ExpiresIn: int64(expiry.Seconds())}, nil
 }
 
 // CompleteMultipartUpload 完成大文件分片上传
@@ -313,73 +704,200 @@ func (s *fileService) CompleteMultipartUpload(ctx context.Context, fileID uuid.U
 		return nil, fmt.Errorf("file is not in multipart upload mode")
 	}
 
-	// 完成 S3 分片上传
+	// 校验各分片的校验值并计算组合校验值
+	composite, err := multipartChecksum(file, parts, s.encryption.For(file.StorageKey).PartETagIsMD5())
+	if err != nil {
+		return nil, err
+	}
+
+	// 完成 S3 分片上传（存储服务核对各分片校验值）
 	if err := s.storage.CompleteMultipartUpload(ctx, file.StorageKey, file.UploadID, parts); err != nil {
 		return nil, fmt.Errorf("failed to complete multipart upload: %w", err)
 	}
+	file.Checksum = composite
 
 	// 更新状态为已完成
-	file.Status = models.FileStatusCompleted
-	if err := s.fileRepo.Update(ctx, file); err != nil {
-		return nil, fmt.Errorf("failed to update file status: %w", err)
+	if err := s.completeFile(ctx, file); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// checkPartChecksum 检查分片校验值与初始化时声明的校验算法一致
+func checkPartChecksum(file *models.File, checksum *storage.Checksum) error {
+	if file.ChecksumAlgorithm == "" {
+		if checksum != nil {
+			return fmt.Errorf("invalid checksum: multipart upload was initialized without a checksum algorithm")
+		}
+		return nil
+	}
+	if checksum == nil {
+		return fmt.Errorf("checksum required: multipart upload uses %s", file.ChecksumAlgorithm)
+	}
+	if string(checksum.Algorithm) != file.ChecksumAlgorithm {
+		return fmt.Errorf("invalid checksum: multipart upload uses %s, got %s", file.ChecksumAlgorithm, checksum.Algorithm)
+	}
+	return nil
+}
+
+// multipartChecksum 校验完成请求中的分片校验值并计算组合校验值（未声明校验算法时返回空）
+// etagIsMD5 时 MD5 分片额外与分片 ETag 比对（存储服务按 Content-MD5 校验分片后返回 MD5 ETag）；
+// SSE-KMS / SSE-C 对象的 ETag 不是 MD5，只依靠存储服务校验签名到分片 URL 的 Content-MD5
+func multipartChecksum(file *models.File, parts []storage.CompletedPart, etagIsMD5 bool) (string, error) {
+	if file.ChecksumAlgorithm == "" {
+		return "", nil
+	}
+
+	checksums := make([]*storage.Checksum, len(parts))
+	for i, part := range parts {
+		if err := checkPartChecksum(file, part.Checksum); err != nil {
+			return "", fmt.Errorf("part %d: %w", part.PartNumber, err)
+		}
+		if etagIsMD5 && part.Checksum.Algorithm == storage.ChecksumMD5 && !part.Checksum.MatchesETag(part.ETag) {
+			return "", fmt.Errorf("checksum mismatch: part %d ETag %s does not match declared MD5", part.PartNumber, part.ETag)
+		}
+		checksums[i] = part.Checksum
+	}
+
+	return storage.CompositeChecksum(storage.ChecksumAlgorithm(file.ChecksumAlgorithm), checksums)
+}
+
+// AbortMultipartUpload 取消大文件分片上传
+func (s *fileService) AbortMultipartUpload(ctx context.Context, fileID uuid.UUID) (*models.File, error) {
+	// 查询文件记录
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+
+	if file.UploadID == "" {
+		return nil, fmt.Errorf("file is not in multipart upload mode")
+	}
+	if file.Status != models.FileStatusUploading {
+		return nil, fmt.Errorf("multipart upload is already %s", file.Status)
+	}
+
+	// 取消 S3 分片上传
+	if err := s.storage.AbortMultipartUpload(ctx, file.StorageKey, file.UploadID); err != nil {
+		return nil, fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	// 更新状态为失败
+	err = s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		file.Status = models.FileStatusFailed
+		if err := s.fileRepo.Update(ctx, file); err != nil {
+			return fmt.Errorf("failed to update file status: %w", err)
+		}
+		return s.recordEvents(ctx, file, EventMultipartAborted)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return file, nil
 }
 
 // DownloadFile 直接下载文件内容（流式传输）
-func (s *fileService) DownloadFile(ctx context.Context, fileID uuid.UUID) (io.ReadCloser, *models.File, error) {
+func (s *fileService) DownloadFile(ctx context.Context, fileID uuid.UUID, acceptEncoding string) (*DownloadResult, error) {
 	// 查询文件记录
 	file, err := s.fileRepo.GetByID(ctx, fileID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("file not found: %w", err)
+		return nil, fmt.Errorf("file not found: %w", err)
 	}
 
-	// 检查文件状态
-	if file.Status != models.FileStatusCompleted {
-		return nil, nil, fmt.Errorf("file is not ready for download")
+	// 检查文件状态及扫描结果
+	if err := checkDownloadable(file, s.policy); err != nil {
+		return nil, err
+	}
+
+	// 压缩保存的文件在客户端接受该编码时原样返回压缩内容，无需解压
+	if file.ContentEncoding != "" && utils.AcceptsEncoding(acceptEncoding, file.ContentEncoding) {
+		encodedCtx := storage.WithEncodedContent(ctx, storage.Codec(file.ContentEncoding))
+		reader, _, size, err := s.storage.GetObject(encodedCtx, file.StorageKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file: %w", err)
+		}
+		return &DownloadResult{Reader: s.trackDownload(ctx, file, reader), File: file, ContentEncoding: file.ContentEncoding, Size: size}, nil
 	}
 
 	// 从存储获取文件流
 	reader, _, _, err := s.storage.GetObject(ctx, file.StorageKey)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get file: %w", err)
+		return nil, fmt.Errorf("failed to get file: %w", err)
 	}
 
-	return reader, file, nil
+	return &DownloadResult{Reader: s.trackDownload(ctx, file, reader), File: file, Size: file.Size}, nil
 }
 
 // GetDownloadURL 生成下载预签名 URL
-func (s *fileService) GetDownloadURL(ctx context.Context, fileID uuid.UUID, expiry time.Duration) (string, error) {
-	// 查询文件记录
-	file, err := s.fileRepo.GetByID(ctx, fileID)
+func (s *fileService) GetDownloadURL(ctx context.Context, fileID uuid.UUID, opts DownloadURLOptions) (*PresignedURLResult, error) {
+	expiry, err := s.presignExpiry(opts.Expiry, s.presign.DownloadExpiry)
 	if err != nil {
-		return "", fmt.Errorf("file not found: %w", err)
+		return nil, err
+	}
+	if opts.Disposition != "" && opts.Disposition != "inline" && opts.Disposition != "attachment" {
+		return nil, fmt.Errorf("invalid disposition: must be inline or attachment")
+	}
+	if strings.IndexFunc(opts.CacheControl, unicode.IsControl) >= 0 || len(opts.CacheControl) > maxCacheControlLength {
+		return nil, fmt.Errorf("invalid cache control: must be at most %d printable characters", maxCacheControlLength)
 	}
 
-	if file.Status != models.FileStatusCompleted {
-		return "", fmt.Errorf("file is not ready for download")
+	// 查询文件记录
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
 	}
 
-	// 判断是否可预览
-	disposition := "attachment"
-	if utils.IsPreviewable(file.ContentType) {
-		disposition = "inline"
+	if err := checkDownloadable(file, s.policy); err != nil {
+		return nil, err
 	}
 
-	// 构造响应头选项
-	opts := &storage.PresignOptions{
+	// 构造响应头选项（默认可预览的文件使用 inline，文件名按 RFC 6266 编码）
+	presignOpts := &storage.PresignOptions{
 		ContentType:        file.ContentType,
-		ContentDisposition: disposition,
+		ContentDisposition: DispositionType(file),
+		Filename:           file.Name,
+		CacheControl:       opts.CacheControl,
+	}
+	// 内容类型校验不一致的文件始终使用 attachment，不允许客户端改为 inline
+	if opts.Disposition != "" && !file.ContentTypeMismatch {
+		presignOpts.ContentDisposition = opts.Disposition
+	}
+	if opts.Filename != "" {
+		presignOpts.Filename = utils.SanitizeFilename(opts.Filename)
 	}
 
 	// 生成下载预签名 URL
-	downloadURL, err := s.storage.GeneratePresignedDownloadURL(ctx, file.StorageKey, expiry, opts)
+	presigned, err := s.storage.GeneratePresignedDownloadURL(ctx, file.StorageKey, expiry, presignOpts)
+	if errors.Is(err, storage.ErrPresignNotSupported) {
+		// 客户端加密的文件只能由后端解密，返回代理下载地址
+		return &PresignedURLResult{URL: "/api/v1/files/" + file.ID.String() + "/download"}, nil
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to generate download URL: %w", err)
+		return nil, fmt.Errorf("failed to generate download URL: %w", err)
 	}
+	s.recordAccess(ctx, file, AccessLink, 0)
 
-	return downloadURL, nil
+	return &PresignedURLResult{URL: presigned.URL, Headers: presigned.Headers, ExpiresIn: int64(expiry.Seconds())}, nil
+}
+
+// recordAccess 记录文件访问统计和最近访问时间（生命周期规则按最后下载时间转换存储类型，记录失败不影响下载）
+func (s *fileService) recordAccess(ctx context.Context, file *models.File, kind AccessKind, bytesServed int64) {
+	if s.stats == nil {
+		_ = s.fileRepo.MarkAccessed(ctx, file.ID, time.Now())
+		return
+	}
+	_ = s.stats.RecordAccess(ctx, file.ID, kind, bytesServed, time.Now())
+}
+
+// trackDownload 包装下载流，关闭时按实际传输的字节数记录一次下载
+// 请求结束时上下文可能已取消，记录使用不随请求取消的上下文
+func (s *fileService) trackDownload(ctx context.Context, file *models.File, reader io.ReadCloser) io.ReadCloser {
+	ctx = context.WithoutCancel(ctx)
+	return &downloadReader{ReadCloser: reader, onClose: func(read int64) {
+		s.recordAccess(ctx, file, AccessDownload, read)
+	}}
 }
 
 // DeleteFile 删除文件（S3 + 数据库）
@@ -390,32 +908,111 @@ func (s *fileService) DeleteFile(ctx context.Context, fileID uuid.UUID) error {
 		return fmt.Errorf("file not found: %w", err)
 	}
 
-	// 开启事务
-	tx := s.db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	// 在事务中删除记录并写入事件，S3 删除失败时回滚
+	return s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		// 删除数据库记录（存储对象一并删除，记录不能再通过批量 restore 恢复）
+		if err := s.fileRepo.DeleteWithObject(ctx, file.ID, time.Now()); err != nil {
+			return fmt.Errorf("failed to delete file record: %w", err)
+		}
+		if err := s.recordEvents(ctx, file, EventFileDeleted); err != nil {
+			return err
+		}
+
+		// 删除 S3 文件
+		if err := s.storage.Delete(ctx, file.StorageKey); err != nil {
+			return fmt.Errorf("failed to delete from storage: %w", err)
+		}
+		return nil
+	})
+}
+
+// CopyFile 服务端复制文件（不经过后端中转数据），生成新的文件记录
+func (s *fileService) CopyFile(ctx context.Context, fileID uuid.UUID, opts CopyFileOptions) (*models.File, error) {
+	// 查询文件记录
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+
+	return s.copyFile(ctx, file, opts)
+}
+
+// copyFile 复制存储对象并创建新的文件记录
+func (s *fileService) copyFile(ctx context.Context, file *models.File, opts CopyFileOptions) (*models.File, error) {
+	if file.Status != models.FileStatusCompleted {
+		return nil, fmt.Errorf("file is not ready for copy")
+	}
+	// 归档对象须取回后才能复制（副本使用默认存储类型）
+	if RequiresRestore(file, time.Now()) {
+		return nil, fmt.Errorf("file is archived (storage class %s): restore it before copying", file.StorageClass)
+	}
+
+	if opts.Tenant != "" {
+		if !storage.ValidTenant(opts.Tenant) {
+			return nil, fmt.Errorf("invalid tenant: must be a single key segment of letters, digits, '_', '-' or '.'")
 		}
-	}()
+		if !s.keys.HasTenant() {
+			return nil, fmt.Errorf("invalid tenant: storage key template %q has no {tenant}", s.keys)
+		}
+	}
 
-	// 删除数据库记录
-	if err := tx.Delete(file).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to delete file record: %w", err)
+	name := file.Name
+	if opts.Name != "" {
+		name = utils.SanitizeFilename(opts.Name)
+	}
+	folder := file.Folder
+	if opts.Folder != "" {
+		folder = opts.Folder
 	}
 
-	// 删除 S3 文件
-	if err := s.storage.Delete(ctx, file.StorageKey); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to delete from storage: %w", err)
+	copied := &models.File{
+		BaseModel:   models.BaseModel{ID: uuid.New()},
+		Name:        name,
+		Size:        file.Size,
+		ContentType: file.ContentType,
+		Status:      models.FileStatusCompleted,
+		Hash:        file.Hash,
+		Folder:      normalizeFolder(folder),
+		Tags:        append(models.Tags(nil), file.Tags...),
+		Metadata:    copyMetadata(file.Metadata),
+		// 服务端复制内容不变，沿用源文件的校验值
+		ChecksumAlgorithm: file.ChecksumAlgorithm,
+		Checksum:          file.Checksum,
+		// 在源文件所在的后端原样复制，沿用源文件的后端、压缩编码和数据密钥
+		Backend:         file.Backend,
+		ContentEncoding: file.ContentEncoding,
+		EncryptionKeyID: file.EncryptionKeyID,
+		EncryptionKey:   file.EncryptionKey,
+		// 内容类型识别和扫描结果沿用源文件（启用扫描时 file.completed 仍会触发重新扫描）
+		DetectedContentType: file.DetectedContentType,
+		ContentTypeMismatch: file.ContentTypeMismatch,
+		ScanResult:          file.ScanResult,
+		ScanSignature:       file.ScanSignature,
+		ScanEngine:          file.ScanEngine,
+		ScannedAt:           file.ScannedAt,
+		// 记录目标租户，迁移存储键时保留 {tenant}
+		Tenant: opts.Tenant,
 	}
+	copied.StorageKey = newTenantStorageKey(s.keys, copied, copied.Tenant)
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	// 服务端复制存储对象
+	if err := s.storage.Copy(ctx, file.StorageKey, copied.StorageKey); err != nil {
+		return nil, fmt.Errorf("failed to copy object: %w", err)
 	}
 
-	return nil
+	// 创建文件记录并写入事件（失败时清理已复制的对象）
+	err := s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.fileRepo.Create(ctx, copied); err != nil {
+			return fmt.Errorf("failed to create file record: %w", err)
+		}
+		return s.recordEvents(ctx, copied, EventFileCreated, EventFileCompleted)
+	})
+	if err != nil {
+		_ = s.storage.Delete(ctx, copied.StorageKey)
+		return nil, err
+	}
+
+	return copied, nil
 }
 
 // GetFile 获取文件信息
@@ -1,34 +1,44 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/NanoBoom/asethub/internal/cache"
 	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // MockFileRepository 用于测试的 Mock 实现
 type MockFileRepository struct {
-	files  map[uint]*models.File
-	nextID uint
+	files map[uuid.UUID]*models.File
 }
 
 func NewMockFileRepository() *MockFileRepository {
 	return &MockFileRepository{
-		files:  make(map[uint]*models.File),
-		nextID: 1,
+		files: make(map[uuid.UUID]*models.File),
 	}
 }
 
 func (m *MockFileRepository) Create(ctx context.Context, file *models.File) error {
-	file.ID = m.nextID
-	m.nextID++
+	if file.ID == uuid.Nil {
+		file.ID = uuid.New()
+	}
 	m.files[file.ID] = file
 	return nil
 }
 
-func (m *MockFileRepository) GetByID(ctx context.Context, id uint) (*models.File, error) {
-	if file, ok := m.files[id]; ok {
+func (m *MockFileRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.File, error) {
+	if file, ok := m.files[id]; ok && !file.DeletedAt.Valid {
 		return file, nil
 	}
 	return nil, nil
@@ -48,7 +58,7 @@ func (m *MockFileRepository) Update(ctx context.Context, file *models.File) erro
 	return nil
 }
 
-func (m *MockFileRepository) UpdateStatus(ctx context.Context, id uint, status models.FileStatus) error {
+func (m *MockFileRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.FileStatus) error {
 	if file, ok := m.files[id]; ok {
 		file.Status = status
 		return nil
@@ -56,8 +66,10 @@ func (m *MockFileRepository) UpdateStatus(ctx context.Context, id uint, status m
 	return nil
 }
 
-func (m *MockFileRepository) Delete(ctx context.Context, id uint) error {
-	delete(m.files, id)
+func (m *MockFileRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if file, ok := m.files[id]; ok {
+		file.DeletedAt = gorm.DeletedAt{Valid: true}
+	}
 	return nil
 }
 
@@ -69,6 +81,350 @@ func (m *MockFileRepository) List(ctx context.Context, offset, limit int) ([]*mo
 	return files, int64(len(files)), nil
 }
 
+func (m *MockFileRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.File, error) {
+	var files []*models.File
+	for _, id := range ids {
+		if file, ok := m.files[id]; ok {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func (m *MockFileRepository) DeleteWithObject(ctx context.Context, id uuid.UUID, at time.Time) error {
+	if file, ok := m.files[id]; ok && !file.DeletedAt.Valid {
+		file.DeletedAt = gorm.DeletedAt{Time: at, Valid: true}
+		file.ObjectPurgedAt = &at
+	}
+	return nil
+}
+
+func (m *MockFileRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	if file, ok := m.files[id]; ok && file.DeletedAt.Valid && file.ObjectPurgedAt == nil {
+		file.DeletedAt = gorm.DeletedAt{}
+		return nil
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *MockFileRepository) ListByFolder(ctx context.Context, folder string) ([]*models.File, error) {
+	var files []*models.File
+	for _, file := range m.files {
+		if file.DeletedAt.Valid {
+			continue
+		}
+		if folder == "/" || file.Folder == folder || strings.HasPrefix(file.Folder, folder+"/") {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Folder != files[j].Folder {
+			return files[i].Folder < files[j].Folder
+		}
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+func (m *MockFileRepository) DeletePermanently(ctx context.Context, ids []uuid.UUID) error {
+	for _, id := range ids {
+		delete(m.files, id)
+	}
+	return nil
+}
+
+func (m *MockFileRepository) ListAfter(ctx context.Context, afterID uuid.UUID, limit int) ([]*models.File, error) {
+	var files []*models.File
+	for _, file := range m.files {
+		if bytes.Compare(file.ID[:], afterID[:]) > 0 {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return bytes.Compare(files[i].ID[:], files[j].ID[:]) < 0
+	})
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return files, nil
+}
+
+func (m *MockFileRepository) UpdateStorageKey(ctx context.Context, id uuid.UUID, oldKey, newKey string) error {
+	file, ok := m.files[id]
+	if !ok || file.StorageKey != oldKey {
+		return gorm.ErrRecordNotFound
+	}
+	file.StorageKey = newKey
+	return nil
+}
+
+func (m *MockFileRepository) UpdateStorageClass(ctx context.Context, id uuid.UUID, from, to string) error {
+	file, ok := m.files[id]
+	if !ok || file.StorageClass != from {
+		return gorm.ErrRecordNotFound
+	}
+	file.StorageClass = to
+	file.RestoreStatus = ""
+	file.RestoreExpiresAt = nil
+	return nil
+}
+
+func (m *MockFileRepository) UpdateScanResult(ctx context.Context, file *models.File) error {
+	current, ok := m.files[file.ID]
+	if !ok || current.DeletedAt.Valid ||
+		(current.Status != models.FileStatusCompleted && current.Status != models.FileStatusQuarantined) {
+		return gorm.ErrRecordNotFound
+	}
+	current.ScanResult = file.ScanResult
+	current.ScanSignature = file.ScanSignature
+	current.ScanEngine = file.ScanEngine
+	current.ScannedAt = file.ScannedAt
+	current.Status = file.Status
+	return nil
+}
+
+func (m *MockFileRepository) UpdateRestoreStatus(ctx context.Context, id uuid.UUID, status models.RestoreStatus, expiresAt *time.Time) error {
+	file, ok := m.files[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	file.RestoreStatus = status
+	file.RestoreExpiresAt = expiresAt
+	return nil
+}
+
+func (m *MockFileRepository) MarkAccessed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	// 与数据库实现一致，不会回退到更早的时间
+	if file, ok := m.files[id]; ok && (file.LastAccessedAt == nil || file.LastAccessedAt.Before(at)) {
+		file.LastAccessedAt = &at
+	}
+	return nil
+}
+
+// MockStorage 用于测试的内存存储实现
+type MockStorage struct {
+	objects map[string][]byte
+
+	// 最近一次生成下载 URL 的参数
+	lastExpiry  time.Duration
+	lastPresign *storage.PresignOptions
+
+	// 已取消的分片上传：存储键 -> upload ID；abortErr 不为空时取消失败
+	aborted  map[string]string
+	abortErr error
+}
+
+func NewMockStorage() *MockStorage {
+	return &MockStorage{objects: make(map[string][]byte), aborted: make(map[string]string)}
+}
+
+func (m *MockStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	m.objects[key] = data
+	return nil
+}
+
+func (m *MockStorage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string, checksum *storage.Checksum) (*storage.PresignedRequest, error) {
+	return &storage.PresignedRequest{URL: "https://mock.example.com/upload/" + key}, nil
+}
+
+func (m *MockStorage) GeneratePresignedPost(ctx context.Context, key string, expiry time.Duration, policy storage.PostPolicy) (*storage.PresignedPost, error) {
+	return &storage.PresignedPost{
+		URL:    "https://mock.example.com/upload",
+		Fields: map[string]string{"key": key, "Content-Type": policy.ContentType, "content-length-range": fmt.Sprintf("%d,%d", policy.MinSize, policy.MaxSize)},
+	}, nil
+}
+
+func (m *MockStorage) InitMultipartUpload(ctx context.Context, key string, contentType string, algorithm storage.ChecksumAlgorithm) (*storage.MultipartUpload, error) {
+	return &storage.MultipartUpload{UploadID: "mock-upload-id", Key: key}, nil
+}
+
+func (m *MockStorage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration, checksum *storage.Checksum) (*storage.PresignedRequest, error) {
+	return &storage.PresignedRequest{URL: fmt.Sprintf("https://mock.example.com/upload/%s?partNumber=%d", key, partNumber)}, nil
+}
+
+func (m *MockStorage) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []storage.CompletedPart) error {
+	m.objects[key] = []byte("multipart-upload-completed")
+	return nil
+}
+
+func (m *MockStorage) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	if m.abortErr != nil {
+		return m.abortErr
+	}
+	m.aborted[key] = uploadID
+	return nil
+}
+
+func (m *MockStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, "", 0, fmt.Errorf("object not found: %s", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), "application/octet-stream", int64(len(data)), nil
+}
+
+func (m *MockStorage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("object not found: %s", key)
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	end := offset + length
+	if end > int64(len(data)) {
+		end = int64(len(data))
+	}
+	return io.NopCloser(bytes.NewReader(data[offset:end])), nil
+}
+
+func (m *MockStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *storage.PresignOptions) (*storage.PresignedRequest, error) {
+	m.lastExpiry = expiry
+	m.lastPresign = opts
+	return &storage.PresignedRequest{URL: "https://mock.example.com/download/" + key}, nil
+}
+
+func (m *MockStorage) Delete(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *MockStorage) DeleteObjects(ctx context.Context, keys []string) (map[string]error, error) {
+	failed := make(map[string]error)
+	for _, key := range keys {
+		if _, ok := m.objects[key]; !ok {
+			failed[key] = fmt.Errorf("object not found")
+			continue
+		}
+		delete(m.objects, key)
+	}
+	return failed, nil
+}
+
+func (m *MockStorage) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	data, ok := m.objects[srcKey]
+	if !ok {
+		return fmt.Errorf("object not found: %s", srcKey)
+	}
+	m.objects[dstKey] = append([]byte(nil), data...)
+	return nil
+}
+
+// MockOutboxRepository 用于测试的内存发件箱
+type MockOutboxRepository struct {
+	events []*models.OutboxEvent
+}
+
+func NewMockOutboxRepository() *MockOutboxRepository {
+	return &MockOutboxRepository{}
+}
+
+func (m *MockOutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	event.Sequence = int64(len(m.events) + 1)
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *MockOutboxRepository) ListUnpublished(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+	for _, event := range m.events {
+		if event.PublishedAt == nil && event.DeadAt == nil && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (m *MockOutboxRepository) MarkPublished(ctx context.Context, ids []uuid.UUID, publishedAt time.Time) error {
+	for _, id := range ids {
+		for _, event := range m.events {
+			if event.ID == id {
+				event.PublishedAt = &publishedAt
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error {
+	for _, event := range m.events {
+		if event.ID == id {
+			event.Attempts++
+			event.LastError = errMsg
+		}
+	}
+	return nil
+}
+
+func (m *MockOutboxRepository) MarkDead(ctx context.Context, id uuid.UUID, errMsg string, deadAt time.Time) error {
+	for _, event := range m.events {
+		if event.ID == id {
+			event.Attempts++
+			event.LastError = errMsg
+			event.DeadAt = &deadAt
+		}
+	}
+	return nil
+}
+
+func (m *MockOutboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	var kept []*models.OutboxEvent
+	for _, event := range m.events {
+		if event.PublishedAt == nil || !event.PublishedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(m.events) - len(kept))
+	m.events = kept
+	return deleted, nil
+}
+
+// eventTypes 返回发件箱中的事件类型（按写入顺序）
+func (m *MockOutboxRepository) eventTypes() []string {
+	types := make([]string, len(m.events))
+	for i, event := range m.events {
+		types[i] = event.EventType
+	}
+	return types
+}
+
+// MockTransactor 直接执行 fn 的事务实现（内存 Mock 不需要事务）
+type MockTransactor struct{}
+
+func (MockTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// MemoryJSONStore 用于测试的内存 JSON 存储
+type MemoryJSONStore struct {
+	data map[string][]byte
+}
+
+func NewMemoryJSONStore() *MemoryJSONStore {
+	return &MemoryJSONStore{data: make(map[string][]byte)}
+}
+
+func (m *MemoryJSONStore) SetJSON(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.data[key] = data
+	return nil
+}
+
+func (m *MemoryJSONStore) GetJSON(ctx context.Context, key string, dest interface{}) error {
+	data, ok := m.data[key]
+	if !ok {
+		return cache.ErrNotFound
+	}
+	return json.Unmarshal(data, dest)
+}
+
 // TestMockFileRepository 测试 Mock Repository 基本功能
 func TestMockFileRepository(t *testing.T) {
 	ctx := context.Background()
@@ -88,7 +444,7 @@ func TestMockFileRepository(t *testing.T) {
 		t.Fatalf("Create failed: %v", err)
 	}
 
-	if file.ID == 0 {
+	if file.ID == uuid.Nil {
 		t.Fatalf("File ID not set")
 	}
 
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// KeyMigrationOptions 存储键迁移选项
+type KeyMigrationOptions struct {
+	DryRun    bool // 只计算新存储键，不复制对象也不更新数据库
+	BatchSize int  // 每批查询的文件数（默认 100）
+	Limit     int  // 最多迁移的文件数（0 不限制）
+}
+
+// KeyMigrationResult 存储键迁移结果
+type KeyMigrationResult struct {
+	Scanned  int `json:"scanned"`  // 检查的文件数
+	Migrated int `json:"migrated"` // 已迁移（或 DryRun 时待迁移）的文件数
+	Skipped  int `json:"skipped"`  // 跳过的文件数（存储键已符合模板，或上传未完成）
+	Failed   int `json:"failed"`   // 失败的文件数
+}
+
+// KeyMigrationReport 单个文件的迁移结果（用于输出进度）
+type KeyMigrationReport struct {
+	FileID uuid.UUID
+	OldKey string
+	NewKey string
+	Err    error
+}
+
+// KeyMigrator 存储键迁移器：按当前模板重写已有文件的存储键
+// 逐个文件执行：复制对象到新键 -> 更新数据库 -> 删除旧对象
+// 中途中断可重新执行（已迁移的文件会被跳过，复制到新键但未更新数据库的对象会被覆盖）
+type KeyMigrator interface {
+	Migrate(ctx context.Context, opts KeyMigrationOptions, report func(KeyMigrationReport)) (*KeyMigrationResult, error)
+}
+
+// keyMigrator 存储键迁移器实现
+type keyMigrator struct {
+	fileRepo repositories.FileRepository
+	storage  storage.Storage
+	keys     *storage.KeyTemplate
+}
+
+// NewKeyMigrator 创建存储键迁移器
+func NewKeyMigrator(fileRepo repositories.FileRepository, storage storage.Storage, keys *storage.KeyTemplate) KeyMigrator {
+	return &keyMigrator{
+		fileRepo: fileRepo,
+		storage:  storage,
+		keys:     keyTemplateOrDefault(keys),
+	}
+}
+
+// Migrate 迁移所有文件（包含回收站中的文件）的存储键
+// 等待上传或分片上传中的文件不迁移（预签名 URL 和分片上传已绑定旧存储键）
+func (m *keyMigrator) Migrate(ctx context.Context, opts KeyMigrationOptions, report func(KeyMigrationReport)) (*KeyMigrationResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	result := &KeyMigrationResult{}
+	afterID := uuid.Nil
+	for {
+		files, err := m.fileRepo.ListAfter(ctx, afterID, opts.BatchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to list files: %w", err)
+		}
+		if len(files) == 0 {
+			return result, nil
+		}
+
+		for _, file := range files {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			if opts.Limit > 0 && result.Migrated+result.Failed >= opts.Limit {
+				return result, nil
+			}
+			afterID = file.ID
+			result.Scanned++
+
+			newKey := m.targetKey(file)
+			if newKey == file.StorageKey || (file.Status != models.FileStatusCompleted && file.Status != models.FileStatusQuarantined) {
+				result.Skipped++
+				continue
+			}
+
+			oldKey := file.StorageKey
+			var err error
+			if !opts.DryRun {
+				err = m.migrateFile(ctx, file, newKey)
+			}
+			if err != nil {
+				result.Failed++
+			} else {
+				result.Migrated++
+			}
+			if report != nil {
+				report(KeyMigrationReport{FileID: file.ID, OldKey: oldKey, NewKey: newKey, Err: err})
+			}
+		}
+	}
+}
+
+// targetKey 按模板计算文件的存储键（日期变量使用文件创建时间，{tenant} 使用文件记录的租户，重复执行结果一致）
+func (m *keyMigrator) targetKey(file *models.File) string {
+	return m.keys.Render(storage.KeyParams{
+		ID:          file.ID,
+		Name:        file.Name,
+		ContentType: file.ContentType,
+		Folder:      file.Folder,
+		Time:        file.CreatedAt,
+		Tenant:      file.Tenant,
+	})
+}
+
+// migrateFile 迁移单个文件：复制对象、更新数据库后删除旧对象
+func (m *keyMigrator) migrateFile(ctx context.Context, file *models.File, newKey string) error {
+	oldKey := file.StorageKey
+	if err := m.storage.Copy(ctx, oldKey, newKey); err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+
+	if err := m.fileRepo.UpdateStorageKey(ctx, file.ID, oldKey, newKey); err != nil {
+		// 文件已被删除或存储键已变化，清理刚复制的对象
+		_ = m.storage.Delete(ctx, newKey)
+		return fmt.Errorf("failed to update storage key: %w", err)
+	}
+	file.StorageKey = newKey
+
+	// 旧对象删除失败只会留下孤儿对象，不影响文件访问
+	_ = m.storage.Delete(ctx, oldKey)
+	return nil
+}
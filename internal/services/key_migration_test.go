@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestKeyMigrator(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	keys, err := storage.ParseKeyTemplate("{yyyy}/{mm}/{uuid}{ext}", "")
+	if err != nil {
+		t.Fatalf("ParseKeyTemplate() error = %v", err)
+	}
+	created := time.Date(2025, 11, 2, 0, 0, 0, 0, time.UTC)
+
+	newFile := func(name string, status models.FileStatus) *models.File {
+		file := &models.File{BaseModel: models.BaseModel{ID: uuid.New(), CreatedAt: created}, Name: name, StorageKey: "files/1700000000/" + name, Status: status}
+		repo.Create(ctx, file)
+		store.objects[file.StorageKey] = []byte(name)
+		return file
+	}
+	completed := newFile("a.png", models.FileStatusCompleted)
+	trashed := newFile("b.pdf", models.FileStatusCompleted)
+	trashed.DeletedAt = gorm.DeletedAt{Time: created, Valid: true}
+	pending := newFile("c.txt", models.FileStatusPending)
+	migrated := newFile("d.txt", models.FileStatusCompleted)
+	migrated.StorageKey = "2025/11/" + migrated.ID.String() + ".txt"
+
+	migrator := NewKeyMigrator(repo, store, keys)
+
+	// DryRun 不修改任何内容
+	result, err := migrator.Migrate(ctx, KeyMigrationOptions{DryRun: true, BatchSize: 2}, nil)
+	if err != nil || result.Scanned != 4 || result.Migrated != 2 || result.Skipped != 2 {
+		t.Fatalf("Migrate(dry run) = %+v, %v", result, err)
+	}
+	if completed.StorageKey != "files/1700000000/a.png" {
+		t.Errorf("dry run changed storage key to %s", completed.StorageKey)
+	}
+
+	var reports []KeyMigrationReport
+	result, err = migrator.Migrate(ctx, KeyMigrationOptions{BatchSize: 2}, func(r KeyMigrationReport) {
+		reports = append(reports, r)
+	})
+	if err != nil || result.Migrated != 2 || result.Failed != 0 || len(reports) != 2 {
+		t.Fatalf("Migrate() = %+v, %v", result, err)
+	}
+
+	for _, file := range []*models.File{completed, trashed} {
+		want := "2025/11/" + file.ID.String() + ".png"
+		if file == trashed {
+			want = "2025/11/" + file.ID.String() + ".pdf"
+		}
+		if file.StorageKey != want {
+			t.Errorf("%s storage key = %s, want %s", file.Name, file.StorageKey, want)
+		}
+		if string(store.objects[want]) != file.Name {
+			t.Errorf("object %s not copied", want)
+		}
+		if _, ok := store.objects["files/1700000000/"+file.Name]; ok {
+			t.Errorf("old object for %s not deleted", file.Name)
+		}
+	}
+	if pending.StorageKey != "files/1700000000/c.txt" {
+		t.Errorf("pending upload should not be migrated, key = %s", pending.StorageKey)
+	}
+
+	// 重复执行时全部跳过
+	if result, _ := migrator.Migrate(ctx, KeyMigrationOptions{}, nil); result.Migrated != 0 {
+		t.Errorf("second run migrated %d files", result.Migrated)
+	}
+}
+
+// TestKeyMigratorKeepsTenant 测试迁移复制到其他租户的文件时保留其租户
+func TestKeyMigratorKeepsTenant(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	keys, err := storage.ParseKeyTemplate("{tenant}/{uuid}{ext}", "acme")
+	if err != nil {
+		t.Fatalf("ParseKeyTemplate() error = %v", err)
+	}
+	file := newBatchTestFile(t, repo, store, "a.txt")
+	svc := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{Keys: keys})
+	copied, err := svc.CopyFile(ctx, file.ID, CopyFileOptions{Tenant: "globex"})
+	if err != nil {
+		t.Fatalf("CopyFile() error = %v", err)
+	}
+	if copied.Tenant != "globex" {
+		t.Errorf("copied tenant = %q, want globex", copied.Tenant)
+	}
+
+	// 模板变更后迁移，副本仍在 globex 下
+	keys, err = storage.ParseKeyTemplate("{tenant}/{yyyy}/{uuid}{ext}", "acme")
+	if err != nil {
+		t.Fatalf("ParseKeyTemplate() error = %v", err)
+	}
+	result, err := NewKeyMigrator(repo, store, keys).Migrate(ctx, KeyMigrationOptions{}, nil)
+	if err != nil || result.Failed != 0 {
+		t.Fatalf("Migrate() = %+v, %v", result, err)
+	}
+	want := "globex/" + copied.CreatedAt.Format("2006") + "/" + copied.ID.String() + ".txt"
+	if copied.StorageKey != want {
+		t.Errorf("copied storage key = %s, want %s", copied.StorageKey, want)
+	}
+	if string(store.objects[want]) != "hello" {
+		t.Errorf("object %s not copied", want)
+	}
+}
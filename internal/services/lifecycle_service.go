@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// 生命周期操作
+const (
+	LifecycleTransition     = "transition"      // 转换存储类型
+	LifecycleExpire         = "expire"          // 到期永久删除
+	LifecycleRestored       = "restored"        // 归档文件取回完成
+	LifecycleRestoreExpired = "restore_expired" // 取回的副本已过期
+)
+
+// LifecycleAction 单个文件的生命周期操作结果（用于输出日志）
+type LifecycleAction struct {
+	FileID       uuid.UUID
+	Rule         string               // 适用的规则名称（取回状态更新时为空）
+	Action       string               // 操作（LifecycleTransition 等）
+	StorageClass storage.StorageClass // 转换的目标存储类型
+	Err          error
+}
+
+// LifecycleSummary 一次评估的统计
+type LifecycleSummary struct {
+	Scanned      int // 评估的文件数
+	Transitioned int // 转换存储类型的文件数
+	Expired      int // 永久删除的文件数
+	Restored     int // 取回完成的文件数
+	Failed       int // 操作失败的文件数
+}
+
+// LifecycleService 存储分层和生命周期服务
+// 按规则（目录、标签、上传时间、最后下载时间）把文件转换到更冷的存储类型或到期删除，并处理归档文件的取回
+// 存储类型只会变冷：已归档的文件不再转换，取回只生成临时副本，不改变存储类型
+type LifecycleService interface {
+	// Run 按间隔评估规则直到 ctx 取消（report 可为 nil）
+	Run(ctx context.Context, report func(LifecycleAction))
+
+	// Evaluate 按文件 ID 顺序评估一次所有文件，同时更新取回中和已过期的取回状态
+	Evaluate(ctx context.Context, report func(LifecycleAction)) (*LifecycleSummary, error)
+
+	// RestoreFile 取回归档文件（days 为 0 时使用默认天数，tier 为空时使用默认优先级）
+	RestoreFile(ctx context.Context, fileID uuid.UUID, days int, tier string) (*models.File, error)
+
+	// GetRestoreStatus 查询文件的存储类型和取回状态（取回中时向存储查询最新状态）
+	GetRestoreStatus(ctx context.Context, fileID uuid.UUID) (*models.File, error)
+}
+
+// lifecycleRule 解析后的生命周期规则
+type lifecycleRule struct {
+	name        string
+	folder      string
+	tags        []string
+	transitions []lifecycleTransition
+	expireAfter time.Duration // 上传后经过的时间（0 不删除）
+	expireIdle  time.Duration // 最后一次下载后经过的时间（0 不删除）
+}
+
+// lifecycleTransition 解析后的存储类型转换
+type lifecycleTransition struct {
+	class storage.StorageClass
+	age   time.Duration // 上传后经过的时间（0 不限制）
+	idle  time.Duration // 最后一次下载后经过的时间（0 不限制）
+}
+
+// matches 判断文件是否适用该规则
+func (r *lifecycleRule) matches(file *models.File) bool {
+	if r.folder != "/" && file.Folder != r.folder && !strings.HasPrefix(file.Folder, r.folder+"/") {
+		return false
+	}
+	if len(r.tags) > 0 && !slices.ContainsFunc(r.tags, func(tag string) bool { return slices.Contains(file.Tags, tag) }) {
+		return false
+	}
+	return true
+}
+
+// lastAccess 返回文件最后一次下载的时间（从未下载时为上传时间）
+func lastAccess(file *models.File) time.Time {
+	if file.LastAccessedAt != nil && file.LastAccessedAt.After(file.CreatedAt) {
+		return *file.LastAccessedAt
+	}
+	return file.CreatedAt
+}
+
+// expired 判断文件在 now 时是否应永久删除
+func (r *lifecycleRule) expired(file *models.File, now time.Time) bool {
+	return (r.expireAfter > 0 && now.Sub(file.CreatedAt) >= r.expireAfter) ||
+		(r.expireIdle > 0 && now.Sub(lastAccess(file)) >= r.expireIdle)
+}
+
+// target 返回文件在 now 时应处于的最冷存储类型（没有满足条件的转换时为空）
+func (r *lifecycleRule) target(file *models.File, now time.Time) storage.StorageClass {
+	accessed := lastAccess(file)
+
+	var target storage.StorageClass
+	for _, t := range r.transitions {
+		if now.Sub(file.CreatedAt) < t.age || now.Sub(accessed) < t.idle {
+			continue
+		}
+		if target == "" || t.class.ColderThan(target) {
+			target = t.class
+		}
+	}
+	return target
+}
+
+// lifecycleService 存储分层和生命周期服务实现
+type lifecycleService struct {
+	fileRepo    repositories.FileRepository
+	files       FileService // 到期文件通过批量操作永久删除
+	storage     storage.TieredStorage
+	rules       []lifecycleRule
+	cfg         config.LifecycleConfig
+	restoreTier storage.RestoreTier
+	now         func() time.Time
+}
+
+// NewLifecycleService 创建存储分层和生命周期服务（校验规则）
+// tiered 须为原始存储后端（单一 S3/OSS 后端或多后端路由存储），不经过加密和压缩装饰器
+func NewLifecycleService(fileRepo repositories.FileRepository, files FileService, tiered storage.TieredStorage, cfg config.LifecycleConfig) (LifecycleService, error) {
+	restoreTier, err := storage.ParseRestoreTier(cfg.RestoreTier)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lifecycle restore_tier: %w", err)
+	}
+	if cfg.RestoreDays <= 0 {
+		return nil, fmt.Errorf("invalid lifecycle restore_days: must be positive")
+	}
+	if len(cfg.Rules) > 0 && cfg.Interval <= 0 {
+		return nil, fmt.Errorf("invalid lifecycle interval: must be positive")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	rules := make([]lifecycleRule, len(cfg.Rules))
+	for i, ruleCfg := range cfg.Rules {
+		name := ruleCfg.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		if len(ruleCfg.Transitions) == 0 && ruleCfg.ExpireDays <= 0 && ruleCfg.ExpireDaysSinceAccess <= 0 {
+			return nil, fmt.Errorf("invalid lifecycle rule %s: no transitions, expire_days or expire_days_since_access", name)
+		}
+		if ruleCfg.ExpireDays < 0 || ruleCfg.ExpireDaysSinceAccess < 0 {
+			return nil, fmt.Errorf("invalid lifecycle rule %s: expire_days and expire_days_since_access must not be negative", name)
+		}
+
+		rule := lifecycleRule{
+			name:        name,
+			folder:      "/",
+			tags:        ruleCfg.Tags,
+			expireAfter: dayDuration(ruleCfg.ExpireDays),
+			expireIdle:  dayDuration(ruleCfg.ExpireDaysSinceAccess),
+		}
+		if ruleCfg.Folder != "" {
+			rule.folder = normalizeFolder(ruleCfg.Folder)
+		}
+		for _, t := range ruleCfg.Transitions {
+			class, err := storage.ParseStorageClass(t.StorageClass)
+			if err != nil {
+				return nil, fmt.Errorf("invalid lifecycle rule %s: %w", name, err)
+			}
+			if t.StorageClass == "" || class == storage.StorageClassStandard {
+				return nil, fmt.Errorf("invalid lifecycle rule %s: transitions must target infrequent_access, archive or cold_archive", name)
+			}
+			if t.Days < 0 || t.DaysSinceAccess < 0 || t.Days+t.DaysSinceAccess == 0 {
+				return nil, fmt.Errorf("invalid lifecycle rule %s: transition to %s needs positive days or days_since_access", name, class)
+			}
+			rule.transitions = append(rule.transitions, lifecycleTransition{class: class, age: dayDuration(t.Days), idle: dayDuration(t.DaysSinceAccess)})
+		}
+		rules[i] = rule
+	}
+
+	return &lifecycleService{
+		fileRepo:    fileRepo,
+		files:       files,
+		storage:     tiered,
+		rules:       rules,
+		cfg:         cfg,
+		restoreTier: restoreTier,
+		now:         time.Now,
+	}, nil
+}
+
+// dayDuration 将天数转换为时长
+func dayDuration(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// RequiresRestore 判断文件是否为尚未取回（或取回已过期）的归档文件，这类文件不能下载或复制
+func RequiresRestore(file *models.File, now time.Time) bool {
+	class, err := storage.ParseStorageClass(file.StorageClass)
+	if err != nil || !class.RequiresRestore() {
+		return false
+	}
+	restored := file.RestoreStatus == models.RestoreStatusRestored && file.RestoreExpiresAt != nil && now.Before(*file.RestoreExpiresAt)
+	return !restored
+}
+
+// Run 按间隔评估规则
+func (s *lifecycleService) Run(ctx context.Context, report func(LifecycleAction)) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = s.Evaluate(ctx, report)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// match 返回文件适用的第一条规则
+func (s *lifecycleService) match(file *models.File) *lifecycleRule {
+	for i := range s.rules {
+		if s.rules[i].matches(file) {
+			return &s.rules[i]
+		}
+	}
+	return nil
+}
+
+// Evaluate 评估一次所有文件
+func (s *lifecycleService) Evaluate(ctx context.Context, report func(LifecycleAction)) (*LifecycleSummary, error) {
+	if report == nil {
+		report = func(LifecycleAction) {}
+	}
+	summary := &LifecycleSummary{}
+	now := s.now()
+
+	var after uuid.UUID
+	for {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		files, err := s.fileRepo.ListAfter(ctx, after, s.cfg.BatchSize)
+		if err != nil {
+			return summary, fmt.Errorf("failed to list files: %w", err)
+		}
+		if len(files) == 0 {
+			return summary, nil
+		}
+		after = files[len(files)-1].ID
+
+		var expire []BatchOperation
+		expireRules := make(map[uuid.UUID]string)
+		for _, file := range files {
+			// 回收站中和未上传完成的文件不处理
+			if file.DeletedAt.Valid || file.Status != models.FileStatusCompleted {
+				continue
+			}
+			summary.Scanned++
+
+			if action, changed := s.refreshRestore(ctx, file, now); changed {
+				if action.Err != nil {
+					summary.Failed++
+				} else if action.Action == LifecycleRestored {
+					summary.Restored++
+				}
+				report(action)
+			}
+
+			rule := s.match(file)
+			if rule == nil {
+				continue
+			}
+			if rule.expired(file, now) {
+				expire = append(expire, BatchOperation{Op: BatchOpDelete, FileID: file.ID, Permanent: true})
+				expireRules[file.ID] = rule.name
+				continue
+			}
+
+			target := rule.target(file, now)
+			current, err := storage.ParseStorageClass(file.StorageClass)
+			if target == "" || err != nil || !target.ColderThan(current) || current.RequiresRestore() {
+				continue
+			}
+			action := LifecycleAction{FileID: file.ID, Rule: rule.name, Action: LifecycleTransition, StorageClass: target}
+			if action.Err = s.transition(ctx, file, current, target); action.Err != nil {
+				summary.Failed++
+			} else {
+				summary.Transitioned++
+			}
+			report(action)
+		}
+
+		if len(expire) > 0 {
+			results, err := s.files.BatchOperate(ctx, expire)
+			if err != nil {
+				return summary, fmt.Errorf("failed to expire files: %w", err)
+			}
+			for _, result := range results {
+				action := LifecycleAction{FileID: result.FileID, Rule: expireRules[result.FileID], Action: LifecycleExpire}
+				if result.Success {
+					summary.Expired++
+				} else {
+					action.Err = errors.New(result.Error)
+					summary.Failed++
+				}
+				report(action)
+			}
+		}
+
+		if len(files) < s.cfg.BatchSize {
+			return summary, nil
+		}
+	}
+}
+
+// transition 转换存储对象的存储类型并更新文件记录
+func (s *lifecycleService) transition(ctx context.Context, file *models.File, from, to storage.StorageClass) error {
+	if err := s.storage.SetStorageClass(ctx, file.StorageKey, to); err != nil {
+		return err
+	}
+	if err := s.fileRepo.UpdateStorageClass(ctx, file.ID, file.StorageClass, string(to)); err != nil {
+		return fmt.Errorf("failed to update storage class from %s to %s: %w", from, to, err)
+	}
+	file.StorageClass = string(to)
+	file.RestoreStatus = ""
+	file.RestoreExpiresAt = nil
+	return nil
+}
+
+// refreshRestore 更新取回中的状态，清除已过期的取回状态（返回是否有变化）
+func (s *lifecycleService) refreshRestore(ctx context.Context, file *models.File, now time.Time) (LifecycleAction, bool) {
+	action := LifecycleAction{FileID: file.ID}
+	switch file.RestoreStatus {
+	case models.RestoreStatusInProgress:
+		state, err := s.storage.RestoreState(ctx, file.StorageKey)
+		if err != nil {
+			action.Action, action.Err = LifecycleRestored, fmt.Errorf("failed to get restore state: %w", err)
+			return action, true
+		}
+		if state.InProgress {
+			return action, false
+		}
+		if state.Restored(now) {
+			action.Action, action.Err = LifecycleRestored, s.setRestoreStatus(ctx, file, models.RestoreStatusRestored, state.ExpiresAt)
+		} else {
+			action.Action, action.Err = LifecycleRestoreExpired, s.setRestoreStatus(ctx, file, "", nil)
+		}
+		return action, true
+	case models.RestoreStatusRestored:
+		if file.RestoreExpiresAt != nil && now.Before(*file.RestoreExpiresAt) {
+			return action, false
+		}
+		action.Action, action.Err = LifecycleRestoreExpired, s.setRestoreStatus(ctx, file, "", nil)
+		return action, true
+	}
+	return action, false
+}
+
+// setRestoreStatus 更新文件记录上的取回状态
+func (s *lifecycleService) setRestoreStatus(ctx context.Context, file *models.File, status models.RestoreStatus, expiresAt *time.Time) error {
+	if err := s.fileRepo.UpdateRestoreStatus(ctx, file.ID, status, expiresAt); err != nil {
+		return fmt.Errorf("failed to update restore status: %w", err)
+	}
+	file.RestoreStatus = status
+	file.RestoreExpiresAt = expiresAt
+	return nil
+}
+
+// RestoreFile 取回归档文件
+// 已取回的文件再次取回会延长副本的保留时间
+func (s *lifecycleService) RestoreFile(ctx context.Context, fileID uuid.UUID, days int, tier string) (*models.File, error) {
+	if days == 0 {
+		days = s.cfg.RestoreDays
+	}
+	if days < 0 {
+		return nil, fmt.Errorf("invalid restore days: must be positive")
+	}
+	restoreTier := s.restoreTier
+	if tier != "" {
+		var err error
+		if restoreTier, err = storage.ParseRestoreTier(tier); err != nil {
+			return nil, fmt.Errorf("invalid restore tier: %w", err)
+		}
+	}
+
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+	if file.Status != models.FileStatusCompleted {
+		return nil, fmt.Errorf("file is not ready for restore")
+	}
+	class, err := storage.ParseStorageClass(file.StorageClass)
+	if err != nil {
+		return nil, err
+	}
+	if !class.RequiresRestore() {
+		return nil, fmt.Errorf("file is not archived (storage class %s)", class)
+	}
+	if file.RestoreStatus == models.RestoreStatusInProgress {
+		return file, nil
+	}
+
+	if err := s.storage.RestoreObject(ctx, file.StorageKey, days, restoreTier); err != nil {
+		return nil, fmt.Errorf("failed to restore file: %w", err)
+	}
+
+	// 已取回的对象再次取回时立即返回新的过期时间，其余情况标记为取回中，由查询或生命周期任务更新
+	if state, err := s.storage.RestoreState(ctx, file.StorageKey); err == nil && state.Restored(s.now()) {
+		err = s.setRestoreStatus(ctx, file, models.RestoreStatusRestored, state.ExpiresAt)
+		return file, err
+	}
+	if err := s.setRestoreStatus(ctx, file, models.RestoreStatusInProgress, nil); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// GetRestoreStatus 查询文件的存储类型和取回状态
+func (s *lifecycleService) GetRestoreStatus(ctx context.Context, fileID uuid.UUID) (*models.File, error) {
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+	if action, changed := s.refreshRestore(ctx, file, s.now()); changed && action.Err != nil {
+		return nil, action.Err
+	}
+	return file, nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+)
+
+// MockTieredStorage 记录存储类型和取回请求的内存实现
+type MockTieredStorage struct {
+	classes  map[string]storage.StorageClass
+	restores map[string]*storage.RestoreState
+}
+
+func NewMockTieredStorage() *MockTieredStorage {
+	return &MockTieredStorage{
+		classes:  make(map[string]storage.StorageClass),
+		restores: make(map[string]*storage.RestoreState),
+	}
+}
+
+func (m *MockTieredStorage) SetStorageClass(ctx context.Context, key string, class storage.StorageClass) error {
+	m.classes[key] = class
+	return nil
+}
+
+func (m *MockTieredStorage) RestoreObject(ctx context.Context, key string, days int, tier storage.RestoreTier) error {
+	if _, ok := m.restores[key]; !ok {
+		m.restores[key] = &storage.RestoreState{InProgress: true}
+	}
+	return nil
+}
+
+func (m *MockTieredStorage) RestoreState(ctx context.Context, key string) (*storage.RestoreState, error) {
+	if state, ok := m.restores[key]; ok {
+		return state, nil
+	}
+	return &storage.RestoreState{}, nil
+}
+
+// newLifecycleTestFile 创建指定天数前上传完成的测试文件
+func newLifecycleTestFile(t *testing.T, repo *MockFileRepository, store *MockStorage, name, folder string, age time.Duration, tags ...string) *models.File {
+	t.Helper()
+
+	file := newBatchTestFile(t, repo, store, name)
+	file.Folder = folder
+	file.Tags = tags
+	file.StorageClass = string(storage.StorageClassStandard)
+	file.CreatedAt = time.Now().Add(-age)
+	return file
+}
+
+// TestNewLifecycleServiceValidation 测试生命周期规则校验
+func TestNewLifecycleServiceValidation(t *testing.T) {
+	base := config.LifecycleConfig{Interval: time.Hour, RestoreDays: 7, RestoreTier: "standard"}
+	tests := []struct {
+		name  string
+		rules []config.LifecycleRuleConfig
+		tier  string
+		want  string
+	}{
+		{"empty rule", []config.LifecycleRuleConfig{{Name: "empty"}}, "", "no transitions, expire_days"},
+		{"standard target", []config.LifecycleRuleConfig{{Transitions: []config.LifecycleTransitionConfig{{StorageClass: "standard", Days: 30}}}}, "", "transitions must target"},
+		{"unknown class", []config.LifecycleRuleConfig{{Transitions: []config.LifecycleTransitionConfig{{StorageClass: "glacier", Days: 30}}}}, "", "unsupported storage class"},
+		{"no condition", []config.LifecycleRuleConfig{{Transitions: []config.LifecycleTransitionConfig{{StorageClass: "archive"}}}}, "", "needs positive days"},
+		{"bad tier", nil, "slow", "restore_tier"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.Rules = tt.rules
+			if tt.tier != "" {
+				cfg.RestoreTier = tt.tier
+			}
+			_, err := NewLifecycleService(NewMockFileRepository(), nil, NewMockTieredStorage(), cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewLifecycleService() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestLifecycleEvaluate 测试按目录、标签、上传时间和最后下载时间转换存储类型及过期删除
+func TestLifecycleEvaluate(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	tiered := NewMockTieredStorage()
+	files := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	svc, err := NewLifecycleService(repo, files, tiered, config.LifecycleConfig{
+		Interval:    time.Hour,
+		RestoreDays: 7,
+		Rules: []config.LifecycleRuleConfig{
+			{Name: "tmp", Folder: "/tmp", ExpireDays: 1},
+			{Name: "drafts", Folder: "/drafts", ExpireDaysSinceAccess: 30},
+			{Name: "reports", Folder: "/reports", Tags: []string{"final"}, Transitions: []config.LifecycleTransitionConfig{
+				{StorageClass: "infrequent_access", Days: 30},
+				{StorageClass: "archive", Days: 90, DaysSinceAccess: 60},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLifecycleService failed: %v", err)
+	}
+
+	day := 24 * time.Hour
+	expired := newLifecycleTestFile(t, repo, store, "expired.txt", "/tmp/upload", 2*day)
+	fresh := newLifecycleTestFile(t, repo, store, "fresh.txt", "/tmp", time.Hour)
+	untagged := newLifecycleTestFile(t, repo, store, "untagged.txt", "/reports", 100*day)
+	warm := newLifecycleTestFile(t, repo, store, "warm.txt", "/reports/2025", 40*day, "final")
+	cold := newLifecycleTestFile(t, repo, store, "cold.txt", "/reports", 100*day, "final")
+	accessed := newLifecycleTestFile(t, repo, store, "accessed.txt", "/reports", 100*day, "final")
+	recent := time.Now().Add(-10 * day)
+	accessed.LastAccessedAt = &recent
+	stale := newLifecycleTestFile(t, repo, store, "stale.txt", "/drafts", 100*day)
+	draft := newLifecycleTestFile(t, repo, store, "draft.txt", "/drafts", 100*day)
+	draft.LastAccessedAt = &recent
+
+	var actions []LifecycleAction
+	summary, err := svc.Evaluate(ctx, func(action LifecycleAction) { actions = append(actions, action) })
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if summary.Scanned != 8 || summary.Expired != 2 || summary.Transitioned != 3 || summary.Failed != 0 {
+		t.Errorf("unexpected summary: %+v (actions: %+v)", summary, actions)
+	}
+
+	if _, ok := repo.files[expired.ID]; ok || store.objects[expired.StorageKey] != nil {
+		t.Error("expired file should be deleted permanently")
+	}
+	if _, ok := repo.files[stale.ID]; ok {
+		t.Error("file not downloaded for 30 days should be deleted permanently")
+	}
+	if _, ok := repo.files[fresh.ID]; !ok {
+		t.Error("fresh file should not be deleted")
+	}
+	if _, ok := repo.files[draft.ID]; !ok {
+		t.Error("recently downloaded file should not be deleted")
+	}
+	wantClasses := map[*models.File]storage.StorageClass{
+		untagged: storage.StorageClassStandard,
+		warm:     storage.StorageClassInfrequentAccess,
+		cold:     storage.StorageClassArchive,
+		accessed: storage.StorageClassInfrequentAccess,
+	}
+	for file, want := range wantClasses {
+		if file.StorageClass != string(want) {
+			t.Errorf("%s: storage class = %s, want %s", file.Name, file.StorageClass, want)
+		}
+		if want != storage.StorageClassStandard && tiered.classes[file.StorageKey] != want {
+			t.Errorf("%s: object storage class = %s, want %s", file.Name, tiered.classes[file.StorageKey], want)
+		}
+	}
+
+	// 已转换的文件不会重复转换
+	summary, err = svc.Evaluate(ctx, nil)
+	if err != nil || summary.Transitioned != 0 || summary.Expired != 0 {
+		t.Errorf("second Evaluate = %+v, %v, want no changes", summary, err)
+	}
+}
+
+// TestLifecycleRestore 测试归档文件取回和下载限制
+func TestLifecycleRestore(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	tiered := NewMockTieredStorage()
+	files := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	svc, err := NewLifecycleService(repo, files, tiered, config.LifecycleConfig{RestoreDays: 7})
+	if err != nil {
+		t.Fatalf("NewLifecycleService failed: %v", err)
+	}
+
+	standard := newLifecycleTestFile(t, repo, store, "standard.txt", "/", 0)
+	if _, err := svc.RestoreFile(ctx, standard.ID, 0, ""); err == nil || !strings.Contains(err.Error(), "not archived") {
+		t.Errorf("RestoreFile() on standard file error = %v, want not archived", err)
+	}
+
+	archived := newLifecycleTestFile(t, repo, store, "archived.txt", "/", 0)
+	archived.StorageClass = string(storage.StorageClassArchive)
+	if _, err := files.DownloadFile(ctx, archived.ID, ""); err == nil || !strings.Contains(err.Error(), "archived") {
+		t.Errorf("DownloadFile() on archived file error = %v, want archived", err)
+	}
+
+	file, err := svc.RestoreFile(ctx, archived.ID, 3, "bulk")
+	if err != nil || file.RestoreStatus != models.RestoreStatusInProgress {
+		t.Fatalf("RestoreFile() = %+v, %v, want in_progress", file, err)
+	}
+
+	// 取回完成后查询状态更新为已取回，过期前可以下载
+	expiresAt := time.Now().Add(72 * time.Hour)
+	tiered.restores[archived.StorageKey] = &storage.RestoreState{ExpiresAt: &expiresAt}
+	file, err = svc.GetRestoreStatus(ctx, archived.ID)
+	if err != nil || file.RestoreStatus != models.RestoreStatusRestored || !file.RestoreExpiresAt.Equal(expiresAt) {
+		t.Fatalf("GetRestoreStatus() = %+v, %v, want restored", file, err)
+	}
+	if RequiresRestore(file, time.Now()) {
+		t.Error("restored file should be downloadable")
+	}
+	if _, err := files.DownloadFile(ctx, archived.ID, ""); err != nil {
+		t.Errorf("DownloadFile() on restored file error = %v", err)
+	}
+
+	// 取回副本过期后清除取回状态
+	if !RequiresRestore(file, expiresAt) {
+		t.Error("restored copy should require restore after it expires")
+	}
+	past := time.Now().Add(-time.Hour)
+	archived.RestoreExpiresAt = &past
+	if _, err := svc.Evaluate(ctx, nil); err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if archived.RestoreStatus != "" || archived.RestoreExpiresAt != nil {
+		t.Errorf("expired restore should be cleared: %+v", archived)
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/cache"
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/google/uuid"
+)
+
+// outboxCleanupInterval 清理已发布事件的间隔
+const outboxCleanupInterval = time.Hour
+
+// OutboxRelay 发件箱中继
+// 按写入顺序读取未发布的事件，依次交给所有发布器，全部成功后标记为已发布
+// 读取、发布和标记在同一事务中完成：事件行在事务期间被锁定，多实例部署时不会被重复处理
+type OutboxRelay struct {
+	outboxRepo repositories.OutboxRepository
+	transactor repositories.Transactor
+	publishers []EventPublisher
+	cfg        config.OutboxConfig
+}
+
+// NewOutboxRelay 创建发件箱中继
+func NewOutboxRelay(outboxRepo repositories.OutboxRepository, transactor repositories.Transactor, cfg config.OutboxConfig, publishers ...EventPublisher) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo: outboxRepo,
+		transactor: transactor,
+		publishers: publishers,
+		cfg:        cfg,
+	}
+}
+
+// Run 持续发布直到 ctx 取消
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Now()
+	for {
+		// 本批发布满时立即继续，否则等待下一次轮询
+		n, err := r.RelayPending(ctx)
+		if err == nil && n >= r.cfg.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		if time.Since(lastCleanup) >= outboxCleanupInterval {
+			_, _ = r.outboxRepo.DeletePublishedBefore(ctx, time.Now().Add(-r.cfg.Retention))
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending 发布一批未发布的事件，返回成功发布的事件数
+// 某个事件发布失败时停止本批（保持顺序），已成功的事件仍会被标记
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	var published int
+	var relayErr error
+
+	err := r.transactor.Transaction(ctx, func(ctx context.Context) error {
+		events, err := r.outboxRepo.ListUnpublished(ctx, r.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list outbox events: %w", err)
+		}
+
+		var ids []uuid.UUID
+		for _, record := range events {
+			var event Event
+			if jsonErr := json.Unmarshal([]byte(record.Payload), &event); jsonErr != nil {
+				// 无法解析的事件重试也不会成功：放弃发布，不再占用后续批次
+				if err := r.outboxRepo.MarkDead(ctx, record.ID, fmt.Sprintf("invalid payload: %v", jsonErr), time.Now()); err != nil {
+					return fmt.Errorf("failed to mark outbox event dead: %w", err)
+				}
+				continue
+			}
+
+			if pubErr := r.publish(ctx, &event); pubErr != nil {
+				relayErr = fmt.Errorf("failed to publish event %s: %w", record.ID, pubErr)
+				if err := r.outboxRepo.MarkFailed(ctx, record.ID, pubErr.Error()); err != nil {
+					return fmt.Errorf("failed to mark outbox event failed: %w", err)
+				}
+				break
+			}
+			ids = append(ids, record.ID)
+		}
+
+		if err := r.outboxRepo.MarkPublished(ctx, ids, time.Now()); err != nil {
+			return fmt.Errorf("failed to mark outbox events published: %w", err)
+		}
+		published = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, relayErr
+}
+
+// publish 将单个事件交给所有发布器
+func (r *OutboxRelay) publish(ctx context.Context, event *Event) error {
+	for _, publisher := range r.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamStore Redis Stream 存储（由 cache.RedisClient 实现）
+type streamStore interface {
+	XAddOnce(ctx context.Context, stream, dedupeKey string, dedupeTTL time.Duration, maxLen int64, values map[string]interface{}) (string, error)
+	EnsureStreamGroup(ctx context.Context, stream, group string) error
+}
+
+// RedisStreamPublisher 将事件发布到 Redis Stream
+// 以事件 ID 作为幂等键追加消息，中继重复发布时不会产生重复消息；
+// 配合消费者组（XREADGROUP + XACK），每个组内每个事件只会被一个消费者处理一次
+type RedisStreamPublisher struct {
+	redis       streamStore
+	cfg         config.OutboxConfig
+	groupsReady bool
+}
+
+// NewRedisStreamPublisher 创建 Redis Stream 发布器
+func NewRedisStreamPublisher(redis *cache.RedisClient, cfg config.OutboxConfig) *RedisStreamPublisher {
+	return &RedisStreamPublisher{
+		redis: redis,
+		cfg:   cfg,
+	}
+}
+
+// Publish 追加事件到 Stream（消息字段：event_id、type、payload）
+func (p *RedisStreamPublisher) Publish(ctx context.Context, event *Event) error {
+	// 首次发布前创建消费者组，保证组能消费到所有事件
+	if !p.groupsReady {
+		for _, group := range p.cfg.ConsumerGroups {
+			if err := p.redis.EnsureStreamGroup(ctx, p.cfg.Stream, group); err != nil {
+				return fmt.Errorf("failed to create consumer group %s: %w", group, err)
+			}
+		}
+		p.groupsReady = true
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	dedupeKey := fmt.Sprintf("%s:published:%s", p.cfg.Stream, event.ID)
+	_, err = p.redis.XAddOnce(ctx, p.cfg.Stream, dedupeKey, p.cfg.DedupeTTL, p.cfg.StreamMaxLen, map[string]interface{}{
+		"event_id": event.ID.String(),
+		"type":     string(event.Type),
+		"payload":  string(payload),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add event to stream: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+)
+
+// recordingPublisher 记录收到的事件，可模拟发布失败
+type recordingPublisher struct {
+	events []*Event
+	fail   bool
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event *Event) error {
+	if p.fail {
+		return errors.New("broker unavailable")
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// memoryStreamStore 内存实现的 Redis Stream（模拟幂等键）
+type memoryStreamStore struct {
+	entries []map[string]interface{}
+	dedupe  map[string]string
+	groups  map[string]bool
+}
+
+func (m *memoryStreamStore) XAddOnce(ctx context.Context, stream, dedupeKey string, dedupeTTL time.Duration, maxLen int64, values map[string]interface{}) (string, error) {
+	if id, ok := m.dedupe[dedupeKey]; ok {
+		return id, nil
+	}
+	m.entries = append(m.entries, values)
+	id := uuid.NewString()
+	m.dedupe[dedupeKey] = id
+	return id, nil
+}
+
+func (m *memoryStreamStore) EnsureStreamGroup(ctx context.Context, stream, group string) error {
+	m.groups[group] = true
+	return nil
+}
+
+func TestOutboxRelay(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	outbox := NewMockOutboxRepository()
+	store := NewMockStorage()
+	svc := NewFileService(repo, outbox, store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	// 预签名上传 + 确认：产生 file.created、file.completed
+	result, err := svc.InitPresignedUpload(ctx, "a.png", "image/png", 10, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("InitPresignedUpload() error = %v", err)
+	}
+	if _, err := svc.ConfirmUpload(ctx, result.FileID); err != nil {
+		t.Fatalf("ConfirmUpload() error = %v", err)
+	}
+	// 重复确认不会再次产生 file.completed
+	if _, err := svc.ConfirmUpload(ctx, result.FileID); err == nil || !strings.Contains(err.Error(), "not awaiting confirmation") {
+		t.Fatalf("second ConfirmUpload() error = %v, want not awaiting confirmation", err)
+	}
+
+	publisher := &recordingPublisher{fail: true}
+	relay := NewOutboxRelay(outbox, MockTransactor{}, config.OutboxConfig{BatchSize: 10}, publisher)
+
+	// 发布失败：事件保持未发布，记录失败原因
+	if n, err := relay.RelayPending(ctx); err == nil || n != 0 {
+		t.Fatalf("RelayPending() = %d, %v; want failure", n, err)
+	}
+	if outbox.events[0].PublishedAt != nil || outbox.events[0].Attempts != 1 {
+		t.Fatalf("failed event should stay unpublished: %+v", outbox.events[0])
+	}
+
+	// 恢复后按顺序发布并标记
+	publisher.fail = false
+	if n, err := relay.RelayPending(ctx); err != nil || n != 2 {
+		t.Fatalf("RelayPending() = %d, %v; want 2", n, err)
+	}
+	if len(publisher.events) != 2 || publisher.events[0].Type != EventFileCreated || publisher.events[1].Type != EventFileCompleted {
+		t.Fatalf("published events = %+v", publisher.events)
+	}
+	if publisher.events[1].Data.ID != result.FileID || publisher.events[1].Data.Status != models.FileStatusCompleted {
+		t.Errorf("event data = %+v", publisher.events[1].Data)
+	}
+	if n, _ := relay.RelayPending(ctx); n != 0 {
+		t.Errorf("published events should not be relayed again, got %d", n)
+	}
+}
+
+// TestOutboxRelayPoisonEvents 测试无法解析的事件多于一批时不会阻塞后续事件
+func TestOutboxRelayPoisonEvents(t *testing.T) {
+	ctx := context.Background()
+	outbox := NewMockOutboxRepository()
+	for i := 0; i < 3; i++ {
+		outbox.Create(ctx, &models.OutboxEvent{ID: uuid.New(), EventType: string(EventFileCreated), Payload: "not json"})
+	}
+	valid := &models.OutboxEvent{ID: uuid.New(), EventType: string(EventFileCreated), Payload: `{"type":"file.created"}`}
+	outbox.Create(ctx, valid)
+
+	publisher := &recordingPublisher{}
+	relay := NewOutboxRelay(outbox, MockTransactor{}, config.OutboxConfig{BatchSize: 2}, publisher)
+	for i := 0; i < 2; i++ {
+		if _, err := relay.RelayPending(ctx); err != nil {
+			t.Fatalf("RelayPending() error = %v", err)
+		}
+	}
+
+	if valid.PublishedAt == nil || len(publisher.events) != 1 {
+		t.Fatalf("valid event should be published after the poison events, published = %d", len(publisher.events))
+	}
+	for _, event := range outbox.events[:3] {
+		if event.DeadAt == nil || event.PublishedAt != nil || !strings.Contains(event.LastError, "invalid payload") {
+			t.Errorf("poison event should be dead: %+v", event)
+		}
+	}
+	if events, _ := outbox.ListUnpublished(ctx, 10); len(events) != 0 {
+		t.Errorf("unpublished events = %d, want 0", len(events))
+	}
+}
+
+func TestRedisStreamPublisher(t *testing.T) {
+	ctx := context.Background()
+	streams := &memoryStreamStore{dedupe: make(map[string]string), groups: make(map[string]bool)}
+	publisher := &RedisStreamPublisher{
+		redis: streams,
+		cfg:   config.OutboxConfig{Stream: "assethub:events", ConsumerGroups: []string{"indexer"}},
+	}
+
+	event := NewFileEvent(EventFileDeleted, &models.File{BaseModel: models.BaseModel{ID: uuid.New()}})
+	for i := 0; i < 2; i++ {
+		if err := publisher.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	// 重复发布同一事件只追加一条消息
+	if len(streams.entries) != 1 {
+		t.Fatalf("stream entries = %d, want 1", len(streams.entries))
+	}
+	if streams.entries[0]["type"] != "file.deleted" || streams.entries[0]["event_id"] != event.ID.String() {
+		t.Errorf("unexpected entry: %v", streams.entries[0])
+	}
+	if !streams.groups["indexer"] {
+		t.Errorf("consumer group should be created before publishing")
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+)
+
+// TestGetDownloadURLOptions 测试下载 URL 的有效期范围和响应头选项
+func TestGetDownloadURLOptions(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	presign := config.PresignConfig{DownloadExpiry: 10 * time.Minute, MinExpiry: time.Minute, MaxExpiry: time.Hour}
+	svc := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{Presign: presign})
+
+	file := newBatchTestFile(t, repo, store, "report.txt")
+	mismatched := newBatchTestFile(t, repo, store, "image.txt")
+	mismatched.ContentTypeMismatch = true
+
+	tests := []struct {
+		name            string
+		opts            DownloadURLOptions
+		mismatch        bool
+		wantErr         string
+		wantExpiry      time.Duration
+		wantDisposition string
+		wantFilename    string
+	}{
+		{name: "defaults", wantExpiry: 10 * time.Minute, wantDisposition: "inline", wantFilename: "report.txt"},
+		{name: "custom", opts: DownloadURLOptions{Expiry: 30 * time.Minute, Disposition: "attachment", Filename: "../Q3 报告.txt", CacheControl: "private, max-age=60"},
+			wantExpiry: 30 * time.Minute, wantDisposition: "attachment", wantFilename: "Q3 报告.txt"},
+		{name: "mismatch stays attachment", opts: DownloadURLOptions{Disposition: "inline"}, mismatch: true,
+			wantExpiry: 10 * time.Minute, wantDisposition: "attachment", wantFilename: "image.txt"},
+		{name: "too short", opts: DownloadURLOptions{Expiry: time.Second}, wantErr: "invalid expiry"},
+		{name: "too long", opts: DownloadURLOptions{Expiry: 2 * time.Hour}, wantErr: "invalid expiry"},
+		{name: "bad disposition", opts: DownloadURLOptions{Disposition: "download"}, wantErr: "invalid disposition"},
+		{name: "header injection", opts: DownloadURLOptions{CacheControl: "no-cache\r\nSet-Cookie: a=b"}, wantErr: "invalid cache control"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := file.ID
+			if tt.mismatch {
+				id = mismatched.ID
+			}
+
+			result, err := svc.GetDownloadURL(ctx, id, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetDownloadURL() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetDownloadURL() error = %v", err)
+			}
+
+			if store.lastExpiry != tt.wantExpiry || result.ExpiresIn != int64(tt.wantExpiry.Seconds()) {
+				t.Errorf("expiry = %v (expires_in %d), want %v", store.lastExpiry, result.ExpiresIn, tt.wantExpiry)
+			}
+			got := store.lastPresign
+			if got.ContentDisposition != tt.wantDisposition || got.Filename != tt.wantFilename || got.CacheControl != tt.opts.CacheControl {
+				t.Errorf("presign options = %+v, want disposition %q, filename %q, cache control %q",
+					got, tt.wantDisposition, tt.wantFilename, tt.opts.CacheControl)
+			}
+		})
+	}
+}
+
+// TestPresignedUploadExpiry 测试上传和分片 URL 的有效期
+func TestPresignedUploadExpiry(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	svc := NewFileService(repo, NewMockOutboxRepository(), NewMockStorage(), MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	// 未配置时默认 1 小时
+	result, err := svc.InitPresignedUpload(ctx, "a.png", "image/png", 10, 0, nil, nil)
+	if err != nil || result.ExpiresIn != 3600 {
+		t.Fatalf("InitPresignedUpload() = %+v, %v, want expires_in 3600", result, err)
+	}
+
+	if _, err := svc.InitPresignedUpload(ctx, "a.png", "image/png", 10, 8*24*time.Hour, nil, nil); err == nil || !strings.Contains(err.Error(), "invalid expiry") {
+		t.Errorf("InitPresignedUpload(8 days) error = %v, want invalid expiry", err)
+	}
+
+	multipart, err := svc.InitMultipartUpload(ctx, "b.mp4", "video/mp4", 1<<30, "", nil)
+	if err != nil {
+		t.Fatalf("InitMultipartUpload() error = %v", err)
+	}
+	part, err := svc.GeneratePartUploadURL(ctx, multipart.FileID, 1, 5*time.Minute, nil)
+	if err != nil || part.ExpiresIn != 300 {
+		t.Errorf("GeneratePartUploadURL() = %+v, %v, want expires_in 300", part, err)
+	}
+}
+
+// TestInitPresignedPost 测试 POST 表单策略条件来自文件记录
+func TestInitPresignedPost(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	svc := NewFileService(repo, NewMockOutboxRepository(), NewMockStorage(), MockTransactor{}, DownloadPolicy{}, FileServiceOptions{})
+
+	// 声明类型与扩展名不符时按扩展名推断
+	result, err := svc.InitPresignedPost(ctx, "photo.png", "application/x-msdownload", 2048, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("InitPresignedPost() error = %v", err)
+	}
+
+	file, _ := repo.GetByID(ctx, result.FileID)
+	if file == nil || file.Status != models.FileStatusPending {
+		t.Fatalf("file record = %+v, want pending", file)
+	}
+	if result.Method != "POST" || result.ExpiresIn != 3600 {
+		t.Errorf("result = %+v, want POST with default expiry", result)
+	}
+	want := map[string]string{"key": file.StorageKey, "Content-Type": "image/png", "content-length-range": "2048,2048"}
+	for name, value := range want {
+		if result.Fields[name] != value {
+			t.Errorf("Fields[%s] = %q, want %q", name, result.Fields[name], value)
+		}
+	}
+}
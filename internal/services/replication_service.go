@@ -0,0 +1,519 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/queue"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+)
+
+// ReplicationJobType 文件复制任务类型
+const ReplicationJobType = "file.replicate"
+
+// replicationJobPayload 复制任务载荷
+type replicationJobPayload struct {
+	FileID uuid.UUID `json:"file_id"`
+}
+
+// BackendSet 按名称访问存储后端（由 storage.RoutingStorage 实现）
+type BackendSet interface {
+	// Backend 按名称返回后端
+	Backend(name string) (storage.Storage, bool)
+
+	// DefaultBackend 返回默认后端名称
+	DefaultBackend() string
+}
+
+// ReplicationOptions 批量复制选项
+type ReplicationOptions struct {
+	Source    string // 源后端：只处理主对象在该后端的文件（为空表示默认后端）
+	Target    string // 目标后端
+	Promote   bool   // 复制后将目标后端设为主后端，源对象保留为副本（迁移）
+	Restart   bool   // 忽略未完成任务的检查点，从头开始
+	BatchSize int    // 每批查询的文件数（默认 100）
+	Limit     int    // 本次最多复制的文件数（0 不限制）
+}
+
+// ReplicationReport 单个文件的复制结果（用于输出进度）
+type ReplicationReport struct {
+	FileID  uuid.UUID
+	Key     string
+	Size    int64 // 复制的字节数（副本已是最新时为 0）
+	Skipped bool  // 副本已是最新，未复制
+	Err     error
+}
+
+// Replicator 跨后端复制器：把存储对象原样复制到其他后端，并在数据库中记录副本位置和校验状态
+// 副本与主对象使用相同的存储键；客户端加密的密文和压缩后的内容按原样复制，读取时沿用文件记录上的数据密钥和压缩编码
+type Replicator interface {
+	// ReplicateFile 将文件复制到所有配置的目标后端（副本已是最新时跳过）
+	// 文件记录已永久删除时删除所有副本；在回收站中的文件保留副本
+	ReplicateFile(ctx context.Context, fileID uuid.UUID) ([]*models.FileReplica, error)
+
+	// ListReplicas 查询文件的副本
+	ListReplicas(ctx context.Context, fileID uuid.UUID) ([]*models.FileReplica, error)
+
+	// Migrate 批量复制主对象在源后端的文件到目标后端，按文件 ID 顺序处理并保存检查点
+	// 中断后以相同的源、目标和 Promote 重新执行会从检查点继续
+	Migrate(ctx context.Context, opts ReplicationOptions, report func(ReplicationReport)) (*models.ReplicationRun, error)
+}
+
+// replicator 跨后端复制器实现
+type replicator struct {
+	fileRepo   repositories.FileRepository
+	replicas   repositories.ReplicationRepository
+	transactor repositories.Transactor
+	backends   BackendSet
+	targets    []string
+	verify     bool
+	files      *rate.Limiter // 文件数限速（nil 不限制）
+	bytes      *rate.Limiter // 字节数限速（nil 不限制）
+}
+
+// NewReplicator 创建跨后端复制器（目标后端须已配置）
+func NewReplicator(fileRepo repositories.FileRepository, replicas repositories.ReplicationRepository, transactor repositories.Transactor, backends BackendSet, cfg config.ReplicationConfig) (Replicator, error) {
+	for _, target := range cfg.Targets {
+		if _, ok := backends.Backend(target); !ok {
+			return nil, fmt.Errorf("replication target %q is not a configured storage backend", target)
+		}
+	}
+
+	r := &replicator{
+		fileRepo:   fileRepo,
+		replicas:   replicas,
+		transactor: transactor,
+		backends:   backends,
+		targets:    cfg.Targets,
+		verify:     cfg.Verify,
+	}
+	if cfg.FilesPerSecond > 0 {
+		r.files = rate.NewLimiter(rate.Limit(cfg.FilesPerSecond), 1)
+	}
+	if cfg.BytesPerSecond > 0 {
+		// 每次读取不超过突发量，突发量最多 1MiB，保证限速平滑
+		r.bytes = rate.NewLimiter(rate.Limit(cfg.BytesPerSecond), int(min(cfg.BytesPerSecond, 1<<20)))
+	}
+	return r, nil
+}
+
+// primary 返回文件主对象所在的后端
+func (r *replicator) primary(file *models.File) string {
+	if file.Backend == "" {
+		return r.backends.DefaultBackend()
+	}
+	return file.Backend
+}
+
+// ReplicateFile 将文件同步到所有目标后端
+func (r *replicator) ReplicateFile(ctx context.Context, fileID uuid.UUID) ([]*models.FileReplica, error) {
+	files, err := r.fileRepo.GetByIDs(ctx, []uuid.UUID{fileID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, r.purge(ctx, fileID)
+	}
+
+	file := files[0]
+	if file.DeletedAt.Valid {
+		return r.replicas.ListReplicas(ctx, file.ID)
+	}
+	if file.Status != models.FileStatusCompleted && file.Status != models.FileStatusQuarantined {
+		return nil, fmt.Errorf("file is not ready for replication")
+	}
+
+	var errs []error
+	for _, target := range r.targets {
+		if _, _, err := r.sync(ctx, file, target); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to replicate file: %w", errors.Join(errs...))
+	}
+	return r.replicas.ListReplicas(ctx, file.ID)
+}
+
+// ListReplicas 查询文件的副本
+func (r *replicator) ListReplicas(ctx context.Context, fileID uuid.UUID) ([]*models.FileReplica, error) {
+	if _, err := r.fileRepo.GetByID(ctx, fileID); err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+	return r.replicas.ListReplicas(ctx, fileID)
+}
+
+// purge 删除已永久删除文件的所有副本
+func (r *replicator) purge(ctx context.Context, fileID uuid.UUID) error {
+	replicas, err := r.replicas.ListReplicas(ctx, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to list replicas: %w", err)
+	}
+	for _, replica := range replicas {
+		if backend, ok := r.backends.Backend(replica.Backend); ok {
+			if err := backend.Delete(ctx, replica.StorageKey); err != nil {
+				return fmt.Errorf("failed to delete replica on %s: %w", replica.Backend, err)
+			}
+		}
+		if err := r.replicas.DeleteReplica(ctx, replica.ID); err != nil {
+			return fmt.Errorf("failed to delete replica record: %w", err)
+		}
+	}
+	return nil
+}
+
+// sync 将文件复制到目标后端，返回副本以及是否实际复制（目标是主后端或副本已是最新时不复制）
+func (r *replicator) sync(ctx context.Context, file *models.File, target string) (*models.FileReplica, bool, error) {
+	source := r.primary(file)
+	if target == source {
+		return nil, false, nil
+	}
+
+	replicas, err := r.replicas.ListReplicas(ctx, file.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list replicas: %w", err)
+	}
+	var replica *models.FileReplica
+	for _, existing := range replicas {
+		if existing.Backend == target {
+			replica = existing
+		}
+	}
+	if replica != nil && replica.Status != models.ReplicaStatusFailed && replica.StorageKey == file.StorageKey {
+		return replica, false, nil
+	}
+	if replica == nil {
+		replica = &models.FileReplica{FileID: file.ID, Backend: target, StorageKey: file.StorageKey}
+	}
+
+	src, ok := r.backends.Backend(source)
+	if !ok {
+		return nil, false, fmt.Errorf("storage backend %q is not configured", source)
+	}
+	dst, ok := r.backends.Backend(target)
+	if !ok {
+		return nil, false, fmt.Errorf("storage backend %q is not configured", target)
+	}
+
+	if r.files != nil {
+		if err := r.files.Wait(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+
+	size, sum, err := r.copyObject(ctx, src, dst, file.StorageKey)
+	if err == nil && r.verify {
+		err = verifyReplica(ctx, dst, file.StorageKey, size, sum)
+	}
+	if err != nil {
+		replica.Status = models.ReplicaStatusFailed
+		replica.Attempts++
+		replica.LastError = err.Error()
+		if saveErr := r.replicas.SaveReplica(ctx, replica); saveErr != nil {
+			return nil, false, fmt.Errorf("%w (failed to save replica: %v)", err, saveErr)
+		}
+		return replica, false, err
+	}
+
+	// 存储键已变化（如迁移存储键）时，旧键上的副本在新副本写入后删除
+	oldKey := replica.StorageKey
+	now := time.Now()
+	replica.StorageKey = file.StorageKey
+	replica.Size = size
+	replica.SHA256 = sum
+	replica.Status = models.ReplicaStatusReplicated
+	replica.Attempts = 0
+	replica.LastError = ""
+	replica.ReplicatedAt = &now
+	replica.VerifiedAt = nil
+	if r.verify {
+		replica.Status = models.ReplicaStatusVerified
+		replica.VerifiedAt = &now
+	}
+	if err := r.replicas.SaveReplica(ctx, replica); err != nil {
+		return nil, false, fmt.Errorf("failed to save replica: %w", err)
+	}
+	if oldKey != file.StorageKey {
+		_ = dst.Delete(ctx, oldKey)
+	}
+	return replica, true, nil
+}
+
+// copyObject 流式复制对象（不经过本地磁盘），返回复制的字节数和 SHA-256
+func (r *replicator) copyObject(ctx context.Context, src, dst storage.Storage, key string) (int64, string, error) {
+	reader, contentType, size, err := src.GetObject(ctx, key)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read source object: %w", err)
+	}
+	defer reader.Close()
+	if size < 0 {
+		return 0, "", fmt.Errorf("source object size is unknown")
+	}
+
+	hasher := sha256.New()
+	var body io.Reader = io.TeeReader(reader, hasher)
+	if r.bytes != nil {
+		body = &rateLimitedReader{ctx: ctx, reader: body, limiter: r.bytes}
+	}
+	if err := dst.Upload(ctx, key, body, size, contentType); err != nil {
+		return 0, "", fmt.Errorf("failed to write replica: %w", err)
+	}
+	return size, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// verifyReplica 读回副本，比对大小和 SHA-256
+func verifyReplica(ctx context.Context, dst storage.Storage, key string, size int64, sum string) error {
+	reader, _, _, err := dst.GetObject(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to read replica: %w", err)
+	}
+	defer reader.Close()
+
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, reader)
+	if err != nil {
+		return fmt.Errorf("failed to read replica: %w", err)
+	}
+	if n != size || hex.EncodeToString(hasher.Sum(nil)) != sum {
+		return fmt.Errorf("replica checksum mismatch")
+	}
+	return nil
+}
+
+// Migrate 批量复制（或迁移）主对象在源后端的文件
+// 跳过上传未完成的文件和回收站中的文件；每批处理完成后保存检查点
+func (r *replicator) Migrate(ctx context.Context, opts ReplicationOptions, report func(ReplicationReport)) (*models.ReplicationRun, error) {
+	if opts.Source == "" {
+		opts.Source = r.backends.DefaultBackend()
+	}
+	for _, name := range []string{opts.Source, opts.Target} {
+		if _, ok := r.backends.Backend(name); !ok {
+			return nil, fmt.Errorf("storage backend %q is not configured", name)
+		}
+	}
+	if opts.Source == opts.Target {
+		return nil, fmt.Errorf("source and target backends must be different")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	var run *models.ReplicationRun
+	if !opts.Restart {
+		existing, err := r.replicas.FindRunningRun(ctx, opts.Source, opts.Target, opts.Promote)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+		run = existing
+	}
+	if run == nil {
+		run = &models.ReplicationRun{
+			Source:  opts.Source,
+			Target:  opts.Target,
+			Promote: opts.Promote,
+			Status:  models.ReplicationRunRunning,
+		}
+		if err := r.replicas.CreateRun(ctx, run); err != nil {
+			return nil, fmt.Errorf("failed to create replication run: %w", err)
+		}
+	}
+
+	// 中断时用独立的上下文保存检查点
+	checkpoint := func() error {
+		if err := r.replicas.UpdateRun(context.WithoutCancel(ctx), run); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+		return nil
+	}
+
+	processed := 0
+	for {
+		files, err := r.fileRepo.ListAfter(ctx, run.Cursor, opts.BatchSize)
+		if err != nil {
+			return run, errors.Join(fmt.Errorf("failed to list files: %w", err), checkpoint())
+		}
+		if len(files) == 0 {
+			now := time.Now()
+			run.Status = models.ReplicationRunCompleted
+			run.FinishedAt = &now
+			return run, checkpoint()
+		}
+
+		for _, file := range files {
+			if err := ctx.Err(); err != nil {
+				return run, errors.Join(err, checkpoint())
+			}
+			if opts.Limit > 0 && processed >= opts.Limit {
+				return run, checkpoint()
+			}
+			run.Scanned++
+
+			if r.primary(file) != opts.Source || file.DeletedAt.Valid ||
+				(file.Status != models.FileStatusCompleted && file.Status != models.FileStatusQuarantined) {
+				run.Skipped++
+				run.Cursor = file.ID
+				continue
+			}
+
+			replica, copied, err := r.sync(ctx, file, opts.Target)
+			if err == nil && opts.Promote {
+				err = r.promote(ctx, file, opts.Source, replica)
+			}
+			// 上下文取消导致的失败不推进检查点，下次从该文件继续
+			if err != nil && ctx.Err() != nil {
+				return run, errors.Join(ctx.Err(), checkpoint())
+			}
+			run.Cursor = file.ID
+
+			result := ReplicationReport{FileID: file.ID, Key: file.StorageKey, Skipped: err == nil && !copied, Err: err}
+			switch {
+			case err != nil:
+				run.Failed++
+				processed++
+			case copied:
+				run.Copied++
+				run.Bytes += replica.Size
+				result.Size = replica.Size
+				processed++
+			default:
+				run.Skipped++
+			}
+			if report != nil {
+				report(result)
+			}
+		}
+
+		if err := checkpoint(); err != nil {
+			return run, err
+		}
+	}
+}
+
+// promote 将目标后端设为文件的主后端，原主对象记录为副本
+func (r *replicator) promote(ctx context.Context, file *models.File, source string, replica *models.FileReplica) error {
+	err := r.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := r.replicas.PromoteBackend(ctx, file.ID, file.Backend, replica.Backend); err != nil {
+			return fmt.Errorf("failed to promote backend: %w", err)
+		}
+		if err := r.replicas.DeleteReplica(ctx, replica.ID); err != nil {
+			return fmt.Errorf("failed to delete replica record: %w", err)
+		}
+		return r.replicas.SaveReplica(ctx, &models.FileReplica{
+			FileID:       file.ID,
+			Backend:      source,
+			StorageKey:   file.StorageKey,
+			Status:       replica.Status,
+			Size:         replica.Size,
+			SHA256:       replica.SHA256,
+			ReplicatedAt: replica.ReplicatedAt,
+			VerifiedAt:   replica.VerifiedAt,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	file.Backend = replica.Backend
+	return nil
+}
+
+// rateLimitedReader 按字节数限速的读取器
+type rateLimitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+// Read 每次最多读取限速器的突发量，读取后等待令牌
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// ReplicationService 持续复制服务
+// 同时实现 EventPublisher：由 OutboxRelay 调用，文件上传完成或删除后创建复制任务
+type ReplicationService interface {
+	EventPublisher
+	Replicator
+
+	// EnqueueReplication 为已上传完成的文件创建复制任务（用于复制启用前上传的文件或重试失败的副本）
+	EnqueueReplication(ctx context.Context, fileID uuid.UUID) (*queue.Job, error)
+}
+
+// replicationService 持续复制服务实现
+type replicationService struct {
+	Replicator
+	fileRepo repositories.FileRepository
+	jobs     jobQueue
+	cfg      config.ReplicationConfig
+}
+
+// NewReplicationService 创建持续复制服务实例，并注册复制任务处理器
+func NewReplicationService(replicator Replicator, fileRepo repositories.FileRepository, jobs jobQueue, cfg config.ReplicationConfig) ReplicationService {
+	s := &replicationService{
+		Replicator: replicator,
+		fileRepo:   fileRepo,
+		jobs:       jobs,
+		cfg:        cfg,
+	}
+	jobs.Register(ReplicationJobType, queue.Typed(s.handleReplicationJob))
+	return s
+}
+
+// Publish 文件上传完成后复制，文件删除后清理副本（重复发布只会导致重复检查，副本已是最新时不会重新复制）
+func (s *replicationService) Publish(ctx context.Context, event *Event) error {
+	if (event.Type != EventFileCompleted && event.Type != EventFileDeleted) || event.Data == nil {
+		return nil
+	}
+	_, err := s.enqueue(ctx, event.Data.ID)
+	return err
+}
+
+// EnqueueReplication 为已上传完成的文件创建复制任务
+func (s *replicationService) EnqueueReplication(ctx context.Context, fileID uuid.UUID) (*queue.Job, error) {
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+	if file.Status != models.FileStatusCompleted && file.Status != models.FileStatusQuarantined {
+		return nil, fmt.Errorf("file is not ready for replication")
+	}
+
+	return s.enqueue(ctx, file.ID)
+}
+
+// enqueue 创建复制任务
+func (s *replicationService) enqueue(ctx context.Context, fileID uuid.UUID) (*queue.Job, error) {
+	job, err := s.jobs.Enqueue(ctx, ReplicationJobType, replicationJobPayload{FileID: fileID}, &queue.EnqueueOptions{Queue: s.cfg.Queue})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue replication: %w", err)
+	}
+	return job, nil
+}
+
+// handleReplicationJob 执行复制任务（文件状态不允许复制时不再重试）
+func (s *replicationService) handleReplicationJob(ctx context.Context, job *queue.Job, payload replicationJobPayload) error {
+	_, err := s.ReplicateFile(ctx, payload.FileID)
+	if err != nil && strings.Contains(err.Error(), "not ready") {
+		return queue.Permanent(err)
+	}
+	return err
+}
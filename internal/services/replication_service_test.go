@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memReplicationRepository 内存副本仓储
+type memReplicationRepository struct {
+	files    *MockFileRepository
+	replicas map[uuid.UUID]*models.FileReplica
+	runs     []*models.ReplicationRun
+}
+
+func newMemReplicationRepository(files *MockFileRepository) *memReplicationRepository {
+	return &memReplicationRepository{files: files, replicas: make(map[uuid.UUID]*models.FileReplica)}
+}
+
+func (m *memReplicationRepository) ListReplicas(ctx context.Context, fileID uuid.UUID) ([]*models.FileReplica, error) {
+	var replicas []*models.FileReplica
+	for _, replica := range m.replicas {
+		if replica.FileID == fileID {
+			copied := *replica
+			replicas = append(replicas, &copied)
+		}
+	}
+	return replicas, nil
+}
+
+func (m *memReplicationRepository) SaveReplica(ctx context.Context, replica *models.FileReplica) error {
+	for id, existing := range m.replicas {
+		if existing.FileID == replica.FileID && existing.Backend == replica.Backend {
+			delete(m.replicas, id)
+		}
+	}
+	if replica.ID == uuid.Nil {
+		replica.ID = uuid.New()
+	}
+	copied := *replica
+	m.replicas[replica.ID] = &copied
+	return nil
+}
+
+func (m *memReplicationRepository) DeleteReplica(ctx context.Context, id uuid.UUID) error {
+	delete(m.replicas, id)
+	return nil
+}
+
+func (m *memReplicationRepository) HasReplica(ctx context.Context, key string, backend string) (bool, error) {
+	for _, replica := range m.replicas {
+		if replica.StorageKey == key && replica.Backend == backend && replica.Status != models.ReplicaStatusFailed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memReplicationRepository) PromoteBackend(ctx context.Context, fileID uuid.UUID, from, to string) error {
+	file, ok := m.files.files[fileID]
+	if !ok || file.Backend != from {
+		return gorm.ErrRecordNotFound
+	}
+	file.Backend = to
+	return nil
+}
+
+func (m *memReplicationRepository) CreateRun(ctx context.Context, run *models.ReplicationRun) error {
+	run.ID = uuid.New()
+	m.runs = append(m.runs, run)
+	return nil
+}
+
+func (m *memReplicationRepository) FindRunningRun(ctx context.Context, source, target string, promote bool) (*models.ReplicationRun, error) {
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		run := m.runs[i]
+		if run.Source == source && run.Target == target && run.Promote == promote && run.Status == models.ReplicationRunRunning {
+			return run, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memReplicationRepository) UpdateRun(ctx context.Context, run *models.ReplicationRun) error {
+	return nil
+}
+
+// replica 返回文件在指定后端上的副本
+func (m *memReplicationRepository) replica(fileID uuid.UUID, backend string) *models.FileReplica {
+	for _, replica := range m.replicas {
+		if replica.FileID == fileID && replica.Backend == backend {
+			return replica
+		}
+	}
+	return nil
+}
+
+// memBackendSet 内存后端集合
+type memBackendSet map[string]*MockStorage
+
+func (m memBackendSet) Backend(name string) (storage.Storage, bool) {
+	backend, ok := m[name]
+	return backend, ok
+}
+
+func (m memBackendSet) DefaultBackend() string {
+	return "primary"
+}
+
+func newTestReplicator(t *testing.T, cfg config.ReplicationConfig) (Replicator, *MockFileRepository, memBackendSet, *memReplicationRepository) {
+	t.Helper()
+	repo := NewMockFileRepository()
+	replicas := newMemReplicationRepository(repo)
+	backends := memBackendSet{"primary": NewMockStorage(), "backup": NewMockStorage()}
+	r, err := NewReplicator(repo, replicas, MockTransactor{}, backends, cfg)
+	if err != nil {
+		t.Fatalf("NewReplicator() error = %v", err)
+	}
+	return r, repo, backends, replicas
+}
+
+func TestNewReplicatorRejectsUnknownTarget(t *testing.T) {
+	backends := memBackendSet{"primary": NewMockStorage()}
+	if _, err := NewReplicator(NewMockFileRepository(), nil, MockTransactor{}, backends, config.ReplicationConfig{Targets: []string{"missing"}}); err == nil {
+		t.Fatal("unknown replication target should be rejected")
+	}
+}
+
+func TestReplicateFile(t *testing.T) {
+	ctx := context.Background()
+	r, repo, backends, replicas := newTestReplicator(t, config.ReplicationConfig{Targets: []string{"backup"}, Verify: true})
+	file := newBatchTestFile(t, repo, backends["primary"], "a.txt")
+
+	result, err := r.ReplicateFile(ctx, file.ID)
+	if err != nil {
+		t.Fatalf("ReplicateFile() error = %v", err)
+	}
+	if len(result) != 1 || result[0].Status != models.ReplicaStatusVerified || result[0].Size != 5 || result[0].SHA256 == "" {
+		t.Fatalf("replicas = %+v, want one verified replica", result)
+	}
+	if string(backends["backup"].objects[file.StorageKey]) != "hello" {
+		t.Fatalf("replica object = %q, want hello", backends["backup"].objects[file.StorageKey])
+	}
+
+	// 副本已是最新时不重新复制
+	delete(backends["backup"].objects, file.StorageKey)
+	if _, err := r.ReplicateFile(ctx, file.ID); err != nil {
+		t.Fatalf("ReplicateFile() error = %v", err)
+	}
+	if _, ok := backends["backup"].objects[file.StorageKey]; ok {
+		t.Fatal("up-to-date replica should not be copied again")
+	}
+
+	// 存储键变化后复制到新键
+	backends["backup"].objects[file.StorageKey] = []byte("hello")
+	oldKey := file.StorageKey
+	backends["primary"].objects["files/new/a.txt"] = backends["primary"].objects[oldKey]
+	file.StorageKey = "files/new/a.txt"
+	if _, err := r.ReplicateFile(ctx, file.ID); err != nil {
+		t.Fatalf("ReplicateFile() error = %v", err)
+	}
+	if _, ok := backends["backup"].objects[oldKey]; ok {
+		t.Fatal("replica at the old key should be deleted")
+	}
+	if replica := replicas.replica(file.ID, "backup"); replica == nil || replica.StorageKey != file.StorageKey {
+		t.Fatalf("replica = %+v, want storage key %s", replica, file.StorageKey)
+	}
+
+	// 源对象不存在时记录失败
+	missing := newBatchTestFile(t, repo, backends["primary"], "missing.txt")
+	delete(backends["primary"].objects, missing.StorageKey)
+	if _, err := r.ReplicateFile(ctx, missing.ID); err == nil {
+		t.Fatal("ReplicateFile() should fail when the source object is missing")
+	}
+	if replica := replicas.replica(missing.ID, "backup"); replica == nil || replica.Status != models.ReplicaStatusFailed || replica.Attempts != 1 || replica.LastError == "" {
+		t.Fatalf("failed replica = %+v", replica)
+	}
+
+	// 文件记录永久删除后清理副本
+	repo.DeletePermanently(ctx, []uuid.UUID{file.ID})
+	if _, err := r.ReplicateFile(ctx, file.ID); err != nil {
+		t.Fatalf("ReplicateFile() after delete error = %v", err)
+	}
+	if _, ok := backends["backup"].objects[file.StorageKey]; ok || replicas.replica(file.ID, "backup") != nil {
+		t.Fatal("replicas of a permanently deleted file should be removed")
+	}
+}
+
+func TestReplicatorMigrate(t *testing.T) {
+	ctx := context.Background()
+	r, repo, backends, replicas := newTestReplicator(t, config.ReplicationConfig{Verify: true})
+	a := newBatchTestFile(t, repo, backends["primary"], "a.txt")
+	b := newBatchTestFile(t, repo, backends["primary"], "b.txt")
+	pending := newBatchTestFile(t, repo, backends["primary"], "pending.txt")
+	pending.Status = models.FileStatusPending
+
+	// 第一次只处理一个文件，第二次从检查点继续
+	run, err := r.Migrate(ctx, ReplicationOptions{Target: "backup", Promote: true, BatchSize: 1, Limit: 1}, nil)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if run.Status != models.ReplicationRunRunning || run.Copied != 1 {
+		t.Fatalf("first run = %+v, want running with one copied file", run)
+	}
+
+	resumed, err := r.Migrate(ctx, ReplicationOptions{Target: "backup", Promote: true, BatchSize: 1}, nil)
+	if err != nil {
+		t.Fatalf("Migrate() resume error = %v", err)
+	}
+	if resumed.ID != run.ID || resumed.Status != models.ReplicationRunCompleted || resumed.Copied != 2 || resumed.Skipped != 1 {
+		t.Fatalf("resumed run = %+v, want completed with 2 copied and 1 skipped", resumed)
+	}
+
+	for _, file := range []*models.File{a, b} {
+		if file.Backend != "backup" {
+			t.Errorf("file %s backend = %q, want backup", file.Name, file.Backend)
+		}
+		if replicas.replica(file.ID, "backup") != nil {
+			t.Errorf("file %s still has a replica on its new primary backend", file.Name)
+		}
+		if replica := replicas.replica(file.ID, "primary"); replica == nil || replica.Status != models.ReplicaStatusVerified {
+			t.Errorf("file %s former primary replica = %+v, want verified", file.Name, replica)
+		}
+	}
+	if pending.Backend != "" || backends["backup"].objects[pending.StorageKey] != nil {
+		t.Error("pending upload should be skipped")
+	}
+
+	if _, err := r.Migrate(ctx, ReplicationOptions{Source: "backup", Target: "backup"}, nil); err == nil {
+		t.Error("migrating a backend onto itself should be rejected")
+	}
+}
+
+func TestReplicationServicePublish(t *testing.T) {
+	ctx := context.Background()
+	r, repo, backends, _ := newTestReplicator(t, config.ReplicationConfig{Targets: []string{"backup"}})
+	jobs := &fakeJobQueue{}
+	svc := NewReplicationService(r, repo, jobs, config.ReplicationConfig{Queue: "replication"})
+
+	if jobs.handlers[ReplicationJobType] == nil {
+		t.Fatal("replication job handler should be registered")
+	}
+
+	file := newBatchTestFile(t, repo, backends["primary"], "a.txt")
+	svc.Publish(ctx, NewFileEvent(EventFileCreated, file))
+	svc.Publish(ctx, NewFileEvent(EventFileCompleted, file))
+	svc.Publish(ctx, NewFileEvent(EventFileDeleted, file))
+	if len(jobs.jobs) != 2 || jobs.jobs[0].Queue != "replication" {
+		t.Fatalf("enqueued jobs = %+v, want two replication jobs", jobs.jobs)
+	}
+
+	file.Status = models.FileStatusPending
+	if _, err := svc.EnqueueReplication(ctx, file.ID); err == nil {
+		t.Fatal("EnqueueReplication() should reject pending files")
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/queue"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/NanoBoom/asethub/pkg/clamav"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScanJobType 恶意文件扫描任务类型
+const ScanJobType = "file.scan"
+
+// scanJobPayload 扫描任务载荷
+type scanJobPayload struct {
+	FileID uuid.UUID `json:"file_id"`
+}
+
+// Scanner 恶意文件扫描引擎（由 clamav.Client 实现）
+type Scanner interface {
+	// Scan 流式扫描内容
+	Scan(ctx context.Context, r io.Reader) (*clamav.Result, error)
+
+	// Version 查询引擎及病毒库版本
+	Version(ctx context.Context) (string, error)
+}
+
+// jobQueue 任务队列（由 queue.Manager 实现）
+type jobQueue interface {
+	Register(jobType string, handler queue.Handler)
+	Enqueue(ctx context.Context, jobType string, payload interface{}, opts *queue.EnqueueOptions) (*queue.Job, error)
+}
+
+// ScanService 恶意文件扫描服务接口
+// 同时实现 EventPublisher：由 OutboxRelay 调用，文件上传完成（file.completed）后创建扫描任务
+type ScanService interface {
+	EventPublisher
+
+	// EnqueueScan 为已上传完成的文件创建扫描任务（用于重新扫描或扫描启用前上传的文件）
+	EnqueueScan(ctx context.Context, fileID uuid.UUID) (*queue.Job, error)
+
+	// ScanFile 从存储流式读取文件并扫描，记录结果；发现恶意内容时将文件标记为已隔离
+	ScanFile(ctx context.Context, fileID uuid.UUID) (*models.File, error)
+}
+
+// scanService 恶意文件扫描服务实现
+type scanService struct {
+	fileRepo repositories.FileRepository
+	storage  storage.Storage
+	scanner  Scanner
+	jobs     jobQueue
+	cfg      config.ScanConfig
+}
+
+// NewScanService 创建恶意文件扫描服务实例，并注册扫描任务处理器
+func NewScanService(fileRepo repositories.FileRepository, storage storage.Storage, scanner Scanner, jobs jobQueue, cfg config.ScanConfig) ScanService {
+	s := &scanService{
+		fileRepo: fileRepo,
+		storage:  storage,
+		scanner:  scanner,
+		jobs:     jobs,
+		cfg:      cfg,
+	}
+	jobs.Register(ScanJobType, queue.Typed(s.handleScanJob))
+	return s
+}
+
+// Publish 文件上传完成后创建扫描任务（重复发布只会导致重复扫描，结果不变）
+func (s *scanService) Publish(ctx context.Context, event *Event) error {
+	if event.Type != EventFileCompleted || event.Data == nil {
+		return nil
+	}
+	_, err := s.enqueue(ctx, event.Data.ID)
+	return err
+}
+
+// EnqueueScan 为已上传完成的文件创建扫描任务
+func (s *scanService) EnqueueScan(ctx context.Context, fileID uuid.UUID) (*queue.Job, error) {
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+	if file.Status != models.FileStatusCompleted && file.Status != models.FileStatusQuarantined {
+		return nil, fmt.Errorf("file is not ready for scanning")
+	}
+
+	return s.enqueue(ctx, file.ID)
+}
+
+// enqueue 创建扫描任务
+func (s *scanService) enqueue(ctx context.Context, fileID uuid.UUID) (*queue.Job, error) {
+	job, err := s.jobs.Enqueue(ctx, ScanJobType, scanJobPayload{FileID: fileID}, &queue.EnqueueOptions{Queue: s.cfg.Queue})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue scan: %w", err)
+	}
+	return job, nil
+}
+
+// ScanFile 扫描文件并记录结果
+func (s *scanService) ScanFile(ctx context.Context, fileID uuid.UUID) (*models.File, error) {
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+	// 已隔离的文件允许重新扫描（病毒库更新后可能解除误报）
+	if file.Status != models.FileStatusCompleted && file.Status != models.FileStatusQuarantined {
+		return nil, fmt.Errorf("file is not ready for scanning")
+	}
+
+	reader, _, _, err := s.storage.GetObject(ctx, file.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	defer reader.Close()
+
+	result, err := s.scanner.Scan(ctx, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan file: %w", err)
+	}
+	engine, err := s.scanner.Version(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scanner version: %w", err)
+	}
+
+	now := time.Now()
+	scanned := *file
+	scanned.ScanEngine = engine
+	scanned.ScannedAt = &now
+	if result.Infected {
+		scanned.ScanResult = models.ScanResultInfected
+		scanned.ScanSignature = result.Signature
+		scanned.Status = models.FileStatusQuarantined
+	} else {
+		scanned.ScanResult = models.ScanResultClean
+		scanned.ScanSignature = ""
+		scanned.Status = models.FileStatusCompleted
+	}
+
+	// 只更新扫描结果和状态，扫描期间的重命名、移动、删除等修改不会被覆盖
+	if err := s.fileRepo.UpdateScanResult(ctx, &scanned); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("file is not ready for scanning: deleted or changed during scan")
+		}
+		return nil, fmt.Errorf("failed to update scan result: %w", err)
+	}
+
+	return &scanned, nil
+}
+
+// handleScanJob 执行扫描任务（文件已删除或状态变化时不再重试）
+func (s *scanService) handleScanJob(ctx context.Context, job *queue.Job, payload scanJobPayload) error {
+	_, err := s.ScanFile(ctx, payload.FileID)
+	if err != nil && (strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "not ready")) {
+		return queue.Permanent(err)
+	}
+	return err
+}
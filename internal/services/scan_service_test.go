@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/queue"
+	"github.com/NanoBoom/asethub/pkg/clamav"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeScanner 内容包含 "EICAR" 时报告感染
+type fakeScanner struct{}
+
+func (fakeScanner) Scan(ctx context.Context, r io.Reader) (*clamav.Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(data), "EICAR") {
+		return &clamav.Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+	}
+	return &clamav.Result{}, nil
+}
+
+func (fakeScanner) Version(ctx context.Context) (string, error) {
+	return "ClamAV 1.2.1/27120", nil
+}
+
+// fakeJobQueue 记录入队的任务
+type fakeJobQueue struct {
+	handlers map[string]queue.Handler
+	jobs     []*queue.Job
+}
+
+func (q *fakeJobQueue) Register(jobType string, handler queue.Handler) {
+	if q.handlers == nil {
+		q.handlers = make(map[string]queue.Handler)
+	}
+	q.handlers[jobType] = handler
+}
+
+func (q *fakeJobQueue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts *queue.EnqueueOptions) (*queue.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := &queue.Job{ID: uuid.New(), Queue: opts.Queue, Type: jobType, Payload: data, MaxAttempts: 3, RunAt: time.Now().Add(opts.Delay)}
+	q.jobs = append(q.jobs, job)
+	return job, nil
+}
+
+// run 依次执行已入队的任务（模拟队列领取任务，Attempts 加一）
+func (q *fakeJobQueue) run(ctx context.Context) []error {
+	var errs []error
+	for len(q.jobs) > 0 {
+		job := q.jobs[0]
+		q.jobs = q.jobs[1:]
+		job.Attempts++
+		errs = append(errs, q.handlers[job.Type].Handle(ctx, job))
+	}
+	return errs
+}
+
+func TestScanService(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	jobs := &fakeJobQueue{}
+	svc := NewScanService(repo, store, fakeScanner{}, jobs, config.ScanConfig{Queue: "scan"})
+
+	if jobs.handlers[ScanJobType] == nil {
+		t.Fatal("scan job handler should be registered")
+	}
+
+	clean := newBatchTestFile(t, repo, store, "clean.txt")
+	infected := newBatchTestFile(t, repo, store, "infected.txt")
+	store.objects[infected.StorageKey] = []byte("X5O!P%@AP EICAR test")
+
+	// 只有 file.completed 事件会创建扫描任务
+	svc.Publish(ctx, NewFileEvent(EventFileCreated, clean))
+	svc.Publish(ctx, NewFileEvent(EventFileCompleted, clean))
+	if len(jobs.jobs) != 1 || jobs.jobs[0].Queue != "scan" {
+		t.Fatalf("enqueued jobs = %+v, want one scan job", jobs.jobs)
+	}
+
+	file, err := svc.ScanFile(ctx, clean.ID)
+	if err != nil {
+		t.Fatalf("ScanFile(clean) error = %v", err)
+	}
+	if file.ScanResult != models.ScanResultClean || file.Status != models.FileStatusCompleted || file.ScanEngine != "ClamAV 1.2.1/27120" || file.ScannedAt == nil {
+		t.Errorf("clean file = status %s result %s engine %q", file.Status, file.ScanResult, file.ScanEngine)
+	}
+
+	file, err = svc.ScanFile(ctx, infected.ID)
+	if err != nil {
+		t.Fatalf("ScanFile(infected) error = %v", err)
+	}
+	if file.ScanResult != models.ScanResultInfected || file.Status != models.FileStatusQuarantined || file.ScanSignature != "Eicar-Test-Signature" {
+		t.Errorf("infected file = status %s result %s signature %q", file.Status, file.ScanResult, file.ScanSignature)
+	}
+
+	// 未上传完成的文件不能扫描
+	pending := &models.File{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "p.txt", StorageKey: "files/p.txt", Status: models.FileStatusPending}
+	repo.Create(ctx, pending)
+	if _, err := svc.EnqueueScan(ctx, pending.ID); err == nil || !strings.Contains(err.Error(), "not ready") {
+		t.Errorf("EnqueueScan(pending) error = %v", err)
+	}
+}
+
+// hookScanner 扫描前执行 hook（模拟扫描期间文件被并发修改）
+type hookScanner struct {
+	fakeScanner
+	hook func()
+}
+
+func (s hookScanner) Scan(ctx context.Context, r io.Reader) (*clamav.Result, error) {
+	s.hook()
+	return s.fakeScanner.Scan(ctx, r)
+}
+
+// TestScanFileConcurrentChange 测试扫描结果只更新扫描字段和状态，扫描期间删除或状态变化的文件不更新
+func TestScanFileConcurrentChange(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	file := newBatchTestFile(t, repo, store, "a.txt")
+
+	var hook func()
+	svc := NewScanService(repo, store, hookScanner{hook: func() { hook() }}, &fakeJobQueue{}, config.ScanConfig{})
+
+	// 扫描期间重命名：保留新名称
+	hook = func() {
+		repo.files[file.ID] = &models.File{BaseModel: file.BaseModel, Name: "renamed.txt", StorageKey: file.StorageKey, Status: models.FileStatusCompleted}
+	}
+	if _, err := svc.ScanFile(ctx, file.ID); err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if stored := repo.files[file.ID]; stored.Name != "renamed.txt" || stored.ScanResult != models.ScanResultClean {
+		t.Errorf("stored file = name %q result %q, want renamed and clean", stored.Name, stored.ScanResult)
+	}
+
+	// 扫描期间删除：不更新，任务不再重试
+	hook = func() { repo.files[file.ID].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true} }
+	repo.files[file.ID].ScanResult = ""
+	if _, err := svc.ScanFile(ctx, file.ID); err == nil || !strings.Contains(err.Error(), "not ready") {
+		t.Errorf("ScanFile(deleted during scan) error = %v, want not ready", err)
+	}
+	if repo.files[file.ID].ScanResult != "" {
+		t.Error("deleted file should not be updated")
+	}
+}
+
+func TestDownloadPolicy(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	outbox := NewMockOutboxRepository()
+
+	clean := newBatchTestFile(t, repo, store, "clean.txt")
+	clean.ScanResult = models.ScanResultClean
+	unscanned := newBatchTestFile(t, repo, store, "unscanned.txt")
+	quarantined := newBatchTestFile(t, repo, store, "infected.txt")
+	quarantined.Status = models.FileStatusQuarantined
+	quarantined.ScanResult = models.ScanResultInfected
+	// 状态仍为 completed 但扫描结果为 infected 的文件同样不能下载
+	infected := newBatchTestFile(t, repo, store, "infected-completed.txt")
+	infected.ScanResult = models.ScanResultInfected
+
+	tests := []struct {
+		policy  DownloadPolicy
+		file    *models.File
+		wantErr string
+	}{
+		{DownloadPolicy{}, unscanned, ""},
+		{DownloadPolicy{}, quarantined, "quarantined"},
+		{DownloadPolicy{}, infected, "quarantined"},
+		{DownloadPolicy{RequireScan: true}, clean, ""},
+		{DownloadPolicy{RequireScan: true}, unscanned, "malware scan"},
+	}
+
+	for _, tt := range tests {
+		svc := NewFileService(repo, outbox, store, MockTransactor{}, tt.policy, FileServiceOptions{})
+		_, err := svc.GetDownloadURL(ctx, tt.file.ID, DownloadURLOptions{})
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("GetDownloadURL(%s, require=%v) error = %v, want %q", tt.file.Name, tt.policy.RequireScan, err, tt.wantErr)
+		}
+	}
+
+	// 打包下载同样按策略过滤
+	archives := NewArchiveService(repo, store, nil, &fakeJobQueue{}, DownloadPolicy{RequireScan: true}, config.ArchiveConfig{})
+	files, err := archives.ResolveFiles(ctx, ArchiveRequest{FileIDs: []uuid.UUID{clean.ID, unscanned.ID, quarantined.ID}})
+	if err != nil || len(files) != 1 || files[0].ID != clean.ID {
+		t.Errorf("ResolveFiles() = %v, %v, want only the clean file", files, err)
+	}
+}
@@ -1,8 +1,19 @@
 package services
 
+import (
+	"context"
+	"time"
+)
+
 // BaseService provides common service operations
 type BaseService struct{}
 
 func NewBaseService() *BaseService {
 	return &BaseService{}
 }
+
+// jsonStore JSON 键值存储（由 cache.RedisClient 实现，用于保存异步任务状态）
+type jsonStore interface {
+	SetJSON(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	GetJSON(ctx context.Context, key string, dest interface{}) error
+}
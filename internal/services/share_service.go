@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/google/uuid"
+)
+
+// CreateShareOptions 创建分享链接的选项（零值使用默认行为）
+type CreateShareOptions struct {
+	ExpiresIn    time.Duration    // 有效期（0 表示不过期，配置了 shares.max_expiry 时默认为最长有效期）
+	Password     string           // 访问密码（为空表示无密码）
+	MaxDownloads int              // 最多下载次数（0 不限制）
+	Mode         models.ShareMode // 访问方式（为空时为 redirect）
+}
+
+// CreatedShare 新建的分享链接（令牌只在创建时返回）
+type CreatedShare struct {
+	Share *models.FileShare
+	Token string // 分享令牌
+	URL   string // 分享地址（shares.base_url + /s/{token}）
+}
+
+// ShareAccess 访问分享链接的结果（二选一）
+type ShareAccess struct {
+	RedirectURL string          // 非空时重定向到该预签名 URL
+	Download    *DownloadResult // 代理下载的文件内容（使用后必须关闭 Reader）
+}
+
+// ShareService 文件分享链接服务接口
+// 分享链接不受存储后端预签名有效期上限限制，可以随时撤销；每次访问时重新生成短期预签名 URL 或由服务端代理传输
+type ShareService interface {
+	// CreateShare 为已上传完成的文件创建分享链接
+	CreateShare(ctx context.Context, fileID uuid.UUID, opts CreateShareOptions) (*CreatedShare, error)
+
+	// ListShares 查询文件的所有分享链接
+	ListShares(ctx context.Context, fileID uuid.UUID) ([]*models.FileShare, error)
+
+	// RevokeShare 撤销分享链接
+	RevokeShare(ctx context.Context, fileID, shareID uuid.UUID) error
+
+	// OpenShare 校验令牌和密码后返回下载地址或文件内容，并计入下载次数
+	// clientIP 用于限制同一客户端校验密码的频率
+	OpenShare(ctx context.Context, token, password, clientIP, acceptEncoding string) (*ShareAccess, error)
+}
+
+// attemptCounter 密码尝试计数器（由 cache.RedisClient 实现，多个实例共享计数）
+type attemptCounter interface {
+	IncrWindow(ctx context.Context, key string, window time.Duration) (int64, error)
+	Count(ctx context.Context, key string) (int64, error)
+	Delete(ctx context.Context, keys ...string) error
+}
+
+const (
+	// shareTokenBytes 分享令牌的随机字节数
+	shareTokenBytes = 32
+	// maxSharePasswordLength 访问密码的最大长度
+	maxSharePasswordLength = 128
+	// sharePasswordIterations PBKDF2 迭代次数
+	sharePasswordIterations = 100000
+	// shareAttemptKeyPrefix 密码尝试计数器的键前缀
+	shareAttemptKeyPrefix = "shares:attempts:"
+)
+
+// shareService 文件分享链接服务实现
+type shareService struct {
+	shareRepo repositories.ShareRepository
+	fileRepo  repositories.FileRepository
+	files     FileService // 访问时通过文件服务生成下载地址或读取内容（检查文件状态并记录下载统计）
+	attempts  attemptCounter
+	cfg       config.SharesConfig
+	now       func() time.Time
+}
+
+// NewShareService 创建文件分享链接服务
+func NewShareService(shareRepo repositories.ShareRepository, fileRepo repositories.FileRepository, files FileService, attempts attemptCounter, cfg config.SharesConfig) ShareService {
+	return &shareService{
+		shareRepo: shareRepo,
+		fileRepo:  fileRepo,
+		files:     files,
+		attempts:  attempts,
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+// CreateShare 创建分享链接
+func (s *shareService) CreateShare(ctx context.Context, fileID uuid.UUID, opts CreateShareOptions) (*CreatedShare, error) {
+	if opts.ExpiresIn < 0 || (s.cfg.MaxExpiry > 0 && opts.ExpiresIn > s.cfg.MaxExpiry) {
+		return nil, fmt.Errorf("invalid expiry: must be positive and at most %s", s.cfg.MaxExpiry)
+	}
+	if opts.ExpiresIn == 0 {
+		opts.ExpiresIn = s.cfg.MaxExpiry
+	}
+	if opts.MaxDownloads < 0 {
+		return nil, fmt.Errorf("invalid max downloads: must not be negative")
+	}
+	if len(opts.Password) > maxSharePasswordLength {
+		return nil, fmt.Errorf("invalid password: must be at most %d characters", maxSharePasswordLength)
+	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = models.ShareModeRedirect
+	case models.ShareModeRedirect, models.ShareModeProxy:
+	default:
+		return nil, fmt.Errorf("invalid mode: must be redirect or proxy")
+	}
+
+	file, err := s.fileRepo.GetByID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+	if file.Status != models.FileStatusCompleted {
+		return nil, fmt.Errorf("file is not ready for sharing")
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+	share := &models.FileShare{
+		FileID:       file.ID,
+		TokenHash:    hashShareToken(token),
+		Mode:         opts.Mode,
+		MaxDownloads: opts.MaxDownloads,
+	}
+	if opts.ExpiresIn > 0 {
+		expiresAt := s.now().Add(opts.ExpiresIn)
+		share.ExpiresAt = &expiresAt
+	}
+	if opts.Password != "" {
+		if share.PasswordHash, err = hashSharePassword(opts.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.shareRepo.Create(ctx, share); err != nil {
+		return nil, fmt.Errorf("failed to create share: %w", err)
+	}
+	return &CreatedShare{
+		Share: share,
+		Token: token,
+		URL:   strings.TrimSuffix(s.cfg.BaseURL, "/") + "/s/" + token,
+	}, nil
+}
+
+// ListShares 查询文件的所有分享链接
+func (s *shareService) ListShares(ctx context.Context, fileID uuid.UUID) ([]*models.FileShare, error) {
+	if _, err := s.fileRepo.GetByID(ctx, fileID); err != nil {
+		return nil, fmt.Errorf("file not found: %w", err)
+	}
+	return s.shareRepo.ListByFile(ctx, fileID)
+}
+
+// RevokeShare 撤销分享链接
+func (s *shareService) RevokeShare(ctx context.Context, fileID, shareID uuid.UUID) error {
+	if err := s.shareRepo.Revoke(ctx, fileID, shareID, s.now()); err != nil {
+		return fmt.Errorf("share not found: %w", err)
+	}
+	return nil
+}
+
+// OpenShare 访问分享链接
+// 文件可以下载后才计入下载次数，文件不可用（如已隔离、归档未取回）时不消耗次数
+func (s *shareService) OpenShare(ctx context.Context, token, password, clientIP, acceptEncoding string) (*ShareAccess, error) {
+	share, err := s.shareRepo.GetByTokenHash(ctx, hashShareToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share: %w", err)
+	}
+	if share == nil || share.RevokedAt != nil {
+		return nil, fmt.Errorf("share not found")
+	}
+	now := s.now()
+	if share.ExpiresAt != nil && !now.Before(*share.ExpiresAt) {
+		return nil, fmt.Errorf("share link has expired")
+	}
+	if share.MaxDownloads > 0 && share.DownloadCount >= share.MaxDownloads {
+		return nil, fmt.Errorf("share link download limit reached")
+	}
+	if share.PasswordHash != "" {
+		if password == "" {
+			return nil, fmt.Errorf("share password required")
+		}
+		if err := s.checkPassword(ctx, share, password, clientIP); err != nil {
+			return nil, err
+		}
+	}
+
+	access, err := s.access(ctx, share, acceptEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	consumed, err := s.shareRepo.ConsumeDownload(ctx, share.ID, now)
+	if err == nil && !consumed {
+		err = fmt.Errorf("share link download limit reached")
+	}
+	if err != nil {
+		if access.Download != nil {
+			access.Download.Reader.Close()
+		}
+		return nil, err
+	}
+	return access, nil
+}
+
+// checkPassword 校验访问密码
+// 先检查客户端 IP 的尝试频率和链接是否已锁定，再计算 PBKDF2，避免暴力猜测和大量请求耗尽 CPU
+// 密码错误计入链接的失败次数，达到 shares.max_password_attempts 后在 shares.lockout_duration 内拒绝访问（包括正确密码）
+func (s *shareService) checkPassword(ctx context.Context, share *models.FileShare, password, clientIP string) error {
+	if s.cfg.IPAttemptLimit > 0 {
+		count, err := s.attempts.IncrWindow(ctx, shareAttemptKeyPrefix+"ip:"+clientIP, s.cfg.IPAttemptWindow)
+		if err != nil {
+			return fmt.Errorf("failed to count share password attempts: %w", err)
+		}
+		if count > int64(s.cfg.IPAttemptLimit) {
+			return fmt.Errorf("too many share password attempts, try again later")
+		}
+	}
+
+	shareKey := shareAttemptKeyPrefix + "share:" + share.ID.String()
+	if s.cfg.MaxPasswordAttempts > 0 {
+		failures, err := s.attempts.Count(ctx, shareKey)
+		if err != nil {
+			return fmt.Errorf("failed to count share password attempts: %w", err)
+		}
+		if failures >= int64(s.cfg.MaxPasswordAttempts) {
+			return fmt.Errorf("too many incorrect share passwords, share link is locked")
+		}
+	}
+
+	if !verifySharePassword(share.PasswordHash, password) {
+		if s.cfg.MaxPasswordAttempts > 0 {
+			if _, err := s.attempts.IncrWindow(ctx, shareKey, s.cfg.LockoutDuration); err != nil {
+				return fmt.Errorf("failed to count share password attempts: %w", err)
+			}
+		}
+		return fmt.Errorf("incorrect share password")
+	}
+	if s.cfg.MaxPasswordAttempts > 0 {
+		if err := s.attempts.Delete(ctx, shareKey); err != nil {
+			return fmt.Errorf("failed to reset share password attempts: %w", err)
+		}
+	}
+	return nil
+}
+
+// access 按访问方式生成预签名 URL 或读取文件内容
+// 预签名 URL 须携带请求头（SSE-C）或存储不支持预签名（客户端加密、本地存储）时无法重定向，改为代理
+func (s *shareService) access(ctx context.Context, share *models.FileShare, acceptEncoding string) (*ShareAccess, error) {
+	if share.Mode != models.ShareModeProxy {
+		presigned, err := s.files.GetDownloadURL(ctx, share.FileID, DownloadURLOptions{Expiry: s.cfg.RedirectExpiry})
+		if err != nil {
+			return nil, err
+		}
+		if presigned.ExpiresIn > 0 && len(presigned.Headers) == 0 {
+			return &ShareAccess{RedirectURL: presigned.URL}, nil
+		}
+	}
+
+	download, err := s.files.DownloadFile(ctx, share.FileID, acceptEncoding)
+	if err != nil {
+		return nil, err
+	}
+	return &ShareAccess{Download: download}, nil
+}
+
+// newShareToken 生成随机分享令牌（URL 安全的 Base64）
+func newShareToken() (string, error) {
+	buf := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashShareToken 计算令牌的 SHA-256（令牌是高熵随机值，无需加盐）
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashSharePassword 计算访问密码的哈希，格式：pbkdf2-sha256$迭代次数$盐$哈希
+func hashSharePassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate password salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, sharePasswordIterations, sha256.Size)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash share password: %w", err)
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", sharePasswordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verifySharePassword 校验访问密码
+func verifySharePassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, want) == 1
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MockShareRepository 用于测试的内存分享链接仓储
+type MockShareRepository struct {
+	shares map[uuid.UUID]*models.FileShare
+}
+
+func NewMockShareRepository() *MockShareRepository {
+	return &MockShareRepository{shares: make(map[uuid.UUID]*models.FileShare)}
+}
+
+func (m *MockShareRepository) Create(ctx context.Context, share *models.FileShare) error {
+	if share.ID == uuid.Nil {
+		share.ID = uuid.New()
+	}
+	m.shares[share.ID] = share
+	return nil
+}
+
+func (m *MockShareRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.FileShare, error) {
+	for _, share := range m.shares {
+		if share.TokenHash == tokenHash {
+			copied := *share
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockShareRepository) ListByFile(ctx context.Context, fileID uuid.UUID) ([]*models.FileShare, error) {
+	var shares []*models.FileShare
+	for _, share := range m.shares {
+		if share.FileID == fileID {
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+func (m *MockShareRepository) Revoke(ctx context.Context, fileID, shareID uuid.UUID, at time.Time) error {
+	share, ok := m.shares[shareID]
+	if !ok || share.FileID != fileID || share.RevokedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	share.RevokedAt = &at
+	return nil
+}
+
+func (m *MockShareRepository) ConsumeDownload(ctx context.Context, shareID uuid.UUID, at time.Time) (bool, error) {
+	share, ok := m.shares[shareID]
+	if !ok || share.RevokedAt != nil ||
+		(share.MaxDownloads > 0 && share.DownloadCount >= share.MaxDownloads) ||
+		(share.ExpiresAt != nil && !at.Before(*share.ExpiresAt)) {
+		return false, nil
+	}
+	share.DownloadCount++
+	share.LastAccessedAt = &at
+	return true, nil
+}
+
+// noPresignStorage 不支持预签名下载的存储（如客户端加密、本地存储）
+type noPresignStorage struct {
+	*MockStorage
+}
+
+func (s noPresignStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *storage.PresignOptions) (*storage.PresignedRequest, error) {
+	return nil, storage.ErrPresignNotSupported
+}
+
+// memoryAttemptCounter 用于测试的内存窗口计数器（按 now 判断过期）
+type memoryAttemptCounter struct {
+	now      *time.Time
+	counts   map[string]int64
+	expireAt map[string]time.Time
+}
+
+func newMemoryAttemptCounter(now *time.Time) *memoryAttemptCounter {
+	return &memoryAttemptCounter{now: now, counts: make(map[string]int64), expireAt: make(map[string]time.Time)}
+}
+
+func (m *memoryAttemptCounter) IncrWindow(ctx context.Context, key string, window time.Duration) (int64, error) {
+	if count, _ := m.Count(ctx, key); count == 0 {
+		m.expireAt[key] = m.now.Add(window)
+	}
+	m.counts[key]++
+	return m.counts[key], nil
+}
+
+func (m *memoryAttemptCounter) Count(ctx context.Context, key string) (int64, error) {
+	if expireAt, ok := m.expireAt[key]; ok && !m.now.Before(expireAt) {
+		delete(m.counts, key)
+		delete(m.expireAt, key)
+	}
+	return m.counts[key], nil
+}
+
+func (m *memoryAttemptCounter) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(m.counts, key)
+		delete(m.expireAt, key)
+	}
+	return nil
+}
+
+// newShareTestService 创建使用内存仓储的分享链接服务
+func newShareTestService(repo *MockFileRepository, store storage.Storage, now *time.Time) (*shareService, *MockShareRepository) {
+	shares := NewMockShareRepository()
+	files := NewFileService(repo, NewMockOutboxRepository(), store, MockTransactor{}, DownloadPolicy{}, FileServiceOptions{Presign: config.PresignConfig{DownloadExpiry: 15 * time.Minute, MinExpiry: time.Minute, MaxExpiry: time.Hour}})
+	cfg := config.SharesConfig{
+		BaseURL:             "https://assets.example.com/",
+		RedirectExpiry:      5 * time.Minute,
+		MaxExpiry:           720 * time.Hour,
+		MaxPasswordAttempts: 3,
+		LockoutDuration:     15 * time.Minute,
+		IPAttemptLimit:      5,
+		IPAttemptWindow:     time.Minute,
+	}
+	return &shareService{
+		shareRepo: shares,
+		fileRepo:  repo,
+		files:     files,
+		attempts:  newMemoryAttemptCounter(now),
+		cfg:       cfg,
+		now:       func() time.Time { return *now },
+	}, shares
+}
+
+// TestShareCreateAndRedirect 测试创建分享链接、只保存令牌哈希以及重定向到短期预签名 URL
+func TestShareCreateAndRedirect(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc, shares := newShareTestService(repo, store, &now)
+	file := newBatchTestFile(t, repo, store, "report.txt")
+
+	for _, opts := range []CreateShareOptions{
+		{ExpiresIn: -time.Second},
+		{ExpiresIn: 1000 * time.Hour},
+		{MaxDownloads: -1},
+		{Password: strings.Repeat("x", 129)},
+		{Mode: "inline"},
+	} {
+		if _, err := svc.CreateShare(ctx, file.ID, opts); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("CreateShare(%+v) error = %v, want invalid", opts, err)
+		}
+	}
+
+	created, err := svc.CreateShare(ctx, file.ID, CreateShareOptions{})
+	if err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+	if created.URL != "https://assets.example.com/s/"+created.Token {
+		t.Errorf("URL = %q", created.URL)
+	}
+	stored := shares.shares[created.Share.ID]
+	if stored.TokenHash == created.Token || stored.TokenHash != hashShareToken(created.Token) {
+		t.Errorf("token hash = %q, want sha256 of token", stored.TokenHash)
+	}
+	if stored.Mode != models.ShareModeRedirect || stored.ExpiresAt == nil || !stored.ExpiresAt.Equal(now.Add(720*time.Hour)) {
+		t.Errorf("unexpected defaults: mode=%s expires=%v", stored.Mode, stored.ExpiresAt)
+	}
+
+	access, err := svc.OpenShare(ctx, created.Token, "", "203.0.113.1", "")
+	if err != nil {
+		t.Fatalf("OpenShare failed: %v", err)
+	}
+	if access.RedirectURL != "https://mock.example.com/download/"+file.StorageKey || store.lastExpiry != 5*time.Minute {
+		t.Errorf("redirect = %q with expiry %s", access.RedirectURL, store.lastExpiry)
+	}
+	if stored.DownloadCount != 1 {
+		t.Errorf("download count = %d, want 1", stored.DownloadCount)
+	}
+
+	if _, err := svc.OpenShare(ctx, "unknown", "", "203.0.113.1", ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("unknown token error = %v, want not found", err)
+	}
+
+	// 过期后拒绝访问
+	now = now.Add(721 * time.Hour)
+	if _, err := svc.OpenShare(ctx, created.Token, "", "203.0.113.1", ""); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expired share error = %v, want expired", err)
+	}
+}
+
+// TestShareProxyPasswordAndLimit 测试代理下载、访问密码、下载次数上限和撤销
+func TestShareProxyPasswordAndLimit(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 存储不支持预签名时 redirect 模式也改为代理
+	svc, shares := newShareTestService(repo, noPresignStorage{store}, &now)
+	file := newBatchTestFile(t, repo, store, "report.txt")
+
+	created, err := svc.CreateShare(ctx, file.ID, CreateShareOptions{Password: "s3cret", MaxDownloads: 2})
+	if err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+	if hash := shares.shares[created.Share.ID].PasswordHash; hash == "" || strings.Contains(hash, "s3cret") {
+		t.Errorf("password hash = %q", hash)
+	}
+
+	if _, err := svc.OpenShare(ctx, created.Token, "", "203.0.113.1", ""); err == nil || !strings.Contains(err.Error(), "password required") {
+		t.Errorf("missing password error = %v", err)
+	}
+	if _, err := svc.OpenShare(ctx, created.Token, "wrong", "203.0.113.1", ""); err == nil || !strings.Contains(err.Error(), "incorrect") {
+		t.Errorf("wrong password error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		access, err := svc.OpenShare(ctx, created.Token, "s3cret", "203.0.113.1", "")
+		if err != nil {
+			t.Fatalf("OpenShare #%d failed: %v", i+1, err)
+		}
+		if access.RedirectURL != "" || access.Download == nil {
+			t.Fatalf("OpenShare #%d should proxy the download", i+1)
+		}
+		data, err := io.ReadAll(access.Download.Reader)
+		access.Download.Reader.Close()
+		if err != nil || string(data) != "hello" {
+			t.Errorf("download = %q, %v", data, err)
+		}
+	}
+	if _, err := svc.OpenShare(ctx, created.Token, "s3cret", "203.0.113.1", ""); err == nil || !strings.Contains(err.Error(), "download limit") {
+		t.Errorf("third download error = %v, want download limit", err)
+	}
+	if shares.shares[created.Share.ID].DownloadCount != 2 {
+		t.Errorf("download count = %d, want 2", shares.shares[created.Share.ID].DownloadCount)
+	}
+
+	// 撤销后按不存在处理，重复撤销返回 not found
+	proxied, err := svc.CreateShare(ctx, file.ID, CreateShareOptions{Mode: models.ShareModeProxy})
+	if err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+	if proxied.Share.ExpiresAt == nil {
+		t.Error("share should default to max expiry")
+	}
+	if err := svc.RevokeShare(ctx, file.ID, proxied.Share.ID); err != nil {
+		t.Fatalf("RevokeShare failed: %v", err)
+	}
+	if err := svc.RevokeShare(ctx, file.ID, proxied.Share.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("second revoke error = %v, want not found", err)
+	}
+	if _, err := svc.OpenShare(ctx, proxied.Token, "", "203.0.113.1", ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("revoked share error = %v, want not found", err)
+	}
+
+	list, err := svc.ListShares(ctx, file.ID)
+	if err != nil || len(list) != 2 {
+		t.Errorf("ListShares = %d shares, %v, want 2", len(list), err)
+	}
+
+	// 文件不可用时不消耗下载次数
+	limited, err := svc.CreateShare(ctx, file.ID, CreateShareOptions{MaxDownloads: 1})
+	if err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+	file.Status = models.FileStatusQuarantined
+	if _, err := svc.OpenShare(ctx, limited.Token, "", "203.0.113.1", ""); err == nil {
+		t.Error("OpenShare should fail for a quarantined file")
+	}
+	if shares.shares[limited.Share.ID].DownloadCount != 0 {
+		t.Error("failed access should not consume a download")
+	}
+}
+
+// TestSharePasswordThrottle 测试密码错误次数过多时锁定链接以及按客户端 IP 限流
+func TestSharePasswordThrottle(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	store := NewMockStorage()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc, _ := newShareTestService(repo, store, &now)
+	file := newBatchTestFile(t, repo, store, "report.txt")
+
+	created, err := svc.CreateShare(ctx, file.ID, CreateShareOptions{Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+
+	// 正确密码清零失败次数
+	for i := 0; i < 2; i++ {
+		if _, err := svc.OpenShare(ctx, created.Token, "wrong", "203.0.113.1", ""); err == nil || !strings.Contains(err.Error(), "incorrect") {
+			t.Fatalf("wrong password error = %v, want incorrect", err)
+		}
+	}
+	if _, err := svc.OpenShare(ctx, created.Token, "s3cret", "203.0.113.2", ""); err != nil {
+		t.Fatalf("OpenShare failed: %v", err)
+	}
+
+	// 连续错误 3 次后锁定，正确密码也被拒绝（来自不同 IP）
+	for i := 0; i < 3; i++ {
+		if _, err := svc.OpenShare(ctx, created.Token, "wrong", "203.0.113.3", ""); err == nil || !strings.Contains(err.Error(), "incorrect") {
+			t.Fatalf("wrong password error = %v, want incorrect", err)
+		}
+	}
+	if _, err := svc.OpenShare(ctx, created.Token, "s3cret", "203.0.113.4", ""); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("locked share error = %v, want locked", err)
+	}
+
+	// 锁定到期后恢复
+	now = now.Add(15 * time.Minute)
+	if _, err := svc.OpenShare(ctx, created.Token, "s3cret", "203.0.113.4", ""); err != nil {
+		t.Errorf("OpenShare after lockout failed: %v", err)
+	}
+
+	// 同一 IP 在窗口内最多校验 5 次密码，未携带密码的请求不计数
+	other, err := svc.CreateShare(ctx, file.ID, CreateShareOptions{Password: "other"})
+	if err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := svc.OpenShare(ctx, other.Token, "", "198.51.100.1", ""); err == nil || !strings.Contains(err.Error(), "password required") {
+			t.Fatalf("missing password error = %v", err)
+		}
+	}
+	for _, token := range []string{created.Token, created.Token, other.Token, other.Token, created.Token} {
+		if _, err := svc.OpenShare(ctx, token, "guess", "198.51.100.1", ""); err == nil || !strings.Contains(err.Error(), "incorrect") {
+			t.Fatalf("wrong password error = %v, want incorrect", err)
+		}
+	}
+	if _, err := svc.OpenShare(ctx, other.Token, "other", "198.51.100.1", ""); err == nil || !strings.Contains(err.Error(), "too many") {
+		t.Errorf("rate limited error = %v, want too many", err)
+	}
+	if _, err := svc.OpenShare(ctx, other.Token, "other", "198.51.100.2", ""); err != nil {
+		t.Errorf("OpenShare from another IP failed: %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := svc.OpenShare(ctx, other.Token, "other", "198.51.100.1", ""); err != nil {
+		t.Errorf("OpenShare after the window failed: %v", err)
+	}
+}
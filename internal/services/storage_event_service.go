@@ -0,0 +1,258 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// storageEventConfirmTimeout SNS 订阅确认请求超时
+const storageEventConfirmTimeout = 10 * time.Second
+
+// StorageObjectEvent 存储对象事件（已从各云厂商的通知格式中解析）
+type StorageObjectEvent struct {
+	Name   string // 事件名，如 ObjectCreated:Put、s3:ObjectCreated:Put、ObjectCreated:PutObject
+	Bucket string
+	Key    string // 已解码的对象键
+	Size   int64
+	ETag   string
+}
+
+// Created 是否为对象创建事件
+func (e *StorageObjectEvent) Created() bool {
+	return strings.Contains(e.Name, "ObjectCreated")
+}
+
+// StorageNotification 一次通知请求的解析结果
+type StorageNotification struct {
+	Events       []StorageObjectEvent
+	SubscribeURL string // SNS 订阅确认地址（仅 SubscriptionConfirmation 消息）
+}
+
+// StorageEventResult 通知处理结果
+type StorageEventResult struct {
+	Received  int `json:"received"`  // 通知中的对象事件数
+	Confirmed int `json:"confirmed"` // 被确认完成的文件数
+	Ignored   int `json:"ignored"`   // 忽略的事件数（非创建事件、其他存储桶、未知对象或已确认的文件）
+	Rejected  int `json:"rejected"`  // 内容类型校验未通过而被拒绝的文件数
+}
+
+// StorageEventService 存储桶事件通知服务接口
+// 接收 S3 / MinIO / OSS 的对象创建通知，自动确认对应的预签名上传，客户端无需再调用确认接口
+type StorageEventService interface {
+	// Ingest 处理一次通知请求（请求体为云厂商推送的原始内容）
+	Ingest(ctx context.Context, body []byte) (*StorageEventResult, error)
+}
+
+// storageEventService 存储桶事件通知服务实现
+type storageEventService struct {
+	fileService FileService
+	buckets     map[string]bool
+	httpClient  *http.Client
+}
+
+// NewStorageEventService 创建存储桶事件通知服务（只处理 buckets 中存储桶的事件）
+func NewStorageEventService(fileService FileService, buckets []string) StorageEventService {
+	s := &storageEventService{
+		fileService: fileService,
+		buckets:     make(map[string]bool, len(buckets)),
+		httpClient:  &http.Client{Timeout: storageEventConfirmTimeout},
+	}
+	for _, bucket := range buckets {
+		if bucket != "" {
+			s.buckets[bucket] = true
+		}
+	}
+	return s
+}
+
+// Ingest 处理一次通知请求
+// 重复通知是安全的：已确认的文件会被忽略，不会重复产生 file.completed 事件
+func (s *storageEventService) Ingest(ctx context.Context, body []byte) (*StorageEventResult, error) {
+	notification, err := ParseStorageNotification(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if notification.SubscribeURL != "" {
+		if err := s.confirmSubscription(ctx, notification.SubscribeURL); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &StorageEventResult{Received: len(notification.Events)}
+	for _, event := range notification.Events {
+		// 其他存储桶中的同名对象不能确认本服务的上传
+		if !event.Created() || !s.buckets[event.Bucket] {
+			result.Ignored++
+			continue
+		}
+
+		if _, err := s.fileService.ConfirmStoredObject(ctx, event.Key, event.Size, event.ETag); err != nil {
+			// 非本服务管理的对象、分片上传或已确认的文件
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "not awaiting confirmation") {
+				result.Ignored++
+				continue
+			}
+			if strings.Contains(err.Error(), "content type mismatch") {
+				result.Rejected++
+				continue
+			}
+			// 其他错误返回给推送方，由其重试整个通知
+			return nil, fmt.Errorf("failed to confirm object %s: %w", event.Key, err)
+		}
+		result.Confirmed++
+	}
+
+	return result, nil
+}
+
+// confirmSubscription 确认 SNS 订阅（仅访问 AWS 域名，避免被用于请求任意地址）
+func (s *storageEventService) confirmSubscription(ctx context.Context, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" ||
+		!(strings.HasSuffix(u.Hostname(), ".amazonaws.com") || strings.HasSuffix(u.Hostname(), ".amazonaws.com.cn")) {
+		return fmt.Errorf("invalid subscribe url: %s", subscribeURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription request: %w", err)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to confirm subscription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to confirm subscription: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// storageNotificationPayload 各通知格式的并集
+type storageNotificationPayload struct {
+	// SNS 信封
+	Type         string `json:"Type"`
+	Message      string `json:"Message"`
+	SubscribeURL string `json:"SubscribeURL"`
+
+	// S3 / MinIO
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object storageNotificationObject `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+
+	// OSS（MNS 主题推送）
+	Events []struct {
+		EventName string `json:"eventName"`
+		OSS       struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object storageNotificationObject `json:"object"`
+		} `json:"oss"`
+	} `json:"events"`
+
+	// S3 EventBridge
+	DetailType string `json:"detail-type"`
+	Detail     struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object storageNotificationObject `json:"object"`
+	} `json:"detail"`
+}
+
+// storageNotificationObject 通知中的对象信息（字段名大小写不敏感，EventBridge 使用 etag）
+type storageNotificationObject struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	ETag string `json:"eTag"`
+}
+
+// ParseStorageNotification 解析存储桶事件通知
+// 支持 S3 / MinIO 事件记录、SNS 信封（包括订阅确认）、S3 EventBridge 事件和 OSS MNS 推送（原始或 Base64 编码）
+func ParseStorageNotification(body []byte) (*StorageNotification, error) {
+	body = bytes.TrimSpace(body)
+	// MNS 可配置为 Base64 编码推送
+	if len(body) > 0 && body[0] != '{' {
+		decoded, err := base64.StdEncoding.DecodeString(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid notification body")
+		}
+		body = bytes.TrimSpace(decoded)
+	}
+
+	var payload storageNotificationPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid notification body: %v", err)
+	}
+
+	switch payload.Type {
+	case "SubscriptionConfirmation":
+		return &StorageNotification{SubscribeURL: payload.SubscribeURL}, nil
+	case "Notification":
+		// SNS 消息体为 JSON 字符串形式的 S3 通知
+		return ParseStorageNotification([]byte(payload.Message))
+	case "UnsubscribeConfirmation":
+		return &StorageNotification{}, nil
+	}
+
+	notification := &StorageNotification{}
+	for _, record := range payload.Records {
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object key: %s", record.S3.Object.Key)
+		}
+		notification.Events = append(notification.Events, StorageObjectEvent{
+			Name:   record.EventName,
+			Bucket: record.S3.Bucket.Name,
+			Key:    key,
+			Size:   record.S3.Object.Size,
+			ETag:   record.S3.Object.ETag,
+		})
+	}
+
+	for _, event := range payload.Events {
+		notification.Events = append(notification.Events, StorageObjectEvent{
+			Name:   event.EventName,
+			Bucket: event.OSS.Bucket.Name,
+			Key:    event.OSS.Object.Key,
+			Size:   event.OSS.Object.Size,
+			ETag:   event.OSS.Object.ETag,
+		})
+	}
+
+	if payload.DetailType != "" && payload.Detail.Object.Key != "" {
+		key, err := url.QueryUnescape(payload.Detail.Object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object key: %s", payload.Detail.Object.Key)
+		}
+		name := payload.DetailType
+		if name == "Object Created" {
+			name = "ObjectCreated"
+		}
+		notification.Events = append(notification.Events, StorageObjectEvent{
+			Name:   name,
+			Bucket: payload.Detail.Bucket.Name,
+			Key:    key,
+			Size:   payload.Detail.Object.Size,
+			ETag:   payload.Detail.Object.ETag,
+		})
+	}
+
+	return notification, nil
+}
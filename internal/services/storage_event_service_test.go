@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestParseStorageNotification(t *testing.T) {
+	s3Body := `{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"assets"},"object":{"key":"files/my+photo%281%29.png","size":1024,"eTag":"abc123"}}}]}`
+	snsMessage, _ := json.Marshal(map[string]string{"Type": "Notification", "Message": s3Body})
+	ossBody := `{"events":[{"eventName":"ObjectCreated:PutObject","oss":{"bucket":{"name":"assets"},"object":{"key":"files/a.png","size":2048,"eTag":"\"DEF456\""}}}]}`
+
+	tests := []struct {
+		name     string
+		body     string
+		wantKey  string
+		wantSize int64
+	}{
+		{"S3", s3Body, "files/my photo(1).png", 1024},
+		{"SNS", string(snsMessage), "files/my photo(1).png", 1024},
+		{"OSS", ossBody, "files/a.png", 2048},
+		{"OSS base64", base64.StdEncoding.EncodeToString([]byte(ossBody)), "files/a.png", 2048},
+		{"EventBridge", `{"detail-type":"Object Created","detail":{"bucket":{"name":"assets"},"object":{"key":"files/b.png","size":10,"etag":"e"}}}`, "files/b.png", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification, err := ParseStorageNotification([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseStorageNotification() error = %v", err)
+			}
+			if len(notification.Events) != 1 {
+				t.Fatalf("events = %d, want 1", len(notification.Events))
+			}
+			event := notification.Events[0]
+			if !event.Created() || event.Key != tt.wantKey || event.Size != tt.wantSize {
+				t.Errorf("event = %+v, want key %q size %d", event, tt.wantKey, tt.wantSize)
+			}
+		})
+	}
+
+	confirmation, err := ParseStorageNotification([]byte(`{"Type":"SubscriptionConfirmation","SubscribeURL":"https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription"}`))
+	if err != nil || confirmation.SubscribeURL == "" {
+		t.Errorf("subscription confirmation = %+v, %v", confirmation, err)
+	}
+	if _, err := ParseStorageNotification([]byte("not json")); err == nil {
+		t.Error("ParseStorageNotification() with invalid body should fail")
+	}
+}
+
+func TestStorageEventIngest(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockFileRepository()
+	outbox := NewMockOutboxRepository()
+	svc := NewStorageEventService(NewFileService(repo, outbox, NewMockStorage(), MockTransactor{}, DownloadPolicy{}, FileServiceOptions{}), []string{"assets"})
+
+	pending := &models.File{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "a.png", StorageKey: "files/a.png", Status: models.FileStatusPending}
+	multipart := &models.File{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "b.bin", StorageKey: "files/b.bin", Status: models.FileStatusUploading}
+	repo.Create(ctx, pending)
+	repo.Create(ctx, multipart)
+
+	// 其他存储桶中的同名对象被忽略
+	body := []byte(`{"Records":[
+		{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"other"},"object":{"key":"files/a.png","size":1,"eTag":"\"etag-x\""}}},
+		{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"assets"},"object":{"key":"files/a.png","size":4096,"eTag":"\"etag-a\""}}},
+		{"eventName":"ObjectCreated:CompleteMultipartUpload","s3":{"bucket":{"name":"assets"},"object":{"key":"files/b.bin","size":1}}},
+		{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"assets"},"object":{"key":"other/unknown.png","size":1}}},
+		{"eventName":"ObjectRemoved:Delete","s3":{"bucket":{"name":"assets"},"object":{"key":"files/a.png"}}}
+	]}`)
+
+	result, err := svc.Ingest(ctx, body)
+	if err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	if result.Received != 5 || result.Confirmed != 1 || result.Ignored != 4 {
+		t.Errorf("result = %+v, want 5 received, 1 confirmed, 4 ignored", result)
+	}
+	if pending.Status != models.FileStatusCompleted || pending.Size != 4096 || pending.ETag != "etag-a" {
+		t.Errorf("pending file = status %s size %d etag %q", pending.Status, pending.Size, pending.ETag)
+	}
+	if multipart.Status != models.FileStatusUploading {
+		t.Errorf("multipart file status = %s, want uploading", multipart.Status)
+	}
+
+	// 重复通知不会重复产生事件
+	if result, _ := svc.Ingest(ctx, body); result.Confirmed != 0 {
+		t.Errorf("duplicate notification confirmed %d files", result.Confirmed)
+	}
+	if types := outbox.eventTypes(); len(types) != 1 || types[0] != string(EventFileCompleted) {
+		t.Errorf("outbox events = %v, want [file.completed]", types)
+	}
+}
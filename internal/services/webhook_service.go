@@ -0,0 +1,450 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/NanoBoom/asethub/internal/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook 请求头
+const (
+	WebhookHeaderEvent     = "X-AssetHub-Event"     // 事件类型
+	WebhookHeaderDelivery  = "X-AssetHub-Delivery"  // 投递 ID（重试时不变）
+	WebhookHeaderTimestamp = "X-AssetHub-Timestamp" // 签名时间戳（Unix 秒）
+	WebhookHeaderSignature = "X-AssetHub-Signature" // 签名：sha256=hex(HMAC-SHA256(secret, "<timestamp>.<body>"))
+)
+
+const (
+	// webhookSecretBytes 自动生成的签名密钥长度
+	webhookSecretBytes = 32
+
+	// webhookMaxErrorBody 记录到投递日志的响应体最大长度
+	webhookMaxErrorBody = 1024
+)
+
+// webhookBlockedPrefixes 除私有、环回、链路本地等地址外，同样不允许投递的地址段
+var webhookBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF 协议分配
+	netip.MustParsePrefix("198.18.0.0/15"), // 网络基准测试
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64（可映射到内网 IPv4）
+}
+
+// WebhookInput 创建订阅参数
+type WebhookInput struct {
+	URL         string
+	Secret      string   // 为空时自动生成
+	Events      []string // 为空表示订阅全部事件
+	Description string
+}
+
+// WebhookService Webhook 订阅服务接口
+// 同时实现 EventPublisher：由 OutboxRelay 调用，为每个匹配的订阅写入一条待投递记录，由 WebhookDispatcher 异步投递
+type WebhookService interface {
+	EventPublisher
+
+	// CreateWebhook 创建订阅（返回的记录包含签名密钥，仅此时可见）
+	CreateWebhook(ctx context.Context, input WebhookInput) (*models.Webhook, error)
+
+	// ListWebhooks 查询所有订阅
+	ListWebhooks(ctx context.Context) ([]*models.Webhook, error)
+
+	// GetWebhook 查询订阅
+	GetWebhook(ctx context.Context, id uuid.UUID) (*models.Webhook, error)
+
+	// DeleteWebhook 删除订阅
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+
+	// ListDeliveries 分页查询投递日志（status=dead 即死信列表）
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, status models.WebhookDeliveryStatus, offset, limit int) ([]*models.WebhookDelivery, int64, error)
+
+	// RetryDelivery 重新投递死信
+	RetryDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*models.WebhookDelivery, error)
+}
+
+// webhookService Webhook 订阅服务实现
+type webhookService struct {
+	webhookRepo repositories.WebhookRepository
+	cfg         config.WebhookConfig
+	lookupIP    func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// NewWebhookService 创建 Webhook 订阅服务实例
+func NewWebhookService(webhookRepo repositories.WebhookRepository, cfg config.WebhookConfig) WebhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		cfg:         cfg,
+		lookupIP:    net.DefaultResolver.LookupIPAddr,
+	}
+}
+
+// CreateWebhook 创建订阅
+func (s *webhookService) CreateWebhook(ctx context.Context, input WebhookInput) (*models.Webhook, error) {
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook url: must be an absolute http(s) URL")
+	}
+	if !s.cfg.AllowPrivateNetworks {
+		if err := s.checkWebhookHost(ctx, u.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+
+	events := normalizeTags(input.Events)
+	for _, event := range events {
+		if !IsValidEventType(event) {
+			return nil, fmt.Errorf("invalid event type: %s", event)
+		}
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		buf := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	webhook := &models.Webhook{
+		URL:         input.URL,
+		Secret:      secret,
+		Events:      events,
+		Description: input.Description,
+		Active:      true,
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// checkWebhookHost 拒绝内部主机名以及解析到内网地址的主机（防止 SSRF，投递时还会在建立连接前再次检查）
+func (s *webhookService) checkWebhookHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !webhookAddrAllowed(addr) {
+			return fmt.Errorf("invalid webhook url: address %s is not allowed", host)
+		}
+		return nil
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+	if !strings.Contains(name, ".") || name == "localhost" ||
+		strings.HasSuffix(name, ".localhost") || strings.HasSuffix(name, ".local") || strings.HasSuffix(name, ".internal") {
+		return fmt.Errorf("invalid webhook url: internal host %s is not allowed", host)
+	}
+
+	addrs, err := s.lookupIP(ctx, name)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("invalid webhook url: cannot resolve host %s", host)
+	}
+	for _, ip := range addrs {
+		addr, ok := netip.AddrFromSlice(ip.IP)
+		if !ok || !webhookAddrAllowed(addr) {
+			return fmt.Errorf("invalid webhook url: host %s resolves to a disallowed address %s", host, ip.IP)
+		}
+	}
+	return nil
+}
+
+// webhookAddrAllowed 是否允许向该地址投递（只允许公网单播地址）
+func webhookAddrAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range webhookBlockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookDialControl 建立连接前检查目标地址，防止域名在创建订阅后重新解析到内网地址
+func webhookDialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !webhookAddrAllowed(addr) {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+	return nil
+}
+
+// ListWebhooks 查询所有订阅
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	return s.webhookRepo.List(ctx)
+}
+
+// GetWebhook 查询订阅
+func (s *webhookService) GetWebhook(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("webhook not found: %w", err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook 删除订阅（未投递的记录在投递时进入死信）
+func (s *webhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if err := s.webhookRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("webhook not found: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries 分页查询投递日志
+func (s *webhookService) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status models.WebhookDeliveryStatus, offset, limit int) ([]*models.WebhookDelivery, int64, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, 0, err
+	}
+	return s.webhookRepo.ListDeliveries(ctx, webhookID, status, offset, limit)
+}
+
+// RetryDelivery 重新投递死信（重置尝试次数，立即进入投递队列）
+func (s *webhookService) RetryDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("delivery not found: %w", err)
+	}
+	if delivery.Status != models.WebhookDeliveryDead {
+		return nil, fmt.Errorf("only dead deliveries can be retried (status: %s)", delivery.Status)
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to update delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// Publish 为订阅了该事件的所有启用订阅创建待投递记录
+// 在中继事务中调用时，投递记录与"事件已发布"标记一起提交；重复发布同一事件不会重复创建记录
+func (s *webhookService) Publish(ctx context.Context, event *Event) error {
+	webhooks, err := s.webhookRepo.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	now := time.Now()
+	var deliveries []*models.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(string(event.Type)) {
+			continue
+		}
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     string(event.Type),
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+
+	if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("failed to create deliveries: %w", err)
+	}
+	return nil
+}
+
+// SignWebhookPayload 计算 Webhook 签名（接收方使用相同算法校验）
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDispatcher Webhook 投递器
+// 轮询到期的投递记录并发送，失败按指数退避重试，超过最大次数后进入死信
+type WebhookDispatcher struct {
+	webhookRepo repositories.WebhookRepository
+	client      *http.Client
+	cfg         config.WebhookConfig
+}
+
+// NewWebhookDispatcher 创建 Webhook 投递器
+func NewWebhookDispatcher(webhookRepo repositories.WebhookRepository, cfg config.WebhookConfig) *WebhookDispatcher {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivateNetworks {
+		// 每次建立连接时检查实际连接的地址；不使用环境变量中的代理，否则检查的是代理地址
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: webhookDialControl}
+		transport.DialContext = dialer.DialContext
+		transport.Proxy = nil
+	}
+
+	return &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+			// 不跟随重定向，3xx 视为投递失败
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg: cfg,
+	}
+}
+
+// Run 持续投递直到 ctx 取消
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// 本批领满时立即继续，否则等待下一次轮询
+		n, _ := d.DispatchDue(ctx)
+		if n >= d.cfg.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue 投递一批到期的记录，返回处理的记录数
+func (d *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	// 租约覆盖单次请求超时，进程崩溃时记录会在租约到期后被重新领取
+	lease := d.cfg.Timeout + time.Minute
+	deliveries, err := d.webhookRepo.ClaimDueDeliveries(ctx, time.Now(), d.cfg.BatchSize, lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	webhooks := make(map[uuid.UUID]*models.Webhook)
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = d.webhookRepo.GetByID(ctx, delivery.WebhookID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				// 查询失败时跳过，租约到期后重新领取
+				continue
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+		d.deliver(ctx, webhook, delivery)
+	}
+
+	return len(deliveries), nil
+}
+
+// deliver 发送单条投递并更新记录
+func (d *WebhookDispatcher) deliver(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+
+	var err error
+	switch {
+	case webhook == nil:
+		err = fmt.Errorf("webhook has been deleted")
+		delivery.Attempts = d.cfg.MaxAttempts
+	case !webhook.Active:
+		err = fmt.Errorf("webhook is disabled")
+		delivery.Attempts = d.cfg.MaxAttempts
+	default:
+		delivery.ResponseCode, err = d.send(ctx, webhook, delivery)
+	}
+
+	now := time.Now()
+	if err == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= d.cfg.MaxAttempts {
+			delivery.Status = models.WebhookDeliveryDead
+		} else {
+			delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts, d.cfg.InitialBackoff, d.cfg.MaxBackoff))
+		}
+	}
+
+	// 使用独立上下文，避免关闭时请求已发出但状态未保存导致重复投递
+	saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = d.webhookRepo.UpdateDelivery(saveCtx, delivery)
+}
+
+// send 发送签名请求，返回响应状态码（2xx 视为成功）
+func (d *WebhookDispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AssetHub-Webhook/1.0")
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderDelivery, delivery.ID.String())
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, respBody)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff 计算第 attempt 次失败后的重试间隔（initial * 2^(attempt-1)，不超过 maxDelay）
+func webhookBackoff(attempt int, initial, maxDelay time.Duration) time.Duration {
+	delay := initial
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MockWebhookRepository 内存实现的 Webhook 仓储
+type MockWebhookRepository struct {
+	webhooks   map[uuid.UUID]*models.Webhook
+	deliveries map[uuid.UUID]*models.WebhookDelivery
+}
+
+func NewMockWebhookRepository() *MockWebhookRepository {
+	return &MockWebhookRepository{
+		webhooks:   make(map[uuid.UUID]*models.Webhook),
+		deliveries: make(map[uuid.UUID]*models.WebhookDelivery),
+	}
+}
+
+func (m *MockWebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	if webhook.ID == uuid.Nil {
+		webhook.ID = uuid.New()
+	}
+	m.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (m *MockWebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	if webhook, ok := m.webhooks[id]; ok {
+		return webhook, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockWebhookRepository) List(ctx context.Context) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	for _, webhook := range m.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+func (m *MockWebhookRepository) ListActive(ctx context.Context) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	for _, webhook := range m.webhooks {
+		if webhook.Active {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *MockWebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := m.webhooks[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(m.webhooks, id)
+	return nil
+}
+
+func (m *MockWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	for _, delivery := range deliveries {
+		if delivery.ID == uuid.Nil {
+			delivery.ID = uuid.New()
+		}
+		m.deliveries[delivery.ID] = delivery
+	}
+	return nil
+}
+
+func (m *MockWebhookRepository) GetDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	if delivery, ok := m.deliveries[deliveryID]; ok && delivery.WebhookID == webhookID {
+		return delivery, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	m.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status models.WebhookDeliveryStatus, offset, limit int) ([]*models.WebhookDelivery, int64, error) {
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookID && (status == "" || delivery.Status == status) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, int64(len(deliveries)), nil
+}
+
+func (m *MockWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if len(deliveries) == limit {
+			break
+		}
+		if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			delivery.NextAttemptAt = now.Add(lease)
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func newTestWebhookConfig() config.WebhookConfig {
+	return config.WebhookConfig{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Timeout:        5 * time.Second,
+		PollInterval:   time.Second,
+		BatchSize:      10,
+		// 测试服务器监听在环回地址
+		AllowPrivateNetworks: true,
+	}
+}
+
+// newTestWebhookService 创建使用固定 DNS 解析结果的订阅服务
+func newTestWebhookService(repo *MockWebhookRepository, cfg config.WebhookConfig) *webhookService {
+	hosts := map[string][]string{
+		"example.com":        {"93.184.215.14", "2606:2800:21f:cb07:6820:80da:af6b:8b2c"},
+		"rebind.example.com": {"169.254.169.254"},
+		"mixed.example.com":  {"93.184.215.14", "10.0.0.1"},
+	}
+	svc := NewWebhookService(repo, cfg).(*webhookService)
+	svc.lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		var addrs []net.IPAddr
+		for _, ip := range hosts[host] {
+			addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("no such host")
+		}
+		return addrs, nil
+	}
+	return svc
+}
+
+func TestWebhookPublish(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockWebhookRepository()
+	svc := newTestWebhookService(repo, config.WebhookConfig{})
+
+	all, err := svc.CreateWebhook(ctx, WebhookInput{URL: "https://example.com/all"})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	if len(all.Secret) != webhookSecretBytes*2 {
+		t.Errorf("generated secret length = %d, want %d", len(all.Secret), webhookSecretBytes*2)
+	}
+	deletedOnly, _ := svc.CreateWebhook(ctx, WebhookInput{URL: "https://example.com/deleted", Events: []string{"file.deleted"}})
+	disabled, _ := svc.CreateWebhook(ctx, WebhookInput{URL: "https://example.com/disabled"})
+	disabled.Active = false
+
+	if _, err := svc.CreateWebhook(ctx, WebhookInput{URL: "ftp://example.com"}); err == nil {
+		t.Error("CreateWebhook() with ftp url should fail")
+	}
+	if _, err := svc.CreateWebhook(ctx, WebhookInput{URL: "https://example.com", Events: []string{"file.renamed"}}); err == nil {
+		t.Error("CreateWebhook() with unknown event should fail")
+	}
+
+	file := &models.File{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "a.png", Status: models.FileStatusCompleted}
+	if err := svc.Publish(ctx, NewFileEvent(EventFileCompleted, file)); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	targets := make(map[uuid.UUID]int)
+	for _, delivery := range repo.deliveries {
+		targets[delivery.WebhookID]++
+	}
+	if targets[all.ID] != 1 || targets[deletedOnly.ID] != 0 || targets[disabled.ID] != 0 {
+		t.Errorf("deliveries per webhook = %v, want only %s", targets, all.ID)
+	}
+}
+
+// TestWebhookPrivateAddress 测试拒绝内网地址（创建订阅时按 DNS 解析结果检查，投递时按实际连接地址检查）
+func TestWebhookPrivateAddress(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockWebhookRepository()
+	svc := newTestWebhookService(repo, config.WebhookConfig{})
+
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.1.2.3/hook",
+		"http://192.168.0.1/hook",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://[::ffff:192.168.0.1]/hook",
+		"http://localhost:8080/hook",
+		"http://metadata.google.internal/hook",
+		"http://intranet/hook",
+		"https://rebind.example.com/hook",
+		"https://mixed.example.com/hook",
+		"https://unknown.example.com/hook",
+	} {
+		if _, err := svc.CreateWebhook(ctx, WebhookInput{URL: rawURL}); err == nil || !strings.Contains(err.Error(), "invalid webhook url") {
+			t.Errorf("CreateWebhook(%s) error = %v, want invalid webhook url", rawURL, err)
+		}
+	}
+	for _, rawURL := range []string{"https://example.com/hook", "https://93.184.215.14/hook"} {
+		if _, err := svc.CreateWebhook(ctx, WebhookInput{URL: rawURL}); err != nil {
+			t.Errorf("CreateWebhook(%s) error = %v", rawURL, err)
+		}
+	}
+
+	// 投递时拒绝连接内网地址（如域名重新解析到内网）
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer server.Close()
+
+	repo = NewMockWebhookRepository()
+	webhook := &models.Webhook{URL: server.URL, Secret: "s3cret", Active: true}
+	repo.Create(ctx, webhook)
+	delivery := &models.WebhookDelivery{BaseModel: models.BaseModel{ID: uuid.New()}, WebhookID: webhook.ID, EventType: string(EventFileDeleted), Payload: "{}", Status: models.WebhookDeliveryPending}
+	repo.deliveries[delivery.ID] = delivery
+
+	cfg := newTestWebhookConfig()
+	cfg.AllowPrivateNetworks = false
+	if n, err := NewWebhookDispatcher(repo, cfg).DispatchDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DispatchDue() = %d, %v", n, err)
+	}
+	if received != 0 || delivery.Status != models.WebhookDeliveryPending || !strings.Contains(delivery.LastError, "not allowed") {
+		t.Errorf("received=%d status=%s error=%q, want blocked", received, delivery.Status, delivery.LastError)
+	}
+}
+
+func TestWebhookDispatcher(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockWebhookRepository()
+	svc := NewWebhookService(repo, newTestWebhookConfig())
+
+	fail := true
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(WebhookHeaderTimestamp), 10, 64)
+		if got, want := r.Header.Get(WebhookHeaderSignature), SignWebhookPayload("s3cret", timestamp, body); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if r.Header.Get(WebhookHeaderEvent) != string(EventFileDeleted) {
+			t.Errorf("event header = %q", r.Header.Get(WebhookHeaderEvent))
+		}
+		received++
+		if fail {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	webhook, _ := svc.CreateWebhook(ctx, WebhookInput{URL: server.URL, Secret: "s3cret"})
+	file := &models.File{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "a.png"}
+	if err := svc.Publish(ctx, NewFileEvent(EventFileDeleted, file)); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	dispatcher := NewWebhookDispatcher(repo, newTestWebhookConfig())
+	var delivery *models.WebhookDelivery
+	for _, d := range repo.deliveries {
+		delivery = d
+	}
+
+	// 失败：进入重试，按指数退避推迟
+	if n, err := dispatcher.DispatchDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DispatchDue() = %d, %v", n, err)
+	}
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 1 || delivery.ResponseCode != http.StatusInternalServerError {
+		t.Fatalf("after failure: status=%s attempts=%d code=%d", delivery.Status, delivery.Attempts, delivery.ResponseCode)
+	}
+	if n, _ := dispatcher.DispatchDue(ctx); n != 0 {
+		t.Fatalf("delivery should wait for backoff, dispatched %d", n)
+	}
+
+	// 达到最大次数后进入死信
+	for i := 0; i < 2; i++ {
+		delivery.NextAttemptAt = time.Now()
+		dispatcher.DispatchDue(ctx)
+	}
+	if delivery.Status != models.WebhookDeliveryDead || received != 3 {
+		t.Fatalf("after max attempts: status=%s received=%d", delivery.Status, received)
+	}
+
+	// 重新投递死信
+	if _, err := svc.RetryDelivery(ctx, webhook.ID, delivery.ID); err != nil {
+		t.Fatalf("RetryDelivery() error = %v", err)
+	}
+	fail = false
+	dispatcher.DispatchDue(ctx)
+	if delivery.Status != models.WebhookDeliverySucceeded || delivery.DeliveredAt == nil {
+		t.Fatalf("after retry: status=%s", delivery.Status)
+	}
+	if _, err := svc.RetryDelivery(ctx, webhook.ID, delivery.ID); err == nil {
+		t.Error("RetryDelivery() on succeeded delivery should fail")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{10, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempt, 30*time.Second, time.Hour); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
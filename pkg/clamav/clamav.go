@@ -0,0 +1,180 @@
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize INSTREAM 每个数据块的大小
+const chunkSize = 64 * 1024
+
+// Result 扫描结果
+type Result struct {
+	Infected  bool   // 是否检测到恶意内容
+	Signature string // 命中的病毒签名（如 "Eicar-Test-Signature"）
+}
+
+// Client clamd 客户端
+// 使用 clamd 的 z 前缀命令（以 NUL 结尾），每个命令使用独立连接
+type Client struct {
+	address string
+	timeout time.Duration
+}
+
+// New 创建 clamd 客户端
+// 参数：
+//   - address: clamd 地址，host:port（TCP）或以 / 开头的 Unix socket 路径
+//   - timeout: 单次读写超时（大文件扫描时每个数据块单独计时）
+func New(address string, timeout time.Duration) *Client {
+	return &Client{
+		address: address,
+		timeout: timeout,
+	}
+}
+
+// Ping 检查 clamd 是否可用
+func (c *Client) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "PING")
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected clamd reply: %s", reply)
+	}
+	return nil
+}
+
+// Version 查询扫描引擎及病毒库版本（如 "ClamAV 1.2.1/27120/Tue Dec 12 09:30:00 2023"）
+func (c *Client) Version(ctx context.Context) (string, error) {
+	return c.command(ctx, "VERSION")
+}
+
+// Scan 使用 INSTREAM 命令流式扫描内容
+func (c *Client) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := c.write(conn, []byte("zINSTREAM\x00")); err != nil {
+		return nil, err
+	}
+
+	// 数据块格式：4 字节大端长度 + 数据，长度为 0 的块表示结束
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if err := c.write(conn, buf[:4+n]); err != nil {
+				// clamd 在超过 StreamMaxLength 时会先返回错误再关闭连接
+				if reply, replyErr := c.read(conn); replyErr == nil {
+					return nil, fmt.Errorf("clamd error: %s", reply)
+				}
+				return nil, err
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read content: %w", readErr)
+		}
+	}
+	if err := c.write(conn, []byte{0, 0, 0, 0}); err != nil {
+		return nil, err
+	}
+
+	reply, err := c.read(conn)
+	if err != nil {
+		return nil, err
+	}
+	return parseScanReply(reply)
+}
+
+// parseScanReply 解析扫描响应：stream: OK / stream: <签名> FOUND / <原因> ERROR
+func parseScanReply(reply string) (*Result, error) {
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	case strings.HasSuffix(reply, " ERROR"):
+		return nil, fmt.Errorf("clamd error: %s", strings.TrimSuffix(reply, " ERROR"))
+	default:
+		return nil, fmt.Errorf("unexpected clamd reply: %s", reply)
+	}
+}
+
+// command 发送单个命令并读取响应
+func (c *Client) command(ctx context.Context, name string) (string, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if err := c.write(conn, []byte("z"+name+"\x00")); err != nil {
+		return "", err
+	}
+	return c.read(conn)
+}
+
+// dial 连接 clamd，ctx 取消时关闭连接以中断进行中的读写
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	network := "tcp"
+	if strings.HasPrefix(c.address, "/") {
+		network = "unix"
+	}
+
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	return &ctxConn{Conn: conn, stop: stop}, nil
+}
+
+// write 写入数据（每次写入单独计算超时）
+func (c *Client) write(conn net.Conn, data []byte) error {
+	if c.timeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("failed to write to clamd: %w", err)
+	}
+	return nil
+}
+
+// read 读取以 NUL 结尾的响应
+func (c *Client) read(conn net.Conn) (string, error) {
+	if c.timeout > 0 {
+		_ = conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return "", fmt.Errorf("failed to read from clamd: %w", err)
+	}
+	return strings.TrimSpace(strings.TrimRight(reply, "\x00")), nil
+}
+
+// ctxConn 关闭时同时取消 ctx 监听
+type ctxConn struct {
+	net.Conn
+	stop func() bool
+}
+
+func (c *ctxConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
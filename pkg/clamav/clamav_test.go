@@ -0,0 +1,152 @@
+package clamav
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eicar EICAR 标准测试文件内容
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd 模拟 clamd：支持 PING、VERSION、INSTREAM（内容包含 EICAR 时报告感染），maxStream 为 0 时不限制大小
+func fakeClamd(t *testing.T, maxStream int) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, maxStream)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveClamd(conn net.Conn, maxStream int) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+
+	switch strings.TrimRight(cmd, "\x00") {
+	case "zPING":
+		conn.Write([]byte("PONG\x00"))
+	case "zVERSION":
+		conn.Write([]byte("ClamAV 1.2.1/27120/Tue Dec 12 09:30:00 2023\x00"))
+	case "zINSTREAM":
+		var content bytes.Buffer
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&content, r, int64(size)); err != nil {
+				return
+			}
+			if maxStream > 0 && content.Len() > maxStream {
+				conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+				return
+			}
+		}
+		if strings.Contains(content.String(), eicar) {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+		} else {
+			conn.Write([]byte("stream: OK\x00"))
+		}
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+	}
+}
+
+func TestClientScan(t *testing.T) {
+	ctx := context.Background()
+	client := New(fakeClamd(t, 0), 5*time.Second)
+
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	version, err := client.Version(ctx)
+	if err != nil || !strings.HasPrefix(version, "ClamAV 1.2.1/27120") {
+		t.Errorf("Version() = %q, %v", version, err)
+	}
+
+	// 跨越多个数据块的干净内容
+	clean := bytes.Repeat([]byte("a"), 3*chunkSize+17)
+	result, err := client.Scan(ctx, bytes.NewReader(clean))
+	if err != nil || result.Infected {
+		t.Errorf("Scan(clean) = %+v, %v", result, err)
+	}
+
+	result, err = client.Scan(ctx, strings.NewReader(eicar))
+	if err != nil || !result.Infected || result.Signature != "Eicar-Test-Signature" {
+		t.Errorf("Scan(eicar) = %+v, %v", result, err)
+	}
+
+	result, err = client.Scan(ctx, strings.NewReader(""))
+	if err != nil || result.Infected {
+		t.Errorf("Scan(empty) = %+v, %v", result, err)
+	}
+}
+
+func TestClientScanSizeLimit(t *testing.T) {
+	client := New(fakeClamd(t, chunkSize), 5*time.Second)
+
+	_, err := client.Scan(context.Background(), bytes.NewReader(make([]byte, 4*chunkSize)))
+	if err == nil || !strings.Contains(err.Error(), "size limit exceeded") {
+		t.Errorf("Scan() over limit error = %v", err)
+	}
+}
+
+func TestClientUnavailable(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	if err := New(addr, time.Second).Ping(context.Background()); err == nil {
+		t.Error("Ping() to closed port should fail")
+	}
+}
+
+func TestParseScanReply(t *testing.T) {
+	tests := []struct {
+		reply     string
+		infected  bool
+		signature string
+		wantErr   bool
+	}{
+		{"stream: OK", false, "", false},
+		{"stream: Win.Test.EICAR_HDB-1 FOUND", true, "Win.Test.EICAR_HDB-1", false},
+		{"INSTREAM size limit exceeded. ERROR", false, "", true},
+		{"garbage", false, "", true},
+	}
+
+	for _, tt := range tests {
+		result, err := parseScanReply(tt.reply)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseScanReply(%q) error = %v, wantErr %v", tt.reply, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (result.Infected != tt.infected || result.Signature != tt.signature) {
+			t.Errorf("parseScanReply(%q) = %+v", tt.reply, result)
+		}
+	}
+}
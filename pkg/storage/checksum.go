@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"strings"
+)
+
+// ChecksumAlgorithm 完整性校验算法
+type ChecksumAlgorithm string
+
+const (
+	ChecksumMD5    ChecksumAlgorithm = "md5"    // Content-MD5（S3 与 OSS 均支持）
+	ChecksumCRC32C ChecksumAlgorithm = "crc32c" // x-amz-checksum-crc32c（仅 S3）
+	ChecksumSHA256 ChecksumAlgorithm = "sha256" // x-amz-checksum-sha256（仅 S3）
+)
+
+// checksumSizes 各算法摘要的字节数
+var checksumSizes = map[ChecksumAlgorithm]int{
+	ChecksumMD5:    md5.Size,
+	ChecksumCRC32C: crc32.Size,
+	ChecksumSHA256: sha256.Size,
+}
+
+// Checksum 对象（或分片）的完整性校验值
+// 签名到预签名 URL 后，存储服务会拒绝内容与校验值不一致的上传
+type Checksum struct {
+	Algorithm ChecksumAlgorithm // 校验算法
+	Value     string            // Base64 编码的摘要
+}
+
+// ParseChecksumAlgorithm 解析校验算法（不区分大小写，空字符串表示不校验）
+func ParseChecksumAlgorithm(algorithm string) (ChecksumAlgorithm, error) {
+	if algorithm == "" {
+		return "", nil
+	}
+	alg := ChecksumAlgorithm(strings.ToLower(algorithm))
+	if _, ok := checksumSizes[alg]; !ok {
+		return "", fmt.Errorf("invalid checksum algorithm %q: must be md5, crc32c or sha256", algorithm)
+	}
+	return alg, nil
+}
+
+// ParseChecksum 解析并校验客户端声明的校验值（Base64 编码，长度须与算法一致）
+// algorithm 和 value 均为空时返回 nil
+func ParseChecksum(algorithm, value string) (*Checksum, error) {
+	alg, err := ParseChecksumAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if alg == "" && value == "" {
+		return nil, nil
+	}
+	if alg == "" || value == "" {
+		return nil, fmt.Errorf("invalid checksum: algorithm and value must be provided together")
+	}
+
+	digest, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(digest) != checksumSizes[alg] {
+		return nil, fmt.Errorf("invalid checksum: %s value must be %d base64-encoded bytes", alg, checksumSizes[alg])
+	}
+	return &Checksum{Algorithm: alg, Value: value}, nil
+}
+
+// NewChecksumHash 创建算法对应的 hash.Hash（用于服务端计算校验值）
+func NewChecksumHash(algorithm ChecksumAlgorithm) hash.Hash {
+	switch algorithm {
+	case ChecksumMD5:
+		return md5.New()
+	case ChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case ChecksumSHA256:
+		return sha256.New()
+	default:
+		return nil
+	}
+}
+
+// Digest 返回解码后的摘要
+func (c *Checksum) Digest() []byte {
+	digest, _ := base64.StdEncoding.DecodeString(c.Value)
+	return digest
+}
+
+// MatchesETag 判断 MD5 校验值是否与分片 ETag 一致
+// 只适用于分片 ETag 为 MD5 十六进制的对象（见 Encryption.PartETagIsMD5）
+func (c *Checksum) MatchesETag(etag string) bool {
+	return c.Algorithm == ChecksumMD5 && strings.EqualFold(strings.Trim(etag, `"`), hex.EncodeToString(c.Digest()))
+}
+
+// CompositeChecksum 计算分片上传的组合校验值：对各分片摘要拼接后再次计算摘要，格式为 "<Base64>-<分片数>"
+// 与 S3 对 CRC32C/SHA256 组合校验值的计算方式一致（MD5 对应 S3 分片上传 ETag 的计算方式）
+func CompositeChecksum(algorithm ChecksumAlgorithm, parts []*Checksum) (string, error) {
+	h := NewChecksumHash(algorithm)
+	if h == nil {
+		return "", fmt.Errorf("invalid checksum algorithm %q", algorithm)
+	}
+	for i, part := range parts {
+		if part == nil || part.Algorithm != algorithm {
+			return "", fmt.Errorf("checksum required: part %d has no %s checksum", i+1, algorithm)
+		}
+		h.Write(part.Digest())
+	}
+	return fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(h.Sum(nil)), len(parts)), nil
+}
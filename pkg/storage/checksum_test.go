@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestParseChecksum(t *testing.T) {
+	md5Value := base64.StdEncoding.EncodeToString(make([]byte, 16))
+
+	tests := []struct {
+		name      string
+		algorithm string
+		value     string
+		want      *Checksum
+		wantErr   string
+	}{
+		{name: "empty", want: nil},
+		{name: "md5", algorithm: "MD5", value: md5Value, want: &Checksum{Algorithm: ChecksumMD5, Value: md5Value}},
+		{name: "crc32c", algorithm: "crc32c", value: "yZRlqg==", want: &Checksum{Algorithm: ChecksumCRC32C, Value: "yZRlqg=="}},
+		{name: "unknown algorithm", algorithm: "sha1", value: md5Value, wantErr: "invalid checksum algorithm"},
+		{name: "value without algorithm", value: md5Value, wantErr: "must be provided together"},
+		{name: "algorithm without value", algorithm: "sha256", wantErr: "must be provided together"},
+		{name: "wrong length", algorithm: "sha256", value: md5Value, wantErr: "32 base64-encoded bytes"},
+		{name: "not base64", algorithm: "md5", value: "not-base64!", wantErr: "invalid checksum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChecksum(tt.algorithm, tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseChecksum() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseChecksum() error = %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("ParseChecksum() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksumMatchesETag(t *testing.T) {
+	sum := md5.Sum([]byte("part data"))
+	checksum := &Checksum{Algorithm: ChecksumMD5, Value: base64.StdEncoding.EncodeToString(sum[:])}
+
+	if !checksum.MatchesETag(`"` + strings.ToUpper(hex.EncodeToString(sum[:])) + `"`) {
+		t.Error("MatchesETag() = false for quoted upper-case ETag")
+	}
+	if checksum.MatchesETag(`"0123456789abcdef0123456789abcdef"`) {
+		t.Error("MatchesETag() = true for different ETag")
+	}
+}
+
+func TestCompositeChecksum(t *testing.T) {
+	part := func(data string) *Checksum {
+		h := NewChecksumHash(ChecksumSHA256)
+		h.Write([]byte(data))
+		return &Checksum{Algorithm: ChecksumSHA256, Value: base64.StdEncoding.EncodeToString(h.Sum(nil))}
+	}
+	parts := []*Checksum{part("a"), part("b")}
+
+	got, err := CompositeChecksum(ChecksumSHA256, parts)
+	if err != nil {
+		t.Fatalf("CompositeChecksum() error = %v", err)
+	}
+
+	h := NewChecksumHash(ChecksumSHA256)
+	h.Write(parts[0].Digest())
+	h.Write(parts[1].Digest())
+	if want := base64.StdEncoding.EncodeToString(h.Sum(nil)) + "-2"; got != want {
+		t.Errorf("CompositeChecksum() = %q, want %q", got, want)
+	}
+
+	if _, err := CompositeChecksum(ChecksumSHA256, []*Checksum{parts[0], nil}); err == nil || !strings.Contains(err.Error(), "part 2") {
+		t.Errorf("CompositeChecksum() with missing part error = %v, want part 2", err)
+	}
+}
@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec 对象内容的压缩编码（取值与 HTTP Content-Encoding 一致）
+type Codec string
+
+const (
+	CodecNone Codec = ""     // 未压缩
+	CodecGzip Codec = "gzip" // gzip 压缩
+	CodecZstd Codec = "zstd" // zstd 压缩（压缩率和速度优于 gzip，但并非所有客户端都支持）
+)
+
+// errCompressedPresign 压缩保存的对象不支持预签名下载（存储服务返回的是压缩内容）
+var errCompressedPresign = fmt.Errorf("%w for compressed objects", ErrPresignNotSupported)
+
+// CodecStore 按存储键保存和读取对象的压缩编码
+type CodecStore interface {
+	// GetCodec 读取对象的压缩编码（对象未压缩或没有文件记录时返回 CodecNone）
+	GetCodec(ctx context.Context, key string) (Codec, error)
+
+	// PutCodec 保存对象的压缩编码（对象的文件记录须已存在）
+	PutCodec(ctx context.Context, key string, codec Codec) error
+}
+
+// CompressionPolicy 透明压缩策略：哪些内容类型压缩、使用的编码和压缩级别
+type CompressionPolicy struct {
+	codec        Codec
+	level        int
+	minSize      int64
+	contentTypes []string
+}
+
+// NewCompressionPolicy 根据配置创建压缩策略（未启用时返回 nil，只解压已压缩的对象）
+func NewCompressionPolicy(cfg config.CompressionConfig) (*CompressionPolicy, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	codec := Codec(strings.ToLower(cfg.Codec))
+	level := cfg.Level
+	switch codec {
+	case "", CodecGzip:
+		codec = CodecGzip
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		if level < gzip.BestSpeed && level != gzip.DefaultCompression || level > gzip.BestCompression {
+			return nil, fmt.Errorf("invalid compression level %d: gzip levels are 1-9", cfg.Level)
+		}
+	case CodecZstd:
+		if level == 0 {
+			level = 3 // zstd 默认级别
+		}
+		if level < 1 || level > 22 {
+			return nil, fmt.Errorf("invalid compression level %d: zstd levels are 1-22", cfg.Level)
+		}
+	default:
+		return nil, fmt.Errorf("compression codec %q is not supported (supported: gzip, zstd)", cfg.Codec)
+	}
+
+	contentTypes := make([]string, len(cfg.ContentTypes))
+	for i, contentType := range cfg.ContentTypes {
+		contentTypes[i] = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return &CompressionPolicy{codec: codec, level: level, minSize: cfg.MinSize, contentTypes: contentTypes}, nil
+}
+
+// Compressible 判断内容是否需要压缩（"text/*" 匹配整个主类型，忽略参数）
+func (p *CompressionPolicy) Compressible(contentType string, size int64) bool {
+	if p == nil || size < p.minSize {
+		return false
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, pattern := range p.contentTypes {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// encodedContentContextKey 上下文中客户端可接受的压缩编码的键
+type encodedContentContextKey struct{}
+
+// WithEncodedContent 声明调用方可以直接接收按 codec 压缩的内容
+// 按该编码压缩保存的对象，GetObject 将原样返回压缩内容及其大小（用于向支持该编码的客户端直接返回 Content-Encoding 响应）
+func WithEncodedContent(ctx context.Context, codec Codec) context.Context {
+	return context.WithValue(ctx, encodedContentContextKey{}, codec)
+}
+
+// encodedContentFromContext 读取调用方可接受的压缩编码（未声明时返回 CodecNone）
+func encodedContentFromContext(ctx context.Context) Codec {
+	codec, _ := ctx.Value(encodedContentContextKey{}).(Codec)
+	return codec
+}
+
+// CompressedStorage 透明压缩存储（装饰器）
+// 后端直接上传的可压缩内容（文本、JSON、CSV 等）压缩后写入底层存储，压缩编码记录在文件记录上，读取时自动解压。
+// 压缩后不比原文件小的内容按原样保存。预签名上传和分片上传的内容不经过后端，不会被压缩
+type CompressedStorage struct {
+	inner  Storage
+	codecs CodecStore
+	policy *CompressionPolicy
+}
+
+// NewCompressedStorage 创建透明压缩存储（policy 为 nil 时不压缩新对象，只解压已压缩的对象）
+func NewCompressedStorage(inner Storage, codecs CodecStore, policy *CompressionPolicy) *CompressedStorage {
+	return &CompressedStorage{inner: inner, codecs: codecs, policy: policy}
+}
+
+// Upload 压缩可压缩的内容后上传并保存压缩编码
+// 压缩后的大小须在上传前确定，因此先压缩到临时文件
+func (c *CompressedStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	if !c.policy.Compressible(contentType, size) {
+		return c.inner.Upload(ctx, key, reader, size, contentType)
+	}
+
+	tmp, err := os.CreateTemp("", "assethub-compress-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	writer, err := newCompressWriter(c.policy.codec, c.policy.level, tmp)
+	if err != nil {
+		return err
+	}
+	read, err := io.Copy(writer, reader)
+	if err != nil {
+		return fmt.Errorf("failed to compress object: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress object: %w", err)
+	}
+	if read != size {
+		return fmt.Errorf("size mismatch: declared %d bytes, read %d", size, read)
+	}
+
+	compressedSize, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// 压缩无收益时从临时文件解压出原内容上传（原始流已读完）
+	if compressedSize >= size {
+		plain, err := newDecompressReader(c.policy.codec, io.NopCloser(tmp))
+		if err != nil {
+			return err
+		}
+		defer plain.Close()
+		return c.inner.Upload(ctx, key, plain, size, contentType)
+	}
+
+	// 先保存压缩编码，保存失败时不写入无法正确读取的对象
+	if err := c.codecs.PutCodec(ctx, key, c.policy.codec); err != nil {
+		return fmt.Errorf("failed to store content encoding: %w", err)
+	}
+	return c.inner.Upload(ctx, key, tmp, compressedSize, contentType)
+}
+
+// GeneratePresignedUploadURL 生成上传预签名 URL（直传内容不压缩）
+func (c *CompressedStorage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string, checksum *Checksum) (*PresignedRequest, error) {
+	return c.inner.GeneratePresignedUploadURL(ctx, key, expiry, contentType, checksum)
+}
+
+// GeneratePresignedPost 生成表单上传策略（直传内容不压缩）
+func (c *CompressedStorage) GeneratePresignedPost(ctx context.Context, key string, expiry time.Duration, policy PostPolicy) (*PresignedPost, error) {
+	return c.inner.GeneratePresignedPost(ctx, key, expiry, policy)
+}
+
+// InitMultipartUpload 初始化分片上传（分片内容不压缩）
+func (c *CompressedStorage) InitMultipartUpload(ctx context.Context, key string, contentType string, algorithm ChecksumAlgorithm) (*MultipartUpload, error) {
+	return c.inner.InitMultipartUpload(ctx, key, contentType, algorithm)
+}
+
+// GeneratePresignedPartURL 生成分片上传预签名 URL
+func (c *CompressedStorage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration, checksum *Checksum) (*PresignedRequest, error) {
+	return c.inner.GeneratePresignedPartURL(ctx, key, uploadID, partNumber, expiry, checksum)
+}
+
+// CompleteMultipartUpload 完成分片上传
+func (c *CompressedStorage) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error {
+	return c.inner.CompleteMultipartUpload(ctx, key, uploadID, parts)
+}
+
+// AbortMultipartUpload 取消分片上传
+func (c *CompressedStorage) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	return c.inner.AbortMultipartUpload(ctx, key, uploadID)
+}
+
+// GetObject 读取对象，压缩保存的对象自动解压（解压后大小未知，返回 -1）
+// 调用方通过 WithEncodedContent 声明可接受该编码时原样返回压缩内容及其大小
+func (c *CompressedStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	codec, err := c.codecs.GetCodec(ctx, key)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to load content encoding: %w", err)
+	}
+
+	body, contentType, size, err := c.inner.GetObject(ctx, key)
+	if err != nil || codec == CodecNone || codec == encodedContentFromContext(ctx) {
+		return body, contentType, size, err
+	}
+
+	decompressed, err := newDecompressReader(codec, body)
+	if err != nil {
+		body.Close()
+		return nil, "", 0, err
+	}
+	return decompressed, contentType, -1, nil
+}
+
+// GetObjectRange 读取对象的部分内容（压缩保存的对象从头解压后截取）
+func (c *CompressedStorage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	codec, err := c.codecs.GetCodec(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load content encoding: %w", err)
+	}
+	if codec == CodecNone {
+		return c.inner.GetObjectRange(ctx, key, offset, length)
+	}
+
+	body, _, _, err := c.inner.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := newDecompressReader(codec, body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, decompressed, offset); err != nil && err != io.EOF {
+		decompressed.Close()
+		return nil, err
+	}
+	return &readCloser{Reader: io.LimitReader(decompressed, length), Closer: decompressed}, nil
+}
+
+// GeneratePresignedDownloadURL 生成下载预签名 URL（压缩保存的对象不支持，调用方应改用后端代理下载）
+func (c *CompressedStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error) {
+	codec, err := c.codecs.GetCodec(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load content encoding: %w", err)
+	}
+	if codec != CodecNone {
+		return nil, errCompressedPresign
+	}
+	return c.inner.GeneratePresignedDownloadURL(ctx, key, expiry, opts)
+}
+
+// Delete 删除对象
+func (c *CompressedStorage) Delete(ctx context.Context, key string) error {
+	return c.inner.Delete(ctx, key)
+}
+
+// DeleteObjects 批量删除对象
+func (c *CompressedStorage) DeleteObjects(ctx context.Context, keys []string) (map[string]error, error) {
+	return c.inner.DeleteObjects(ctx, keys)
+}
+
+// Copy 原样复制压缩内容（由调用方把压缩编码复制到目标文件记录）
+func (c *CompressedStorage) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	return c.inner.Copy(ctx, srcKey, dstKey)
+}
+
+// newCompressWriter 创建压缩写入器（关闭时写入压缩流结尾，不关闭 w）
+func newCompressWriter(codec Codec, level int, w io.Writer) (io.WriteCloser, error) {
+	switch codec {
+	case CodecGzip:
+		return gzip.NewWriterLevel(w, level)
+	case CodecZstd:
+		// 单个对象顺序压缩，不需要并发编码
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+	default:
+		return nil, fmt.Errorf("unknown content encoding %q", codec)
+	}
+}
+
+// newDecompressReader 创建解压读取器（关闭时关闭底层对象流）
+func newDecompressReader(codec Codec, body io.ReadCloser) (io.ReadCloser, error) {
+	switch codec {
+	case CodecGzip:
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress object: %w", err)
+		}
+		return &readCloser{Reader: reader, Closer: body}, nil
+	case CodecZstd:
+		decoder, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress object: %w", err)
+		}
+		return &zstdReadCloser{Decoder: decoder, body: body}, nil
+	default:
+		return nil, fmt.Errorf("unknown content encoding %q", codec)
+	}
+}
+
+// readCloser 组合读取器与底层流的关闭
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// zstdReadCloser zstd 解压读取器（关闭时释放解码器并关闭底层流）
+type zstdReadCloser struct {
+	*zstd.Decoder
+	body io.ReadCloser
+}
+
+func (z *zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return z.body.Close()
+}
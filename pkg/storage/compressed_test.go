@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+)
+
+// memCodecs 内存压缩编码存储
+type memCodecs map[string]Codec
+
+func (m memCodecs) GetCodec(ctx context.Context, key string) (Codec, error) {
+	return m[key], nil
+}
+
+func (m memCodecs) PutCodec(ctx context.Context, key string, codec Codec) error {
+	m[key] = codec
+	return nil
+}
+
+func newTestCompressedStorage(t *testing.T) (*CompressedStorage, *memStorage, memCodecs) {
+	t.Helper()
+	policy, err := NewCompressionPolicy(config.CompressionConfig{
+		Enabled:      true,
+		MinSize:      16,
+		ContentTypes: []string{"text/*", "application/json"},
+	})
+	if err != nil {
+		t.Fatalf("NewCompressionPolicy() error = %v", err)
+	}
+	inner := &memStorage{objects: map[string][]byte{}}
+	codecs := memCodecs{}
+	return NewCompressedStorage(inner, codecs, policy), inner, codecs
+}
+
+func TestNewCompressionPolicy(t *testing.T) {
+	policy, err := NewCompressionPolicy(config.CompressionConfig{})
+	if err != nil || policy != nil {
+		t.Fatalf("disabled policy = %v, %v, want nil", policy, err)
+	}
+	if _, err := NewCompressionPolicy(config.CompressionConfig{Enabled: true, Codec: "br"}); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Fatalf("br error = %v, want not supported", err)
+	}
+	if _, err := NewCompressionPolicy(config.CompressionConfig{Enabled: true, Level: 12}); err == nil {
+		t.Fatalf("gzip level 12 should be rejected")
+	}
+	policy, err = NewCompressionPolicy(config.CompressionConfig{Enabled: true, Codec: "ZSTD", Level: 19})
+	if err != nil || policy.codec != CodecZstd || policy.level != 19 {
+		t.Fatalf("zstd policy = %+v, %v", policy, err)
+	}
+	if _, err := NewCompressionPolicy(config.CompressionConfig{Enabled: true, Codec: "zstd", Level: 23}); err == nil {
+		t.Fatalf("zstd level 23 should be rejected")
+	}
+}
+
+func TestCompressionPolicyCompressible(t *testing.T) {
+	s, _, _ := newTestCompressedStorage(t)
+	tests := []struct {
+		contentType string
+		size        int64
+		want        bool
+	}{
+		{"text/csv", 100, true},
+		{"Text/Plain; charset=utf-8", 100, true},
+		{"application/json", 100, true},
+		{"application/json", 10, false},
+		{"image/png", 100, false},
+		{"application/jsonp", 100, false},
+	}
+	for _, tt := range tests {
+		if got := s.policy.Compressible(tt.contentType, tt.size); got != tt.want {
+			t.Errorf("Compressible(%q, %d) = %v, want %v", tt.contentType, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestCompressedStorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s, inner, codecs := newTestCompressedStorage(t)
+	plain := []byte(strings.Repeat("id,name,price\n1,widget,9.99\n", 200))
+
+	if err := s.Upload(ctx, "a.csv", bytes.NewReader(plain), int64(len(plain)), "text/csv"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if codecs["a.csv"] != CodecGzip {
+		t.Fatalf("codec = %q, want gzip", codecs["a.csv"])
+	}
+	if len(inner.objects["a.csv"]) >= len(plain) {
+		t.Fatalf("stored %d bytes, want less than %d", len(inner.objects["a.csv"]), len(plain))
+	}
+
+	body, _, _, err := s.GetObject(ctx, "a.csv")
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("GetObject() returned %d bytes, want original %d", len(got), len(plain))
+	}
+
+	// 客户端接受 gzip 时原样返回压缩内容
+	body, _, size, err := s.GetObject(WithEncodedContent(ctx, CodecGzip), "a.csv")
+	if err != nil {
+		t.Fatalf("GetObject(encoded) error = %v", err)
+	}
+	raw, _ := io.ReadAll(body)
+	if !bytes.Equal(raw, inner.objects["a.csv"]) || size != int64(len(raw)) {
+		t.Fatalf("GetObject(encoded) returned %d bytes (size %d), want stored object", len(raw), size)
+	}
+
+	body, err = s.GetObjectRange(ctx, "a.csv", 14, 13)
+	if err != nil {
+		t.Fatalf("GetObjectRange() error = %v", err)
+	}
+	got, _ = io.ReadAll(body)
+	if string(got) != "1,widget,9.99" {
+		t.Fatalf("GetObjectRange() = %q", got)
+	}
+
+	if _, err := s.GeneratePresignedDownloadURL(ctx, "a.csv", time.Minute, nil); !errors.Is(err, ErrPresignNotSupported) {
+		t.Fatalf("GeneratePresignedDownloadURL() error = %v, want ErrPresignNotSupported", err)
+	}
+}
+
+func TestCompressedStorageStoresIncompressibleAsIs(t *testing.T) {
+	ctx := context.Background()
+	s, inner, codecs := newTestCompressedStorage(t)
+
+	// 随机内容压缩后不会变小
+	plain := make([]byte, 4096)
+	rand.Read(plain)
+	if err := s.Upload(ctx, "noise.txt", bytes.NewReader(plain), int64(len(plain)), "text/plain"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if codecs["noise.txt"] != CodecNone || !bytes.Equal(inner.objects["noise.txt"], plain) {
+		t.Fatalf("incompressible content was not stored as-is (codec %q)", codecs["noise.txt"])
+	}
+
+	if err := s.Upload(ctx, "image.png", strings.NewReader(strings.Repeat("a", 100)), 100, "image/png"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if codecs["image.png"] != CodecNone || len(inner.objects["image.png"]) != 100 {
+		t.Fatalf("non-compressible type was compressed")
+	}
+
+	if err := s.Upload(ctx, "short.txt", strings.NewReader(strings.Repeat("a", 50)), 100, "text/plain"); err == nil || !strings.Contains(err.Error(), "size mismatch") {
+		t.Fatalf("short upload error = %v, want size mismatch", err)
+	}
+}
+
+func TestCompressedStorageZstd(t *testing.T) {
+	ctx := context.Background()
+	policy, err := NewCompressionPolicy(config.CompressionConfig{Enabled: true, Codec: "zstd", ContentTypes: []string{"text/*"}})
+	if err != nil {
+		t.Fatalf("NewCompressionPolicy() error = %v", err)
+	}
+	inner := &memStorage{objects: map[string][]byte{}}
+	codecs := memCodecs{}
+	s := NewCompressedStorage(inner, codecs, policy)
+	plain := []byte(strings.Repeat("id,name,price\n1,widget,9.99\n", 200))
+
+	if err := s.Upload(ctx, "a.csv", bytes.NewReader(plain), int64(len(plain)), "text/csv"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if codecs["a.csv"] != CodecZstd || len(inner.objects["a.csv"]) >= len(plain) {
+		t.Fatalf("codec = %q, stored %d bytes; want zstd smaller than %d", codecs["a.csv"], len(inner.objects["a.csv"]), len(plain))
+	}
+
+	body, _, _, err := s.GetObject(ctx, "a.csv")
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("GetObject() returned %d bytes, want original %d", len(got), len(plain))
+	}
+
+	// 只有客户端接受 zstd 时才原样返回压缩内容
+	body, _, _, err = s.GetObject(WithEncodedContent(ctx, CodecGzip), "a.csv")
+	if err != nil {
+		t.Fatalf("GetObject(gzip) error = %v", err)
+	}
+	got, _ = io.ReadAll(body)
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("GetObject(gzip) returned %d bytes, want decompressed %d", len(got), len(plain))
+	}
+	body, _, size, err := s.GetObject(WithEncodedContent(ctx, CodecZstd), "a.csv")
+	if err != nil {
+		t.Fatalf("GetObject(zstd) error = %v", err)
+	}
+	raw, _ := io.ReadAll(body)
+	if !bytes.Equal(raw, inner.objects["a.csv"]) || size != int64(len(raw)) {
+		t.Fatalf("GetObject(zstd) returned %d bytes (size %d), want stored object", len(raw), size)
+	}
+
+	body, err = s.GetObjectRange(ctx, "a.csv", 14, 13)
+	if err != nil {
+		t.Fatalf("GetObjectRange() error = %v", err)
+	}
+	got, _ = io.ReadAll(body)
+	if string(got) != "1,widget,9.99" {
+		t.Fatalf("GetObjectRange() = %q", got)
+	}
+
+	// 压缩无收益时按原样保存
+	noise := make([]byte, 4096)
+	rand.Read(noise)
+	if err := s.Upload(ctx, "noise.txt", bytes.NewReader(noise), int64(len(noise)), "text/plain"); err != nil {
+		t.Fatalf("Upload(noise) error = %v", err)
+	}
+	if codecs["noise.txt"] != CodecNone || !bytes.Equal(inner.objects["noise.txt"], noise) {
+		t.Fatalf("incompressible content was not stored as-is (codec %q)", codecs["noise.txt"])
+	}
+}
@@ -0,0 +1,376 @@
+package storage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// errEncryptedPresign 客户端加密的对象不支持预签名 URL（存储服务只持有密文）
+var errEncryptedPresign = fmt.Errorf("%w for client-side encrypted objects", ErrPresignNotSupported)
+
+const (
+	// encryptedChunkSize 明文分块大小（每块独立加密，范围读取只需解密涉及的块）
+	encryptedChunkSize = 64 * 1024
+	// encryptedTagSize AES-GCM 认证标签长度
+	encryptedTagSize = 16
+	// encryptedBlockSize 密文分块大小
+	encryptedBlockSize = encryptedChunkSize + encryptedTagSize
+)
+
+// EncryptedStorage 客户端信封加密存储（装饰器）
+// 每个对象使用独立的 256 位数据密钥，以 AES-256-GCM 分块流式加密后写入底层存储；
+// 数据密钥经主密钥加密后保存在 files 表中。只加密匹配 prefixes 的存储键（为空时加密所有对象）；
+// 没有文件记录的对象（如 archives/ 下的临时打包文件）无处保存数据密钥，按明文保存，读取和预签名时同样按明文处理
+//
+// 密文格式：明文按 64KB 分块，最后一块恒短于 64KB（可为空）；
+// 第 i 块的 nonce 为块序号（大端），附加数据标记是否为最后一块，防止分块被截断、重排或替换
+type EncryptedStorage struct {
+	inner    Storage
+	wrapper  KeyWrapper
+	keys     DataKeyStore
+	prefixes []string
+}
+
+// NewEncryptedStorage 创建客户端加密存储
+func NewEncryptedStorage(inner Storage, wrapper KeyWrapper, keys DataKeyStore, prefixes []string) *EncryptedStorage {
+	return &EncryptedStorage{inner: inner, wrapper: wrapper, keys: keys, prefixes: prefixes}
+}
+
+// encrypted 判断存储键是否需要加密
+func (e *EncryptedStorage) encrypted(key string) bool {
+	if len(e.prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range e.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Upload 生成数据密钥，加密后上传并保存数据密钥
+func (e *EncryptedStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	if !e.encrypted(key) {
+		return e.inner.Upload(ctx, key, reader, size, contentType)
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err := e.wrapper.WrapKey(ctx, dataKey)
+	if err != nil {
+		return fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	aead, err := newChunkAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	// 先保存数据密钥，保存失败时不写入无法解密的对象
+	if err := e.keys.PutDataKey(ctx, key, wrapped); err != nil {
+		if errors.Is(err, ErrObjectNotTracked) {
+			return e.inner.Upload(ctx, key, reader, size, contentType)
+		}
+		return fmt.Errorf("failed to store data key: %w", err)
+	}
+
+	encrypter := &encryptReader{src: reader, aead: aead, plain: make([]byte, encryptedChunkSize)}
+	return e.inner.Upload(ctx, key, encrypter, encryptedSize(size), contentType)
+}
+
+// GeneratePresignedUploadURL 加密对象不支持预签名上传（须经后端直接上传）
+func (e *EncryptedStorage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string, checksum *Checksum) (*PresignedRequest, error) {
+	if e.encrypted(key) {
+		return nil, errEncryptedPresign
+	}
+	return e.inner.GeneratePresignedUploadURL(ctx, key, expiry, contentType, checksum)
+}
+
+// GeneratePresignedPost 加密对象不支持表单直传
+func (e *EncryptedStorage) GeneratePresignedPost(ctx context.Context, key string, expiry time.Duration, policy PostPolicy) (*PresignedPost, error) {
+	if e.encrypted(key) {
+		return nil, errEncryptedPresign
+	}
+	return e.inner.GeneratePresignedPost(ctx, key, expiry, policy)
+}
+
+// InitMultipartUpload 加密对象不支持分片直传（分片由客户端直接上传，无法加密）
+func (e *EncryptedStorage) InitMultipartUpload(ctx context.Context, key string, contentType string, algorithm ChecksumAlgorithm) (*MultipartUpload, error) {
+	if e.encrypted(key) {
+		return nil, errEncryptedPresign
+	}
+	return e.inner.InitMultipartUpload(ctx, key, contentType, algorithm)
+}
+
+// GeneratePresignedPartURL 加密对象不支持分片直传
+func (e *EncryptedStorage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration, checksum *Checksum) (*PresignedRequest, error) {
+	if e.encrypted(key) {
+		return nil, errEncryptedPresign
+	}
+	return e.inner.GeneratePresignedPartURL(ctx, key, uploadID, partNumber, expiry, checksum)
+}
+
+// CompleteMultipartUpload 完成分片上传
+func (e *EncryptedStorage) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error {
+	return e.inner.CompleteMultipartUpload(ctx, key, uploadID, parts)
+}
+
+// AbortMultipartUpload 取消分片上传
+func (e *EncryptedStorage) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	return e.inner.AbortMultipartUpload(ctx, key, uploadID)
+}
+
+// GetObject 读取并流式解密对象（返回明文大小）
+func (e *EncryptedStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	if !e.encrypted(key) {
+		return e.inner.GetObject(ctx, key)
+	}
+
+	aead, err := e.dataKey(ctx, key)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if aead == nil {
+		return e.inner.GetObject(ctx, key)
+	}
+
+	body, contentType, size, err := e.inner.GetObject(ctx, key)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	decrypter := &decryptReader{src: body, aead: aead, block: make([]byte, encryptedBlockSize), remaining: -1}
+	return &decryptReadCloser{decryptReader: decrypter, closer: body}, contentType, plaintextSize(size), nil
+}
+
+// GetObjectRange 只读取并解密范围涉及的分块
+func (e *EncryptedStorage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if !e.encrypted(key) {
+		return e.inner.GetObjectRange(ctx, key, offset, length)
+	}
+
+	aead, err := e.dataKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if aead == nil {
+		return e.inner.GetObjectRange(ctx, key, offset, length)
+	}
+
+	first := offset / encryptedChunkSize
+	last := (offset + length - 1) / encryptedChunkSize
+	body, err := e.inner.GetObjectRange(ctx, key, first*encryptedBlockSize, (last-first+1)*encryptedBlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypter := &decryptReader{
+		src:       body,
+		aead:      aead,
+		block:     make([]byte, encryptedBlockSize),
+		index:     uint64(first),
+		skip:      offset - first*encryptedChunkSize,
+		remaining: length,
+	}
+	return &decryptReadCloser{decryptReader: decrypter, closer: body}, nil
+}
+
+// GeneratePresignedDownloadURL 加密对象不支持预签名下载（调用方应改用后端代理下载），按明文保存的对象直接预签名
+func (e *EncryptedStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error) {
+	if e.encrypted(key) {
+		wrapped, err := e.keys.GetDataKey(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load data key: %w", err)
+		}
+		if wrapped != nil {
+			return nil, errEncryptedPresign
+		}
+	}
+	return e.inner.GeneratePresignedDownloadURL(ctx, key, expiry, opts)
+}
+
+// Delete 删除对象（数据密钥随文件记录删除）
+func (e *EncryptedStorage) Delete(ctx context.Context, key string) error {
+	return e.inner.Delete(ctx, key)
+}
+
+// DeleteObjects 批量删除对象
+func (e *EncryptedStorage) DeleteObjects(ctx context.Context, keys []string) (map[string]error, error) {
+	return e.inner.DeleteObjects(ctx, keys)
+}
+
+// Copy 原样复制密文（目标对象沿用源对象的数据密钥，由调用方把数据密钥复制到目标文件记录）
+func (e *EncryptedStorage) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	if e.encrypted(srcKey) != e.encrypted(dstKey) {
+		return fmt.Errorf("cannot copy between encrypted and unencrypted keys (%s -> %s)", srcKey, dstKey)
+	}
+	return e.inner.Copy(ctx, srcKey, dstKey)
+}
+
+// dataKey 读取并解密对象的数据密钥（对象按明文保存时返回 nil）
+func (e *EncryptedStorage) dataKey(ctx context.Context, key string) (cipher.AEAD, error) {
+	wrapped, err := e.keys.GetDataKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data key: %w", err)
+	}
+	if wrapped == nil {
+		return nil, nil
+	}
+
+	dataKey, err := e.wrapper.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return newChunkAEAD(dataKey)
+}
+
+// newChunkAEAD 创建数据密钥的 AES-256-GCM
+func newChunkAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptedSize 明文大小对应的密文大小（最后一块恒短于分块大小，因此块数为 size/分块大小 + 1）
+func encryptedSize(size int64) int64 {
+	return size + (size/encryptedChunkSize+1)*encryptedTagSize
+}
+
+// plaintextSize 密文大小对应的明文大小
+func plaintextSize(size int64) int64 {
+	return size - (size/encryptedBlockSize+1)*encryptedTagSize
+}
+
+// chunkNonce 第 index 块的 nonce（数据密钥每个对象唯一，块序号不会重复）
+func chunkNonce(index uint64) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], index)
+	return nonce
+}
+
+// chunkAAD 分块附加数据（标记最后一块）
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// encryptReader 分块加密读取器
+type encryptReader struct {
+	src   io.Reader
+	aead  cipher.AEAD
+	index uint64
+	plain []byte // 明文分块缓冲
+	out   []byte // 待输出的密文
+	done  bool
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		// 读满一块为中间块，读到末尾（短块或空块）为最后一块
+		n, err := io.ReadFull(r.src, r.plain)
+		final := false
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			final = true
+		default:
+			return 0, err
+		}
+
+		r.out = r.aead.Seal(r.out[:0], chunkNonce(r.index), r.plain[:n], chunkAAD(final))
+		r.index++
+		r.done = final
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// decryptReader 分块解密读取器
+// remaining < 0 时读取整个对象并要求以最后一块结束（检测截断）；否则只输出 remaining 字节（范围读取）
+type decryptReader struct {
+	src       io.Reader
+	aead      cipher.AEAD
+	index     uint64
+	block     []byte // 密文分块缓冲
+	out       []byte // 待输出的明文
+	skip      int64  // 首块需要跳过的明文字节数
+	remaining int64
+	done      bool
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done || r.remaining == 0 {
+			return 0, io.EOF
+		}
+
+		// 读满一块为中间块，短块为最后一块
+		n, err := io.ReadFull(r.src, r.block)
+		final := false
+		switch err {
+		case nil:
+		case io.ErrUnexpectedEOF:
+			final = true
+		case io.EOF:
+			if r.remaining < 0 {
+				return 0, fmt.Errorf("encrypted object is truncated")
+			}
+			return 0, io.EOF
+		default:
+			return 0, err
+		}
+
+		plain, err := r.aead.Open(r.block[:0], chunkNonce(r.index), r.block[:n], chunkAAD(final))
+		if err != nil {
+			return 0, fmt.Errorf("failed to decrypt chunk %d: %w", r.index, err)
+		}
+		r.index++
+		r.done = final
+
+		if r.skip > 0 {
+			skip := min(r.skip, int64(len(plain)))
+			plain = plain[skip:]
+			r.skip -= skip
+		}
+		if r.remaining >= 0 && int64(len(plain)) > r.remaining {
+			plain = plain[:r.remaining]
+		}
+		r.out = plain
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	if r.remaining > 0 {
+		r.remaining -= int64(n)
+	}
+	return n, nil
+}
+
+// decryptReadCloser 关闭时关闭底层密文流
+type decryptReadCloser struct {
+	*decryptReader
+	closer io.Closer
+}
+
+func (r *decryptReadCloser) Close() error {
+	return r.closer.Close()
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memStorage 内存存储（只实现加密存储测试用到的方法）
+type memStorage struct {
+	Storage
+	objects map[string][]byte
+}
+
+func (m *memStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return errors.New("size mismatch")
+	}
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	data := m.objects[key]
+	return io.NopCloser(bytes.NewReader(data)), "application/octet-stream", int64(len(data)), nil
+}
+
+func (m *memStorage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	data := m.objects[key]
+	if offset >= int64(len(data)) {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	end := min(offset+length, int64(len(data)))
+	return io.NopCloser(bytes.NewReader(data[offset:end])), nil
+}
+
+func (m *memStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error) {
+	return &PresignedRequest{URL: "https://example.com/" + key}, nil
+}
+
+// memDataKeys 内存数据密钥存储
+type memDataKeys map[string]*WrappedKey
+
+func (m memDataKeys) GetDataKey(ctx context.Context, key string) (*WrappedKey, error) {
+	return m[key], nil
+}
+
+func (m memDataKeys) PutDataKey(ctx context.Context, key string, wrapped *WrappedKey) error {
+	m[key] = wrapped
+	return nil
+}
+
+func newTestEncryptedStorage(t *testing.T, prefixes ...string) (*EncryptedStorage, *memStorage, memDataKeys) {
+	t.Helper()
+	keyring, err := NewKeyring("k1", map[string][]byte{"k1": []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	inner := &memStorage{objects: map[string][]byte{}}
+	keys := memDataKeys{}
+	return NewEncryptedStorage(inner, keyring, keys, prefixes), inner, keys
+}
+
+func testPlaintext(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+func TestEncryptedStorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for _, size := range []int{0, 1, encryptedChunkSize - 1, encryptedChunkSize, encryptedChunkSize*2 + 100} {
+		s, inner, keys := newTestEncryptedStorage(t)
+		plain := testPlaintext(size)
+
+		if err := s.Upload(ctx, "a/file", bytes.NewReader(plain), int64(size), "text/plain"); err != nil {
+			t.Fatalf("size %d: Upload() error = %v", size, err)
+		}
+		if keys["a/file"] == nil || keys["a/file"].KeyID != "k1" {
+			t.Fatalf("size %d: data key not stored: %+v", size, keys["a/file"])
+		}
+		// 极短的明文可能偶然出现在密文中，只检查足够长的内容
+		if size >= 16 && bytes.Contains(inner.objects["a/file"], plain) {
+			t.Fatalf("size %d: stored object contains plaintext", size)
+		}
+
+		body, _, length, err := s.GetObject(ctx, "a/file")
+		if err != nil {
+			t.Fatalf("size %d: GetObject() error = %v", size, err)
+		}
+		got, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("size %d: read error = %v", size, err)
+		}
+		if length != int64(size) || !bytes.Equal(got, plain) {
+			t.Fatalf("size %d: GetObject() = %d bytes (length %d), want original", size, len(got), length)
+		}
+	}
+}
+
+func TestEncryptedStorageRange(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTestEncryptedStorage(t)
+	plain := testPlaintext(encryptedChunkSize*3 + 10)
+	if err := s.Upload(ctx, "a/file", bytes.NewReader(plain), int64(len(plain)), ""); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	tests := []struct {
+		offset, length int64
+	}{
+		{0, 512},
+		{encryptedChunkSize - 10, 20},
+		{encryptedChunkSize * 2, encryptedChunkSize},
+		{int64(len(plain)) - 5, 100},
+		{int64(len(plain)) + 10, 100},
+	}
+	for _, tt := range tests {
+		body, err := s.GetObjectRange(ctx, "a/file", tt.offset, tt.length)
+		if err != nil {
+			t.Fatalf("GetObjectRange(%d, %d) error = %v", tt.offset, tt.length, err)
+		}
+		got, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("GetObjectRange(%d, %d) read error = %v", tt.offset, tt.length, err)
+		}
+		start := min(tt.offset, int64(len(plain)))
+		want := plain[start:min(tt.offset+tt.length, int64(len(plain)))]
+		if !bytes.Equal(got, want) {
+			t.Fatalf("GetObjectRange(%d, %d) = %d bytes, want %d", tt.offset, tt.length, len(got), len(want))
+		}
+	}
+}
+
+func TestEncryptedStorageTampering(t *testing.T) {
+	ctx := context.Background()
+	s, inner, _ := newTestEncryptedStorage(t)
+	plain := testPlaintext(encryptedChunkSize * 2)
+	if err := s.Upload(ctx, "a/file", bytes.NewReader(plain), int64(len(plain)), ""); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	stored := inner.objects["a/file"]
+
+	// 篡改密文
+	tampered := bytes.Clone(stored)
+	tampered[10] ^= 1
+	inner.objects["a/file"] = tampered
+	if _, err := readAll(s, "a/file"); err == nil || !strings.Contains(err.Error(), "failed to decrypt") {
+		t.Fatalf("tampered object error = %v, want decrypt failure", err)
+	}
+
+	// 截掉最后一块（剩余块均为完整的中间块）
+	inner.objects["a/file"] = stored[:encryptedBlockSize*2]
+	if _, err := readAll(s, "a/file"); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Fatalf("truncated object error = %v, want truncated", err)
+	}
+}
+
+func readAll(s Storage, key string) ([]byte, error) {
+	body, _, _, err := s.GetObject(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func TestEncryptedStoragePrefixes(t *testing.T) {
+	ctx := context.Background()
+	s, inner, keys := newTestEncryptedStorage(t, "private/")
+
+	if err := s.Upload(ctx, "public/file", strings.NewReader("hello"), 5, ""); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if string(inner.objects["public/file"]) != "hello" || keys["public/file"] != nil {
+		t.Fatalf("unencrypted prefix was encrypted")
+	}
+	if _, err := s.GeneratePresignedDownloadURL(ctx, "public/file", time.Minute, nil); err != nil {
+		t.Fatalf("GeneratePresignedDownloadURL() error = %v", err)
+	}
+
+	if err := s.Upload(ctx, "private/file", strings.NewReader("hello"), 5, ""); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if _, err := s.GeneratePresignedDownloadURL(ctx, "private/file", time.Minute, nil); !errors.Is(err, ErrPresignNotSupported) {
+		t.Fatalf("GeneratePresignedDownloadURL() error = %v, want ErrPresignNotSupported", err)
+	}
+	if err := s.Copy(ctx, "private/file", "public/file"); err == nil {
+		t.Fatalf("Copy() across prefixes should fail")
+	}
+}
+
+// untrackedDataKeys 没有文件记录的数据密钥存储（如 archives/ 下的临时打包文件）
+type untrackedDataKeys struct {
+	memDataKeys
+}
+
+func (untrackedDataKeys) PutDataKey(ctx context.Context, key string, wrapped *WrappedKey) error {
+	return ErrObjectNotTracked
+}
+
+// TestEncryptedStorageUntracked 测试没有文件记录的对象按明文保存、读取和预签名
+func TestEncryptedStorageUntracked(t *testing.T) {
+	ctx := context.Background()
+	s, inner, keys := newTestEncryptedStorage(t)
+	s.keys = untrackedDataKeys{keys}
+
+	if err := s.Upload(ctx, "archives/a.zip", strings.NewReader("hello"), 5, "application/zip"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if string(inner.objects["archives/a.zip"]) != "hello" {
+		t.Fatalf("untracked object = %q, want plaintext", inner.objects["archives/a.zip"])
+	}
+
+	reader, _, size, err := s.GetObject(ctx, "archives/a.zip")
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "hello" || size != 5 {
+		t.Errorf("GetObject() = %q (%d bytes), want hello", data, size)
+	}
+
+	reader, err = s.GetObjectRange(ctx, "archives/a.zip", 1, 3)
+	if err != nil {
+		t.Fatalf("GetObjectRange() error = %v", err)
+	}
+	data, _ = io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "ell" {
+		t.Errorf("GetObjectRange() = %q, want ell", data)
+	}
+
+	if _, err := s.GeneratePresignedDownloadURL(ctx, "archives/a.zip", time.Minute, nil); err != nil {
+		t.Errorf("GeneratePresignedDownloadURL() error = %v", err)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	ctx := context.Background()
+	old, err := NewKeyring("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	wrapped, err := old.WrapKey(ctx, []byte("data key"))
+	if err != nil {
+		t.Fatalf("WrapKey() error = %v", err)
+	}
+
+	rotated, err := NewKeyring("k2", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32), "k2": bytes.Repeat([]byte{2}, 32)})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	got, err := rotated.UnwrapKey(ctx, wrapped)
+	if err != nil || string(got) != "data key" {
+		t.Fatalf("UnwrapKey() = %q, %v", got, err)
+	}
+
+	// 密文换到其他主密钥 ID 下无法解密
+	if _, err := rotated.UnwrapKey(ctx, &WrappedKey{KeyID: "k2", Ciphertext: wrapped.Ciphertext}); err == nil {
+		t.Fatalf("UnwrapKey() with wrong key ID should fail")
+	}
+	if _, err := NewKeyring("k3", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}); err == nil {
+		t.Fatalf("NewKeyring() with missing active key should fail")
+	}
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/NanoBoom/asethub/internal/config"
+)
+
+// EncryptionMode 服务端加密模式
+type EncryptionMode string
+
+const (
+	EncryptionNone   EncryptionMode = "none"    // 不指定（使用存储桶默认加密）
+	EncryptionSSES3  EncryptionMode = "sse-s3"  // 存储服务托管密钥（S3 AES256 / OSS AES256）
+	EncryptionSSEKMS EncryptionMode = "sse-kms" // KMS 托管密钥（S3 aws:kms / OSS KMS）
+	EncryptionSSEC   EncryptionMode = "sse-c"   // 客户提供密钥（仅 S3，每个请求携带密钥）
+)
+
+// Encryption 对象的服务端加密设置
+type Encryption struct {
+	Mode      EncryptionMode // 加密模式
+	KMSKeyID  string         // KMS 密钥 ID（sse-kms，为空时使用默认 KMS 密钥）
+	BucketKey bool           // 启用 S3 Bucket Key（sse-kms，降低 KMS 调用费用）
+}
+
+// EncryptionPolicy 服务端加密策略：部署级默认设置 + 按租户覆盖
+// 租户设置匹配以 "<tenant>/" 开头的存储键（存储键模板以 {tenant} 开头时生效）
+type EncryptionPolicy struct {
+	defaults Encryption
+	tenants  map[string]Encryption
+}
+
+// NewEncryptionPolicy 根据配置创建加密策略
+func NewEncryptionPolicy(cfg config.EncryptionConfig) (*EncryptionPolicy, error) {
+	defaults, err := parseEncryption(cfg.EncryptionRule)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &EncryptionPolicy{defaults: defaults, tenants: make(map[string]Encryption, len(cfg.Tenants))}
+	for tenant, rule := range cfg.Tenants {
+		if tenant == "" || strings.Contains(tenant, "/") {
+			return nil, fmt.Errorf("invalid encryption tenant %q: must be a single key segment", tenant)
+		}
+		enc, err := parseEncryption(rule)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+		policy.tenants[tenant] = enc
+	}
+	return policy, nil
+}
+
+// parseEncryption 校验单条加密设置
+func parseEncryption(rule config.EncryptionRule) (Encryption, error) {
+	mode := EncryptionMode(strings.ToLower(rule.Mode))
+	switch mode {
+	case "", EncryptionNone:
+		mode = EncryptionNone
+	case EncryptionSSES3, EncryptionSSEKMS, EncryptionSSEC:
+	default:
+		return Encryption{}, fmt.Errorf("invalid encryption mode %q (supported: none, sse-s3, sse-kms, sse-c)", rule.Mode)
+	}
+
+	if mode != EncryptionSSEKMS && (rule.KMSKeyID != "" || rule.BucketKey) {
+		return Encryption{}, fmt.Errorf("invalid encryption: kms_key_id and bucket_key require mode sse-kms")
+	}
+	return Encryption{Mode: mode, KMSKeyID: rule.KMSKeyID, BucketKey: rule.BucketKey}, nil
+}
+
+// For 返回存储键适用的加密设置（nil 策略表示不加密）
+func (p *EncryptionPolicy) For(key string) Encryption {
+	if p == nil {
+		return Encryption{Mode: EncryptionNone}
+	}
+	if tenant, _, ok := strings.Cut(key, "/"); ok {
+		if enc, ok := p.tenants[tenant]; ok {
+			return enc
+		}
+	}
+	return p.defaults
+}
+
+// PartETagIsMD5 判断分片 ETag 是否为分片内容的 MD5（SSE-KMS 与 SSE-C 加密时不是，S3 与 OSS 相同）
+func (e Encryption) PartETagIsMD5() bool {
+	return e.Mode != EncryptionSSEKMS && e.Mode != EncryptionSSEC
+}
+
+// Uses 判断策略中是否有设置使用了指定模式
+func (p *EncryptionPolicy) Uses(mode EncryptionMode) bool {
+	if p == nil {
+		return false
+	}
+	if p.defaults.Mode == mode {
+		return true
+	}
+	for _, enc := range p.tenants {
+		if enc.Mode == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// objectEncryption 单次请求使用的加密参数
+type objectEncryption struct {
+	Encryption
+	CustomerKey *CustomerKey // SSE-C 客户密钥（仅 sse-c）
+}
+
+// resolve 解析请求使用的加密参数
+// sse-c 对象要求上下文中携带客户密钥；其他对象不接受客户密钥（避免客户端误以为对象已按其密钥加密）
+func (p *EncryptionPolicy) resolve(ctx context.Context, key string) (objectEncryption, error) {
+	enc := objectEncryption{Encryption: p.For(key)}
+	customerKey := CustomerKeyFromContext(ctx)
+
+	if enc.Mode == EncryptionSSEC {
+		if customerKey == nil {
+			return enc, fmt.Errorf("encryption key required: %s is encrypted with sse-c", key)
+		}
+		enc.CustomerKey = customerKey
+		return enc, nil
+	}
+	if customerKey != nil {
+		return enc, fmt.Errorf("invalid encryption key: sse-c is not enabled for %s", key)
+	}
+	return enc, nil
+}
+
+// CustomerKey SSE-C 客户提供的 256 位密钥（只在请求上下文中传递，不落库）
+type CustomerKey struct {
+	key []byte
+}
+
+// ParseCustomerKey 解析 Base64 编码的 256 位客户密钥
+func ParseCustomerKey(value string) (*CustomerKey, error) {
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid encryption key: must be a base64-encoded 256-bit key")
+	}
+	return &CustomerKey{key: key}, nil
+}
+
+// Base64 返回 Base64 编码的密钥
+func (k *CustomerKey) Base64() string {
+	return base64.StdEncoding.EncodeToString(k.key)
+}
+
+// MD5 返回 Base64 编码的密钥 MD5（存储服务据此校验密钥传输完整性）
+func (k *CustomerKey) MD5() string {
+	sum := md5.Sum(k.key)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// customerKeyContextKey 上下文中客户密钥的键
+type customerKeyContextKey struct{}
+
+// WithCustomerKey 将 SSE-C 客户密钥放入上下文（存储实现从上下文读取）
+func WithCustomerKey(ctx context.Context, key *CustomerKey) context.Context {
+	return context.WithValue(ctx, customerKeyContextKey{}, key)
+}
+
+// CustomerKeyFromContext 从上下文读取 SSE-C 客户密钥（未提供时返回 nil）
+func CustomerKeyFromContext(ctx context.Context) *CustomerKey {
+	key, _ := ctx.Value(customerKeyContextKey{}).(*CustomerKey)
+	return key
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// testCustomerKey 测试用的 256 位客户密钥
+var testCustomerKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+func TestNewEncryptionPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.EncryptionConfig
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "kms", cfg: config.EncryptionConfig{EncryptionRule: config.EncryptionRule{Mode: "SSE-KMS", KMSKeyID: "alias/assets", BucketKey: true}}},
+		{name: "unknown mode", cfg: config.EncryptionConfig{EncryptionRule: config.EncryptionRule{Mode: "aes"}}, wantErr: "invalid encryption mode"},
+		{name: "key id without kms", cfg: config.EncryptionConfig{EncryptionRule: config.EncryptionRule{Mode: "sse-s3", KMSKeyID: "alias/assets"}}, wantErr: "require mode sse-kms"},
+		{name: "nested tenant", cfg: config.EncryptionConfig{Tenants: map[string]config.EncryptionRule{"a/b": {Mode: "sse-c"}}}, wantErr: "invalid encryption tenant"},
+		{name: "bad tenant rule", cfg: config.EncryptionConfig{Tenants: map[string]config.EncryptionRule{"acme": {Mode: "rot13"}}}, wantErr: "tenant acme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEncryptionPolicy(tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewEncryptionPolicy() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewEncryptionPolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncryptionPolicyResolve(t *testing.T) {
+	policy, err := NewEncryptionPolicy(config.EncryptionConfig{
+		EncryptionRule: config.EncryptionRule{Mode: "sse-kms", KMSKeyID: "alias/assets"},
+		Tenants:        map[string]config.EncryptionRule{"acme": {Mode: "sse-c"}, "public": {Mode: "none"}},
+	})
+	if err != nil {
+		t.Fatalf("NewEncryptionPolicy() error = %v", err)
+	}
+	key, err := ParseCustomerKey(testCustomerKey)
+	if err != nil {
+		t.Fatalf("ParseCustomerKey() error = %v", err)
+	}
+	withKey := WithCustomerKey(context.Background(), key)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		key      string
+		wantMode EncryptionMode
+		wantErr  string
+	}{
+		{name: "default", ctx: context.Background(), key: "files/1/a.png", wantMode: EncryptionSSEKMS},
+		{name: "tenant override", ctx: context.Background(), key: "public/a.png", wantMode: EncryptionNone},
+		{name: "tenant prefix only", ctx: context.Background(), key: "acme-files/a.png", wantMode: EncryptionSSEKMS},
+		{name: "sse-c with key", ctx: withKey, key: "acme/a.png", wantMode: EncryptionSSEC},
+		{name: "sse-c without key", ctx: context.Background(), key: "acme/a.png", wantErr: "encryption key required"},
+		{name: "key for non sse-c", ctx: withKey, key: "files/1/a.png", wantErr: "sse-c is not enabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := policy.resolve(tt.ctx, tt.key)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve() error = %v", err)
+			}
+			if enc.Mode != tt.wantMode {
+				t.Errorf("resolve() mode = %s, want %s", enc.Mode, tt.wantMode)
+			}
+		})
+	}
+
+	if _, err := ParseCustomerKey("c2hvcnQ="); err == nil {
+		t.Error("ParseCustomerKey(short key) error = nil")
+	}
+}
+
+// TestS3PresignEncryptionHeaders 测试加密头签入预签名 URL 并返回给客户端
+func TestS3PresignEncryptionHeaders(t *testing.T) {
+	policy, err := NewEncryptionPolicy(config.EncryptionConfig{
+		EncryptionRule: config.EncryptionRule{Mode: "sse-kms", KMSKeyID: "alias/assets"},
+		Tenants:        map[string]config.EncryptionRule{"acme": {Mode: "sse-c"}},
+	})
+	if err != nil {
+		t.Fatalf("NewEncryptionPolicy() error = %v", err)
+	}
+	s, err := NewS3Storage(context.Background(), S3Config{
+		Region:          "us-east-1",
+		Bucket:          "assets",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		Endpoint:        "http://localhost:9000",
+		UsePathStyle:    true,
+		Encryption:      policy,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage() error = %v", err)
+	}
+
+	// SSE-KMS：上传须携带加密头，下载无需加密头
+	upload, err := s.GeneratePresignedUploadURL(context.Background(), "files/a.png", time.Hour, "image/png", nil)
+	if err != nil {
+		t.Fatalf("GeneratePresignedUploadURL() error = %v", err)
+	}
+	if upload.Headers["X-Amz-Server-Side-Encryption"] != "aws:kms" || upload.Headers["X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"] != "alias/assets" {
+		t.Errorf("upload headers = %v, want sse-kms headers", upload.Headers)
+	}
+	if !strings.Contains(upload.URL, "x-amz-server-side-encryption") {
+		t.Errorf("URL %q does not sign the encryption headers", upload.URL)
+	}
+	download, err := s.GeneratePresignedDownloadURL(context.Background(), "files/a.png", time.Hour, nil)
+	if err != nil {
+		t.Fatalf("GeneratePresignedDownloadURL() error = %v", err)
+	}
+	if len(download.Headers) != 0 {
+		t.Errorf("download headers = %v, want none", download.Headers)
+	}
+
+	// SSE-C：返回算法和密钥 MD5，不回显密钥
+	key, _ := ParseCustomerKey(testCustomerKey)
+	ctx := WithCustomerKey(context.Background(), key)
+	download, err = s.GeneratePresignedDownloadURL(ctx, "acme/a.png", time.Hour, nil)
+	if err != nil {
+		t.Fatalf("GeneratePresignedDownloadURL(sse-c) error = %v", err)
+	}
+	if download.Headers["X-Amz-Server-Side-Encryption-Customer-Algorithm"] != "AES256" || download.Headers["X-Amz-Server-Side-Encryption-Customer-Key-Md5"] != key.MD5() {
+		t.Errorf("sse-c headers = %v, want algorithm and key MD5", download.Headers)
+	}
+	for name, value := range download.Headers {
+		if value == key.Base64() {
+			t.Errorf("header %s echoes the customer key", name)
+		}
+	}
+	if _, err := s.GeneratePresignedDownloadURL(context.Background(), "acme/a.png", time.Hour, nil); err == nil || !strings.Contains(err.Error(), "encryption key required") {
+		t.Errorf("GeneratePresignedDownloadURL(no key) error = %v, want encryption key required", err)
+	}
+
+	// POST 表单：加密字段写入表单和策略条件
+	post, err := s.GeneratePresignedPost(context.Background(), "files/b.png", time.Hour, PostPolicy{MinSize: 1, MaxSize: 10})
+	if err != nil {
+		t.Fatalf("GeneratePresignedPost() error = %v", err)
+	}
+	if post.Fields["x-amz-server-side-encryption"] != "aws:kms" {
+		t.Errorf("Fields = %v, want x-amz-server-side-encryption", post.Fields)
+	}
+	if document := decodePostPolicy(t, post.Fields["policy"]); !strings.Contains(document, `{"x-amz-server-side-encryption":"aws:kms"}`) {
+		t.Errorf("policy %s missing encryption condition", document)
+	}
+}
+
+func TestOSSEncryption(t *testing.T) {
+	policy, _ := NewEncryptionPolicy(config.EncryptionConfig{Tenants: map[string]config.EncryptionRule{"acme": {Mode: "sse-c"}}})
+	if _, err := NewOSSStorage(context.Background(), OSSConfig{Endpoint: "oss-cn-hangzhou.aliyuncs.com", Bucket: "assets", Encryption: policy}); err == nil || !strings.Contains(err.Error(), "not supported by OSS") {
+		t.Errorf("NewOSSStorage(sse-c) error = %v, want not supported", err)
+	}
+
+	policy, _ = NewEncryptionPolicy(config.EncryptionConfig{EncryptionRule: config.EncryptionRule{Mode: "sse-kms", KMSKeyID: "key-1"}})
+	o, err := NewOSSStorage(context.Background(), OSSConfig{Endpoint: "oss-cn-hangzhou.aliyuncs.com", Bucket: "assets", AccessKeyID: "id", AccessKeySecret: "secret", Encryption: policy})
+	if err != nil {
+		t.Fatalf("NewOSSStorage() error = %v", err)
+	}
+	upload, err := o.GeneratePresignedUploadURL(context.Background(), "files/a.png", time.Hour, "image/png", nil)
+	if err != nil {
+		t.Fatalf("GeneratePresignedUploadURL() error = %v", err)
+	}
+	headers := make(map[string]string)
+	for name, value := range upload.Headers {
+		headers[strings.ToLower(name)] = value
+	}
+	if headers["x-oss-server-side-encryption"] != "KMS" || headers["x-oss-server-side-encryption-key-id"] != "key-1" {
+		t.Errorf("upload headers = %v, want OSS KMS headers", upload.Headers)
+	}
+
+	post, err := o.GeneratePresignedPost(context.Background(), "files/b.png", time.Hour, PostPolicy{MinSize: 1, MaxSize: 10})
+	if err != nil {
+		t.Fatalf("GeneratePresignedPost() error = %v", err)
+	}
+	if document := decodePostPolicy(t, post.Fields["policy"]); !strings.Contains(document, `["eq","$x-oss-server-side-encryption","KMS"]`) {
+		t.Errorf("policy %s missing encryption condition", document)
+	}
+}
+
+func TestS3MultipartCopyInput(t *testing.T) {
+	head := &s3.HeadObjectOutput{
+		ContentType:          aws.String("video/mp4"),
+		CacheControl:         aws.String("max-age=3600"),
+		ContentDisposition:   aws.String(`attachment; filename="a.mp4"`),
+		ContentEncoding:      aws.String("gzip"),
+		Metadata:             map[string]string{"owner": "ops"},
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+		SSEKMSKeyId:          aws.String("arn:aws:kms:us-east-1:111122223333:key/source"),
+		BucketKeyEnabled:     aws.Bool(true),
+	}
+
+	// 目标键未配置加密：沿用源对象的 SSE-KMS 设置和元数据
+	input := multipartCopyInput("assets", "files/b.mp4", head, types.StorageClassStandardIa, s3SSE{}.withSource(head))
+	if aws.ToString(input.ContentType) != "video/mp4" || aws.ToString(input.CacheControl) != "max-age=3600" ||
+		aws.ToString(input.ContentDisposition) != `attachment; filename="a.mp4"` || aws.ToString(input.ContentEncoding) != "gzip" ||
+		input.Metadata["owner"] != "ops" || input.StorageClass != types.StorageClassStandardIa {
+		t.Errorf("metadata not copied: %+v", input)
+	}
+	if input.ServerSideEncryption != types.ServerSideEncryptionAwsKms || aws.ToString(input.SSEKMSKeyId) != aws.ToString(head.SSEKMSKeyId) || !aws.ToBool(input.BucketKeyEnabled) {
+		t.Errorf("source encryption not kept: %s %v", input.ServerSideEncryption, aws.ToString(input.SSEKMSKeyId))
+	}
+
+	// 目标键配置了加密：使用目标键的设置
+	dst := s3SSE{serverSideEncryption: types.ServerSideEncryptionAes256}
+	if got := dst.withSource(head); got.serverSideEncryption != types.ServerSideEncryptionAes256 || got.kmsKeyID != nil {
+		t.Errorf("destination encryption overridden: %+v", got)
+	}
+}
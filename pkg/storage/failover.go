@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+)
+
+// ReplicaStore 查询对象在副本后端上是否有可用的副本
+type ReplicaStore interface {
+	// HasReplica 判断对象在后端上是否有已复制（或已校验）的副本
+	HasReplica(ctx context.Context, key string, backend string) (bool, error)
+}
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"    // 正常：读取主后端
+	BreakerOpen     = "open"      // 熔断：直接读取副本
+	BreakerHalfOpen = "half-open" // 熔断到期：放行一个探测请求，成功后恢复
+)
+
+// BackendHealth 主后端的健康状态和故障转移计数
+type BackendHealth struct {
+	Backend             string     `json:"backend"`                   // 主后端
+	Replica             string     `json:"replica"`                   // 副本后端
+	State               string     `json:"state"`                     // 熔断器状态：closed / open / half-open
+	ConsecutiveFailures int        `json:"consecutive_failures"`      // 连续失败次数
+	Failures            int64      `json:"failures"`                  // 主后端累计失败次数
+	Failovers           int64      `json:"failovers"`                 // 由副本提供的读取次数
+	LastError           string     `json:"last_error,omitempty"`      // 最近一次失败原因
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"` // 最近一次失败时间
+}
+
+// circuitBreaker 主后端熔断器：连续失败达到阈值后熔断，熔断到期后放行一个探测请求
+type circuitBreaker struct {
+	mu           sync.Mutex
+	threshold    int
+	openDuration time.Duration
+	now          func() time.Time
+
+	state         string
+	consecutive   int
+	openedAt      time.Time
+	probing       bool
+	failures      int64
+	failovers     int64
+	lastError     string
+	lastFailureAt time.Time
+}
+
+// allow 判断请求是否可以发往主后端
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.openDuration {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// closed 判断熔断器是否处于正常状态（不放行探测请求）
+func (b *circuitBreaker) closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == BreakerClosed
+}
+
+// success 记录主后端成功（恢复正常）
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.consecutive = 0
+	b.probing = false
+}
+
+// failure 记录主后端失败（达到阈值或探测失败时熔断）
+func (b *circuitBreaker) failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.consecutive++
+	b.lastError = err.Error()
+	b.lastFailureAt = b.now()
+	if b.state == BreakerHalfOpen || b.consecutive >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+	b.probing = false
+}
+
+// release 结束探测请求但不改变状态（请求因调用方原因失败，无法判断主后端是否健康）
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// failover 记录一次由副本提供的读取
+func (b *circuitBreaker) failover() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failovers++
+}
+
+// FailoverStorage 读取故障转移存储
+// 包装多后端路由存储：对象所在的主后端读取失败或超时时，从配置的副本后端读取（只读取记录为已复制的副本）。
+// 每个主后端有一个熔断器，熔断期间读取和下载预签名 URL 直接使用副本。写入、删除等操作不做故障转移
+type FailoverStorage struct {
+	*RoutingStorage
+	replicas map[string]string // 主后端 -> 副本后端
+	breakers map[string]*circuitBreaker
+	store    ReplicaStore
+	timeout  time.Duration
+}
+
+// NewFailoverStorage 创建读取故障转移存储
+func NewFailoverStorage(routing *RoutingStorage, store ReplicaStore, cfg config.FailoverConfig) (*FailoverStorage, error) {
+	if cfg.Timeout <= 0 {
+		return nil, fmt.Errorf("invalid storage failover: timeout must be positive")
+	}
+	threshold := cfg.FailureThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	f := &FailoverStorage{
+		RoutingStorage: routing,
+		replicas:       make(map[string]string, len(cfg.Replicas)),
+		breakers:       make(map[string]*circuitBreaker, len(cfg.Replicas)),
+		store:          store,
+		timeout:        cfg.Timeout,
+	}
+	for primary, replica := range cfg.Replicas {
+		if _, ok := routing.Backend(primary); !ok {
+			return nil, fmt.Errorf("invalid storage failover: backend %q is not configured", primary)
+		}
+		if _, ok := routing.Backend(replica); !ok {
+			return nil, fmt.Errorf("invalid storage failover: replica backend %q is not configured", replica)
+		}
+		if primary == replica {
+			return nil, fmt.Errorf("invalid storage failover: backend %q cannot be its own replica", primary)
+		}
+		f.replicas[primary] = replica
+		f.breakers[primary] = &circuitBreaker{
+			threshold:    threshold,
+			openDuration: cfg.OpenDuration,
+			now:          time.Now,
+			state:        BreakerClosed,
+		}
+	}
+	return f, nil
+}
+
+// Health 返回各主后端的健康状态（按后端名称排序）
+func (f *FailoverStorage) Health() []BackendHealth {
+	health := make([]BackendHealth, 0, len(f.breakers))
+	for name, breaker := range f.breakers {
+		breaker.mu.Lock()
+		h := BackendHealth{
+			Backend:             name,
+			Replica:             f.replicas[name],
+			State:               breaker.state,
+			ConsecutiveFailures: breaker.consecutive,
+			Failures:            breaker.failures,
+			Failovers:           breaker.failovers,
+			LastError:           breaker.lastError,
+		}
+		if !breaker.lastFailureAt.IsZero() {
+			lastFailureAt := breaker.lastFailureAt
+			h.LastFailureAt = &lastFailureAt
+		}
+		breaker.mu.Unlock()
+		health = append(health, h)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Backend < health[j].Backend })
+	return health
+}
+
+// isClientError 判断是否为调用方造成的错误（如缺少 SSE-C 密钥、对象不存在），这类错误不计入主后端失败，也不转移
+func isClientError(err error) bool {
+	return errors.Is(err, ErrNotFound) || strings.Contains(err.Error(), "encryption key")
+}
+
+// cancelOnClose 关闭时释放读取上下文的 ReadCloser
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close 关闭读取器并释放上下文
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// read 从对象所在的主后端读取，失败或超时时从副本读取
+func (f *FailoverStorage) read(ctx context.Context, key string, get func(ctx context.Context, backend Storage) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	name, err := f.BackendName(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	primary, ok := f.Backend(name)
+	if !ok {
+		return nil, fmt.Errorf("storage backend %q of %s is not configured", name, key)
+	}
+	breaker, ok := f.breakers[name]
+	if !ok {
+		return get(ctx, primary)
+	}
+
+	primaryErr := fmt.Errorf("storage backend %s is unavailable (circuit open)", name)
+	if breaker.allow() {
+		body, err := f.readPrimary(ctx, primary, get)
+		if err == nil {
+			breaker.success()
+			return body, nil
+		}
+		if ctx.Err() != nil || isClientError(err) {
+			breaker.release()
+			return nil, err
+		}
+		breaker.failure(err)
+		primaryErr = err
+	}
+
+	replicaName := f.replicas[name]
+	replicated, err := f.store.HasReplica(ctx, key, replicaName)
+	if err != nil {
+		return nil, errors.Join(primaryErr, fmt.Errorf("failed to look up replica: %w", err))
+	}
+	if !replicated {
+		return nil, primaryErr
+	}
+
+	replica, _ := f.Backend(replicaName)
+	breaker.failover()
+	body, err := get(ctx, replica)
+	if err != nil {
+		return nil, errors.Join(primaryErr, fmt.Errorf("failed to read replica from %s: %w", replicaName, err))
+	}
+	return body, nil
+}
+
+// readPrimary 读取主后端，超过超时仍未返回响应时取消请求
+// 超时只限制首字节：返回后读取器继续使用请求上下文，关闭时释放
+func (f *FailoverStorage) readPrimary(ctx context.Context, primary Storage, get func(ctx context.Context, backend Storage) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	readCtx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(f.timeout, cancel)
+
+	body, err := get(readCtx, primary)
+	if !timer.Stop() {
+		if body != nil {
+			body.Close()
+		}
+		cancel()
+		return nil, fmt.Errorf("storage backend timed out after %s", f.timeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &cancelOnClose{ReadCloser: body, cancel: cancel}, nil
+}
+
+// GetObject 读取对象（主后端失败时读取副本）
+func (f *FailoverStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	var contentType string
+	var size int64
+	body, err := f.read(ctx, key, func(ctx context.Context, backend Storage) (io.ReadCloser, error) {
+		body, ct, n, err := backend.GetObject(ctx, key)
+		contentType, size = ct, n
+		return body, err
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return body, contentType, size, nil
+}
+
+// GetObjectRange 读取对象的部分内容（主后端失败时读取副本）
+func (f *FailoverStorage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	return f.read(ctx, key, func(ctx context.Context, backend Storage) (io.ReadCloser, error) {
+		return backend.GetObjectRange(ctx, key, offset, length)
+	})
+}
+
+// GeneratePresignedDownloadURL 生成下载预签名 URL
+// 签名不访问存储服务，无法发现故障：熔断器正常时使用主后端，熔断期间（或签名失败时）使用副本
+func (f *FailoverStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error) {
+	name, err := f.BackendName(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	primary, ok := f.Backend(name)
+	if !ok {
+		return nil, fmt.Errorf("storage backend %q of %s is not configured", name, key)
+	}
+	breaker, ok := f.breakers[name]
+	if !ok {
+		return primary.GeneratePresignedDownloadURL(ctx, key, expiry, opts)
+	}
+
+	var primaryErr error
+	if breaker.closed() {
+		presigned, err := primary.GeneratePresignedDownloadURL(ctx, key, expiry, opts)
+		if err == nil || errors.Is(err, ErrPresignNotSupported) || ctx.Err() != nil || isClientError(err) {
+			return presigned, err
+		}
+		primaryErr = err
+	}
+
+	replicaName := f.replicas[name]
+	replicated, err := f.store.HasReplica(ctx, key, replicaName)
+	if err != nil || !replicated {
+		// 没有副本时仍返回主后端的 URL（主后端可能已恢复）
+		if primaryErr != nil {
+			return nil, primaryErr
+		}
+		return primary.GeneratePresignedDownloadURL(ctx, key, expiry, opts)
+	}
+
+	replica, _ := f.Backend(replicaName)
+	breaker.failover()
+	return replica.GeneratePresignedDownloadURL(ctx, key, expiry, opts)
+}
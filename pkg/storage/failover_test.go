@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+)
+
+// faultyStorage 可注入错误和延迟的内存存储
+type faultyStorage struct {
+	*memStorage
+	err   error
+	delay time.Duration
+}
+
+func (f *faultyStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, "", 0, ctx.Err()
+		}
+	}
+	if f.err != nil {
+		return nil, "", 0, f.err
+	}
+	return f.memStorage.GetObject(ctx, key)
+}
+
+func (f *faultyStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &PresignedRequest{URL: "https://primary.example.com/" + key}, nil
+}
+
+// codedError 带错误码的存储服务错误（与 S3 / OSS SDK 的错误一样实现 ErrorCode）
+type codedError string
+
+func (e codedError) Error() string     { return "api error " + string(e) }
+func (e codedError) ErrorCode() string { return string(e) }
+
+// memReplicas 内存副本记录（后端 -> 键）
+type memReplicas map[string]map[string]bool
+
+func (m memReplicas) HasReplica(ctx context.Context, key string, backend string) (bool, error) {
+	return m[backend][key], nil
+}
+
+func newTestFailoverStorage(t *testing.T, cfg config.FailoverConfig) (*FailoverStorage, *faultyStorage, *memStorage, memReplicas) {
+	t.Helper()
+	primary := &faultyStorage{memStorage: &memStorage{objects: map[string][]byte{"a.txt": []byte("primary")}}}
+	replica := &memStorage{objects: map[string][]byte{"a.txt": []byte("replica")}}
+	routing, err := NewRoutingStorage(map[string]Storage{"main": primary, "backup": replica}, config.RoutingConfig{Default: "main"}, memBackends{})
+	if err != nil {
+		t.Fatalf("NewRoutingStorage() error = %v", err)
+	}
+	replicas := memReplicas{"backup": {"a.txt": true}}
+	cfg.Replicas = map[string]string{"main": "backup"}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Second
+	}
+	f, err := NewFailoverStorage(routing, replicas, cfg)
+	if err != nil {
+		t.Fatalf("NewFailoverStorage() error = %v", err)
+	}
+	return f, primary, replica, replicas
+}
+
+func readObject(t *testing.T, s Storage, key string) (string, error) {
+	t.Helper()
+	body, _, _, err := s.GetObject(context.Background(), key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	return string(data), nil
+}
+
+func TestNewFailoverStorageValidatesBackends(t *testing.T) {
+	routing, err := NewRoutingStorage(map[string]Storage{"main": &memStorage{}}, config.RoutingConfig{Default: "main"}, memBackends{})
+	if err != nil {
+		t.Fatalf("NewRoutingStorage() error = %v", err)
+	}
+	tests := []map[string]string{
+		{"main": "missing"},
+		{"missing": "main"},
+		{"main": "main"},
+	}
+	for _, replicas := range tests {
+		if _, err := NewFailoverStorage(routing, memReplicas{}, config.FailoverConfig{Replicas: replicas, Timeout: time.Second}); err == nil {
+			t.Errorf("replicas %v should be rejected", replicas)
+		}
+	}
+}
+
+func TestFailoverStorageGetObject(t *testing.T) {
+	f, primary, _, replicas := newTestFailoverStorage(t, config.FailoverConfig{FailureThreshold: 5})
+
+	if got, err := readObject(t, f, "a.txt"); err != nil || got != "primary" {
+		t.Fatalf("GetObject() = %q, %v, want primary", got, err)
+	}
+
+	// 主后端失败时读取副本
+	primary.err = errors.New("connection refused")
+	if got, err := readObject(t, f, "a.txt"); err != nil || got != "replica" {
+		t.Fatalf("GetObject() = %q, %v, want replica", got, err)
+	}
+
+	// 没有副本记录时返回主后端的错误
+	delete(replicas["backup"], "a.txt")
+	if _, err := readObject(t, f, "a.txt"); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("GetObject() error = %v, want primary error", err)
+	}
+
+	// 调用方错误不计入失败，也不转移
+	replicas["backup"]["a.txt"] = true
+	primary.err = errors.New("object is encrypted with a customer-provided encryption key")
+	if _, err := readObject(t, f, "a.txt"); err == nil {
+		t.Fatal("GetObject() should return client errors")
+	}
+
+	// 对象不存在同样不计入失败
+	primary.err = fmt.Errorf("failed to get object: %w", wrapNotFound(codedError("NoSuchKey")))
+	if _, err := readObject(t, f, "a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetObject() error = %v, want ErrNotFound", err)
+	}
+
+	health := f.Health()
+	if len(health) != 1 || health[0].Backend != "main" || health[0].Replica != "backup" || health[0].State != BreakerClosed ||
+		health[0].Failures != 2 || health[0].Failovers != 1 || health[0].LastFailureAt == nil {
+		t.Fatalf("Health() = %+v", health)
+	}
+}
+
+func TestWrapNotFound(t *testing.T) {
+	for code, want := range map[string]bool{"NoSuchKey": true, "NotFound": true, "NoSuchBucket": false, "AccessDenied": false} {
+		if got := errors.Is(wrapNotFound(codedError(code)), ErrNotFound); got != want {
+			t.Errorf("wrapNotFound(%s) is ErrNotFound = %v, want %v", code, got, want)
+		}
+	}
+	if wrapNotFound(nil) != nil {
+		t.Error("wrapNotFound(nil) should be nil")
+	}
+}
+
+func TestFailoverStorageTimeout(t *testing.T) {
+	f, primary, _, _ := newTestFailoverStorage(t, config.FailoverConfig{Timeout: 20 * time.Millisecond, FailureThreshold: 5})
+	primary.delay = time.Second
+
+	if got, err := readObject(t, f, "a.txt"); err != nil || got != "replica" {
+		t.Fatalf("GetObject() = %q, %v, want replica after timeout", got, err)
+	}
+	if health := f.Health(); !strings.Contains(health[0].LastError, "timed out") {
+		t.Fatalf("last error = %q, want timeout", health[0].LastError)
+	}
+}
+
+func TestFailoverStorageCircuitBreaker(t *testing.T) {
+	f, primary, _, _ := newTestFailoverStorage(t, config.FailoverConfig{FailureThreshold: 2, OpenDuration: time.Minute})
+	now := time.Now()
+	f.breakers["main"].now = func() time.Time { return now }
+	ctx := context.Background()
+
+	primary.err = errors.New("service unavailable")
+	for range 2 {
+		readObject(t, f, "a.txt")
+	}
+	if state := f.Health()[0].State; state != BreakerOpen {
+		t.Fatalf("state = %s, want open", state)
+	}
+
+	// 熔断期间不访问主后端，预签名 URL 使用副本
+	primary.err = nil
+	if got, err := readObject(t, f, "a.txt"); err != nil || got != "replica" {
+		t.Fatalf("GetObject() = %q, %v, want replica while open", got, err)
+	}
+	presigned, err := f.GeneratePresignedDownloadURL(ctx, "a.txt", time.Minute, nil)
+	if err != nil || presigned.URL != "https://example.com/a.txt" {
+		t.Fatalf("GeneratePresignedDownloadURL() = %+v, %v, want replica URL", presigned, err)
+	}
+
+	// 熔断到期后探测成功，恢复正常
+	now = now.Add(time.Minute)
+	if got, err := readObject(t, f, "a.txt"); err != nil || got != "primary" {
+		t.Fatalf("GetObject() = %q, %v, want primary after recovery", got, err)
+	}
+	if state := f.Health()[0].State; state != BreakerClosed {
+		t.Fatalf("state = %s, want closed", state)
+	}
+	presigned, err = f.GeneratePresignedDownloadURL(ctx, "a.txt", time.Minute, nil)
+	if err != nil || presigned.URL != "https://primary.example.com/a.txt" {
+		t.Fatalf("GeneratePresignedDownloadURL() = %+v, %v, want primary URL", presigned, err)
+	}
+
+	// 探测失败时重新熔断
+	f.breakers["main"].failure(errors.New("service unavailable"))
+	f.breakers["main"].failure(errors.New("service unavailable"))
+	now = now.Add(time.Minute)
+	primary.err = errors.New("service unavailable")
+	readObject(t, f, "a.txt")
+	if state := f.Health()[0].State; state != BreakerOpen {
+		t.Fatalf("state = %s, want open after failed probe", state)
+	}
+}
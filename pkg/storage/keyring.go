@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/NanoBoom/asethub/internal/config"
+)
+
+// WrappedKey 经主密钥加密的数据密钥（保存在 files 表中）
+type WrappedKey struct {
+	KeyID      string // 加密数据密钥使用的主密钥 ID
+	Ciphertext string // Base64 编码的 nonce + 密文
+}
+
+// KeyWrapper 数据密钥加解密（信封加密的主密钥一侧，可替换为云 KMS）
+type KeyWrapper interface {
+	// WrapKey 使用当前主密钥加密数据密钥
+	WrapKey(ctx context.Context, dataKey []byte) (*WrappedKey, error)
+
+	// UnwrapKey 使用 WrappedKey.KeyID 对应的主密钥解密数据密钥
+	UnwrapKey(ctx context.Context, wrapped *WrappedKey) ([]byte, error)
+}
+
+// DataKeyStore 按存储键保存和读取对象的数据密钥（密文形式）
+type DataKeyStore interface {
+	// GetDataKey 读取对象的数据密钥（对象未加密或没有文件记录时返回 nil）
+	GetDataKey(ctx context.Context, key string) (*WrappedKey, error)
+
+	// PutDataKey 保存对象的数据密钥，存储键没有文件记录时返回 ErrObjectNotTracked
+	PutDataKey(ctx context.Context, key string, wrapped *WrappedKey) error
+}
+
+// Keyring 本地主密钥环（KMS 的本地替代）
+// 使用 active 主密钥加密新的数据密钥，保留旧主密钥用于解密（支持主密钥轮换）
+type Keyring struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// keyringFile 密钥环文件格式（JSON）
+type keyringFile struct {
+	Active string            `json:"active"` // 当前主密钥 ID
+	Keys   map[string]string `json:"keys"`   // 主密钥 ID -> Base64 编码的 256 位密钥
+}
+
+// NewKeyring 创建主密钥环
+func NewKeyring(active string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[active]; !ok || active == "" {
+		return nil, fmt.Errorf("invalid keyring: active master key %q not found", active)
+	}
+
+	k := &Keyring{active: active, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("invalid keyring: master key %q must be 256 bits", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+	return k, nil
+}
+
+// LoadKeyring 从 JSON 文件加载主密钥环（{"active": "k2", "keys": {"k1": "<base64>", "k2": "<base64>"}}）
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid keyring %s: %w", path, err)
+	}
+
+	keys := make(map[string][]byte, len(file.Keys))
+	for id, value := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keyring: master key %q is not base64", id)
+		}
+		keys[id] = key
+	}
+	return NewKeyring(file.Active, keys)
+}
+
+// NewKeyWrapper 根据配置创建主密钥（keyring_file 优先，否则使用 master_key）
+func NewKeyWrapper(cfg config.ClientEncryptionConfig) (KeyWrapper, error) {
+	if cfg.KeyringFile != "" {
+		return LoadKeyring(cfg.KeyringFile)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.MasterKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid client encryption master key: must be a base64-encoded 256-bit key")
+	}
+	id := cfg.MasterKeyID
+	if id == "" {
+		id = "master"
+	}
+	return NewKeyring(id, map[string][]byte{id: key})
+}
+
+// WrapKey 使用当前主密钥加密数据密钥（主密钥 ID 作为附加数据，防止密文被换到其他主密钥下）
+func (k *Keyring) WrapKey(ctx context.Context, dataKey []byte) (*WrappedKey, error) {
+	aead := k.keys[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, dataKey, []byte(k.active))
+	return &WrappedKey{KeyID: k.active, Ciphertext: base64.StdEncoding.EncodeToString(sealed)}, nil
+}
+
+// UnwrapKey 解密数据密钥
+func (k *Keyring) UnwrapKey(ctx context.Context, wrapped *WrappedKey) ([]byte, error) {
+	aead, ok := k.keys[wrapped.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q not found in keyring", wrapped.KeyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(wrapped.Ciphertext)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("invalid wrapped data key")
+	}
+	dataKey, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(wrapped.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return dataKey, nil
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/NanoBoom/asethub/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// DefaultKeyTemplate 默认存储键模板（与早期 files/<时间戳>/ 目录结构兼容）
+const DefaultKeyTemplate = "files/{unix}/{uuid}{ext}"
+
+// maxShardLength 哈希分片前缀的最大长度
+const maxShardLength = 8
+
+// KeyParams 生成存储键所需的文件信息
+type KeyParams struct {
+	ID          uuid.UUID // 文件 ID（保证存储键唯一）
+	Name        string    // 文件名（用于 {name} 和 {ext}）
+	ContentType string    // MIME 类型（文件名无扩展名时推断 {ext}）
+	Folder      string    // 虚拟目录（如 "/campaigns/2026"）
+	Time        time.Time // 时间（用于日期变量，迁移已有文件时使用创建时间）
+	Tenant      string    // 租户（用于 {tenant}，为空时使用 storage.key_tenant；如复制到其他租户）
+}
+
+// KeyTemplate 存储键模板
+// 支持的变量：
+//   - {uuid}: 文件 ID（必须包含，保证唯一）
+//   - {ext}: 扩展名（带点，小写；文件名无扩展名时按 MIME 类型推断）
+//   - {name}: 文件名（不含扩展名，转为适合对象键的形式）
+//   - {folder}: 虚拟目录（不含开头的 /）
+//   - {tenant}: 租户/命名空间（storage.key_tenant）
+//   - {yyyy} {mm} {dd} {hh}: UTC 日期
+//   - {unix}: Unix 时间戳
+//   - {shard} / {shard:N}: 文件 ID 的 SHA-256 前 N 位十六进制（默认 2，最大 8），用于分散 S3 分区前缀
+type KeyTemplate struct {
+	template string
+	tenant   string
+	parts    []keyPart
+}
+
+// keyPart 模板片段（字面量或变量）
+type keyPart struct {
+	literal string
+	name    string
+	arg     int
+}
+
+// ParseKeyTemplate 解析存储键模板，模板为空时使用默认模板
+func ParseKeyTemplate(template string, tenant string) (*KeyTemplate, error) {
+	if template == "" {
+		template = DefaultKeyTemplate
+	}
+
+	t := &KeyTemplate{template: template, tenant: tenant}
+	hasUUID := false
+	rest := template
+	for rest != "" {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			if strings.ContainsRune(rest, '}') {
+				return nil, fmt.Errorf("invalid key template %q: unexpected '}'", template)
+			}
+			t.parts = append(t.parts, keyPart{literal: rest})
+			break
+		}
+		if strings.ContainsRune(rest[:start], '}') {
+			return nil, fmt.Errorf("invalid key template %q: unexpected '}'", template)
+		}
+		if start > 0 {
+			t.parts = append(t.parts, keyPart{literal: rest[:start]})
+		}
+
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid key template %q: unclosed '{'", template)
+		}
+		part, err := parseKeyVariable(rest[start+1 : start+end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid key template %q: %w", template, err)
+		}
+		if part.name == "tenant" && tenant == "" {
+			return nil, fmt.Errorf("invalid key template %q: {tenant} requires storage.key_tenant", template)
+		}
+		hasUUID = hasUUID || part.name == "uuid"
+		t.parts = append(t.parts, part)
+		rest = rest[start+end+1:]
+	}
+
+	if !hasUUID {
+		return nil, fmt.Errorf("invalid key template %q: must contain {uuid}", template)
+	}
+	return t, nil
+}
+
+// parseKeyVariable 解析模板变量（如 "yyyy"、"shard:4"）
+func parseKeyVariable(variable string) (keyPart, error) {
+	name, arg, hasArg := strings.Cut(variable, ":")
+	switch name {
+	case "uuid", "ext", "name", "folder", "tenant", "yyyy", "mm", "dd", "hh", "unix":
+		if hasArg {
+			return keyPart{}, fmt.Errorf("variable {%s} does not take an argument", name)
+		}
+		return keyPart{name: name}, nil
+	case "shard":
+		length := 2
+		if hasArg {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 || n > maxShardLength {
+				return keyPart{}, fmt.Errorf("shard length must be between 1 and %d", maxShardLength)
+			}
+			length = n
+		}
+		return keyPart{name: name, arg: length}, nil
+	default:
+		return keyPart{}, fmt.Errorf("unknown variable {%s}", variable)
+	}
+}
+
+// String 返回模板原文
+func (t *KeyTemplate) String() string {
+	return t.template
+}
+
+// HasTenant 判断模板是否包含 {tenant}
+func (t *KeyTemplate) HasTenant() bool {
+	for _, part := range t.parts {
+		if part.name == "tenant" {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidTenant 判断租户是否可以作为存储键的一段（只包含字母、数字、_、-、.，最长 64 字节）
+func ValidTenant(tenant string) bool {
+	return tenant != "" && tenant != "." && tenant != ".." && len(tenant) <= 64 &&
+		strings.IndexFunc(tenant, func(r rune) bool { return !isKeyChar(r) }) < 0
+}
+
+// Render 根据模板生成存储键（去除多余的 /，不以 / 开头）
+func (t *KeyTemplate) Render(params KeyParams) string {
+	ts := params.Time.UTC()
+	ext := keyExtension(params.Name, params.ContentType)
+
+	var b strings.Builder
+	for _, part := range t.parts {
+		switch part.name {
+		case "":
+			b.WriteString(part.literal)
+		case "uuid":
+			b.WriteString(params.ID.String())
+		case "ext":
+			b.WriteString(ext)
+		case "name":
+			b.WriteString(keySafeName(strings.TrimSuffix(params.Name, filepath.Ext(params.Name))))
+		case "folder":
+			b.WriteString(keySafeFolder(params.Folder))
+		case "tenant":
+			if params.Tenant != "" {
+				b.WriteString(params.Tenant)
+			} else {
+				b.WriteString(t.tenant)
+			}
+		case "yyyy":
+			fmt.Fprintf(&b, "%04d", ts.Year())
+		case "mm":
+			fmt.Fprintf(&b, "%02d", int(ts.Month()))
+		case "dd":
+			fmt.Fprintf(&b, "%02d", ts.Day())
+		case "hh":
+			fmt.Fprintf(&b, "%02d", ts.Hour())
+		case "unix":
+			b.WriteString(strconv.FormatInt(ts.Unix(), 10))
+		case "shard":
+			sum := sha256.Sum256(params.ID[:])
+			b.WriteString(hex.EncodeToString(sum[:])[:part.arg])
+		}
+	}
+
+	// 空变量（如根目录的 {folder}）会产生连续的 /
+	segments := strings.Split(b.String(), "/")
+	kept := segments[:0]
+	for _, segment := range segments {
+		if segment != "" {
+			kept = append(kept, segment)
+		}
+	}
+	return strings.Join(kept, "/")
+}
+
+// keyExtension 返回存储键扩展名（优先使用文件名中的扩展名）
+func keyExtension(name string, contentType string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" || ext == "." || strings.IndexFunc(ext[1:], func(r rune) bool { return !isKeyChar(r) }) >= 0 {
+		ext = utils.GetExtensionFromMIME(contentType)
+	}
+	return ext
+}
+
+// keySafeName 将文件名转为适合对象键的形式（保留字母和数字，其余字符替换为 -，最长 64 字节）
+func keySafeName(name string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range name {
+		if isKeyChar(r) {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+
+	result := strings.Trim(b.String(), "-.")
+	if len(result) > 64 {
+		result = strings.TrimRight(strings.ToValidUTF8(result[:64], ""), "-.")
+	}
+	if result == "" {
+		return "file"
+	}
+	return result
+}
+
+// keySafeFolder 将虚拟目录转为对象键前缀（逐段处理，去除开头的 /）
+func keySafeFolder(folder string) string {
+	var segments []string
+	for _, segment := range strings.Split(folder, "/") {
+		if segment != "" && segment != "." && segment != ".." {
+			segments = append(segments, keySafeName(segment))
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isKeyChar 判断字符是否可直接用于对象键
+func isKeyChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestKeyTemplateRender(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	ts := time.Date(2026, 3, 7, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		template string
+		params   KeyParams
+		want     string
+	}{
+		{DefaultKeyTemplate, KeyParams{ID: id, Name: "photo.JPG", Time: ts}, "files/1772875800/550e8400-e29b-41d4-a716-446655440000.jpg"},
+		{"{tenant}/{yyyy}/{mm}/{uuid}{ext}", KeyParams{ID: id, Name: "report.pdf", Time: ts}, "acme/2026/03/550e8400-e29b-41d4-a716-446655440000.pdf"},
+		{"{tenant}/{uuid}", KeyParams{ID: id, Time: ts, Tenant: "globex"}, "globex/550e8400-e29b-41d4-a716-446655440000"},
+		{"{shard}/{shard:4}/{uuid}", KeyParams{ID: id, Time: ts}, "ce/cee8/550e8400-e29b-41d4-a716-446655440000"},
+		{"{folder}/{name}-{uuid}{ext}", KeyParams{ID: id, Name: "季度 报告(final).docx", Folder: "/campaigns/2026", Time: ts}, "campaigns/2026/季度-报告-final-550e8400-e29b-41d4-a716-446655440000.docx"},
+		{"/{folder}//{uuid}{ext}", KeyParams{ID: id, Name: "README", ContentType: "text/plain", Folder: "/", Time: ts}, "550e8400-e29b-41d4-a716-446655440000.txt"},
+		{"{yyyy}{mm}{dd}{hh}/{uuid}{ext}", KeyParams{ID: id, Name: "x", Time: ts}, "2026030709/550e8400-e29b-41d4-a716-446655440000.bin"},
+	}
+
+	for _, tt := range tests {
+		keys, err := ParseKeyTemplate(tt.template, "acme")
+		if err != nil {
+			t.Fatalf("ParseKeyTemplate(%q) error = %v", tt.template, err)
+		}
+		if got := keys.Render(tt.params); got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestParseKeyTemplateErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		tenant   string
+		wantErr  string
+	}{
+		{"files/{yyyy}/{name}{ext}", "", "must contain {uuid}"},
+		{"files/{uuid}{unknown}", "", "unknown variable"},
+		{"files/{uuid", "", "unclosed"},
+		{"files/}{uuid}", "", "unexpected"},
+		{"{shard:9}/{uuid}", "", "shard length"},
+		{"{tenant}/{uuid}", "", "requires storage.key_tenant"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseKeyTemplate(tt.template, tt.tenant)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseKeyTemplate(%q) error = %v, want %q", tt.template, err, tt.wantErr)
+		}
+	}
+}
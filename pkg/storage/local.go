@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/pkg/utils"
+)
+
+// errLocalPresign 本地存储没有对外的 HTTP 地址，不支持预签名 URL（下载由后端代理）
+var errLocalPresign = fmt.Errorf("%w by local storage", ErrPresignNotSupported)
+
+// LocalStorage 本地文件系统存储（开发环境或作为多后端中的临时存储）
+// 对象键映射为 basePath 下的相对路径，写入先落临时文件再重命名，读取方不会看到写了一半的对象。
+// 不支持预签名 URL、分片上传和服务端加密；Content-Type 按存储键扩展名推断
+type LocalStorage struct {
+	basePath string
+}
+
+// NewLocalStorage 创建本地存储实例（根目录不存在时自动创建）
+func NewLocalStorage(basePath string) (*LocalStorage, error) {
+	if basePath == "" {
+		return nil, fmt.Errorf("local storage base_path is required")
+	}
+	abs, err := filepath.Abs(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local storage base_path: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create local storage directory: %w", err)
+	}
+	return &LocalStorage{basePath: abs}, nil
+}
+
+// path 返回对象键对应的文件路径（清理 ".." 等路径段，保证不会逃出根目录）
+func (l *LocalStorage) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.basePath, filepath.FromSlash(cleaned)), nil
+}
+
+// Upload 写入文件（先写临时文件，大小一致后重命名）
+func (l *LocalStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	if _, err := l.write(key, io.LimitReader(reader, size+1), size); err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	return nil
+}
+
+// write 将内容写入对象文件（size < 0 时不校验大小），返回写入的字节数
+func (l *LocalStorage) write(key string, reader io.Reader, size int64) (int64, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if size >= 0 && written != size {
+		return 0, fmt.Errorf("size mismatch: declared %d bytes, received %d", size, written)
+	}
+	return written, os.Rename(tmp.Name(), target)
+}
+
+// GeneratePresignedUploadURL 本地存储不支持预签名上传
+func (l *LocalStorage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string, checksum *Checksum) (*PresignedRequest, error) {
+	return nil, errLocalPresign
+}
+
+// GeneratePresignedPost 本地存储不支持表单直传
+func (l *LocalStorage) GeneratePresignedPost(ctx context.Context, key string, expiry time.Duration, policy PostPolicy) (*PresignedPost, error) {
+	return nil, errLocalPresign
+}
+
+// InitMultipartUpload 本地存储不支持分片直传
+func (l *LocalStorage) InitMultipartUpload(ctx context.Context, key string, contentType string, algorithm ChecksumAlgorithm) (*MultipartUpload, error) {
+	return nil, errLocalPresign
+}
+
+// GeneratePresignedPartURL 本地存储不支持分片直传
+func (l *LocalStorage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration, checksum *Checksum) (*PresignedRequest, error) {
+	return nil, errLocalPresign
+}
+
+// CompleteMultipartUpload 本地存储不支持分片上传
+func (l *LocalStorage) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error {
+	return fmt.Errorf("multipart upload is not supported by local storage")
+}
+
+// AbortMultipartUpload 本地存储不支持分片上传
+func (l *LocalStorage) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	return fmt.Errorf("multipart upload is not supported by local storage")
+}
+
+// GetObject 打开对象文件
+func (l *LocalStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	file, info, err := l.open(key)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return file, utils.DetectContentTypeFromFilename(key), info.Size(), nil
+}
+
+// GetObjectRange 读取对象的部分内容（超出文件大小时返回到文件末尾的内容）
+func (l *LocalStorage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	file, _, err := l.open(key)
+	if err != nil {
+		return nil, err
+	}
+	return &readCloser{Reader: io.NewSectionReader(file, offset, length), Closer: file}, nil
+}
+
+// open 打开对象文件
+func (l *LocalStorage) open(key string) (*os.File, os.FileInfo, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	return file, info, nil
+}
+
+// GeneratePresignedDownloadURL 本地存储不支持预签名下载（调用方应改用后端代理下载）
+func (l *LocalStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error) {
+	return nil, errLocalPresign
+}
+
+// Delete 删除对象文件（对象不存在时视为成功，与 S3/OSS 一致）
+func (l *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+// DeleteObjects 逐个删除对象文件
+func (l *LocalStorage) DeleteObjects(ctx context.Context, keys []string) (map[string]error, error) {
+	failed := make(map[string]error)
+	for _, key := range keys {
+		if err := l.Delete(ctx, key); err != nil {
+			failed[key] = err
+		}
+	}
+	return failed, nil
+}
+
+// Copy 复制对象文件
+func (l *LocalStorage) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	src, _, err := l.open(srcKey)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if _, err := l.write(dstKey, src, -1); err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	return nil
+}
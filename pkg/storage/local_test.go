@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+
+	content := "hello local storage"
+	if err := s.Upload(ctx, "files/a.txt", strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	body, contentType, size, err := s.GetObject(ctx, "files/a.txt")
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if string(got) != content || size != int64(len(content)) || !strings.HasPrefix(contentType, "text/plain") {
+		t.Fatalf("GetObject() = %q, %q, %d", got, contentType, size)
+	}
+
+	body, err = s.GetObjectRange(ctx, "files/a.txt", 6, 5)
+	if err != nil {
+		t.Fatalf("GetObjectRange() error = %v", err)
+	}
+	got, _ = io.ReadAll(body)
+	body.Close()
+	if string(got) != "local" {
+		t.Fatalf("GetObjectRange() = %q, want %q", got, "local")
+	}
+
+	if err := s.Copy(ctx, "files/a.txt", "copies/b.txt"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if _, _, size, err := s.GetObject(ctx, "copies/b.txt"); err != nil || size != int64(len(content)) {
+		t.Fatalf("copied object size = %d, %v", size, err)
+	}
+
+	if err := s.Delete(ctx, "files/a.txt"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := s.Delete(ctx, "files/a.txt"); err != nil {
+		t.Fatalf("Delete() of missing object error = %v, want nil", err)
+	}
+	if _, _, _, err := s.GetObject(ctx, "files/a.txt"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("GetObject() after delete error = %v, want not found", err)
+	}
+
+	if _, err := s.GeneratePresignedDownloadURL(ctx, "copies/b.txt", time.Minute, nil); !errors.Is(err, ErrPresignNotSupported) {
+		t.Fatalf("GeneratePresignedDownloadURL() error = %v, want ErrPresignNotSupported", err)
+	}
+}
+
+func TestLocalStorageRejectsSizeMismatch(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+
+	if err := s.Upload(ctx, "a.txt", strings.NewReader("short"), 10, "text/plain"); err == nil || !strings.Contains(err.Error(), "size mismatch") {
+		t.Fatalf("Upload() error = %v, want size mismatch", err)
+	}
+	if _, _, _, err := s.GetObject(ctx, "a.txt"); err == nil {
+		t.Fatalf("partial upload should not be visible")
+	}
+}
+
+func TestLocalStorageKeepsKeysInsideBasePath(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	base := filepath.Join(root, "objects")
+	s, err := NewLocalStorage(base)
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+
+	if err := s.Upload(ctx, "../../escape.txt", strings.NewReader("x"), 1, "text/plain"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escape.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("object escaped the base path")
+	}
+	if _, err := os.Stat(filepath.Join(base, "escape.txt")); err != nil {
+		t.Fatalf("object not stored under the base path: %v", err)
+	}
+
+	if err := s.Upload(ctx, "dir/", strings.NewReader("x"), 1, "text/plain"); err == nil {
+		t.Fatalf("directory key should be rejected")
+	}
+}
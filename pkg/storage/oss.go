@@ -2,6 +2,11 @@ package storage
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -17,16 +22,26 @@ type OSSConfig struct {
 	Bucket          string // OSS bucket 名称
 	AccessKeyID     string // 阿里云 Access Key ID
 	AccessKeySecret string // 阿里云 Access Key Secret
+
+	Encryption *EncryptionPolicy // 服务端加密策略（可选，OSS 不支持 sse-c）
 }
 
 // OSSStorage OSS 存储实现
 type OSSStorage struct {
-	client *oss.Client
-	bucket string
+	client      *oss.Client
+	bucket      string
+	endpoint    string
+	region      string
+	credentials credentials.CredentialsProvider // 用于签名 PostObject 策略（SDK 未提供 POST 签名）
+	encryption  *EncryptionPolicy
 }
 
 // NewOSSStorage 创建 OSS 存储实例
 func NewOSSStorage(ctx context.Context, cfg OSSConfig) (*OSSStorage, error) {
+	if cfg.Encryption.Uses(EncryptionSSEC) {
+		return nil, fmt.Errorf("encryption mode sse-c is not supported by OSS (supported: none, sse-s3, sse-kms)")
+	}
+
 	// 创建凭证提供者
 	credentialsProvider := credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.AccessKeySecret)
 
@@ -52,17 +67,56 @@ func NewOSSStorage(ctx context.Context, cfg OSSConfig) (*OSSStorage, error) {
 	client := oss.NewClient(clientCfg)
 
 	return &OSSStorage{
-		client: client,
-		bucket: cfg.Bucket,
+		client:      client,
+		bucket:      cfg.Bucket,
+		endpoint:    cfg.Endpoint,
+		region:      region,
+		credentials: credentialsProvider,
+		encryption:  cfg.Encryption,
 	}, nil
 }
 
+// ossSSE 返回对象的 x-oss-server-side-encryption 和 KMS 密钥 ID（未加密时均为 nil）
+func (o *OSSStorage) ossSSE(ctx context.Context, key string) (algorithm, kmsKeyID *string, err error) {
+	enc, err := o.encryption.resolve(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch enc.Mode {
+	case EncryptionSSES3:
+		return oss.Ptr("AES256"), nil, nil
+	case EncryptionSSEKMS:
+		if enc.KMSKeyID != "" {
+			kmsKeyID = oss.Ptr(enc.KMSKeyID)
+		}
+		return oss.Ptr("KMS"), kmsKeyID, nil
+	}
+	return nil, nil, nil
+}
+
+// ossPresignedRequest 转换 SDK 的预签名结果
+func ossPresignedRequest(result *oss.PresignResult) *PresignedRequest {
+	headers := make(map[string]string, len(result.SignedHeaders))
+	for name, value := range result.SignedHeaders {
+		headers[name] = value
+	}
+	return &PresignedRequest{URL: result.URL, Headers: headers}
+}
+
 // Upload 直接上传文件（后端代理）
 func (o *OSSStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	algorithm, kmsKeyID, err := o.ossSSE(ctx, key)
+	if err != nil {
+		return err
+	}
+
 	req := &oss.PutObjectRequest{
-		Bucket: oss.Ptr(o.bucket),
-		Key:    oss.Ptr(key),
-		Body:   reader,
+		Bucket:                    oss.Ptr(o.bucket),
+		Key:                       oss.Ptr(key),
+		Body:                      reader,
+		ServerSideEncryption:      algorithm,
+		ServerSideEncryptionKeyId: kmsKeyID,
 	}
 
 	// 设置 Content-Type（必须在上传时设置，不能在下载时覆盖）
@@ -70,7 +124,7 @@ func (o *OSSStorage) Upload(ctx context.Context, key string, reader io.Reader, s
 		req.ContentType = oss.Ptr(contentType)
 	}
 
-	_, err := o.client.PutObject(ctx, req)
+	_, err = o.client.PutObject(ctx, req)
 	if err != nil {
 		return fmt.Errorf("failed to upload object: %w", err)
 	}
@@ -78,30 +132,176 @@ func (o *OSSStorage) Upload(ctx context.Context, key string, reader io.Reader, s
 }
 
 // GeneratePresignedUploadURL 生成小文件上传预签名 URL（前端直传）
-func (o *OSSStorage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string) (string, error) {
+func (o *OSSStorage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string, checksum *Checksum) (*PresignedRequest, error) {
+	contentMD5, err := ossContentMD5(checksum)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, kmsKeyID, err := o.ossSSE(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// x-oss-* 加密头作为签名头（客户端上传时须原样携带）
 	req := &oss.PutObjectRequest{
-		Bucket: oss.Ptr(o.bucket),
-		Key:    oss.Ptr(key),
+		Bucket:                    oss.Ptr(o.bucket),
+		Key:                       oss.Ptr(key),
+		ServerSideEncryption:      algorithm,
+		ServerSideEncryptionKeyId: kmsKeyID,
 	}
 
 	// 设置 Content-Type（必须与实际上传时一致，否则 OSS V4 签名验证失败）
 	if contentType != "" {
 		req.ContentType = oss.Ptr(contentType)
 	}
+	// 签名 Content-MD5（OSS 校验上传内容，不一致时返回 InvalidDigest）
+	if contentMD5 != "" {
+		req.ContentMD5 = oss.Ptr(contentMD5)
+	}
 
 	result, err := o.client.Presign(ctx, req, oss.PresignExpires(expiry))
 	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned upload URL: %w", err)
+		return nil, fmt.Errorf("failed to generate presigned upload URL: %w", err)
+	}
+
+	return ossPresignedRequest(result), nil
+}
+
+// GeneratePresignedPost 生成预签名 PostObject 表单上传策略（V4 签名）
+func (o *OSSStorage) GeneratePresignedPost(ctx context.Context, key string, expiry time.Duration, policy PostPolicy) (*PresignedPost, error) {
+	if _, err := ossContentMD5(policy.Checksum); err != nil {
+		return nil, err
+	}
+	algorithm, kmsKeyID, err := o.ossSSE(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	sseFields := make(map[string]string)
+	if algorithm != nil {
+		sseFields["x-oss-server-side-encryption"] = *algorithm
+	}
+	if kmsKeyID != nil {
+		sseFields["x-oss-server-side-encryption-key-id"] = *kmsKeyID
+	}
+
+	creds, err := o.credentials.GetCredentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials: %w", err)
 	}
 
-	return result.URL, nil
+	fields, err := ossPostFields(creds, o.bucket, o.region, key, time.Now(), expiry, policy, sseFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate presigned post: %w", err)
+	}
+
+	// 表单提交到虚拟主机风格的存储桶地址
+	scheme, host := "https", o.endpoint
+	if before, after, ok := strings.Cut(o.endpoint, "://"); ok {
+		scheme, host = before, after
+	}
+	return &PresignedPost{URL: fmt.Sprintf("%s://%s.%s", scheme, o.bucket, strings.TrimSuffix(host, "/")), Fields: fields}, nil
+}
+
+// ossPostFields 构造 PostObject 表单字段
+// 策略（Base64 编码）使用 OSS4-HMAC-SHA256 签名，条件包含存储桶、精确对象键、Content-Type 和文件大小范围
+// sseFields 为服务端加密字段，与 Content-MD5 一样要求精确匹配
+func ossPostFields(creds credentials.Credentials, bucket, region, key string, now time.Time, expiry time.Duration, policy PostPolicy, sseFields map[string]string) (map[string]string, error) {
+	now = now.UTC()
+	date := now.Format("20060102")
+	credential := fmt.Sprintf("%s/%s/%s/oss/aliyun_v4_request", creds.AccessKeyID, date, region)
+
+	fields := map[string]string{
+		"key":                     key,
+		"x-oss-signature-version": "OSS4-HMAC-SHA256",
+		"x-oss-credential":        credential,
+		"x-oss-date":              now.Format("20060102T150405Z"),
+	}
+	if creds.SecurityToken != "" {
+		fields["x-oss-security-token"] = creds.SecurityToken
+	}
+	if policy.ContentType != "" {
+		fields["Content-Type"] = policy.ContentType
+	}
+	if policy.Checksum != nil && policy.Checksum.Algorithm == ChecksumMD5 {
+		fields["Content-MD5"] = policy.Checksum.Value
+	}
+	for name, value := range sseFields {
+		fields[name] = value
+	}
+
+	conditions := []interface{}{
+		map[string]string{"bucket": bucket},
+		[]interface{}{"eq", "$key", key},
+		[]interface{}{"content-length-range", policy.MinSize, policy.MaxSize},
+	}
+	for _, name := range []string{"x-oss-signature-version", "x-oss-credential", "x-oss-date", "x-oss-security-token"} {
+		if value, ok := fields[name]; ok {
+			conditions = append(conditions, map[string]string{name: value})
+		}
+	}
+	if policy.ContentType != "" {
+		conditions = append(conditions, []interface{}{"eq", "$Content-Type", policy.ContentType})
+	}
+	for _, name := range []string{"Content-MD5", "x-oss-server-side-encryption", "x-oss-server-side-encryption-key-id"} {
+		if value, ok := fields[name]; ok {
+			conditions = append(conditions, []interface{}{"eq", "$" + name, value})
+		}
+	}
+
+	document, err := json.Marshal(map[string]interface{}{
+		"expiration": now.Add(expiry).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(document)
+
+	// 签名密钥：HMAC-SHA256 链（aliyun_v4 + Secret -> 日期 -> 区域 -> oss -> aliyun_v4_request）
+	signingKey := hmacSHA256([]byte("aliyun_v4"+creds.AccessKeySecret), date)
+	for _, part := range []string{region, "oss", "aliyun_v4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+
+	fields["policy"] = encoded
+	fields["x-oss-signature"] = hex.EncodeToString(hmacSHA256(signingKey, encoded))
+	return fields, nil
+}
+
+// ossContentMD5 返回 Content-MD5 校验值（OSS 仅支持 MD5，CRC32C/SHA256 返回错误）
+func ossContentMD5(checksum *Checksum) (string, error) {
+	if checksum == nil {
+		return "", nil
+	}
+	if checksum.Algorithm != ChecksumMD5 {
+		return "", fmt.Errorf("checksum algorithm %s is not supported by OSS", checksum.Algorithm)
+	}
+	return checksum.Value, nil
+}
+
+// hmacSHA256 计算 HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
 }
 
 // InitMultipartUpload 初始化分片上传
-func (o *OSSStorage) InitMultipartUpload(ctx context.Context, key string, contentType string) (*MultipartUpload, error) {
+func (o *OSSStorage) InitMultipartUpload(ctx context.Context, key string, contentType string, algorithm ChecksumAlgorithm) (*MultipartUpload, error) {
+	if algorithm != "" && algorithm != ChecksumMD5 {
+		return nil, fmt.Errorf("checksum algorithm %s is not supported by OSS", algorithm)
+	}
+	sseAlgorithm, kmsKeyID, err := o.ossSSE(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// 服务端加密在初始化时设置，分片无需携带加密头
 	req := &oss.InitiateMultipartUploadRequest{
-		Bucket: oss.Ptr(o.bucket),
-		Key:    oss.Ptr(key),
+		Bucket:                    oss.Ptr(o.bucket),
+		Key:                       oss.Ptr(key),
+		ServerSideEncryption:      sseAlgorithm,
+		ServerSideEncryptionKeyId: kmsKeyID,
 	}
 
 	// 设置 Content-Type（必须在初始化时设置）
@@ -122,18 +322,28 @@ func (o *OSSStorage) InitMultipartUpload(ctx context.Context, key string, conten
 }
 
 // GeneratePresignedPartURL 生成分片上传预签名 URL
-func (o *OSSStorage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration) (string, error) {
-	req, err := o.client.Presign(ctx, &oss.UploadPartRequest{
+func (o *OSSStorage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration, checksum *Checksum) (*PresignedRequest, error) {
+	contentMD5, err := ossContentMD5(checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &oss.UploadPartRequest{
 		Bucket:     oss.Ptr(o.bucket),
 		Key:        oss.Ptr(key),
 		UploadId:   oss.Ptr(uploadID),
 		PartNumber: int32(partNumber),
-	}, oss.PresignExpires(expiry))
+	}
+	if contentMD5 != "" {
+		input.ContentMD5 = oss.Ptr(contentMD5)
+	}
+
+	req, err := o.client.Presign(ctx, input, oss.PresignExpires(expiry))
 	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned part URL: %w", err)
+		return nil, fmt.Errorf("failed to generate presigned part URL: %w", err)
 	}
 
-	return req.URL, nil
+	return ossPresignedRequest(req), nil
 }
 
 // CompleteMultipartUpload 完成分片上传
@@ -162,6 +372,20 @@ func (o *OSSStorage) CompleteMultipartUpload(ctx context.Context, key string, up
 	return nil
 }
 
+// AbortMultipartUpload 取消分片上传
+func (o *OSSStorage) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	_, err := o.client.AbortMultipartUpload(ctx, &oss.AbortMultipartUploadRequest{
+		Bucket:   oss.Ptr(o.bucket),
+		Key:      oss.Ptr(key),
+		UploadId: oss.Ptr(uploadID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	return nil
+}
+
 // GetObject 获取对象内容（流式读取）
 func (o *OSSStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
 	req := &oss.GetObjectRequest{
@@ -171,7 +395,7 @@ func (o *OSSStorage) GetObject(ctx context.Context, key string) (io.ReadCloser,
 
 	result, err := o.client.GetObject(ctx, req)
 	if err != nil {
-		return nil, "", 0, fmt.Errorf("failed to get object: %w", err)
+		return nil, "", 0, fmt.Errorf("failed to get object: %w", wrapNotFound(err))
 	}
 
 	// 提取 Content-Type
@@ -186,24 +410,44 @@ func (o *OSSStorage) GetObject(ctx context.Context, key string) (io.ReadCloser,
 	return result.Body, contentType, contentLength, nil
 }
 
+// GetObjectRange 获取对象的部分内容
+func (o *OSSStorage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	result, err := o.client.GetObject(ctx, &oss.GetObjectRequest{
+		Bucket: oss.Ptr(o.bucket),
+		Key:    oss.Ptr(key),
+		Range:  oss.Ptr(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if isInvalidRange(err) {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object range: %w", wrapNotFound(err))
+	}
+
+	return result.Body, nil
+}
+
 // GeneratePresignedDownloadURL 生成下载预签名 URL
-func (o *OSSStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (string, error) {
+func (o *OSSStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error) {
 	req := &oss.GetObjectRequest{
 		Bucket: oss.Ptr(o.bucket),
 		Key:    oss.Ptr(key),
 	}
 
-	// 只设置 Content-Disposition（OSS 不允许覆盖 Content-Type）
-	if opts != nil && opts.ContentDisposition != "" {
-		req.ResponseContentDisposition = oss.Ptr(opts.ContentDisposition)
+	// 只设置 Content-Disposition 和 Cache-Control（OSS 不允许覆盖 Content-Type）
+	if disposition := opts.responseContentDisposition(); disposition != "" {
+		req.ResponseContentDisposition = oss.Ptr(disposition)
+	}
+	if opts != nil && opts.CacheControl != "" {
+		req.ResponseCacheControl = oss.Ptr(opts.CacheControl)
 	}
 
 	result, err := o.client.Presign(ctx, req, oss.PresignExpires(expiry))
 	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned download URL: %w", err)
+		return nil, fmt.Errorf("failed to generate presigned download URL: %w", err)
 	}
 
-	return result.URL, nil
+	return ossPresignedRequest(result), nil
 }
 
 // Delete 删除对象
@@ -218,3 +462,129 @@ func (o *OSSStorage) Delete(ctx context.Context, key string) error {
 
 	return nil
 }
+
+// DeleteObjects 批量删除对象
+func (o *OSSStorage) DeleteObjects(ctx context.Context, keys []string) (map[string]error, error) {
+	failed := make(map[string]error)
+
+	for _, chunk := range chunkKeys(keys) {
+		objects := make([]oss.ObjectIdentifier, len(chunk))
+		for i, key := range chunk {
+			objects[i] = oss.ObjectIdentifier{Key: oss.Ptr(key)}
+		}
+
+		// OSS 不返回失败明细，使用非 Quiet 模式，未出现在已删除列表中的对象视为失败
+		result, err := o.client.DeleteMultipleObjects(ctx, &oss.DeleteMultipleObjectsRequest{
+			Bucket: oss.Ptr(o.bucket),
+			Delete: &oss.Delete{
+				Objects: objects,
+				Quiet:   false,
+			},
+		})
+		if err != nil {
+			for _, key := range chunk {
+				failed[key] = fmt.Errorf("failed to delete objects: %w", err)
+			}
+			continue
+		}
+
+		deleted := make(map[string]bool, len(result.DeletedObjects))
+		for _, info := range result.DeletedObjects {
+			deleted[oss.ToString(info.Key)] = true
+		}
+		for _, key := range chunk {
+			if !deleted[key] {
+				failed[key] = fmt.Errorf("object was not deleted")
+			}
+		}
+	}
+
+	return failed, nil
+}
+
+// Copy 服务端复制对象（Copier 在对象较大时自动使用 UploadPartCopy 分片复制）
+func (o *OSSStorage) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	return o.copyObject(ctx, srcKey, dstKey, "")
+}
+
+// copyObject 服务端复制对象（storageClass 为空时使用存储桶默认存储类型）
+func (o *OSSStorage) copyObject(ctx context.Context, srcKey string, dstKey string, storageClass oss.StorageClassType) error {
+	// 目标对象按目标键的策略加密（OSS 复制时透明解密源对象）
+	algorithm, kmsKeyID, err := o.ossSSE(ctx, dstKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.client.NewCopier().Copy(ctx, &oss.CopyObjectRequest{
+		Bucket:                    oss.Ptr(o.bucket),
+		Key:                       oss.Ptr(dstKey),
+		SourceBucket:              oss.Ptr(o.bucket),
+		SourceKey:                 oss.Ptr(srcKey),
+		StorageClass:              storageClass,
+		ServerSideEncryption:      algorithm,
+		ServerSideEncryptionKeyId: kmsKeyID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+
+	return nil
+}
+
+// ossStorageClasses 存储类型对应的 OSS 存储类型
+var ossStorageClasses = map[StorageClass]oss.StorageClassType{
+	StorageClassStandard:         oss.StorageClassStandard,
+	StorageClassInfrequentAccess: oss.StorageClassIA,
+	StorageClassArchive:          oss.StorageClassArchive,
+	StorageClassColdArchive:      oss.StorageClassColdArchive,
+}
+
+// SetStorageClass 修改对象的存储类型（复制到原键）
+func (o *OSSStorage) SetStorageClass(ctx context.Context, key string, class StorageClass) error {
+	storageClass, ok := ossStorageClasses[class]
+	if !ok {
+		return fmt.Errorf("unsupported storage class: %s", class)
+	}
+	if err := o.copyObject(ctx, key, key, storageClass); err != nil {
+		return fmt.Errorf("failed to change storage class: %w", err)
+	}
+	return nil
+}
+
+// RestoreObject 解冻归档对象（优先级只对冷归档对象生效）
+func (o *OSSStorage) RestoreObject(ctx context.Context, key string, days int, tier RestoreTier) error {
+	var ossTier string
+	switch tier {
+	case RestoreTierExpedited:
+		ossTier = "Expedited"
+	case RestoreTierBulk:
+		ossTier = "Bulk"
+	default:
+		ossTier = "Standard"
+	}
+
+	_, err := o.client.RestoreObject(ctx, &oss.RestoreObjectRequest{
+		Bucket: oss.Ptr(o.bucket),
+		Key:    oss.Ptr(key),
+		RestoreRequest: &oss.RestoreRequest{
+			Days:          int32(days),
+			JobParameters: &oss.JobParameters{Tier: oss.Ptr(ossTier)},
+		},
+	})
+	if err != nil && !isRestoreInProgress(err) {
+		return fmt.Errorf("failed to restore object: %w", err)
+	}
+	return nil
+}
+
+// RestoreState 查询归档对象的解冻状态（HeadObject 的 x-oss-restore 响应头）
+func (o *OSSStorage) RestoreState(ctx context.Context, key string) (*RestoreState, error) {
+	head, err := o.client.HeadObject(ctx, &oss.HeadObjectRequest{
+		Bucket: oss.Ptr(o.bucket),
+		Key:    oss.Ptr(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object: %w", err)
+	}
+	return parseRestoreHeader(oss.ToString(head.Restore))
+}
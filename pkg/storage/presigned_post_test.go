@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss/credentials"
+)
+
+// decodePostPolicy 解码 Base64 策略文档
+func decodePostPolicy(t *testing.T, encoded string) string {
+	t.Helper()
+
+	document, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("policy is not base64: %v", err)
+	}
+	var policy struct {
+		Expiration string        `json:"expiration"`
+		Conditions []interface{} `json:"conditions"`
+	}
+	if err := json.Unmarshal(document, &policy); err != nil {
+		t.Fatalf("policy is not JSON: %v", err)
+	}
+	return string(document)
+}
+
+// TestS3GeneratePresignedPost 测试 S3 POST 策略包含对象键、Content-Type 和大小条件
+func TestS3GeneratePresignedPost(t *testing.T) {
+	s, err := NewS3Storage(context.Background(), S3Config{
+		Region:          "us-east-1",
+		Bucket:          "assets",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		Endpoint:        "http://localhost:9000",
+		UsePathStyle:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage() error = %v", err)
+	}
+
+	post, err := s.GeneratePresignedPost(context.Background(), "files/a.png", time.Hour, PostPolicy{ContentType: "image/png", MinSize: 1024, MaxSize: 1024})
+	if err != nil {
+		t.Fatalf("GeneratePresignedPost() error = %v", err)
+	}
+
+	if !strings.HasPrefix(post.URL, "http://localhost:9000/assets") {
+		t.Errorf("URL = %q, want path-style bucket URL", post.URL)
+	}
+	if post.Fields["key"] != "files/a.png" || post.Fields["Content-Type"] != "image/png" || post.Fields["X-Amz-Signature"] == "" {
+		t.Errorf("Fields = %v, want key, Content-Type and signature", post.Fields)
+	}
+	document := decodePostPolicy(t, post.Fields["policy"])
+	for _, want := range []string{`["content-length-range",1024,1024]`, `{"Content-Type":"image/png"}`, `{"key":"files/a.png"}`} {
+		if !strings.Contains(document, want) {
+			t.Errorf("policy %s missing %s", document, want)
+		}
+	}
+}
+
+// TestOSSPostFields 测试 OSS PostObject 策略字段和 V4 签名
+func TestOSSPostFields(t *testing.T) {
+	creds := credentials.Credentials{AccessKeyID: "LTAIEXAMPLE", AccessKeySecret: "secret"}
+	now := time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)
+
+	fields, err := ossPostFields(creds, "assets", "cn-hangzhou", "files/a.png", now, time.Hour, PostPolicy{ContentType: "image/png", MinSize: 1, MaxSize: 2048}, nil)
+	if err != nil {
+		t.Fatalf("ossPostFields() error = %v", err)
+	}
+
+	if fields["x-oss-credential"] != "LTAIEXAMPLE/20260301/cn-hangzhou/oss/aliyun_v4_request" || fields["x-oss-date"] != "20260301T083000Z" {
+		t.Errorf("credential fields = %v", fields)
+	}
+	if len(fields["x-oss-signature"]) != 64 {
+		t.Errorf("x-oss-signature = %q, want hex SHA-256", fields["x-oss-signature"])
+	}
+	if _, ok := fields["x-oss-security-token"]; ok {
+		t.Errorf("x-oss-security-token set without STS credentials")
+	}
+
+	document := decodePostPolicy(t, fields["policy"])
+	for _, want := range []string{`"expiration":"2026-03-01T09:30:00.000Z"`, `{"bucket":"assets"}`, `["eq","$key","files/a.png"]`, `["content-length-range",1,2048]`, `["eq","$Content-Type","image/png"]`} {
+		if !strings.Contains(document, want) {
+			t.Errorf("policy %s missing %s", document, want)
+		}
+	}
+
+	// 相同输入生成相同签名，不同密钥签名不同
+	again, _ := ossPostFields(creds, "assets", "cn-hangzhou", "files/a.png", now, time.Hour, PostPolicy{ContentType: "image/png", MinSize: 1, MaxSize: 2048}, nil)
+	creds.AccessKeySecret = "other"
+	other, _ := ossPostFields(creds, "assets", "cn-hangzhou", "files/a.png", now, time.Hour, PostPolicy{ContentType: "image/png", MinSize: 1, MaxSize: 2048}, nil)
+	if again["x-oss-signature"] != fields["x-oss-signature"] || other["x-oss-signature"] == fields["x-oss-signature"] {
+		t.Errorf("signature is not deterministic per secret")
+	}
+}
@@ -0,0 +1,384 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+)
+
+// ErrObjectNotTracked 对象没有对应的文件记录（如打包下载生成的 ZIP），无法记录放置位置或数据密钥
+var ErrObjectNotTracked = errors.New("object has no file record")
+
+// BackendStore 按存储键保存和读取对象所在的后端
+type BackendStore interface {
+	// GetBackend 读取对象所在的后端名称（没有记录时返回空字符串）
+	GetBackend(ctx context.Context, key string) (string, error)
+
+	// PutBackend 记录对象所在的后端（对象没有文件记录时返回 ErrObjectNotTracked）
+	PutBackend(ctx context.Context, key string, backend string) error
+}
+
+// ObjectHints 对象放置提示：存储接口参数之外、用于匹配放置规则的对象属性
+type ObjectHints struct {
+	Size int64    // 文件大小（预签名上传和分片上传时存储接口不带大小）
+	Tags []string // 文件标签
+}
+
+// objectHintsContextKey 上下文中对象放置提示的键
+type objectHintsContextKey struct{}
+
+// WithObjectHints 将对象放置提示放入上下文（写入对象前由调用方设置）
+func WithObjectHints(ctx context.Context, hints ObjectHints) context.Context {
+	return context.WithValue(ctx, objectHintsContextKey{}, hints)
+}
+
+// objectHintsFromContext 读取对象放置提示（未设置时返回零值）
+func objectHintsFromContext(ctx context.Context) ObjectHints {
+	hints, _ := ctx.Value(objectHintsContextKey{}).(ObjectHints)
+	return hints
+}
+
+// routingRule 放置规则
+type routingRule struct {
+	backend      string
+	contentTypes []string
+	minSize      int64
+	maxSize      int64
+	tenants      []string
+	tags         []string
+}
+
+// matches 判断对象是否满足规则的所有非空条件
+func (r routingRule) matches(key string, contentType string, size int64, tags []string) bool {
+	if len(r.contentTypes) > 0 && !matchContentType(r.contentTypes, contentType) {
+		return false
+	}
+	if size < r.minSize || (r.maxSize > 0 && size > r.maxSize) {
+		return false
+	}
+	if len(r.tenants) > 0 {
+		tenant, _, ok := strings.Cut(key, "/")
+		if !ok || !slices.Contains(r.tenants, tenant) {
+			return false
+		}
+	}
+	if len(r.tags) > 0 && !slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(r.tags, tag) }) {
+		return false
+	}
+	return true
+}
+
+// matchContentType 判断内容类型是否匹配（"video/*" 匹配整个主类型，忽略大小写和参数）
+func matchContentType(patterns []string, contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutingStorage 多后端路由存储
+// 新对象按放置规则（内容类型、大小、租户、标签）选择后端，后端名称记录在文件记录上；
+// 之后对该对象的读写、删除和复制都路由到记录的后端（没有记录的对象使用默认后端）
+type RoutingStorage struct {
+	backends       map[string]Storage
+	defaultBackend string
+	rules          []routingRule
+	store          BackendStore
+}
+
+// NewRoutingStorage 创建多后端路由存储
+func NewRoutingStorage(backends map[string]Storage, cfg config.RoutingConfig, store BackendStore) (*RoutingStorage, error) {
+	if _, ok := backends[cfg.Default]; !ok {
+		return nil, fmt.Errorf("invalid storage routing: default backend %q is not configured", cfg.Default)
+	}
+
+	rules := make([]routingRule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		if _, ok := backends[rule.Backend]; !ok {
+			return nil, fmt.Errorf("invalid storage routing rule %d: backend %q is not configured", i+1, rule.Backend)
+		}
+		if rule.MaxSize > 0 && rule.MaxSize < rule.MinSize {
+			return nil, fmt.Errorf("invalid storage routing rule %d: max_size is less than min_size", i+1)
+		}
+		rules[i] = routingRule{
+			backend:      rule.Backend,
+			contentTypes: rule.ContentTypes,
+			minSize:      rule.MinSize,
+			maxSize:      rule.MaxSize,
+			tenants:      rule.Tenants,
+			tags:         rule.Tags,
+		}
+	}
+
+	return &RoutingStorage{backends: backends, defaultBackend: cfg.Default, rules: rules, store: store}, nil
+}
+
+// Place 返回新对象的放置后端（按顺序匹配第一条规则，均不匹配时使用默认后端）
+func (r *RoutingStorage) Place(key string, contentType string, size int64, tags []string) string {
+	for _, rule := range r.rules {
+		if rule.matches(key, contentType, size, tags) {
+			return rule.backend
+		}
+	}
+	return r.defaultBackend
+}
+
+// Backend 按名称返回后端（跨后端复制时直接读写某个后端）
+func (r *RoutingStorage) Backend(name string) (Storage, bool) {
+	backend, ok := r.backends[name]
+	return backend, ok
+}
+
+// DefaultBackend 返回默认后端名称（文件记录上没有后端时对象在默认后端）
+func (r *RoutingStorage) DefaultBackend() string {
+	return r.defaultBackend
+}
+
+// place 选择新对象的后端并记录在文件记录上
+// 没有文件记录的对象（如打包文件）只能写入默认后端，读取时才能找到
+func (r *RoutingStorage) place(ctx context.Context, key string, contentType string, size int64) (Storage, error) {
+	// 优先使用文件大小提示（经压缩、加密等装饰器后，上传大小不再是文件大小）
+	hints := objectHintsFromContext(ctx)
+	if hints.Size > 0 || size < 0 {
+		size = hints.Size
+	}
+
+	name := r.Place(key, contentType, size, hints.Tags)
+	if err := r.store.PutBackend(ctx, key, name); err != nil {
+		if !errors.Is(err, ErrObjectNotTracked) {
+			return nil, fmt.Errorf("failed to record storage backend: %w", err)
+		}
+		name = r.defaultBackend
+	}
+	return r.backends[name], nil
+}
+
+// BackendName 返回已有对象所在的后端名称
+func (r *RoutingStorage) BackendName(ctx context.Context, key string) (string, error) {
+	name, err := r.store.GetBackend(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to load storage backend: %w", err)
+	}
+	if name == "" {
+		name = r.defaultBackend
+	}
+	return name, nil
+}
+
+// backend 返回已有对象所在的后端
+func (r *RoutingStorage) backend(ctx context.Context, key string) (Storage, error) {
+	name, err := r.BackendName(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	backend, ok := r.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("storage backend %q of %s is not configured", name, key)
+	}
+	return backend, nil
+}
+
+// Upload 按放置规则选择后端并上传
+func (r *RoutingStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	backend, err := r.place(ctx, key, contentType, size)
+	if err != nil {
+		return err
+	}
+	return backend.Upload(ctx, key, reader, size, contentType)
+}
+
+// GeneratePresignedUploadURL 按放置规则选择后端并生成上传预签名 URL（文件大小取自 ObjectHints）
+func (r *RoutingStorage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string, checksum *Checksum) (*PresignedRequest, error) {
+	backend, err := r.place(ctx, key, contentType, -1)
+	if err != nil {
+		return nil, err
+	}
+	return backend.GeneratePresignedUploadURL(ctx, key, expiry, contentType, checksum)
+}
+
+// GeneratePresignedPost 按放置规则选择后端并生成表单上传策略
+func (r *RoutingStorage) GeneratePresignedPost(ctx context.Context, key string, expiry time.Duration, policy PostPolicy) (*PresignedPost, error) {
+	backend, err := r.place(ctx, key, policy.ContentType, policy.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	return backend.GeneratePresignedPost(ctx, key, expiry, policy)
+}
+
+// InitMultipartUpload 按放置规则选择后端并初始化分片上传（文件大小取自 ObjectHints）
+func (r *RoutingStorage) InitMultipartUpload(ctx context.Context, key string, contentType string, algorithm ChecksumAlgorithm) (*MultipartUpload, error) {
+	backend, err := r.place(ctx, key, contentType, -1)
+	if err != nil {
+		return nil, err
+	}
+	return backend.InitMultipartUpload(ctx, key, contentType, algorithm)
+}
+
+// GeneratePresignedPartURL 生成分片上传预签名 URL
+func (r *RoutingStorage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration, checksum *Checksum) (*PresignedRequest, error) {
+	backend, err := r.backend(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return backend.GeneratePresignedPartURL(ctx, key, uploadID, partNumber, expiry, checksum)
+}
+
+// CompleteMultipartUpload 完成分片上传
+func (r *RoutingStorage) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error {
+	backend, err := r.backend(ctx, key)
+	if err != nil {
+		return err
+	}
+	return backend.CompleteMultipartUpload(ctx, key, uploadID, parts)
+}
+
+// AbortMultipartUpload 取消分片上传
+func (r *RoutingStorage) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	backend, err := r.backend(ctx, key)
+	if err != nil {
+		return err
+	}
+	return backend.AbortMultipartUpload(ctx, key, uploadID)
+}
+
+// GetObject 从对象所在的后端读取
+func (r *RoutingStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	backend, err := r.backend(ctx, key)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return backend.GetObject(ctx, key)
+}
+
+// GetObjectRange 从对象所在的后端读取部分内容
+func (r *RoutingStorage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	backend, err := r.backend(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return backend.GetObjectRange(ctx, key, offset, length)
+}
+
+// GeneratePresignedDownloadURL 生成对象所在后端的下载预签名 URL
+func (r *RoutingStorage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error) {
+	backend, err := r.backend(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return backend.GeneratePresignedDownloadURL(ctx, key, expiry, opts)
+}
+
+// Delete 从对象所在的后端删除
+func (r *RoutingStorage) Delete(ctx context.Context, key string) error {
+	backend, err := r.backend(ctx, key)
+	if err != nil {
+		return err
+	}
+	return backend.Delete(ctx, key)
+}
+
+// DeleteObjects 按后端分组批量删除
+func (r *RoutingStorage) DeleteObjects(ctx context.Context, keys []string) (map[string]error, error) {
+	failed := make(map[string]error)
+	groups := make(map[string][]string)
+	for _, key := range keys {
+		name, err := r.store.GetBackend(ctx, key)
+		if err != nil {
+			failed[key] = fmt.Errorf("failed to load storage backend: %w", err)
+			continue
+		}
+		if name == "" {
+			name = r.defaultBackend
+		}
+		groups[name] = append(groups[name], key)
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		backend, ok := r.backends[name]
+		if !ok {
+			for _, key := range groups[name] {
+				failed[key] = fmt.Errorf("storage backend %q is not configured", name)
+			}
+			continue
+		}
+		result, err := backend.DeleteObjects(ctx, groups[name])
+		if err != nil {
+			return nil, err
+		}
+		for key, keyErr := range result {
+			failed[key] = keyErr
+		}
+	}
+	return failed, nil
+}
+
+// Copy 在源对象所在的后端内服务端复制（目标对象与源对象在同一后端，由调用方把后端名称复制到目标文件记录）
+func (r *RoutingStorage) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	backend, err := r.backend(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	return backend.Copy(ctx, srcKey, dstKey)
+}
+
+// tiered 返回对象所在的后端（后端不支持存储类型时返回 ErrTieringNotSupported）
+func (r *RoutingStorage) tiered(ctx context.Context, key string) (TieredStorage, error) {
+	backend, err := r.backend(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	tiered, ok := backend.(TieredStorage)
+	if !ok {
+		return nil, ErrTieringNotSupported
+	}
+	return tiered, nil
+}
+
+// SetStorageClass 修改对象所在后端上的存储类型
+func (r *RoutingStorage) SetStorageClass(ctx context.Context, key string, class StorageClass) error {
+	backend, err := r.tiered(ctx, key)
+	if err != nil {
+		return err
+	}
+	return backend.SetStorageClass(ctx, key, class)
+}
+
+// RestoreObject 在对象所在的后端上取回归档对象
+func (r *RoutingStorage) RestoreObject(ctx context.Context, key string, days int, tier RestoreTier) error {
+	backend, err := r.tiered(ctx, key)
+	if err != nil {
+		return err
+	}
+	return backend.RestoreObject(ctx, key, days, tier)
+}
+
+// RestoreState 查询对象所在后端上的取回状态
+func (r *RoutingStorage) RestoreState(ctx context.Context, key string) (*RestoreState, error) {
+	backend, err := r.tiered(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return backend.RestoreState(ctx, key)
+}
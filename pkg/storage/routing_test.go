@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/NanoBoom/asethub/internal/config"
+)
+
+func (m *memStorage) DeleteObjects(ctx context.Context, keys []string) (map[string]error, error) {
+	for _, key := range keys {
+		delete(m.objects, key)
+	}
+	return map[string]error{}, nil
+}
+
+// memBackends 内存后端记录存储（只有登记过的键才有文件记录）
+type memBackends map[string]string
+
+func (m memBackends) GetBackend(ctx context.Context, key string) (string, error) {
+	return m[key], nil
+}
+
+func (m memBackends) PutBackend(ctx context.Context, key string, backend string) error {
+	if _, ok := m[key]; !ok {
+		return ErrObjectNotTracked
+	}
+	m[key] = backend
+	return nil
+}
+
+func newTestRoutingStorage(t *testing.T) (*RoutingStorage, map[string]*memStorage, memBackends) {
+	t.Helper()
+	mems := map[string]*memStorage{
+		"main":    {objects: map[string][]byte{}},
+		"videos":  {objects: map[string][]byte{}},
+		"scratch": {objects: map[string][]byte{}},
+	}
+	backends := make(map[string]Storage, len(mems))
+	for name, mem := range mems {
+		backends[name] = mem
+	}
+	store := memBackends{}
+	r, err := NewRoutingStorage(backends, config.RoutingConfig{
+		Default: "main",
+		Rules: []config.RoutingRuleConfig{
+			{Backend: "videos", ContentTypes: []string{"video/*"}, MinSize: 100},
+			{Backend: "scratch", Tags: []string{"temp"}},
+			{Backend: "scratch", Tenants: []string{"sandbox"}, MaxSize: 50},
+		},
+	}, store)
+	if err != nil {
+		t.Fatalf("NewRoutingStorage() error = %v", err)
+	}
+	return r, mems, store
+}
+
+func TestNewRoutingStorageValidatesBackends(t *testing.T) {
+	backends := map[string]Storage{"main": &memStorage{}}
+	if _, err := NewRoutingStorage(backends, config.RoutingConfig{Default: "missing"}, memBackends{}); err == nil {
+		t.Fatalf("unknown default backend should be rejected")
+	}
+	rules := []config.RoutingRuleConfig{{Backend: "missing"}}
+	if _, err := NewRoutingStorage(backends, config.RoutingConfig{Default: "main", Rules: rules}, memBackends{}); err == nil {
+		t.Fatalf("unknown rule backend should be rejected")
+	}
+}
+
+func TestRoutingStoragePlace(t *testing.T) {
+	r, _, _ := newTestRoutingStorage(t)
+	tests := []struct {
+		key         string
+		contentType string
+		size        int64
+		tags        []string
+		want        string
+	}{
+		{"files/a.mp4", "video/mp4", 1000, nil, "videos"},
+		{"files/a.mp4", "Video/MP4", 1000, nil, "videos"},
+		{"files/a.mp4", "video/mp4", 10, nil, "main"},
+		{"files/a.png", "image/png", 1000, []string{"temp"}, "scratch"},
+		{"sandbox/a.txt", "text/plain", 10, nil, "scratch"},
+		{"sandbox/a.txt", "text/plain", 100, nil, "main"},
+		{"files/a.txt", "text/plain", 10, nil, "main"},
+	}
+	for _, tt := range tests {
+		if got := r.Place(tt.key, tt.contentType, tt.size, tt.tags); got != tt.want {
+			t.Errorf("Place(%q, %q, %d, %v) = %q, want %q", tt.key, tt.contentType, tt.size, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestRoutingStorageRoutesByRecordedBackend(t *testing.T) {
+	ctx := context.Background()
+	r, mems, store := newTestRoutingStorage(t)
+
+	// 有文件记录的对象按规则放置并记录后端（大小取自提示而非上传大小）
+	store["files/a.mp4"] = ""
+	hinted := WithObjectHints(ctx, ObjectHints{Size: 1000})
+	if err := r.Upload(hinted, "files/a.mp4", strings.NewReader("movie"), 5, "video/mp4"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if store["files/a.mp4"] != "videos" || mems["videos"].objects["files/a.mp4"] == nil {
+		t.Fatalf("object placed in %q, want videos", store["files/a.mp4"])
+	}
+
+	body, _, _, err := r.GetObject(ctx, "files/a.mp4")
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	if string(got) != "movie" {
+		t.Fatalf("GetObject() = %q, want movie", got)
+	}
+
+	// 没有文件记录的对象写入默认后端
+	if err := r.Upload(WithObjectHints(ctx, ObjectHints{Tags: []string{"temp"}}), "archives/a.zip", strings.NewReader("zip"), 3, "application/zip"); err != nil {
+		t.Fatalf("Upload() of untracked object error = %v", err)
+	}
+	if mems["main"].objects["archives/a.zip"] == nil {
+		t.Fatalf("untracked object not stored in the default backend")
+	}
+
+	failed, err := r.DeleteObjects(ctx, []string{"files/a.mp4", "archives/a.zip"})
+	if err != nil || len(failed) != 0 {
+		t.Fatalf("DeleteObjects() = %v, %v", failed, err)
+	}
+	if len(mems["videos"].objects) != 0 || len(mems["main"].objects) != 0 {
+		t.Fatalf("DeleteObjects() did not delete from each object's backend")
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	single := &config.StorageConfig{Type: "s3", S3: config.S3Config{Bucket: "assets"}, OSS: config.OSSConfig{Bucket: "unused"}}
+	if got := Buckets(single); len(got) != 1 || got[0] != "assets" {
+		t.Errorf("Buckets(single) = %v, want [assets]", got)
+	}
+
+	// 配置多后端时只使用 backends 中的存储桶
+	multi := &config.StorageConfig{
+		Type: "s3",
+		S3:   config.S3Config{Bucket: "unused"},
+		Backends: map[string]config.BackendConfig{
+			"hot":   {Type: "s3", S3: config.S3Config{Bucket: "hot-assets"}},
+			"cold":  {Type: "oss", OSS: config.OSSConfig{Bucket: "cold-assets"}},
+			"local": {Type: "local"},
+		},
+	}
+	got := strings.Join(Buckets(multi), ",")
+	if len(Buckets(multi)) != 2 || !strings.Contains(got, "hot-assets") || !strings.Contains(got, "cold-assets") {
+		t.Errorf("Buckets(multi) = %s, want hot-assets and cold-assets", got)
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -21,12 +25,22 @@ type S3Config struct {
 	SecretAccessKey string // AWS Secret Access Key（可选，留空使用 IAM 角色）
 	Endpoint        string // 自定义端点（用于 MinIO 等 S3 兼容服务）
 	UsePathStyle    bool   // 是否使用路径风格（MinIO 需要设为 true）
+
+	Encryption *EncryptionPolicy // 服务端加密策略（可选，nil 表示不指定）
 }
 
+const (
+	// s3MaxCopyObjectSize CopyObject 支持的最大对象大小（5GB），超过时使用分片复制
+	s3MaxCopyObjectSize = 5 * 1024 * 1024 * 1024
+	// s3CopyPartSize 分片复制的分片大小
+	s3CopyPartSize = 512 * 1024 * 1024
+)
+
 // S3Storage S3 存储实现
 type S3Storage struct {
-	client *s3.Client
-	bucket string
+	client     *s3.Client
+	bucket     string
+	encryption *EncryptionPolicy
 }
 
 // NewS3Storage 创建 S3 存储实例
@@ -69,18 +83,116 @@ func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
 	client := s3.NewFromConfig(awsCfg, clientOpts...)
 
 	return &S3Storage{
-		client: client,
-		bucket: cfg.Bucket,
+		client:     client,
+		bucket:     cfg.Bucket,
+		encryption: cfg.Encryption,
 	}, nil
 }
 
+// s3SSE S3 请求的服务端加密参数
+type s3SSE struct {
+	serverSideEncryption types.ServerSideEncryption
+	kmsKeyID             *string
+	bucketKeyEnabled     *bool
+	customerAlgorithm    *string // SSE-C 固定为 AES256
+	customerKey          *string
+	customerKeyMD5       *string
+}
+
+// sse 解析对象的服务端加密参数
+func (s *S3Storage) sse(ctx context.Context, key string) (s3SSE, error) {
+	enc, err := s.encryption.resolve(ctx, key)
+	if err != nil {
+		return s3SSE{}, err
+	}
+
+	var sse s3SSE
+	switch enc.Mode {
+	case EncryptionSSES3:
+		sse.serverSideEncryption = types.ServerSideEncryptionAes256
+	case EncryptionSSEKMS:
+		sse.serverSideEncryption = types.ServerSideEncryptionAwsKms
+		if enc.KMSKeyID != "" {
+			sse.kmsKeyID = aws.String(enc.KMSKeyID)
+		}
+		if enc.BucketKey {
+			sse.bucketKeyEnabled = aws.Bool(true)
+		}
+	case EncryptionSSEC:
+		sse.customerAlgorithm = aws.String("AES256")
+		sse.customerKey = aws.String(enc.CustomerKey.Base64())
+		sse.customerKeyMD5 = aws.String(enc.CustomerKey.MD5())
+	}
+	return sse, nil
+}
+
+// withSource 目标键未配置加密时沿用源对象的 SSE-S3 / SSE-KMS 设置（否则复制后使用存储桶默认加密）
+// SSE-C 密钥不会保存在对象上，无法沿用
+func (e s3SSE) withSource(head *s3.HeadObjectOutput) s3SSE {
+	if e.serverSideEncryption != "" || e.customerAlgorithm != nil || head.ServerSideEncryption == "" {
+		return e
+	}
+	e.serverSideEncryption = head.ServerSideEncryption
+	if head.ServerSideEncryption != types.ServerSideEncryptionAes256 {
+		e.kmsKeyID = head.SSEKMSKeyId
+	}
+	if aws.ToBool(head.BucketKeyEnabled) {
+		e.bucketKeyEnabled = aws.Bool(true)
+	}
+	return e
+}
+
+// postFields 返回 POST 表单中的服务端加密字段（SSE-C 密钥须写入表单和策略）
+func (e s3SSE) postFields() map[string]string {
+	fields := make(map[string]string)
+	if e.serverSideEncryption != "" {
+		fields["x-amz-server-side-encryption"] = string(e.serverSideEncryption)
+	}
+	if e.kmsKeyID != nil {
+		fields["x-amz-server-side-encryption-aws-kms-key-id"] = *e.kmsKeyID
+	}
+	if e.bucketKeyEnabled != nil {
+		fields["x-amz-server-side-encryption-bucket-key-enabled"] = "true"
+	}
+	if e.customerAlgorithm != nil {
+		fields["x-amz-server-side-encryption-customer-algorithm"] = *e.customerAlgorithm
+		fields["x-amz-server-side-encryption-customer-key"] = *e.customerKey
+		fields["x-amz-server-side-encryption-customer-key-MD5"] = *e.customerKeyMD5
+	}
+	return fields
+}
+
+// s3PresignedRequest 转换 SDK 的预签名请求（去掉 Host 和 SSE-C 密钥，客户端自行填写密钥）
+func s3PresignedRequest(req *v4.PresignedHTTPRequest) *PresignedRequest {
+	headers := make(map[string]string)
+	for name, values := range req.SignedHeader {
+		name = http.CanonicalHeaderKey(name)
+		if name == "Host" || name == "X-Amz-Server-Side-Encryption-Customer-Key" || len(values) == 0 {
+			continue
+		}
+		headers[name] = values[0]
+	}
+	return &PresignedRequest{URL: req.URL, Headers: headers}
+}
+
 // Upload 直接上传文件（后端代理）
 func (s *S3Storage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return err
+	}
+
 	input := &s3.PutObjectInput{
-		Bucket:        aws.String(s.bucket),
-		Key:           aws.String(key),
-		Body:          reader,
-		ContentLength: aws.Int64(size),
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		Body:                 reader,
+		ContentLength:        aws.Int64(size),
+		ServerSideEncryption: sse.serverSideEncryption,
+		SSEKMSKeyId:          sse.kmsKeyID,
+		BucketKeyEnabled:     sse.bucketKeyEnabled,
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
 	}
 
 	// 设置 Content-Type
@@ -88,7 +200,7 @@ func (s *S3Storage) Upload(ctx context.Context, key string, reader io.Reader, si
 		input.ContentType = aws.String(contentType)
 	}
 
-	_, err := s.client.PutObject(ctx, input)
+	_, err = s.client.PutObject(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to upload object: %w", err)
 	}
@@ -96,12 +208,24 @@ func (s *S3Storage) Upload(ctx context.Context, key string, reader io.Reader, si
 }
 
 // GeneratePresignedUploadURL 生成小文件上传预签名 URL（前端直传）
-func (s *S3Storage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string) (string, error) {
+func (s *S3Storage) GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string, checksum *Checksum) (*PresignedRequest, error) {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
 	presignClient := s3.NewPresignClient(s.client)
 
+	// 加密头作为签名头（客户端上传时须原样携带）
 	input := &s3.PutObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		ServerSideEncryption: sse.serverSideEncryption,
+		SSEKMSKeyId:          sse.kmsKeyID,
+		BucketKeyEnabled:     sse.bucketKeyEnabled,
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
 	}
 
 	// 设置 Content-Type（必须与实际上传时一致，否则 S3 V4 签名验证失败）
@@ -109,21 +233,108 @@ func (s *S3Storage) GeneratePresignedUploadURL(ctx context.Context, key string,
 		input.ContentType = aws.String(contentType)
 	}
 
+	// 签名完整性校验头（S3 校验上传内容，不一致时返回 BadDigest）
+	if checksum != nil {
+		switch checksum.Algorithm {
+		case ChecksumMD5:
+			input.ContentMD5 = aws.String(checksum.Value)
+		case ChecksumCRC32C:
+			input.ChecksumAlgorithm = types.ChecksumAlgorithmCrc32c
+			input.ChecksumCRC32C = aws.String(checksum.Value)
+		case ChecksumSHA256:
+			input.ChecksumAlgorithm = types.ChecksumAlgorithmSha256
+			input.ChecksumSHA256 = aws.String(checksum.Value)
+		}
+	}
+
 	req, err := presignClient.PresignPutObject(ctx, input, func(opts *s3.PresignOptions) {
 		opts.Expires = expiry
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned upload URL: %w", err)
+		return nil, fmt.Errorf("failed to generate presigned upload URL: %w", err)
 	}
 
-	return req.URL, nil
+	return s3PresignedRequest(req), nil
 }
 
-// InitMultipartUpload 初始化分片上传
-func (s *S3Storage) InitMultipartUpload(ctx context.Context, key string, contentType string) (*MultipartUpload, error) {
-	input := &s3.CreateMultipartUploadInput{
+// GeneratePresignedPost 生成预签名 POST 表单上传策略
+func (s *S3Storage) GeneratePresignedPost(ctx context.Context, key string, expiry time.Duration, policy PostPolicy) (*PresignedPost, error) {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	presignClient := s3.NewPresignClient(s.client)
+
+	// SDK 自动添加 bucket、key 和签名相关条件
+	conditions := []interface{}{
+		[]interface{}{"content-length-range", policy.MinSize, policy.MaxSize},
+	}
+	if policy.ContentType != "" {
+		conditions = append(conditions, map[string]string{"Content-Type": policy.ContentType})
+	}
+	extraFields := sse.postFields()
+	for name, value := range s3PostChecksumFields(policy.Checksum) {
+		extraFields[name] = value
+	}
+	for name, value := range extraFields {
+		conditions = append(conditions, map[string]string{name: value})
+	}
+
+	req, err := presignClient.PresignPostObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
+	}, func(opts *s3.PresignPostOptions) {
+		opts.Expires = expiry
+		opts.Conditions = conditions
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate presigned post: %w", err)
+	}
+
+	fields := req.Values
+	if policy.ContentType != "" {
+		fields["Content-Type"] = policy.ContentType
+	}
+	for name, value := range extraFields {
+		fields[name] = value
+	}
+	return &PresignedPost{URL: req.URL, Fields: fields}, nil
+}
+
+// s3PostChecksumFields 返回 POST 表单中的完整性校验字段
+func s3PostChecksumFields(checksum *Checksum) map[string]string {
+	if checksum == nil {
+		return nil
+	}
+	switch checksum.Algorithm {
+	case ChecksumMD5:
+		return map[string]string{"Content-MD5": checksum.Value}
+	case ChecksumCRC32C:
+		return map[string]string{"x-amz-checksum-algorithm": "CRC32C", "x-amz-checksum-crc32c": checksum.Value}
+	case ChecksumSHA256:
+		return map[string]string{"x-amz-checksum-algorithm": "SHA256", "x-amz-checksum-sha256": checksum.Value}
+	}
+	return nil
+}
+
+// InitMultipartUpload 初始化分片上传
+func (s *S3Storage) InitMultipartUpload(ctx context.Context, key string, contentType string, algorithm ChecksumAlgorithm) (*MultipartUpload, error) {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// 服务端加密在初始化时设置（SSE-C 的每个分片还须携带相同密钥）
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		ServerSideEncryption: sse.serverSideEncryption,
+		SSEKMSKeyId:          sse.kmsKeyID,
+		BucketKeyEnabled:     sse.bucketKeyEnabled,
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
 	}
 
 	// 设置 Content-Type（必须在初始化时设置）
@@ -131,6 +342,14 @@ func (s *S3Storage) InitMultipartUpload(ctx context.Context, key string, content
 		input.ContentType = aws.String(contentType)
 	}
 
+	// CRC32C/SHA256 分片校验须在初始化时声明（S3 据此计算组合校验值），MD5 使用 Content-MD5 无需声明
+	switch algorithm {
+	case ChecksumCRC32C:
+		input.ChecksumAlgorithm = types.ChecksumAlgorithmCrc32c
+	case ChecksumSHA256:
+		input.ChecksumAlgorithm = types.ChecksumAlgorithmSha256
+	}
+
 	output, err := s.client.CreateMultipartUpload(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init multipart upload: %w", err)
@@ -144,26 +363,55 @@ func (s *S3Storage) InitMultipartUpload(ctx context.Context, key string, content
 }
 
 // GeneratePresignedPartURL 生成分片上传预签名 URL
-func (s *S3Storage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration) (string, error) {
+func (s *S3Storage) GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration, checksum *Checksum) (*PresignedRequest, error) {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
 	presignClient := s3.NewPresignClient(s.client)
 
-	req, err := presignClient.PresignUploadPart(ctx, &s3.UploadPartInput{
-		Bucket:     aws.String(s.bucket),
-		Key:        aws.String(key),
-		UploadId:   aws.String(uploadID),
-		PartNumber: aws.Int32(int32(partNumber)),
-	}, func(opts *s3.PresignOptions) {
+	input := &s3.UploadPartInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		UploadId:             aws.String(uploadID),
+		PartNumber:           aws.Int32(int32(partNumber)),
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
+	}
+
+	// 签名分片校验头
+	if checksum != nil {
+		switch checksum.Algorithm {
+		case ChecksumMD5:
+			input.ContentMD5 = aws.String(checksum.Value)
+		case ChecksumCRC32C:
+			input.ChecksumAlgorithm = types.ChecksumAlgorithmCrc32c
+			input.ChecksumCRC32C = aws.String(checksum.Value)
+		case ChecksumSHA256:
+			input.ChecksumAlgorithm = types.ChecksumAlgorithmSha256
+			input.ChecksumSHA256 = aws.String(checksum.Value)
+		}
+	}
+
+	req, err := presignClient.PresignUploadPart(ctx, input, func(opts *s3.PresignOptions) {
 		opts.Expires = expiry
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned part URL: %w", err)
+		return nil, fmt.Errorf("failed to generate presigned part URL: %w", err)
 	}
 
-	return req.URL, nil
+	return s3PresignedRequest(req), nil
 }
 
 // CompleteMultipartUpload 完成分片上传
 func (s *S3Storage) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return err
+	}
+
 	// 转换为 S3 类型
 	completedParts := make([]types.CompletedPart, len(parts))
 	for i, part := range parts {
@@ -171,15 +419,26 @@ func (s *S3Storage) CompleteMultipartUpload(ctx context.Context, key string, upl
 			PartNumber: aws.Int32(int32(part.PartNumber)),
 			ETag:       aws.String(part.ETag),
 		}
+		if part.Checksum != nil {
+			switch part.Checksum.Algorithm {
+			case ChecksumCRC32C:
+				completedParts[i].ChecksumCRC32C = aws.String(part.Checksum.Value)
+			case ChecksumSHA256:
+				completedParts[i].ChecksumSHA256 = aws.String(part.Checksum.Value)
+			}
+		}
 	}
 
-	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
 		Bucket:   aws.String(s.bucket),
 		Key:      aws.String(key),
 		UploadId: aws.String(uploadID),
 		MultipartUpload: &types.CompletedMultipartUpload{
 			Parts: completedParts,
 		},
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to complete multipart upload: %w", err)
@@ -188,14 +447,36 @@ func (s *S3Storage) CompleteMultipartUpload(ctx context.Context, key string, upl
 	return nil
 }
 
+// AbortMultipartUpload 取消分片上传
+func (s *S3Storage) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	return nil
+}
+
 // GetObject 获取对象内容（流式读取）
 func (s *S3Storage) GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
 	})
 	if err != nil {
-		return nil, "", 0, fmt.Errorf("failed to get object: %w", err)
+		return nil, "", 0, fmt.Errorf("failed to get object: %w", wrapNotFound(err))
 	}
 
 	// 提取 Content-Type
@@ -213,13 +494,47 @@ func (s *S3Storage) GetObject(ctx context.Context, key string) (io.ReadCloser, s
 	return result.Body, contentType, contentLength, nil
 }
 
+// GetObjectRange 获取对象的部分内容
+func (s *S3Storage) GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		Range:                aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
+	})
+	if isInvalidRange(err) {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object range: %w", wrapNotFound(err))
+	}
+
+	return result.Body, nil
+}
+
 // GeneratePresignedDownloadURL 生成下载预签名 URL
-func (s *S3Storage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (string, error) {
+func (s *S3Storage) GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error) {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
 	presignClient := s3.NewPresignClient(s.client)
 
+	// SSE-C 对象的下载请求须携带客户密钥（SSE-S3/KMS 由 S3 透明解密）
 	input := &s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
 	}
 
 	// 设置响应头选项
@@ -227,8 +542,11 @@ func (s *S3Storage) GeneratePresignedDownloadURL(ctx context.Context, key string
 		if opts.ContentType != "" {
 			input.ResponseContentType = aws.String(opts.ContentType)
 		}
-		if opts.ContentDisposition != "" {
-			input.ResponseContentDisposition = aws.String(opts.ContentDisposition)
+		if disposition := opts.responseContentDisposition(); disposition != "" {
+			input.ResponseContentDisposition = aws.String(disposition)
+		}
+		if opts.CacheControl != "" {
+			input.ResponseCacheControl = aws.String(opts.CacheControl)
 		}
 	}
 
@@ -236,10 +554,10 @@ func (s *S3Storage) GeneratePresignedDownloadURL(ctx context.Context, key string
 		opts.Expires = expiry
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned download URL: %w", err)
+		return nil, fmt.Errorf("failed to generate presigned download URL: %w", err)
 	}
 
-	return req.URL, nil
+	return s3PresignedRequest(req), nil
 }
 
 // Delete 删除对象
@@ -254,3 +572,258 @@ func (s *S3Storage) Delete(ctx context.Context, key string) error {
 
 	return nil
 }
+
+// DeleteObjects 批量删除对象
+func (s *S3Storage) DeleteObjects(ctx context.Context, keys []string) (map[string]error, error) {
+	failed := make(map[string]error)
+
+	for _, chunk := range chunkKeys(keys) {
+		objects := make([]types.ObjectIdentifier, len(chunk))
+		for i, key := range chunk {
+			objects[i] = types.ObjectIdentifier{Key: aws.String(key)}
+		}
+
+		// Quiet 模式：只返回删除失败的对象
+		output, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &types.Delete{
+				Objects: objects,
+				Quiet:   aws.Bool(true),
+			},
+		})
+		if err != nil {
+			for _, key := range chunk {
+				failed[key] = fmt.Errorf("failed to delete objects: %w", err)
+			}
+			continue
+		}
+
+		for _, e := range output.Errors {
+			failed[aws.ToString(e.Key)] = fmt.Errorf("%s: %s", aws.ToString(e.Code), aws.ToString(e.Message))
+		}
+	}
+
+	return failed, nil
+}
+
+// Copy 服务端复制对象
+func (s *S3Storage) Copy(ctx context.Context, srcKey string, dstKey string) error {
+	return s.copyObject(ctx, srcKey, dstKey, "")
+}
+
+// copyObject 服务端复制对象（storageClass 为空时使用存储桶默认存储类型）
+func (s *S3Storage) copyObject(ctx context.Context, srcKey string, dstKey string, storageClass types.StorageClass) error {
+	// 源对象和目标对象可能属于不同租户，分别解析加密参数（目标对象按目标键的策略重新加密）
+	srcSSE, err := s.sse(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	dstSSE, err := s.sse(ctx, dstKey)
+	if err != nil {
+		return err
+	}
+
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(srcKey),
+		SSECustomerAlgorithm: srcSSE.customerAlgorithm,
+		SSECustomerKey:       srcSSE.customerKey,
+		SSECustomerKeyMD5:    srcSSE.customerKeyMD5,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to head source object: %w", err)
+	}
+	dstSSE = dstSSE.withSource(head)
+
+	copySource := s.copySource(srcKey)
+	size := aws.ToInt64(head.ContentLength)
+
+	// 小于 5GB 直接使用 CopyObject（自动保留 Content-Type 等元数据）
+	if size <= s3MaxCopyObjectSize {
+		_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:                         aws.String(s.bucket),
+			Key:                            aws.String(dstKey),
+			CopySource:                     aws.String(copySource),
+			StorageClass:                   storageClass,
+			ServerSideEncryption:           dstSSE.serverSideEncryption,
+			SSEKMSKeyId:                    dstSSE.kmsKeyID,
+			BucketKeyEnabled:               dstSSE.bucketKeyEnabled,
+			SSECustomerAlgorithm:           dstSSE.customerAlgorithm,
+			SSECustomerKey:                 dstSSE.customerKey,
+			SSECustomerKeyMD5:              dstSSE.customerKeyMD5,
+			CopySourceSSECustomerAlgorithm: srcSSE.customerAlgorithm,
+			CopySourceSSECustomerKey:       srcSSE.customerKey,
+			CopySourceSSECustomerKeyMD5:    srcSSE.customerKeyMD5,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to copy object: %w", err)
+		}
+		return nil
+	}
+
+	// 大对象使用分片复制（元数据须从源对象显式带上）
+	output, err := s.client.CreateMultipartUpload(ctx, multipartCopyInput(s.bucket, dstKey, head, storageClass, dstSSE))
+	if err != nil {
+		return fmt.Errorf("failed to init multipart copy: %w", err)
+	}
+	uploadID := output.UploadId
+
+	var parts []types.CompletedPart
+	for start, partNumber := int64(0), int32(1); start < size; start, partNumber = start+s3CopyPartSize, partNumber+1 {
+		end := start + s3CopyPartSize - 1
+		if end >= size {
+			end = size - 1
+		}
+
+		part, err := s.client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:          aws.String(s.bucket),
+			Key:             aws.String(dstKey),
+			UploadId:        uploadID,
+			PartNumber:      aws.Int32(partNumber),
+			CopySource:      aws.String(copySource),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+
+			SSECustomerAlgorithm:           dstSSE.customerAlgorithm,
+			SSECustomerKey:                 dstSSE.customerKey,
+			SSECustomerKeyMD5:              dstSSE.customerKeyMD5,
+			CopySourceSSECustomerAlgorithm: srcSSE.customerAlgorithm,
+			CopySourceSSECustomerKey:       srcSSE.customerKey,
+			CopySourceSSECustomerKeyMD5:    srcSSE.customerKeyMD5,
+		})
+		if err != nil {
+			s.abortMultipartUpload(dstKey, uploadID)
+			return fmt.Errorf("failed to copy part %d: %w", partNumber, err)
+		}
+
+		parts = append(parts, types.CompletedPart{
+			PartNumber: aws.Int32(partNumber),
+			ETag:       part.CopyPartResult.ETag,
+		})
+	}
+
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(dstKey),
+		UploadId:             uploadID,
+		MultipartUpload:      &types.CompletedMultipartUpload{Parts: parts},
+		SSECustomerAlgorithm: dstSSE.customerAlgorithm,
+		SSECustomerKey:       dstSSE.customerKey,
+		SSECustomerKeyMD5:    dstSSE.customerKeyMD5,
+	})
+	if err != nil {
+		s.abortMultipartUpload(dstKey, uploadID)
+		return fmt.Errorf("failed to complete multipart copy: %w", err)
+	}
+
+	return nil
+}
+
+// multipartCopyInput 分片复制的初始化参数
+// CopyObject 会自动保留源对象的元数据，分片复制只能从 HeadObject 的结果逐项带上
+func multipartCopyInput(bucket, dstKey string, head *s3.HeadObjectOutput, storageClass types.StorageClass, sse s3SSE) *s3.CreateMultipartUploadInput {
+	return &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(dstKey),
+		ContentType:          head.ContentType,
+		CacheControl:         head.CacheControl,
+		ContentDisposition:   head.ContentDisposition,
+		ContentEncoding:      head.ContentEncoding,
+		ContentLanguage:      head.ContentLanguage,
+		Expires:              head.Expires,
+		Metadata:             head.Metadata,
+		StorageClass:         storageClass,
+		ServerSideEncryption: sse.serverSideEncryption,
+		SSEKMSKeyId:          sse.kmsKeyID,
+		BucketKeyEnabled:     sse.bucketKeyEnabled,
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
+	}
+}
+
+// s3StorageClasses 存储类型对应的 S3 存储类型
+var s3StorageClasses = map[StorageClass]types.StorageClass{
+	StorageClassStandard:         types.StorageClassStandard,
+	StorageClassInfrequentAccess: types.StorageClassStandardIa,
+	StorageClassArchive:          types.StorageClassGlacier,
+	StorageClassColdArchive:      types.StorageClassDeepArchive,
+}
+
+// SetStorageClass 修改对象的存储类型（复制到原键，大对象使用分片复制）
+func (s *S3Storage) SetStorageClass(ctx context.Context, key string, class StorageClass) error {
+	storageClass, ok := s3StorageClasses[class]
+	if !ok {
+		return fmt.Errorf("unsupported storage class: %s", class)
+	}
+	if err := s.copyObject(ctx, key, key, storageClass); err != nil {
+		return fmt.Errorf("failed to change storage class: %w", err)
+	}
+	return nil
+}
+
+// RestoreObject 取回归档对象（S3 Glacier / Deep Archive）
+func (s *S3Storage) RestoreObject(ctx context.Context, key string, days int, tier RestoreTier) error {
+	var glacierTier types.Tier
+	switch tier {
+	case RestoreTierExpedited:
+		glacierTier = types.TierExpedited
+	case RestoreTierBulk:
+		glacierTier = types.TierBulk
+	default:
+		glacierTier = types.TierStandard
+	}
+
+	_, err := s.client.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		RestoreRequest: &types.RestoreRequest{
+			Days:                 aws.Int32(int32(days)),
+			GlacierJobParameters: &types.GlacierJobParameters{Tier: glacierTier},
+		},
+	})
+	if err != nil && !isRestoreInProgress(err) {
+		return fmt.Errorf("failed to restore object: %w", err)
+	}
+	return nil
+}
+
+// RestoreState 查询归档对象的取回状态（HeadObject 的 x-amz-restore 响应头）
+func (s *S3Storage) RestoreState(ctx context.Context, key string) (*RestoreState, error) {
+	sse, err := s.sse(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		SSECustomerAlgorithm: sse.customerAlgorithm,
+		SSECustomerKey:       sse.customerKey,
+		SSECustomerKeyMD5:    sse.customerKeyMD5,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object: %w", err)
+	}
+	return parseRestoreHeader(aws.ToString(head.Restore))
+}
+
+// copySource 构造 CopySource（bucket/key，key 按路径段 URL 编码）
+func (s *S3Storage) copySource(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.bucket + "/" + strings.Join(segments, "/")
+}
+
+// abortMultipartUpload 取消分片上传（清理已上传的分片，使用独立上下文避免请求取消导致清理失败）
+func (s *S3Storage) abortMultipartUpload(key string, uploadID *string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, _ = s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+}
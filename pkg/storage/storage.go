@@ -2,11 +2,13 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"time"
 
 	"github.com/NanoBoom/asethub/internal/config"
+	"github.com/NanoBoom/asethub/pkg/utils"
 )
 
 // MultipartUpload 分片上传信息
@@ -18,19 +20,53 @@ type MultipartUpload struct {
 
 // CompletedPart 已完成的分片信息
 type CompletedPart struct {
-	PartNumber int    // 分片编号（从 1 开始）
-	ETag       string // S3 返回的 ETag
+	PartNumber int       // 分片编号（从 1 开始）
+	ETag       string    // S3 返回的 ETag
+	Checksum   *Checksum // 分片校验值（分片上传声明了校验算法时必填）
+}
+
+// PostPolicy 预签名 POST 表单上传的策略条件
+// 与预签名 PUT 不同，POST 策略由存储服务强制校验文件大小
+type PostPolicy struct {
+	ContentType string    // 要求的 Content-Type（精确匹配，为空时不限制）
+	MinSize     int64     // 最小文件大小（字节）
+	MaxSize     int64     // 最大文件大小（字节）
+	Checksum    *Checksum // 要求的完整性校验值（可选）
+}
+
+// PresignedPost 预签名 POST 表单上传信息（浏览器表单直传）
+type PresignedPost struct {
+	URL    string            // 表单提交地址（POST multipart/form-data）
+	Fields map[string]string // 表单字段（须在 file 字段之前提交）
+}
+
+// PresignedRequest 预签名请求
+// 部分请求头（Content-Type、校验值、服务端加密）被签入 URL，客户端须原样携带，否则签名验证失败
+type PresignedRequest struct {
+	URL     string            // 预签名 URL
+	Headers map[string]string // 客户端须携带的已签名请求头（不含 Host；SSE-C 密钥本身由客户端自行填写）
 }
 
 // PresignOptions 预签名 URL 选项
-// 用于设置下载 URL 的响应头，控制浏览器行为（预览 vs 下载）
+// 用于设置下载 URL 的响应头，控制浏览器行为（预览 vs 下载）和缓存策略
 type PresignOptions struct {
 	ContentType        string // 响应 Content-Type（如 "image/png"）
-	ContentDisposition string // 响应 Content-Disposition（"inline" 预览 / "attachment" 下载）
+	ContentDisposition string // 响应 Content-Disposition 类型（"inline" 预览 / "attachment" 下载）
+	Filename           string // 下载文件名（按 RFC 6266 编码，支持中文等非 ASCII 文件名）
+	CacheControl       string // 响应 Cache-Control（如 "private, max-age=300"）
+}
+
+// responseContentDisposition 构造响应 Content-Disposition（未设置类型时返回空）
+func (o *PresignOptions) responseContentDisposition() string {
+	if o == nil || o.ContentDisposition == "" {
+		return ""
+	}
+	return utils.ContentDisposition(o.ContentDisposition, o.Filename)
 }
 
 // Storage 统一存储接口
 // 抽象云存储后端（S3/OSS/本地存储），提供文件上传、下载、预签名 URL 生成等核心能力
+// 写入对象时按加密策略设置服务端加密；SSE-C 对象的所有读写请求须在上下文中携带客户密钥（WithCustomerKey）
 type Storage interface {
 	// === 小文件上传（< 100MB）===
 
@@ -52,8 +88,19 @@ type Storage interface {
 	//   - key: 对象键（存储路径）
 	//   - expiry: URL 过期时间（建议 1 小时）
 	//   - contentType: 文件 MIME 类型（必须与实际上传时一致，否则签名验证失败）
-	// 返回：预签名 URL、错误信息
-	GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string) (string, error)
+	//   - checksum: 完整性校验值（可选，签名后客户端须携带相同的 Content-MD5 / x-amz-checksum-* 头，内容不一致时存储服务拒绝上传）
+	// 返回：预签名请求（URL 和须携带的请求头，包括服务端加密头）、错误信息
+	GeneratePresignedUploadURL(ctx context.Context, key string, expiry time.Duration, contentType string, checksum *Checksum) (*PresignedRequest, error)
+
+	// GeneratePresignedPost 生成预签名 POST 表单上传策略（S3 POST Policy / OSS PostObject）
+	// 适用场景：浏览器表单直传，需要由存储服务强制限制文件大小
+	// 参数：
+	//   - ctx: 上下文
+	//   - key: 对象键（策略要求精确匹配）
+	//   - expiry: 策略过期时间
+	//   - policy: 策略条件（Content-Type、文件大小范围）
+	// 返回：表单提交地址和字段（包括服务端加密字段）、错误信息
+	GeneratePresignedPost(ctx context.Context, key string, expiry time.Duration, policy PostPolicy) (*PresignedPost, error)
 
 	// === 大文件分片上传（>= 100MB）===
 
@@ -63,8 +110,9 @@ type Storage interface {
 	//   - ctx: 上下文
 	//   - key: 对象键（存储路径）
 	//   - contentType: 文件 MIME 类型（如 "video/mp4"）
+	//   - algorithm: 分片校验算法（可选，S3 的 CRC32C/SHA256 须在初始化时声明）
 	// 返回：分片上传信息（包含 upload ID 和预签名 URL 列表）、错误信息
-	InitMultipartUpload(ctx context.Context, key string, contentType string) (*MultipartUpload, error)
+	InitMultipartUpload(ctx context.Context, key string, contentType string, algorithm ChecksumAlgorithm) (*MultipartUpload, error)
 
 	// GeneratePresignedPartURL 生成分片上传预签名 URL
 	// 参数：
@@ -73,18 +121,27 @@ type Storage interface {
 	//   - uploadID: 分片上传 ID
 	//   - partNumber: 分片编号（从 1 开始）
 	//   - expiry: URL 过期时间
-	// 返回：预签名 URL、错误信息
-	GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration) (string, error)
+	//   - checksum: 分片校验值（可选，签名方式同 GeneratePresignedUploadURL）
+	// 返回：预签名请求（SSE-C 对象的分片须携带客户密钥头）、错误信息
+	GeneratePresignedPartURL(ctx context.Context, key string, uploadID string, partNumber int, expiry time.Duration, checksum *Checksum) (*PresignedRequest, error)
 
 	// CompleteMultipartUpload 完成分片上传
 	// 参数：
 	//   - ctx: 上下文
 	//   - key: 对象键
 	//   - uploadID: 分片上传 ID
-	//   - parts: 已完成的分片列表（必须按 part number 排序）
+	//   - parts: 已完成的分片列表（必须按 part number 排序，带校验值时由存储服务核对分片）
 	// 返回：错误信息
 	CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error
 
+	// AbortMultipartUpload 取消分片上传（清理已上传的分片）
+	// 参数：
+	//   - ctx: 上下文
+	//   - key: 对象键
+	//   - uploadID: 分片上传 ID
+	// 返回：错误信息
+	AbortMultipartUpload(ctx context.Context, key string, uploadID string) error
+
 	// === 通用操作 ===
 
 	// GetObject 获取对象内容（流式读取）
@@ -95,14 +152,24 @@ type Storage interface {
 	// 返回：io.ReadCloser（文件流，使用后必须关闭）、Content-Type、Content-Length、错误信息
 	GetObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error)
 
+	// GetObjectRange 获取对象的部分内容（HTTP Range 读取）
+	// 适用场景：读取文件头识别真实内容类型，无需下载整个对象
+	// 参数：
+	//   - ctx: 上下文
+	//   - key: 对象键
+	//   - offset: 起始字节
+	//   - length: 读取长度（超出对象大小时返回到对象末尾的内容，空对象返回空内容）
+	// 返回：io.ReadCloser（使用后必须关闭）、错误信息
+	GetObjectRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+
 	// GeneratePresignedDownloadURL 生成下载预签名 URL
 	// 参数：
 	//   - ctx: 上下文
 	//   - key: 对象键
 	//   - expiry: URL 过期时间（建议 15 分钟）
 	//   - opts: 响应头选项（可选，传 nil 使用默认行为）
-	// 返回：预签名 URL、错误信息
-	GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (string, error)
+	// 返回：预签名请求（SSE-C 对象须携带客户密钥头）、错误信息
+	GeneratePresignedDownloadURL(ctx context.Context, key string, expiry time.Duration, opts *PresignOptions) (*PresignedRequest, error)
 
 	// Delete 删除对象
 	// 参数：
@@ -110,11 +177,114 @@ type Storage interface {
 	//   - key: 对象键
 	// 返回：错误信息
 	Delete(ctx context.Context, key string) error
+
+	// DeleteObjects 批量删除对象（S3 DeleteObjects / OSS DeleteMultipleObjects）
+	// 实现需按后端上限（MaxDeleteObjects）自动分批，单批请求失败时该批所有对象记为失败
+	// 参数：
+	//   - ctx: 上下文
+	//   - keys: 对象键列表
+	// 返回：删除失败的对象（key -> 失败原因，全部成功时为空）、错误信息
+	DeleteObjects(ctx context.Context, keys []string) (map[string]error, error)
+
+	// Copy 服务端复制对象（不经过后端中转数据）
+	// 大对象由实现自动切换为分片复制（S3 UploadPartCopy / OSS Copier）
+	// 参数：
+	//   - ctx: 上下文
+	//   - srcKey: 源对象键
+	//   - dstKey: 目标对象键（保留源对象的 Content-Type）
+	// 返回：错误信息
+	Copy(ctx context.Context, srcKey string, dstKey string) error
+}
+
+// ErrPresignNotSupported 对象经存储装饰器转换（客户端加密、透明压缩）后保存，不支持预签名 URL，须经后端读写
+var ErrPresignNotSupported = errors.New("presigned URLs are not supported")
+
+// ErrNotFound 对象不存在（S3 / OSS 的 NoSuchKey、本地文件不存在）
+var ErrNotFound = errors.New("object not found")
+
+// MaxDeleteObjects 单次批量删除请求的对象数上限（S3 与 OSS 均为 1000）
+const MaxDeleteObjects = 1000
+
+// chunkKeys 将对象键按批量删除上限分组
+func chunkKeys(keys []string) [][]string {
+	var chunks [][]string
+	for start := 0; start < len(keys); start += MaxDeleteObjects {
+		end := start + MaxDeleteObjects
+		if end > len(keys) {
+			end = len(keys)
+		}
+		chunks = append(chunks, keys[start:end])
+	}
+	return chunks
+}
+
+// isInvalidRange 判断是否为 Range 超出对象大小的错误（S3 与 OSS 错误码均为 InvalidRange，空对象读取时出现）
+func isInvalidRange(err error) bool {
+	var coded interface{ ErrorCode() string }
+	return errors.As(err, &coded) && coded.ErrorCode() == "InvalidRange"
+}
+
+// wrapNotFound 对象不存在时在错误链中加入 ErrNotFound（S3 与 OSS 错误码均为 NoSuchKey，HEAD 请求为 NotFound）
+// 不按 404 状态码判断：存储桶不存在（NoSuchBucket）同样返回 404，属于后端故障
+func wrapNotFound(err error) error {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) && (coded.ErrorCode() == "NoSuchKey" || coded.ErrorCode() == "NotFound") {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
 }
 
 // NewStorage 根据配置创建存储实例（工厂函数）
-// 这是唯一需要修改的地方，添加新存储类型时只需在此处添加 case
-func NewStorage(ctx context.Context, cfg *config.StorageConfig) (Storage, error) {
+// 未配置 storage.backends 时使用 type 指定的单一后端；配置后创建多后端路由存储，后端名称记录在 backends 中
+// 这是唯一需要修改的地方，添加新存储类型时只需在 newBackend 中添加 case
+func NewStorage(ctx context.Context, cfg *config.StorageConfig, backends BackendStore) (Storage, error) {
+	encryption, err := NewEncryptionPolicy(cfg.Encryption)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Backends) == 0 {
+		return newBackend(ctx, config.BackendConfig{Type: cfg.Type, S3: cfg.S3, OSS: cfg.OSS, Local: cfg.Local}, encryption)
+	}
+
+	if backends == nil {
+		return nil, fmt.Errorf("multiple storage backends require a backend store")
+	}
+	named := make(map[string]Storage, len(cfg.Backends))
+	for name, backendCfg := range cfg.Backends {
+		backend, err := newBackend(ctx, backendCfg, encryption)
+		if err != nil {
+			return nil, fmt.Errorf("storage backend %s: %w", name, err)
+		}
+		named[name] = backend
+	}
+	return NewRoutingStorage(named, cfg.Routing, backends)
+}
+
+// Buckets 返回配置中 S3 / OSS 后端使用的存储桶名称（本地存储没有存储桶）
+func Buckets(cfg *config.StorageConfig) []string {
+	backends := []config.BackendConfig{{Type: cfg.Type, S3: cfg.S3, OSS: cfg.OSS}}
+	if len(cfg.Backends) > 0 {
+		backends = backends[:0]
+		for _, backendCfg := range cfg.Backends {
+			backends = append(backends, backendCfg)
+		}
+	}
+
+	var buckets []string
+	for _, backendCfg := range backends {
+		switch backendCfg.Type {
+		case "s3":
+			buckets = append(buckets, backendCfg.S3.Bucket)
+		case "oss":
+			buckets = append(buckets, backendCfg.OSS.Bucket)
+		}
+	}
+	return buckets
+}
+
+// newBackend 创建单个存储后端
+func newBackend(ctx context.Context, cfg config.BackendConfig, encryption *EncryptionPolicy) (Storage, error) {
 	switch cfg.Type {
 	case "s3":
 		s3Config := S3Config{
@@ -124,6 +294,7 @@ func NewStorage(ctx context.Context, cfg *config.StorageConfig) (Storage, error)
 			SecretAccessKey: cfg.S3.SecretAccessKey,
 			Endpoint:        cfg.S3.Endpoint,
 			UsePathStyle:    cfg.S3.UsePathStyle,
+			Encryption:      encryption,
 		}
 		return NewS3Storage(ctx, s3Config)
 
@@ -133,12 +304,16 @@ func NewStorage(ctx context.Context, cfg *config.StorageConfig) (Storage, error)
 			Bucket:          cfg.OSS.Bucket,
 			AccessKeyID:     cfg.OSS.AccessKeyID,
 			AccessKeySecret: cfg.OSS.AccessKeySecret,
+			Encryption:      encryption,
 		}
 		return NewOSSStorage(ctx, ossConfig)
 
 	case "local":
-		// TODO: 实现本地存储
-		return nil, fmt.Errorf("local storage not implemented yet")
+		// 本地文件没有服务端加密（可使用客户端加密）
+		if encryption.Uses(EncryptionSSES3) || encryption.Uses(EncryptionSSEKMS) || encryption.Uses(EncryptionSSEC) {
+			return nil, fmt.Errorf("server-side encryption is not supported by local storage")
+		}
+		return NewLocalStorage(cfg.Local.BasePath)
 
 	default:
 		return nil, fmt.Errorf("unsupported storage type: %s (supported: s3, oss, local)", cfg.Type)
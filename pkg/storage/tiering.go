@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StorageClass 与后端无关的存储类型（按存储成本从高到低排列，越往后访问成本越高）
+type StorageClass string
+
+const (
+	StorageClassStandard         StorageClass = "standard"          // S3 STANDARD / OSS Standard
+	StorageClassInfrequentAccess StorageClass = "infrequent_access" // S3 STANDARD_IA / OSS IA
+	StorageClassArchive          StorageClass = "archive"           // S3 GLACIER / OSS Archive（读取前须取回）
+	StorageClassColdArchive      StorageClass = "cold_archive"      // S3 DEEP_ARCHIVE / OSS ColdArchive（读取前须取回）
+)
+
+// storageClassRank 存储类型的冷热顺序
+var storageClassRank = map[StorageClass]int{
+	StorageClassStandard:         0,
+	StorageClassInfrequentAccess: 1,
+	StorageClassArchive:          2,
+	StorageClassColdArchive:      3,
+}
+
+// ParseStorageClass 解析存储类型（为空表示 standard）
+func ParseStorageClass(s string) (StorageClass, error) {
+	if s == "" {
+		return StorageClassStandard, nil
+	}
+	class := StorageClass(strings.ToLower(s))
+	if _, ok := storageClassRank[class]; !ok {
+		return "", fmt.Errorf("unsupported storage class: %s (supported: standard, infrequent_access, archive, cold_archive)", s)
+	}
+	return class, nil
+}
+
+// ColderThan 判断存储类型是否比 other 更冷
+func (c StorageClass) ColderThan(other StorageClass) bool {
+	return storageClassRank[c] > storageClassRank[other]
+}
+
+// RequiresRestore 判断该存储类型的对象是否须取回后才能读取
+func (c StorageClass) RequiresRestore() bool {
+	return c == StorageClassArchive || c == StorageClassColdArchive
+}
+
+// RestoreTier 归档取回优先级（越快费用越高）
+type RestoreTier string
+
+const (
+	RestoreTierExpedited RestoreTier = "expedited" // 加急
+	RestoreTierStandard  RestoreTier = "standard"  // 标准
+	RestoreTierBulk      RestoreTier = "bulk"      // 批量
+)
+
+// ParseRestoreTier 解析取回优先级（为空表示 standard）
+func ParseRestoreTier(s string) (RestoreTier, error) {
+	switch tier := RestoreTier(strings.ToLower(s)); tier {
+	case "":
+		return RestoreTierStandard, nil
+	case RestoreTierExpedited, RestoreTierStandard, RestoreTierBulk:
+		return tier, nil
+	default:
+		return "", fmt.Errorf("unsupported restore tier: %s (supported: expedited, standard, bulk)", s)
+	}
+}
+
+// RestoreState 归档对象的取回状态
+type RestoreState struct {
+	InProgress bool       // 取回进行中
+	ExpiresAt  *time.Time // 已取回副本的过期时间（取回完成后才有，过期后须重新取回）
+}
+
+// Restored 判断对象在 now 时是否已取回可读
+func (s *RestoreState) Restored(now time.Time) bool {
+	return s != nil && !s.InProgress && s.ExpiresAt != nil && now.Before(*s.ExpiresAt)
+}
+
+// TieredStorage 支持存储类型转换和归档取回的存储（S3、OSS 及由其组成的多后端路由存储）
+// 存储类型只在生命周期任务中修改，操作的是原始存储对象，不经过客户端加密和压缩装饰器
+type TieredStorage interface {
+	// SetStorageClass 修改对象的存储类型（服务端原地复制，保留元数据）
+	// 归档对象须先取回才能转换
+	SetStorageClass(ctx context.Context, key string, class StorageClass) error
+
+	// RestoreObject 取回归档对象，取回的副本保留 days 天（取回进行中时不报错）
+	RestoreObject(ctx context.Context, key string, days int, tier RestoreTier) error
+
+	// RestoreState 查询归档对象的取回状态
+	RestoreState(ctx context.Context, key string) (*RestoreState, error)
+}
+
+// ErrTieringNotSupported 存储后端不支持存储类型（如本地存储）
+var ErrTieringNotSupported = errors.New("storage classes are not supported")
+
+// isRestoreInProgress 判断是否为取回已在进行中的错误（S3 与 OSS 错误码均为 RestoreAlreadyInProgress）
+func isRestoreInProgress(err error) bool {
+	var coded interface{ ErrorCode() string }
+	return errors.As(err, &coded) && coded.ErrorCode() == "RestoreAlreadyInProgress"
+}
+
+// parseRestoreHeader 解析 x-amz-restore / x-oss-restore 响应头
+// 格式：ongoing-request="true" 或 ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT"
+func parseRestoreHeader(header string) (*RestoreState, error) {
+	state := &RestoreState{}
+	if header == "" {
+		return state, nil
+	}
+
+	rest := header
+	for rest != "" {
+		name, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid restore header: %s", header)
+		}
+		name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), ","))
+		after = strings.TrimSpace(after)
+		if !strings.HasPrefix(after, `"`) {
+			return nil, fmt.Errorf("invalid restore header: %s", header)
+		}
+		value, remaining, ok := strings.Cut(after[1:], `"`)
+		if !ok {
+			return nil, fmt.Errorf("invalid restore header: %s", header)
+		}
+		rest = strings.TrimSpace(remaining)
+
+		switch name {
+		case "ongoing-request":
+			state.InProgress = value == "true"
+		case "expiry-date":
+			expiresAt, err := http.ParseTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid restore expiry date %q: %w", value, err)
+			}
+			state.ExpiresAt = &expiresAt
+		}
+	}
+	return state, nil
+}
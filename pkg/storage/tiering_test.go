@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NanoBoom/asethub/internal/config"
+)
+
+// memTieredStorage 记录存储类型的内存存储
+type memTieredStorage struct {
+	*memStorage
+	classes map[string]StorageClass
+}
+
+func (m *memTieredStorage) SetStorageClass(ctx context.Context, key string, class StorageClass) error {
+	m.classes[key] = class
+	return nil
+}
+
+func (m *memTieredStorage) RestoreObject(ctx context.Context, key string, days int, tier RestoreTier) error {
+	return nil
+}
+
+func (m *memTieredStorage) RestoreState(ctx context.Context, key string) (*RestoreState, error) {
+	return &RestoreState{InProgress: true}, nil
+}
+
+func TestParseStorageClass(t *testing.T) {
+	if class, err := ParseStorageClass(""); err != nil || class != StorageClassStandard {
+		t.Errorf("ParseStorageClass(\"\") = %q, %v, want standard", class, err)
+	}
+	if class, err := ParseStorageClass("Archive"); err != nil || class != StorageClassArchive {
+		t.Errorf("ParseStorageClass(Archive) = %q, %v, want archive", class, err)
+	}
+	if _, err := ParseStorageClass("glacier"); err == nil {
+		t.Error("unknown storage class should be rejected")
+	}
+
+	if !StorageClassColdArchive.ColderThan(StorageClassArchive) || StorageClassStandard.ColderThan(StorageClassInfrequentAccess) {
+		t.Error("ColderThan() order is wrong")
+	}
+	if StorageClassInfrequentAccess.RequiresRestore() || !StorageClassArchive.RequiresRestore() {
+		t.Error("only archive classes should require restore")
+	}
+}
+
+func TestParseRestoreHeader(t *testing.T) {
+	expiry := time.Date(2012, 12, 23, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header     string
+		inProgress bool
+		expiresAt  *time.Time
+	}{
+		{"", false, nil},
+		{`ongoing-request="true"`, true, nil},
+		{`ongoing-request="false", expiry-date="Sun, 23 Dec 2012 00:00:00 GMT"`, false, &expiry},
+	}
+	for _, tt := range tests {
+		state, err := parseRestoreHeader(tt.header)
+		if err != nil {
+			t.Fatalf("parseRestoreHeader(%q) error = %v", tt.header, err)
+		}
+		if state.InProgress != tt.inProgress || (state.ExpiresAt == nil) != (tt.expiresAt == nil) ||
+			(tt.expiresAt != nil && !state.ExpiresAt.Equal(*tt.expiresAt)) {
+			t.Errorf("parseRestoreHeader(%q) = %+v", tt.header, state)
+		}
+	}
+
+	state, _ := parseRestoreHeader(`ongoing-request="false", expiry-date="Sun, 23 Dec 2012 00:00:00 GMT"`)
+	if !state.Restored(expiry.Add(-time.Hour)) || state.Restored(expiry) {
+		t.Error("Restored() should be true only before the expiry date")
+	}
+
+	if _, err := parseRestoreHeader(`ongoing-request=true`); err == nil {
+		t.Error("unquoted restore header should be rejected")
+	}
+}
+
+func TestRoutingStorageTiering(t *testing.T) {
+	tiered := &memTieredStorage{memStorage: &memStorage{objects: map[string][]byte{}}, classes: map[string]StorageClass{}}
+	store := memBackends{"local.txt": "local"}
+	r, err := NewRoutingStorage(map[string]Storage{"s3": tiered, "local": &memStorage{objects: map[string][]byte{}}}, config.RoutingConfig{Default: "s3"}, store)
+	if err != nil {
+		t.Fatalf("NewRoutingStorage() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if err := r.SetStorageClass(ctx, "a.txt", StorageClassArchive); err != nil || tiered.classes["a.txt"] != StorageClassArchive {
+		t.Fatalf("SetStorageClass() error = %v, classes = %v", err, tiered.classes)
+	}
+	if state, err := r.RestoreState(ctx, "a.txt"); err != nil || !state.InProgress {
+		t.Fatalf("RestoreState() = %+v, %v", state, err)
+	}
+	if err := r.SetStorageClass(ctx, "local.txt", StorageClassArchive); !errors.Is(err, ErrTieringNotSupported) {
+		t.Fatalf("SetStorageClass() on local backend error = %v, want ErrTieringNotSupported", err)
+	}
+}
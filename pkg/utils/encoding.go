@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AcceptsEncoding 判断 Accept-Encoding 请求头是否接受指定的内容编码（RFC 9110）
+// 显式列出的编码优先于 "*"，q=0 表示不接受
+func AcceptsEncoding(header string, coding string) bool {
+	wildcard := false
+	for _, item := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(item, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		accepted := qualityOf(params) > 0
+
+		switch name {
+		case strings.ToLower(coding):
+			return accepted
+		case "*":
+			wildcard = accepted
+		}
+	}
+	return wildcard
+}
+
+// qualityOf 解析 ";q=0.5" 形式的权重参数（缺省为 1，无法解析时视为 0）
+func qualityOf(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0
+		}
+		return q
+	}
+	return 1
+}
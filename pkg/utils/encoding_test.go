@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		coding string
+		want   bool
+	}{
+		{"", "gzip", false},
+		{"gzip", "gzip", true},
+		{"deflate, GZIP;q=0.8", "gzip", true},
+		{"br, deflate", "gzip", false},
+		{"gzip;q=0", "gzip", false},
+		{"*", "gzip", true},
+		{"*;q=0.1, gzip;q=0", "gzip", false},
+		{"identity, *;q=0", "gzip", false},
+		{"gzip;q=abc", "gzip", false},
+		{"gzip, deflate, br", "zstd", false},
+		{"gzip, zstd", "zstd", true},
+	}
+
+	for _, tt := range tests {
+		if got := AcceptsEncoding(tt.header, tt.coding); got != tt.want {
+			t.Errorf("AcceptsEncoding(%q, %s) = %v, want %v", tt.header, tt.coding, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxFilenameBytes 文件名最大字节数（常见文件系统上限）
+const maxFilenameBytes = 255
+
+// windowsReservedNames Windows 保留设备名（不区分大小写，带扩展名同样保留）
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename 清理用户提供的文件名（上传时调用），保留中文等非 ASCII 字符
+//   - 统一为 NFC 形式，替换非法 UTF-8 序列
+//   - 去除路径（只保留最后一段）、控制字符和格式字符（如 U+202E 双向覆盖，可伪装扩展名）
+//   - 将 Windows 非法字符 <>:"/\|?* 替换为 "_"，合并连续空白，去除首尾空格和句点
+//   - 避开 Windows 保留设备名，按字节截断到 255（保留扩展名，不截断多字节字符）
+//
+// 清理后为空时返回 "file"
+func SanitizeFilename(name string) string {
+	name = norm.NFC.String(strings.ToValidUTF8(name, "_"))
+
+	// 只保留最后一段路径
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var b strings.Builder
+	lastSpace := false
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			if !lastSpace {
+				b.WriteRune(' ')
+			}
+			lastSpace = true
+			continue
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			continue
+		case strings.ContainsRune(`<>:"|?*`, r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+		lastSpace = false
+	}
+
+	name = strings.Trim(b.String(), " .")
+	if name == "" {
+		return "file"
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+		name = "_" + name
+		base = "_" + base
+	}
+
+	if len(name) > maxFilenameBytes {
+		if len(ext) > maxFilenameBytes/2 {
+			ext = ""
+		}
+		name = truncateUTF8(base, maxFilenameBytes-len(ext)) + ext
+	}
+	return name
+}
+
+// truncateUTF8 按字节截断字符串，不截断多字节字符
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
+
+// ContentDisposition 构造 RFC 6266 Content-Disposition 头
+// 同时输出 ASCII 回退文件名（filename 参数，供旧客户端使用）和 RFC 5987 编码的 UTF-8 文件名（filename* 参数）
+// 参数：
+//   - dispositionType: "inline" 或 "attachment"
+//   - filename: 原始文件名（为空时只返回类型）
+func ContentDisposition(dispositionType string, filename string) string {
+	if filename == "" {
+		return dispositionType
+	}
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, dispositionType, asciiFilename(filename), encodeRFC5987(filename))
+}
+
+// asciiFilename 生成 ASCII 回退文件名
+// 去除重音符号（é -> e），其余非 ASCII 字符、引号、反斜杠和控制字符替换为 "_"，保留扩展名
+func asciiFilename(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// 组合附加符号（分解后的重音）直接去除
+		case r < 0x20 || r >= 0x7f || r == '"' || r == '\\':
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	fallback := b.String()
+	if strings.Trim(strings.TrimSuffix(fallback, filepath.Ext(fallback)), "_ ") == "" {
+		return "download" + filepath.Ext(fallback)
+	}
+	return fallback
+}
+
+// encodeRFC5987 按 RFC 5987 对 UTF-8 字符串进行百分号编码（仅保留 attr-char）
+func encodeRFC5987(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// isAttrChar 判断是否为 RFC 5987 attr-char
+func isAttrChar(c byte) bool {
+	if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"chinese", "季度报告 2026.pdf", "季度报告 2026.pdf"},
+		{"path", "../../etc/passwd", "passwd"},
+		{"windows path", `C:\Users\me\photo.png`, "photo.png"},
+		{"quotes and newline", "a\"b\r\nc.txt", "a_b c.txt"},
+		{"bidi override", "invoice\u202Egnp.exe", "invoicegnp.exe"},
+		{"reserved chars", "what?<is>*this|.txt", "what__is__this_.txt"},
+		{"trailing dots and spaces", "  report. . ", "report"},
+		{"reserved device name", "con.txt", "_con.txt"},
+		{"nfc", "e\u0301.txt", "\u00e9.txt"},
+		{"invalid utf-8", "a\xffb.txt", "a_b.txt"},
+		{"empty", " .. ", "file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFilename(tt.input); got != tt.want {
+				t.Errorf("SanitizeFilename(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	// 超长文件名按字节截断，保留扩展名且不截断多字节字符
+	long := SanitizeFilename(strings.Repeat("文", 200) + ".docx")
+	if len(long) > 255 || !strings.HasSuffix(long, "文.docx") {
+		t.Errorf("SanitizeFilename(long) = %q (%d bytes)", long, len(long))
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		disposition string
+		filename    string
+		want        string
+	}{
+		{"attachment", "report.pdf", `attachment; filename="report.pdf"; filename*=UTF-8''report.pdf`},
+		{"inline", "季度报告.pdf", `inline; filename="download.pdf"; filename*=UTF-8''%E5%AD%A3%E5%BA%A6%E6%8A%A5%E5%91%8A.pdf`},
+		{"attachment", "résumé v2.docx", `attachment; filename="resume v2.docx"; filename*=UTF-8''r%C3%A9sum%C3%A9%20v2.docx`},
+		{"attachment", `a"b\c.txt`, `attachment; filename="a_b_c.txt"; filename*=UTF-8''a%22b%5Cc.txt`},
+		{"inline", "", "inline"},
+	}
+
+	for _, tt := range tests {
+		if got := ContentDisposition(tt.disposition, tt.filename); got != tt.want {
+			t.Errorf("ContentDisposition(%q, %q) = %s, want %s", tt.disposition, tt.filename, got, tt.want)
+		}
+	}
+}
@@ -1,69 +1,285 @@
 package utils
 
 import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"mime"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
-// extensionToMIME 文件扩展名到 MIME 类型的映射
-var extensionToMIME = map[string]string{
-	// 图片
-	".jpg":  "image/jpeg",
-	".jpeg": "image/jpeg",
-	".png":  "image/png",
-	".gif":  "image/gif",
-	".webp": "image/webp",
-	".svg":  "image/svg+xml",
-	".ico":  "image/x-icon",
-	".bmp":  "image/bmp",
-
-	// 视频
-	".mp4":  "video/mp4",
-	".webm": "video/webm",
-	".ogv":  "video/ogg",
-	".mov":  "video/quicktime",
-	".avi":  "video/x-msvideo",
-	".mkv":  "video/x-matroska",
-	".flv":  "video/x-flv",
-
-	// 音频
-	".mp3":  "audio/mpeg",
-	".wav":  "audio/wav",
-	".oga":  "audio/ogg",
-	".m4a":  "audio/mp4",
-	".flac": "audio/flac",
-
-	// 文档
-	".pdf":  "application/pdf",
-	".doc":  "application/msword",
-	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
-	".xls":  "application/vnd.ms-excel",
-	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
-	".ppt":  "application/vnd.ms-powerpoint",
-	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
-
-	// 文本
-	".txt":  "text/plain",
-	".html": "text/html",
-	".css":  "text/css",
-	".js":   "application/javascript",
-	".json": "application/json",
-	".xml":  "application/xml",
-	".csv":  "text/csv",
-
-	// 压缩
-	".zip": "application/zip",
-	".rar": "application/x-rar-compressed",
-	".7z":  "application/x-7z-compressed",
-	".tar": "application/x-tar",
-	".gz":  "application/gzip",
+// defaultMIMETypes 内置 MIME 类型数据库（mime.types 格式）
+//
+//go:embed mime.types
+var defaultMIMETypes string
+
+// defaultMIMEAliases 常见的非标准或历史 MIME 类型别名（别名 -> 规范类型）
+var defaultMIMEAliases = map[string]string{
+	"image/jpg":                    "image/jpeg",
+	"image/pjpeg":                  "image/jpeg",
+	"image/x-png":                  "image/png",
+	"image/x-ms-bmp":               "image/bmp",
+	"image/vnd.microsoft.icon":     "image/x-icon",
+	"audio/x-wav":                  "audio/wav",
+	"audio/wave":                   "audio/wav",
+	"audio/vnd.wave":               "audio/wav",
+	"audio/mp3":                    "audio/mpeg",
+	"audio/x-mp3":                  "audio/mpeg",
+	"audio/x-mpeg":                 "audio/mpeg",
+	"audio/x-flac":                 "audio/flac",
+	"audio/x-m4a":                  "audio/mp4",
+	"video/avi":                    "video/x-msvideo",
+	"video/msvideo":                "video/x-msvideo",
+	"application/x-pdf":            "application/pdf",
+	"application/x-zip":            "application/zip",
+	"application/x-zip-compressed": "application/zip",
+	"application/x-gzip":           "application/gzip",
+	"application/vnd.rar":          "application/x-rar-compressed",
+	"application/x-javascript":     "application/javascript",
+	"text/javascript":              "application/javascript",
+	"text/json":                    "application/json",
+	"text/x-markdown":              "text/markdown",
+	"application/yaml":             "text/yaml",
+	"application/x-yaml":           "text/yaml",
+	"text/x-yaml":                  "text/yaml",
+}
+
+// MIMERegistry MIME 类型注册表
+// 维护扩展名与 MIME 类型的双向映射：每个类型的第一个扩展名为规范扩展名，别名在查询前解析为规范类型
+type MIMERegistry struct {
+	mu         sync.RWMutex
+	byExt      map[string]string   // 扩展名（带点、小写）-> MIME 类型
+	extensions map[string][]string // MIME 类型 -> 扩展名（第一个为规范扩展名）
+	aliases    map[string]string   // 别名 -> 规范类型
+}
+
+// NewMIMERegistry 创建空的 MIME 类型注册表
+func NewMIMERegistry() *MIMERegistry {
+	return &MIMERegistry{
+		byExt:      make(map[string]string),
+		extensions: make(map[string][]string),
+		aliases:    make(map[string]string),
+	}
+}
+
+// DefaultMIMERegistry 默认注册表（内置数据库 + 常见别名，启动时可按配置扩展）
+var DefaultMIMERegistry = newDefaultMIMERegistry()
+
+func newDefaultMIMERegistry() *MIMERegistry {
+	r := NewMIMERegistry()
+	if err := r.Load(strings.NewReader(defaultMIMETypes)); err != nil {
+		panic(fmt.Sprintf("invalid embedded mime.types: %v", err))
+	}
+	for alias, canonical := range defaultMIMEAliases {
+		r.RegisterAlias(alias, canonical)
+	}
+	return r
+}
+
+// Load 从 mime.types 格式的内容加载类型（# 开头为注释，每行：类型 扩展名...）
+func (r *MIMERegistry) Load(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = strings.TrimSpace(text[:i])
+		}
+		if text == "" {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if !strings.Contains(fields[0], "/") {
+			return fmt.Errorf("line %d: invalid MIME type %q", line, fields[0])
+		}
+		r.Register(fields[0], fields[1:]...)
+	}
+	return scanner.Err()
+}
+
+// LoadFile 从 mime.types 格式的文件加载类型
+func (r *MIMERegistry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open mime types file: %w", err)
+	}
+	defer f.Close()
+
+	if err := r.Load(f); err != nil {
+		return fmt.Errorf("failed to load mime types file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Register 注册 MIME 类型及其扩展名（扩展名可带或不带点）
+// 新类型的第一个扩展名作为规范扩展名；已注册的扩展名改为指向该类型
+func (r *MIMERegistry) Register(mimeType string, exts ...string) {
+	mimeType = baseMediaType(mimeType)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ext := range exts {
+		ext = normalizeExt(ext)
+		if ext == "" {
+			continue
+		}
+		if previous, ok := r.byExt[ext]; ok && previous != mimeType {
+			r.extensions[previous] = removeString(r.extensions[previous], ext)
+		}
+		r.byExt[ext] = mimeType
+		if !containsString(r.extensions[mimeType], ext) {
+			r.extensions[mimeType] = append(r.extensions[mimeType], ext)
+		}
+	}
+	if _, ok := r.extensions[mimeType]; !ok {
+		r.extensions[mimeType] = nil
+	}
+}
+
+// RegisterAlias 注册 MIME 类型别名（如 audio/x-wav -> audio/wav）
+func (r *MIMERegistry) RegisterAlias(alias, canonical string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[baseMediaType(alias)] = baseMediaType(canonical)
+}
+
+// Canonical 返回规范 MIME 类型（小写、去除参数并解析别名）
+func (r *MIMERegistry) Canonical(mimeType string) string {
+	base := baseMediaType(mimeType)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if canonical, ok := r.aliases[base]; ok {
+		return canonical
+	}
+	return base
+}
+
+// Known 判断 MIME 类型（或其别名）是否已注册
+func (r *MIMERegistry) Known(mimeType string) bool {
+	canonical := r.Canonical(mimeType)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.extensions[canonical]
+	return ok
+}
+
+// TypeByExtension 根据扩展名（可带或不带点）查询 MIME 类型，未知时返回空字符串
+func (r *MIMERegistry) TypeByExtension(ext string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byExt[normalizeExt(ext)]
+}
+
+// Extension 返回 MIME 类型的规范扩展名（带点），未知时返回空字符串
+func (r *MIMERegistry) Extension(mimeType string) string {
+	canonical := r.Canonical(mimeType)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if exts := r.extensions[canonical]; len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// Extensions 返回 MIME 类型的所有扩展名（第一个为规范扩展名）
+func (r *MIMERegistry) Extensions(mimeType string) []string {
+	canonical := r.Canonical(mimeType)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.extensions[canonical]...)
+}
+
+// Equal 比较两个 Content-Type 是否等价
+// 基础类型按规范类型比较（忽略大小写和别名）；两者都带有的参数需一致（charset 忽略大小写），仅一方带有的参数忽略
+func (r *MIMERegistry) Equal(a, b string) bool {
+	if r.Canonical(a) != r.Canonical(b) {
+		return false
+	}
+
+	paramsA := mediaTypeParams(a)
+	paramsB := mediaTypeParams(b)
+	for key, valueA := range paramsA {
+		valueB, ok := paramsB[key]
+		if !ok {
+			continue
+		}
+		if key == "charset" {
+			if !strings.EqualFold(valueA, valueB) {
+				return false
+			}
+		} else if valueA != valueB {
+			return false
+		}
+	}
+	return true
+}
+
+// baseMediaType 返回去除参数的小写 MIME 类型
+func baseMediaType(mimeType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+}
+
+// mediaTypeParams 解析 Content-Type 参数（参数名小写），格式错误时返回空
+func mediaTypeParams(mimeType string) map[string]string {
+	_, params, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return nil
+	}
+	return params
+}
+
+// normalizeExt 将扩展名统一为带点的小写形式
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext == "" || ext == "." {
+		return ""
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(values []string, s string) []string {
+	result := values[:0]
+	for _, v := range values {
+		if v != s {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// IsGenericContentType 判断是否为未声明具体类型（空或 application/octet-stream）
+func IsGenericContentType(contentType string) bool {
+	canonical := DefaultMIMERegistry.Canonical(contentType)
+	return canonical == "" || canonical == "application/octet-stream"
 }
 
 // DetectContentTypeFromFilename 根据文件名推断 Content-Type
 // 如果无法推断，返回 "application/octet-stream"
 func DetectContentTypeFromFilename(filename string) string {
-	ext := strings.ToLower(filepath.Ext(filename))
-	if mimeType, ok := extensionToMIME[ext]; ok {
+	if mimeType := DefaultMIMERegistry.TypeByExtension(filepath.Ext(filename)); mimeType != "" {
 		return mimeType
 	}
 	return "application/octet-stream"
@@ -71,6 +287,8 @@ func DetectContentTypeFromFilename(filename string) string {
 
 // IsPreviewable 判断 MIME 类型是否可在浏览器中预览
 func IsPreviewable(contentType string) bool {
+	canonical := DefaultMIMERegistry.Canonical(contentType)
+
 	previewable := []string{
 		"image/",
 		"video/",
@@ -80,7 +298,7 @@ func IsPreviewable(contentType string) bool {
 	}
 
 	for _, prefix := range previewable {
-		if strings.HasPrefix(contentType, prefix) {
+		if strings.HasPrefix(canonical, prefix) {
 			return true
 		}
 	}
@@ -93,41 +311,19 @@ func ValidateContentType(filename string, providedType string) (bool, string) {
 	expectedType := DetectContentTypeFromFilename(filename)
 
 	// 如果提供的类型是默认值，使用推断的类型
-	if providedType == "" || providedType == "application/octet-stream" {
+	if IsGenericContentType(providedType) {
 		return false, expectedType
 	}
 
-	// 检查是否匹配（忽略参数，如 charset）
-	providedBase := strings.Split(providedType, ";")[0]
-	expectedBase := strings.Split(expectedType, ";")[0]
-
-	return providedBase == expectedBase, expectedType
+	// 检查是否匹配（解析别名，忽略 charset 等参数）
+	return DefaultMIMERegistry.Equal(providedType, expectedType), expectedType
 }
 
-// GetExtensionFromMIME 从 MIME 类型推断文件扩展名
+// GetExtensionFromMIME 从 MIME 类型推断规范扩展名
 // 如果无法推断，返回 ".bin"
 func GetExtensionFromMIME(mimeType string) string {
-	// 移除参数（如 charset）
-	baseType := strings.Split(mimeType, ";")[0]
-	baseType = strings.TrimSpace(baseType)
-
-	// 优先返回常用扩展名
-	preferredExtensions := map[string]string{
-		"image/jpeg": ".jpg",
-		"image/jpg":  ".jpg",
-	}
-
-	if ext, ok := preferredExtensions[baseType]; ok {
+	if ext := DefaultMIMERegistry.Extension(mimeType); ext != "" {
 		return ext
 	}
-
-	// 反向查找扩展名
-	for ext, mime := range extensionToMIME {
-		if mime == baseType {
-			return ext
-		}
-	}
-
-	// 默认扩展名
 	return ".bin"
 }
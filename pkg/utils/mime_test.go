@@ -1,6 +1,9 @@
 package utils
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestGetExtensionFromMIME(t *testing.T) {
 	tests := []struct {
@@ -54,3 +57,103 @@ func TestGetExtensionFromMIME(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectContentTypeFromFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"photo.JPG", "image/jpeg"},
+		{"photo.jpeg", "image/jpeg"},
+		{"report.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"model.glb", "model/gltf-binary"},
+		{"notes.md", "text/markdown"},
+		{"README", "application/octet-stream"},
+		{"archive.unknown", "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		if got := DetectContentTypeFromFilename(tt.filename); got != tt.want {
+			t.Errorf("DetectContentTypeFromFilename(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestValidateContentType(t *testing.T) {
+	tests := []struct {
+		filename string
+		provided string
+		want     bool
+	}{
+		{"a.wav", "audio/x-wav", true},
+		{"a.jpg", "image/jpg", true},
+		{"a.txt", "text/plain; charset=utf-8", true},
+		{"a.txt", "TEXT/PLAIN", true},
+		{"a.png", "image/jpeg", false},
+		{"a.png", "application/octet-stream", false},
+	}
+
+	for _, tt := range tests {
+		if got, _ := ValidateContentType(tt.filename, tt.provided); got != tt.want {
+			t.Errorf("ValidateContentType(%q, %q) = %v, want %v", tt.filename, tt.provided, got, tt.want)
+		}
+	}
+}
+
+func TestMIMERegistry(t *testing.T) {
+	r := NewMIMERegistry()
+	err := r.Load(strings.NewReader("# comment\nimage/jpeg jpg jpeg jpe\napplication/x-foo foo\n\napplication/x-bar foo bar # 覆盖 foo\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// 规范扩展名与数据库中的顺序一致（与 map 迭代顺序无关）
+	for i := 0; i < 10; i++ {
+		if ext := r.Extension("image/jpeg"); ext != ".jpg" {
+			t.Fatalf("Extension(image/jpeg) = %q, want .jpg", ext)
+		}
+	}
+
+	// 后注册的类型覆盖扩展名
+	if got := r.TypeByExtension("foo"); got != "application/x-bar" {
+		t.Errorf("TypeByExtension(foo) = %q, want application/x-bar", got)
+	}
+	if ext := r.Extension("application/x-foo"); ext != "" {
+		t.Errorf("Extension(application/x-foo) = %q, want empty", ext)
+	}
+
+	r.RegisterAlias("image/pjpeg", "image/jpeg")
+	if got := r.Canonical("Image/PJPEG; q=0.8"); got != "image/jpeg" {
+		t.Errorf("Canonical() = %q, want image/jpeg", got)
+	}
+	if ext := r.Extension("image/pjpeg"); ext != ".jpg" {
+		t.Errorf("Extension(alias) = %q, want .jpg", ext)
+	}
+	if !r.Known("image/pjpeg") || r.Known("image/x-unknown") {
+		t.Error("Known() should resolve aliases and reject unknown types")
+	}
+
+	if err := r.Load(strings.NewReader("not-a-type ext\n")); err == nil {
+		t.Error("Load() with invalid type should fail")
+	}
+}
+
+func TestMIMERegistryEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"text/plain", "text/plain; charset=utf-8", true},
+		{"text/plain; charset=UTF-8", "text/plain; charset=utf-8", true},
+		{"text/plain; charset=utf-8", "text/plain; charset=iso-8859-1", false},
+		{"audio/x-wav", "audio/wav", true},
+		{"text/javascript", "application/javascript", true},
+		{"image/png", "image/jpeg", false},
+	}
+
+	for _, tt := range tests {
+		if got := DefaultMIMERegistry.Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// SniffContentType 根据文件头（魔数）识别真实内容类型
+// 使用 mimetype 签名库（覆盖 Office、可执行文件、压缩包、音视频容器等，比 net/http 更全面）
+// 无法识别时返回空字符串；返回值不含参数（如 charset）
+func SniffContentType(head []byte) string {
+	if len(head) == 0 {
+		return ""
+	}
+
+	detected := strings.TrimSpace(strings.Split(mimetype.Detect(head).String(), ";")[0])
+	if detected == "application/octet-stream" {
+		return ""
+	}
+	return detected
+}
+
+// ContentTypeMatches 判断声明的内容类型与识别结果是否一致
+// 以下情况视为一致：
+//   - 声明类型为空或 application/octet-stream（未声明）
+//   - 类型比较前解析别名并去除参数（如 audio/x-wav 与 audio/wav 一致）
+//   - 未识别出类型，且声明类型不在签名库中（无法校验）
+//   - 两者相同或互为父子类型（如 docx 文件头只读取到 zip 部分）
+//   - 声明为文本类型而识别为 text/plain（如 csv、json 片段）
+func ContentTypeMatches(declared, detected string) bool {
+	if IsGenericContentType(declared) {
+		return true
+	}
+	declared = DefaultMIMERegistry.Canonical(declared)
+
+	if detected == "" {
+		// 签名库可识别的类型却未匹配到签名，说明内容与声明不符
+		return mimetype.Lookup(declared) == nil
+	}
+	detected = DefaultMIMERegistry.Canonical(detected)
+
+	if isSubtypeOf(detected, declared) || isSubtypeOf(declared, detected) {
+		return true
+	}
+
+	return detected == "text/plain" && isTextual(declared)
+}
+
+// isSubtypeOf 判断 child 是否为 parent 或其子类型（沿签名库的父类型链查找，支持别名）
+func isSubtypeOf(child, parent string) bool {
+	for m := mimetype.Lookup(child); m != nil; m = m.Parent() {
+		if m.Is(parent) || DefaultMIMERegistry.Equal(m.String(), parent) {
+			return true
+		}
+	}
+	return DefaultMIMERegistry.Equal(child, parent)
+}
+
+// isTextual 判断是否为文本类型
+func isTextual(contentType string) bool {
+	if strings.HasPrefix(contentType, "text/") ||
+		strings.HasSuffix(contentType, "+json") ||
+		strings.HasSuffix(contentType, "+xml") {
+		return true
+	}
+
+	switch contentType {
+	case "application/json", "application/xml", "application/javascript", "application/x-javascript":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package utils
+
+import "testing"
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{
+			name: "png",
+			head: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+			want: "image/png",
+		},
+		{
+			name: "pdf",
+			head: []byte("%PDF-1.7\n"),
+			want: "application/pdf",
+		},
+		{
+			name: "windows executable",
+			head: append([]byte("MZ\x90\x00"), make([]byte, 60)...),
+			want: "application/vnd.microsoft.portable-executable",
+		},
+		{
+			name: "text",
+			head: []byte("hello, world"),
+			want: "text/plain",
+		},
+		{
+			name: "empty",
+			head: nil,
+			want: "",
+		},
+		{
+			name: "unknown binary",
+			head: []byte{0x00, 0x01, 0x02, 0x03, 0xfe},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SniffContentType(tt.head)
+			if got != tt.want {
+				t.Errorf("SniffContentType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentTypeMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared string
+		detected string
+		want     bool
+	}{
+		{"same type", "image/png", "image/png", true},
+		{"declared with parameters", "text/plain; charset=utf-8", "text/plain", true},
+		{"undeclared", "application/octet-stream", "application/x-elf", true},
+		{"docx detected as zip", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip", true},
+		{"zip detected as jar", "application/zip", "application/jar", true},
+		{"csv detected as text", "text/csv", "text/plain", true},
+		{"json fragment detected as text", "application/json", "text/plain", true},
+		{"alias", "audio/x-wav", "audio/wav", true},
+		{"unknown to signature database", "application/x-custom", "", true},
+		{"executable renamed to png", "image/png", "application/vnd.microsoft.portable-executable", false},
+		{"text renamed to jpeg", "image/jpeg", "text/plain", false},
+		{"png without signature", "image/png", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContentTypeMatches(tt.declared, tt.detected); got != tt.want {
+				t.Errorf("ContentTypeMatches(%q, %q) = %v, want %v", tt.declared, tt.detected, got, tt.want)
+			}
+		})
+	}
+}
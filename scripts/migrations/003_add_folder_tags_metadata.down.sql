@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_files_tags;
+DROP INDEX IF EXISTS idx_files_folder;
+
+ALTER TABLE files DROP COLUMN IF EXISTS metadata;
+ALTER TABLE files DROP COLUMN IF EXISTS tags;
+ALTER TABLE files DROP COLUMN IF EXISTS folder;
@@ -0,0 +1,12 @@
+-- 为文件增加虚拟目录、标签和自定义元数据（批量操作 API 使用）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS folder VARCHAR(500) NOT NULL DEFAULT '/';
+ALTER TABLE files ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE files ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_files_folder ON files(folder);
+CREATE INDEX IF NOT EXISTS idx_files_tags ON files USING GIN (tags);
+
+COMMENT ON COLUMN files.folder IS '虚拟目录（以 / 开头）';
+COMMENT ON COLUMN files.tags IS '标签列表（JSON 数组）';
+COMMENT ON COLUMN files.metadata IS '自定义元数据（JSON 对象）';
@@ -0,0 +1,4 @@
+-- 回滚 Webhook 订阅表和投递记录表
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,51 @@
+-- 创建 Webhook 订阅表和投递记录表
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events JSONB NOT NULL DEFAULT '[]',
+    description VARCHAR(255),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_active ON webhooks(active);
+CREATE INDEX IF NOT EXISTS idx_webhooks_deleted_at ON webhooks(deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES webhooks(id),
+    event_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    response_code INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries(event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_deleted_at ON webhook_deliveries(deleted_at);
+
+COMMENT ON TABLE webhooks IS 'Webhook 订阅表';
+COMMENT ON COLUMN webhooks.url IS '回调地址';
+COMMENT ON COLUMN webhooks.secret IS 'HMAC-SHA256 签名密钥';
+COMMENT ON COLUMN webhooks.events IS '订阅的事件类型（JSON 数组，空数组表示全部事件）';
+COMMENT ON COLUMN webhooks.active IS '是否启用';
+
+COMMENT ON TABLE webhook_deliveries IS 'Webhook 投递记录表（投递日志 + 重试队列）';
+COMMENT ON COLUMN webhook_deliveries.status IS '投递状态: pending, succeeded, dead';
+COMMENT ON COLUMN webhook_deliveries.attempts IS '已尝试次数';
+COMMENT ON COLUMN webhook_deliveries.next_attempt_at IS '下次尝试时间（指数退避）';
+COMMENT ON COLUMN webhook_deliveries.response_code IS '最近一次响应状态码';
+COMMENT ON COLUMN webhook_deliveries.last_error IS '最近一次失败原因';
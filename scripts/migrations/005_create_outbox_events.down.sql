@@ -0,0 +1,4 @@
+-- 回滚事务性发件箱表
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook_event;
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,31 @@
+-- 创建事务性发件箱表（文件事件与文件状态变更在同一事务中写入）
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY,
+    sequence BIGSERIAL NOT NULL UNIQUE,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished ON outbox_events(sequence) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events(published_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate_id ON outbox_events(aggregate_id);
+
+-- 同一事件重复发布时只创建一条 Webhook 投递记录
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_event ON webhook_deliveries(webhook_id, event_id);
+
+COMMENT ON TABLE outbox_events IS '事务性发件箱（待发布的文件事件）';
+COMMENT ON COLUMN outbox_events.sequence IS '写入顺序';
+COMMENT ON COLUMN outbox_events.aggregate_type IS '聚合类型（如 file）';
+COMMENT ON COLUMN outbox_events.aggregate_id IS '聚合 ID（如文件 ID）';
+COMMENT ON COLUMN outbox_events.event_type IS '事件类型: file.created, file.completed, file.deleted, multipart.aborted';
+COMMENT ON COLUMN outbox_events.payload IS '事件内容（JSON）';
+COMMENT ON COLUMN outbox_events.published_at IS '发布时间（为空表示未发布）';
+COMMENT ON COLUMN outbox_events.attempts IS '发布失败次数';
+COMMENT ON COLUMN outbox_events.last_error IS '最近一次发布失败原因';
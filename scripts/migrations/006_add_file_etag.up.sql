@@ -0,0 +1,5 @@
+-- 为文件增加存储对象 ETag（存储事件通知自动确认上传时写入）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS etag VARCHAR(100);
+
+COMMENT ON COLUMN files.etag IS '存储对象 ETag';
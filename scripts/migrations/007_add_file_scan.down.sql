@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_files_scan_result;
+
+ALTER TABLE files DROP COLUMN IF EXISTS scanned_at;
+ALTER TABLE files DROP COLUMN IF EXISTS scan_engine;
+ALTER TABLE files DROP COLUMN IF EXISTS scan_signature;
+ALTER TABLE files DROP COLUMN IF EXISTS scan_result;
@@ -0,0 +1,13 @@
+-- 为文件增加恶意文件扫描结果（ClamAV），扫描发现恶意内容的文件状态为 quarantined
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS scan_result VARCHAR(20);
+ALTER TABLE files ADD COLUMN IF NOT EXISTS scan_signature VARCHAR(255);
+ALTER TABLE files ADD COLUMN IF NOT EXISTS scan_engine VARCHAR(255);
+ALTER TABLE files ADD COLUMN IF NOT EXISTS scanned_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_files_scan_result ON files(scan_result);
+
+COMMENT ON COLUMN files.scan_result IS '恶意文件扫描结果：clean/infected，为空表示未扫描';
+COMMENT ON COLUMN files.scan_signature IS '命中的病毒签名';
+COMMENT ON COLUMN files.scan_engine IS '扫描引擎及病毒库版本';
+COMMENT ON COLUMN files.scanned_at IS '扫描时间';
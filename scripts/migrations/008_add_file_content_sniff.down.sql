@@ -0,0 +1,2 @@
+ALTER TABLE files DROP COLUMN IF EXISTS content_type_mismatch;
+ALTER TABLE files DROP COLUMN IF EXISTS detected_content_type;
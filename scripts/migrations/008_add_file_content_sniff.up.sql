@@ -0,0 +1,7 @@
+-- 为文件增加内容类型校验结果（上传完成后读取文件头识别真实类型）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS detected_content_type VARCHAR(100);
+ALTER TABLE files ADD COLUMN IF NOT EXISTS content_type_mismatch BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN files.detected_content_type IS '根据文件头（魔数）识别的内容类型';
+COMMENT ON COLUMN files.content_type_mismatch IS '识别结果与声明的内容类型不一致';
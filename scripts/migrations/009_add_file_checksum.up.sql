@@ -0,0 +1,7 @@
+-- 为文件增加端到端完整性校验结果（客户端声明、存储服务校验）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS checksum_algorithm VARCHAR(16);
+ALTER TABLE files ADD COLUMN IF NOT EXISTS checksum VARCHAR(100);
+
+COMMENT ON COLUMN files.checksum_algorithm IS '完整性校验算法（md5 / crc32c / sha256）';
+COMMENT ON COLUMN files.checksum IS '已校验的 Base64 摘要（分片上传为组合摘要加分片数）';
@@ -0,0 +1,2 @@
+ALTER TABLE files DROP COLUMN IF EXISTS encryption_key;
+ALTER TABLE files DROP COLUMN IF EXISTS encryption_key_id;
@@ -0,0 +1,7 @@
+-- 为文件增加客户端信封加密的数据密钥（经主密钥加密）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS encryption_key_id VARCHAR(64);
+ALTER TABLE files ADD COLUMN IF NOT EXISTS encryption_key TEXT;
+
+COMMENT ON COLUMN files.encryption_key_id IS '加密数据密钥的主密钥 ID';
+COMMENT ON COLUMN files.encryption_key IS '经主密钥加密的数据密钥（Base64）';
@@ -0,0 +1,5 @@
+-- 为文件增加透明压缩编码（为空表示按原样保存）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS content_encoding VARCHAR(16);
+
+COMMENT ON COLUMN files.content_encoding IS '透明压缩编码（如 gzip）';
@@ -0,0 +1,5 @@
+-- 为文件增加所在的存储后端（多后端路由，为空表示默认后端）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS backend VARCHAR(64);
+
+COMMENT ON COLUMN files.backend IS '对象所在的存储后端名称';
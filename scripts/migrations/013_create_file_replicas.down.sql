@@ -0,0 +1,4 @@
+-- 回滚文件副本表和批量复制任务表
+
+DROP TABLE IF EXISTS replication_runs;
+DROP TABLE IF EXISTS file_replicas;
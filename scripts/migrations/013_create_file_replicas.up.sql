@@ -0,0 +1,50 @@
+-- 创建文件副本表和批量复制任务表（跨后端复制与迁移）
+
+CREATE TABLE IF NOT EXISTS file_replicas (
+    id UUID PRIMARY KEY,
+    file_id UUID NOT NULL,
+    backend VARCHAR(64) NOT NULL,
+    storage_key VARCHAR(1024) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    sha256 VARCHAR(64),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    replicated_at TIMESTAMP,
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_file_replicas_file_backend ON file_replicas(file_id, backend);
+CREATE INDEX IF NOT EXISTS idx_file_replicas_status ON file_replicas(status);
+
+CREATE TABLE IF NOT EXISTS replication_runs (
+    id UUID PRIMARY KEY,
+    source VARCHAR(64) NOT NULL,
+    target VARCHAR(64) NOT NULL,
+    promote BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL,
+    cursor UUID NOT NULL,
+    scanned INTEGER NOT NULL DEFAULT 0,
+    copied INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_replication_runs_status ON replication_runs(status);
+
+COMMENT ON TABLE file_replicas IS '文件副本表（文件在其他存储后端上的原样拷贝）';
+COMMENT ON COLUMN file_replicas.backend IS '副本所在的存储后端名称';
+COMMENT ON COLUMN file_replicas.status IS '副本状态: replicated, verified, failed';
+COMMENT ON COLUMN file_replicas.sha256 IS '复制时计算的存储对象 SHA-256';
+COMMENT ON COLUMN file_replicas.attempts IS '连续失败次数';
+
+COMMENT ON TABLE replication_runs IS '批量复制/迁移任务表（可从检查点继续）';
+COMMENT ON COLUMN replication_runs.promote IS '复制后将目标后端设为文件的主后端';
+COMMENT ON COLUMN replication_runs.status IS '状态: running, completed';
+COMMENT ON COLUMN replication_runs.cursor IS '检查点：最后处理的文件 ID';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_file_replicas_storage_key;
@@ -0,0 +1,3 @@
+-- 按存储键查询副本（读取故障转移时判断副本后端上是否有可用副本）
+
+CREATE INDEX IF NOT EXISTS idx_file_replicas_storage_key ON file_replicas(storage_key, backend);
@@ -0,0 +1,4 @@
+ALTER TABLE files DROP COLUMN IF EXISTS last_accessed_at;
+ALTER TABLE files DROP COLUMN IF EXISTS restore_expires_at;
+ALTER TABLE files DROP COLUMN IF EXISTS restore_status;
+ALTER TABLE files DROP COLUMN IF EXISTS storage_class;
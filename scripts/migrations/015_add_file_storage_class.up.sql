@@ -0,0 +1,11 @@
+-- 为文件增加存储类型、归档取回状态和最近访问时间（存储分层与生命周期规则）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS storage_class VARCHAR(32) NOT NULL DEFAULT 'standard';
+ALTER TABLE files ADD COLUMN IF NOT EXISTS restore_status VARCHAR(20);
+ALTER TABLE files ADD COLUMN IF NOT EXISTS restore_expires_at TIMESTAMP;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS last_accessed_at TIMESTAMP;
+
+COMMENT ON COLUMN files.storage_class IS '存储类型：standard / infrequent_access / archive / cold_archive';
+COMMENT ON COLUMN files.restore_status IS '归档文件的取回状态：in_progress / restored';
+COMMENT ON COLUMN files.restore_expires_at IS '已取回副本的过期时间';
+COMMENT ON COLUMN files.last_accessed_at IS '最近一次下载时间（按小时记录）';
@@ -0,0 +1,5 @@
+-- 回滚文件下载统计表
+
+DROP TABLE IF EXISTS download_stats_flushes;
+DROP TABLE IF EXISTS file_daily_download_stats;
+DROP TABLE IF EXISTS file_download_stats;
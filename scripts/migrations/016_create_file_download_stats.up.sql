@@ -0,0 +1,35 @@
+-- 创建文件下载统计表（Redis 计数器定期汇总写入）
+
+CREATE TABLE IF NOT EXISTS file_download_stats (
+    file_id UUID PRIMARY KEY REFERENCES files(id) ON DELETE CASCADE,
+    downloads BIGINT NOT NULL DEFAULT 0,
+    link_requests BIGINT NOT NULL DEFAULT 0,
+    bytes_served BIGINT NOT NULL DEFAULT 0,
+    last_accessed_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS file_daily_download_stats (
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    downloads BIGINT NOT NULL DEFAULT 0,
+    link_requests BIGINT NOT NULL DEFAULT 0,
+    bytes_served BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (file_id, day)
+);
+
+CREATE TABLE IF NOT EXISTS download_stats_flushes (
+    batch_id UUID PRIMARY KEY,
+    flushed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_download_stats_flushes_flushed_at ON download_stats_flushes(flushed_at);
+
+COMMENT ON TABLE file_download_stats IS '文件累计下载统计';
+COMMENT ON COLUMN file_download_stats.downloads IS '经服务端下载的次数';
+COMMENT ON COLUMN file_download_stats.link_requests IS '生成下载 URL 的次数';
+COMMENT ON COLUMN file_download_stats.bytes_served IS '经服务端下载传输的字节数（不含预签名 URL 直连下载）';
+
+COMMENT ON TABLE file_daily_download_stats IS '文件每日下载统计（UTC 日期）';
+
+COMMENT ON TABLE download_stats_flushes IS '已写入的统计批次（保证同一批计数器只汇总一次）';
@@ -0,0 +1,3 @@
+-- 回滚文件分享链接表
+
+DROP TABLE IF EXISTS file_shares;
@@ -0,0 +1,25 @@
+-- 创建文件分享链接表
+
+CREATE TABLE IF NOT EXISTS file_shares (
+    id UUID PRIMARY KEY,
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    password_hash VARCHAR(255),
+    mode VARCHAR(16) NOT NULL DEFAULT 'redirect',
+    expires_at TIMESTAMP,
+    max_downloads INTEGER NOT NULL DEFAULT 0,
+    download_count INTEGER NOT NULL DEFAULT 0,
+    last_accessed_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_file_shares_token_hash ON file_shares(token_hash);
+CREATE INDEX IF NOT EXISTS idx_file_shares_file_id ON file_shares(file_id);
+
+COMMENT ON TABLE file_shares IS '文件分享链接表';
+COMMENT ON COLUMN file_shares.token_hash IS '分享令牌的 SHA-256（令牌本身不保存）';
+COMMENT ON COLUMN file_shares.password_hash IS '访问密码的 PBKDF2 哈希（为空表示无密码）';
+COMMENT ON COLUMN file_shares.mode IS '访问方式: redirect, proxy';
+COMMENT ON COLUMN file_shares.max_downloads IS '最多下载次数（0 不限制）';
@@ -0,0 +1,5 @@
+-- 记录存储对象已被删除的时间（DELETE /files/{id} 同时删除存储对象，这类软删除的记录不能再恢复）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS object_purged_at TIMESTAMP;
+
+COMMENT ON COLUMN files.object_purged_at IS '存储对象的删除时间（不为空时记录不可恢复）';
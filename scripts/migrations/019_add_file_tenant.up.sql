@@ -0,0 +1,5 @@
+-- 为文件增加所在的租户（复制到其他租户时写入，为空表示 storage.key_tenant）
+
+ALTER TABLE files ADD COLUMN IF NOT EXISTS tenant VARCHAR(64);
+
+COMMENT ON COLUMN files.tenant IS '存储键模板中 {tenant} 的取值（为空表示 storage.key_tenant）';
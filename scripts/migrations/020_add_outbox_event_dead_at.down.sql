@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_events_unpublished;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished ON outbox_events(sequence) WHERE published_at IS NULL;
+
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS dead_at;
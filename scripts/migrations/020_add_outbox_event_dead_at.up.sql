@@ -0,0 +1,8 @@
+-- 记录无法发布的事件（如内容无法解析），中继不再读取这些事件
+
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS dead_at TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_outbox_events_unpublished;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished ON outbox_events(sequence) WHERE published_at IS NULL AND dead_at IS NULL;
+
+COMMENT ON COLUMN outbox_events.dead_at IS '放弃发布的时间（不为空时中继跳过该事件，失败原因见 last_error）';